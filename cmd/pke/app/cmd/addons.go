@@ -0,0 +1,41 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons/disable"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons/enable"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons/list"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons/upgrade"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/controlplane"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdAddons provides commands for managing the built-in cluster addons.
+func NewCmdAddons() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "addons",
+		Short: "Manage cluster addons",
+		Args:  cobra.NoArgs,
+	}
+
+	r := controlplane.Addons()
+	cmd.AddCommand(list.NewCommand(r))
+	cmd.AddCommand(enable.NewCommand(r))
+	cmd.AddCommand(disable.NewCommand(r))
+	cmd.AddCommand(upgrade.NewCommand(r))
+
+	return cmd
+}
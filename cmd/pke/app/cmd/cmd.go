@@ -38,8 +38,15 @@ func NewPKECommand(gitVersion, gitCommit, gitTreeState, buildDate string) *cobra
 		os.Exit(1)
 	}
 
+	cmd.AddCommand(NewCmdAddons())
+	cmd.AddCommand(NewCmdCISCheck())
+	cmd.AddCommand(NewCmdCSRApprover())
 	cmd.AddCommand(NewCmdInstall(c))
 	cmd.AddCommand(NewCmdImage())
+	cmd.AddCommand(NewCmdKubeconfig())
+	cmd.AddCommand(NewCmdNetwork())
+	cmd.AddCommand(NewCmdPodSecurity())
+	cmd.AddCommand(NewCmdSecretsEncryption(c))
 	cmd.AddCommand(NewCmdToken())
 	cmd.AddCommand(NewCmdUpgrade(c))
 	cmd.AddCommand(NewCmdVersion(gitVersion, gitCommit, gitTreeState, buildDate))
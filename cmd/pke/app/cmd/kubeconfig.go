@@ -0,0 +1,37 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig/create"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig/list"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig/revoke"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdKubeconfig provides commands for issuing kubeconfigs authenticating with client certificates.
+func NewCmdKubeconfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "Manage kubeconfigs of users and service identities",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(create.NewCommand())
+	cmd.AddCommand(list.NewCommand())
+	cmd.AddCommand(revoke.NewCommand())
+
+	return cmd
+}
@@ -0,0 +1,33 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/podsecurity/migrate"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPodSecurity provides commands for managing Pod Security Admission.
+func NewCmdPodSecurity() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pod-security",
+		Short: "Manage Pod Security Admission",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(migrate.NewCommand())
+
+	return cmd
+}
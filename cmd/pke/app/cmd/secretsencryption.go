@@ -0,0 +1,34 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/secretsencryption/rotate"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSecretsEncryption provides commands for managing the encryption of Kubernetes secrets.
+func NewCmdSecretsEncryption(c config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets-encryption",
+		Short: "Manage the encryption of Kubernetes secrets",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(rotate.NewCommand(c))
+
+	return cmd
+}
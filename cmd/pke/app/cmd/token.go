@@ -16,7 +16,10 @@ package cmd
 
 import (
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/create"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/delete"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/joincommand"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/list"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/rotate"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +33,9 @@ func NewCmdToken() *cobra.Command {
 
 	cmd.AddCommand(create.NewCommand())
 	cmd.AddCommand(list.NewCommand())
+	cmd.AddCommand(delete.NewCommand())
+	cmd.AddCommand(rotate.NewCommand())
+	cmd.AddCommand(joincommand.NewCommand())
 
 	return cmd
 }
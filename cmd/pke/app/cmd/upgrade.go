@@ -16,10 +16,12 @@ package cmd
 
 import (
 	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/upgrade/controlplane"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/upgrade/node"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/version"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/runtime/container"
 	"github.com/spf13/cobra"
 )
 
@@ -46,6 +48,7 @@ func upgradeMaster(c config.Config) *cobra.Command {
 	}
 
 	cmd.AddCommand(version.NewCommand(c))
+	cmd.AddCommand(container.NewUpgradeCommand(c))
 	cmd.AddCommand(controlplane.NewCommand(c))
 
 	phases.MakeRunnable(cmd)
@@ -53,15 +56,23 @@ func upgradeMaster(c config.Config) *cobra.Command {
 	return cmd
 }
 
+// upgradeWorkerLong explains the drain of workers, they can not evict their pods during the containerd upgrade.
+const upgradeWorkerLong = "Upgrade a single Banzai Cloud Pipeline Kubernetes Engine (PKE) worker machine.\n\n" +
+	"Workers have no admin kubeconfig to drain themselves. If the containerd version changes, drain the node from the control plane " +
+	"with `kubectl drain <node> --ignore-daemonsets --delete-emptydir-data` first and pass --" + constants.FlagNodeDrained + ", " +
+	"then uncordon it after the upgrade. The flag is not needed if containerd is already at the requested version."
+
 func upgradeWorker(c config.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "worker",
 		Short: "Upgrade a single Banzai Cloud Pipeline Kubernetes Engine (PKE) worker machine",
+		Long:  upgradeWorkerLong,
 		Args:  cobra.NoArgs,
 		RunE:  phases.RunEAllSubcommands,
 	}
 
 	cmd.AddCommand(version.NewCommand(c))
+	cmd.AddCommand(container.NewUpgradeCommand(c))
 	cmd.AddCommand(node.NewCommand(c))
 
 	phases.MakeRunnable(cmd)
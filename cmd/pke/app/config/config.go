@@ -25,6 +25,30 @@ type KubernetesConfig struct {
 }
 
 type ContainerRuntimeConfig struct {
-	Type      string `yaml:"type"`
-	Installed bool   `yaml:"installed"`
+	Type         string                    `yaml:"type"`
+	Version      string                    `yaml:"version,omitempty"`
+	CgroupDriver string                    `yaml:"cgroupDriver,omitempty"`
+	Registries   map[string]RegistryConfig `yaml:"registries,omitempty"`
+	Containerd   ContainerdConfig          `yaml:"containerd,omitempty"`
+	Installed    bool                      `yaml:"installed"`
+}
+
+// ContainerdConfig contains containerd specific settings rendered into /etc/containerd/config.toml.
+type ContainerdConfig struct {
+	Snapshotter     string   `yaml:"snapshotter,omitempty"`
+	SandboxRuntimes []string `yaml:"sandboxRuntimes,omitempty"`
+}
+
+// RegistryConfig contains the mirrors, credentials and TLS settings of a single registry host (e.g. docker.io).
+// The settings are applied to every supported container runtime.
+type RegistryConfig struct {
+	Mirrors            []string `yaml:"mirrors,omitempty"`
+	Username           string   `yaml:"username,omitempty"`
+	Password           string   `yaml:"password,omitempty"`
+	Auth               string   `yaml:"auth,omitempty"`
+	IdentityToken      string   `yaml:"identityToken,omitempty"`
+	CAFile             string   `yaml:"caFile,omitempty"`
+	CertFile           string   `yaml:"certFile,omitempty"`
+	KeyFile            string   `yaml:"keyFile,omitempty"`
+	InsecureSkipVerify bool     `yaml:"insecureSkipVerify,omitempty"`
 }
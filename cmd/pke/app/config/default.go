@@ -14,7 +14,13 @@
 
 package config
 
-const DefaultKubernetesVersion = "1.22.6"
+const (
+	DefaultKubernetesVersion = "1.22.6"
+	DefaultContainerdVersion = "1.6.8"
+
+	DefaultCgroupDriver          = "systemd"
+	DefaultContainerdSnapshotter = "overlayfs"
+)
 
 func Default() Config {
 	return Config{
@@ -23,7 +29,12 @@ func Default() Config {
 			Installed: false,
 		},
 		ContainerRuntime: ContainerRuntimeConfig{
-			Type:      "containerd",
+			Type:         "containerd",
+			Version:      DefaultContainerdVersion,
+			CgroupDriver: DefaultCgroupDriver,
+			Containerd: ContainerdConfig{
+				Snapshotter: DefaultContainerdSnapshotter,
+			},
 			Installed: false,
 		},
 	}
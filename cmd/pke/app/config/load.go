@@ -56,6 +56,18 @@ func loadDefaults(config Config) Config {
 		config.ContainerRuntime.Type = def.ContainerRuntime.Type
 	}
 
+	if config.ContainerRuntime.Version == "" && config.ContainerRuntime.Type == def.ContainerRuntime.Type {
+		config.ContainerRuntime.Version = def.ContainerRuntime.Version
+	}
+
+	if config.ContainerRuntime.CgroupDriver == "" {
+		config.ContainerRuntime.CgroupDriver = def.ContainerRuntime.CgroupDriver
+	}
+
+	if config.ContainerRuntime.Containerd.Snapshotter == "" {
+		config.ContainerRuntime.Containerd.Snapshotter = def.ContainerRuntime.Containerd.Snapshotter
+	}
+
 	return config
 }
 
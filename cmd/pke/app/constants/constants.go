@@ -71,6 +71,39 @@ const (
 
 	ContainerRuntimeContainerd = "containerd"
 	ContainerRuntimeDocker     = "docker"
+	ContainerRuntimeCRIO       = "cri-o"
+
+	// FlagContainerdVersion containerd version.
+	FlagContainerdVersion = "containerd-version"
+	// FlagTrustedPublicKey public key trusted for verifying downloaded artifacts.
+	FlagTrustedPublicKey = "trusted-public-key"
+	// FlagChecksumManifest signed checksum manifest of downloaded artifacts.
+	FlagChecksumManifest = "checksum-manifest"
+	// FlagContainerdSHA256 SHA-256 checksum of the containerd release archive. Required for versions without a known checksum.
+	FlagContainerdSHA256 = "containerd-sha256"
+	// FlagContainerdSnapshotter containerd snapshotter used by the CRI plugin.
+	FlagContainerdSnapshotter = "containerd-snapshotter"
+
+	// FlagRegistryMirrors registry mirror endpoints in <registry>=<endpoint> format.
+	FlagRegistryMirrors = "container-registry-mirror"
+	// FlagRegistryAuth registry credentials in <registry>=<username>:<password> format.
+	FlagRegistryAuth = "container-registry-auth"
+	// FlagRegistryCAFile registry CA certificate files in <registry>=<path> format.
+	FlagRegistryCAFile = "container-registry-ca-file"
+	// FlagRegistryInsecure registries with TLS certificate verification disabled.
+	FlagRegistryInsecure = "container-registry-insecure"
+
+	// FlagSandboxRuntimes sandboxed runtimes registered as containerd runtime handlers and RuntimeClasses.
+	FlagSandboxRuntimes = "sandbox-runtimes"
+
+	SandboxRuntimeGVisor = "gvisor"
+	SandboxRuntimeKata   = "kata"
+
+	// FlagCgroupDriver cgroup driver used by both the kubelet and the container runtime.
+	FlagCgroupDriver = "kubernetes-cgroup-driver"
+
+	CgroupDriverSystemd  = "systemd"
+	CgroupDriverCgroupfs = "cgroupfs"
 
 	// FlagNetworkProvider network provider for Kubernetes.
 	FlagNetworkProvider = "kubernetes-network-provider"
@@ -80,13 +113,76 @@ const (
 	FlagPodNetworkCIDR = "kubernetes-pod-network-cidr"
 	// FlagInfrastructureCIDR range of IP addresses from which the advertise address can be calculated using system's network interfaces.
 	FlagInfrastructureCIDR = "kubernetes-infrastructure-cidr"
+	// FlagInfrastructureInterface network interface from which the advertise address is selected, or default-route to use the interface of the default route.
+	FlagInfrastructureInterface = "kubernetes-infrastructure-interface"
+	// FlagNodeIP explicit address of the node, comma separated IPv4 and IPv6 addresses for dual-stack clusters.
+	FlagNodeIP = "node-ip"
+	// InfrastructureInterfaceDefaultRoute selects the interface of the default route.
+	InfrastructureInterfaceDefaultRoute = "default-route"
+	// FlagNodeCIDRMaskSize prefix length of the IPv4 pod network CIDR allocated to each node.
+	FlagNodeCIDRMaskSize = "kubernetes-node-cidr-mask-size"
+	// FlagNodeCIDRMaskSizeIPv6 prefix length of the IPv6 pod network CIDR allocated to each node.
+	FlagNodeCIDRMaskSizeIPv6 = "kubernetes-node-cidr-mask-size-ipv6"
+	// FlagExpectedNodeCount number of nodes the pod network should have room for. 0 means the size is not checked.
+	FlagExpectedNodeCount = "kubernetes-expected-node-count"
 	// FlagMTU maximum transmission unit. 0 means default value of the Kubernetes network provider is used.
 	FlagMTU = "kubernetes-mtu"
-
-	NetworkProviderNone   = "none"
-	NetworkProviderWeave  = "weave"
-	NetworkProviderCalico = "calico"
-	NetworkProviderCilium = "cilium"
+	// FlagNetworkProviderTarget network provider the cluster is migrated to.
+	FlagNetworkProviderTarget = "to"
+	// FlagAddonConfig YAML or JSON configuration file of an addon.
+	FlagAddonConfig = "config"
+	// FlagFlannelBackend backend of the Flannel network provider, one of vxlan or host-gw.
+	FlagFlannelBackend = "kubernetes-flannel-backend"
+
+	// FlagDNSReplicas number of CoreDNS replicas. 0 keeps the kubeadm default.
+	FlagDNSReplicas = "kubernetes-dns-replicas"
+	// FlagDNSUpstreams resolvers CoreDNS forwards external queries to instead of the resolvers of the node.
+	FlagDNSUpstreams = "kubernetes-dns-upstreams"
+	// FlagDNSStubDomains domains forwarded to dedicated resolvers in <domain>=<resolver> format.
+	FlagDNSStubDomains = "kubernetes-dns-stub-domains"
+	// FlagDNSHosts static host entries served by CoreDNS in <host name>=<address> format.
+	FlagDNSHosts = "kubernetes-dns-hosts"
+	// FlagDNSNodeLocalCache deploys NodeLocal DNSCache and points the kubelets to it.
+	FlagDNSNodeLocalCache = "kubernetes-dns-nodelocal-cache"
+	// FlagDNSNodeLocalAddress link-local address NodeLocal DNSCache listens on.
+	FlagDNSNodeLocalAddress = "kubernetes-dns-nodelocal-address"
+
+	// FlagNetworkPolicyProfile cluster-wide network policies applied at bootstrap, baseline or empty.
+	FlagNetworkPolicyProfile = "kubernetes-network-policy-profile"
+	// FlagNetworkPolicyExemptNamespaces namespaces left open by the network policy profile.
+	FlagNetworkPolicyExemptNamespaces = "kubernetes-network-policy-exempt-namespaces"
+
+	// FlagKubeProxyMode mode of kube-proxy, one of iptables, ipvs, nftables or none.
+	FlagKubeProxyMode = "kube-proxy-mode"
+	// FlagKubeProxyIPVSScheduler IPVS scheduler of kube-proxy.
+	FlagKubeProxyIPVSScheduler = "kube-proxy-ipvs-scheduler"
+	// FlagKubeProxyIPVSStrictARP makes kube-proxy in IPVS mode answer ARP requests for local addresses only.
+	FlagKubeProxyIPVSStrictARP = "kube-proxy-ipvs-strict-arp"
+	// FlagKubeProxyConntrackMaxPerCore maximum number of NAT connections to track per CPU core.
+	FlagKubeProxyConntrackMaxPerCore = "kube-proxy-conntrack-max-per-core"
+	// FlagKubeProxyConntrackMin minimum number of conntrack entries to allocate.
+	FlagKubeProxyConntrackMin = "kube-proxy-conntrack-min"
+	// FlagKubeProxyConntrackTCPEstablishedTimeout idle timeout of established TCP connections.
+	FlagKubeProxyConntrackTCPEstablishedTimeout = "kube-proxy-conntrack-tcp-established-timeout"
+	// FlagKubeProxyConntrackTCPCloseWaitTimeout timeout of TCP connections in CLOSE_WAIT state.
+	FlagKubeProxyConntrackTCPCloseWaitTimeout = "kube-proxy-conntrack-tcp-close-wait-timeout"
+
+	KubeProxyModeIPTables = "iptables"
+	KubeProxyModeIPVS     = "ipvs"
+	KubeProxyModeNFTables = "nftables"
+	KubeProxyModeNone     = "none"
+
+	NetworkProviderNone    = "none"
+	NetworkProviderWeave   = "weave"
+	NetworkProviderCalico  = "calico"
+	NetworkProviderCilium  = "cilium"
+	NetworkProviderFlannel = "flannel"
+	NetworkProviderAntrea  = "antrea"
+
+	FlannelBackendVXLAN  = "vxlan"
+	FlannelBackendHostGW = "host-gw"
+
+	NetworkPolicyProfileBaseline = "baseline"
 
 	// FlagCloudProvider cloud provider for kubeadm.
 	FlagCloudProvider = "kubernetes-cloud-provider"
@@ -105,11 +201,29 @@ const (
 
 	// FlagNodeName nodename for init
 	FlagNodeName = "kubernetes-node-name"
+	// FlagNodeDrained the node was cordoned and drained from the control plane before the upgrade.
+	FlagNodeDrained = "kubernetes-node-drained"
 
 	// FlagOIDCIssuerURL OIDC issuer URL
 	FlagOIDCIssuerURL = "kubernetes-oidc-issuer-url"
 	// FlagOIDCClientID OIDC client ID
 	FlagOIDCClientID = "kubernetes-oidc-client-id"
+	// FlagOIDCUsernameClaim OIDC claim to use as the user name
+	FlagOIDCUsernameClaim = "kubernetes-oidc-username-claim"
+	// FlagOIDCUsernamePrefix prefix prepended to OIDC user names
+	FlagOIDCUsernamePrefix = "kubernetes-oidc-username-prefix"
+	// FlagOIDCGroupsClaim OIDC claim to use as the user's groups
+	FlagOIDCGroupsClaim = "kubernetes-oidc-groups-claim"
+	// FlagOIDCGroupsPrefix prefix prepended to OIDC group names
+	FlagOIDCGroupsPrefix = "kubernetes-oidc-groups-prefix"
+	// FlagOIDCCAFile CA certificate of the OIDC issuer
+	FlagOIDCCAFile = "kubernetes-oidc-ca-file"
+	// FlagOIDCRequiredClaims claims OIDC tokens must contain with the given values
+	FlagOIDCRequiredClaims = "kubernetes-oidc-required-claims"
+	// FlagOIDCSigningAlgs accepted OIDC token signing algorithms
+	FlagOIDCSigningAlgs = "kubernetes-oidc-signing-algs"
+	// FlagAuthenticationConfig structured authentication configuration file
+	FlagAuthenticationConfig = "kubernetes-authentication-config"
 
 	// FlagClusterMode possible values: single, default, ha.
 	FlagClusterMode = "kubernetes-master-mode"
@@ -129,8 +243,76 @@ const (
 	// FlagAdmissionPluginPodSecurityPolicy enable admission plugin PodSecurityPolicy.
 	FlagAdmissionPluginPodSecurityPolicy = "with-plugin-psp"
 
+	// FlagPodSecurityStandard Pod Security Standard enforced by the PodSecurity admission plugin.
+	FlagPodSecurityStandard = "pod-security-standard"
+	// FlagPodSecurityAudit Pod Security Standard violations of which are recorded in the audit log.
+	FlagPodSecurityAudit = "pod-security-audit"
+	// FlagPodSecurityWarn Pod Security Standard violations of which are returned as warnings to the user.
+	FlagPodSecurityWarn = "pod-security-warn"
+	// FlagPodSecurityExemptNamespaces namespaces exempted from Pod Security Admission.
+	FlagPodSecurityExemptNamespaces = "pod-security-exempt-namespaces"
+
+	// FlagAdmissionPlugins admission plugins enabled in addition to the default ones.
+	FlagAdmissionPlugins = "admission-plugins"
+	// FlagDisableAdmissionPlugins admission plugins to disable, including default ones.
+	FlagDisableAdmissionPlugins = "disable-admission-plugins"
+	// FlagEventRateLimits limits of the EventRateLimit admission plugin.
+	FlagEventRateLimits = "event-rate-limits"
+	// FlagImagePolicyWebhookConfigFile path to a kubeconfig file defining the ImagePolicyWebhook backend.
+	FlagImagePolicyWebhookConfigFile = "image-policy-webhook-config-file"
+	// FlagImagePolicyDefaultAllow admit images when the ImagePolicyWebhook backend is unreachable.
+	FlagImagePolicyDefaultAllow = "image-policy-default-allow"
+
+	PodSecurityStandardPrivileged = "privileged"
+	PodSecurityStandardBaseline   = "baseline"
+	PodSecurityStandardRestricted = "restricted"
+
+	// FlagDryRun only print the changes without applying them.
+	FlagDryRun = "dry-run"
+
+	// FlagCSRApproverInterval interval between checks for pending kubelet serving certificate requests.
+	FlagCSRApproverInterval = "interval"
+
+	// FlagKubeconfigUser user name of the issued client certificate.
+	FlagKubeconfigUser = "user"
+	// FlagKubeconfigGroups groups of the issued client certificate.
+	FlagKubeconfigGroups = "groups"
+	// FlagKubeconfigTTL validity of the issued client certificate.
+	FlagKubeconfigTTL = "ttl"
+	// FlagKubeconfigCSRAPI sign the client certificate through the CertificateSigningRequest API.
+	FlagKubeconfigCSRAPI = "csr-api"
+	// FlagKubeconfigSerial serial number of an issued client certificate.
+	FlagKubeconfigSerial = "serial"
+	// FlagKubeconfigClusterRole cluster role bound to the user of the issued client certificate.
+	FlagKubeconfigClusterRole = "cluster-role"
+	// FlagOutputFile file to write the output to instead of the standard output.
+	FlagOutputFile = "output-file"
+
+	// FlagTokenTTL duration before the bootstrap token is automatically deleted.
+	FlagTokenTTL = "ttl"
+	// FlagTokenUsages ways in which the bootstrap token can be used.
+	FlagTokenUsages = "usages"
+	// FlagTokenGroups extra groups the bootstrap token authenticates as.
+	FlagTokenGroups = "groups"
+	// FlagTokenDescription human friendly description of the bootstrap token.
+	FlagTokenDescription = "description"
+	// FlagTokenControlPlane print the command joining an additional control plane node.
+	FlagTokenControlPlane = "control-plane"
+
 	// FlagAuditLog enable audit log.
 	FlagAuditLog = "without-audit-log"
+	// FlagAuditPolicyFile path to a custom audit policy replacing the default one.
+	FlagAuditPolicyFile = "audit-policy-file"
+	// FlagAuditLogMaxAge maximum number of days to retain old audit log files.
+	FlagAuditLogMaxAge = "audit-log-maxage"
+	// FlagAuditLogMaxBackup maximum number of audit log files to retain.
+	FlagAuditLogMaxBackup = "audit-log-maxbackup"
+	// FlagAuditLogMaxSize maximum size in megabytes of the audit log file before it gets rotated.
+	FlagAuditLogMaxSize = "audit-log-maxsize"
+	// FlagAuditWebhookConfigFile path to a kubeconfig file defining the audit webhook backend.
+	FlagAuditWebhookConfigFile = "audit-webhook-config-file"
+	// FlagAuditWebhookMode strategy for sending audit events to the webhook backend.
+	FlagAuditWebhookMode = "audit-webhook-mode"
 
 	// Azure specific flags
 	// FlagAzureTenantID the AAD Tenant ID for the Subscription that the cluster is deployed in.
@@ -175,8 +357,17 @@ const (
 	// FlagVspherePassword is the password of vCenter SSO user to use for deploying persistent volumes. (Should be avoided in favor of a K8S secret.)
 	FlagVspherePassword = "vsphere-password"
 
-	// FlagLbRange is the IPv4 range advertised via ARP and allocated for LoadBalancer Services.
+	// FlagHTTPProxy proxy used for HTTP requests by PKE, the package managers, the container runtime and the kubelet.
+	FlagHTTPProxy = "http-proxy"
+	// FlagHTTPSProxy proxy used for HTTPS requests by PKE, the package managers, the container runtime and the kubelet.
+	FlagHTTPSProxy = "https-proxy"
+	// FlagNoProxy hosts, domains and CIDRs that should not be proxied.
+	FlagNoProxy = "no-proxy"
+
+	// FlagLbRange is the comma separated list of ranges advertised via ARP and allocated for LoadBalancer Services.
 	FlagLbRange = "lb-range"
+	// FlagLbConfig is the MetalLB configuration file with address pools, BGP peers and node selectors.
+	FlagLbConfig = "lb-config"
 
 	// FlagDisableDefaultStorageClass adds default storage class.
 	FlagDisableDefaultStorageClass = "disable-default-storage-class"
@@ -200,6 +391,15 @@ const (
 	FlagExternalEtcdPrefix = "etcd-prefix"
 	// FlagEncryptionSecret use this key to encrypt secrets.
 	FlagEncryptionSecret = "encryption-secret"
+	// FlagEncryptionProvider provider used to encrypt secrets.
+	FlagEncryptionProvider = "encryption-provider"
+	// FlagEncryptionKMSEndpoint unix socket of the local KMS plugin.
+	FlagEncryptionKMSEndpoint = "encryption-kms-endpoint"
+
+	EncryptionProviderAESCBC    = "aescbc"
+	EncryptionProviderAESGCM    = "aesgcm"
+	EncryptionProviderSecretbox = "secretbox"
+	EncryptionProviderKMS       = "kms"
 )
 
 var (
@@ -0,0 +1,208 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cischeck
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	manifestsDir         = "/etc/kubernetes/manifests"
+	kubeletConfig        = "/var/lib/kubelet/config.yaml"
+	kubeletFlagsEnv      = "/var/lib/kubelet/kubeadm-flags.env"
+	kubeletKubeConfig    = "/etc/kubernetes/kubelet.conf"
+	kubeletServiceDropIn = "/etc/systemd/system/kubelet.service.d/10-kubeadm.conf"
+	pkiDir               = "/etc/kubernetes/pki"
+	etcdDataDir          = "/var/lib/etcd"
+)
+
+// node gives access to the configuration of the components running on the node, loading each of them once.
+type node struct {
+	manifests     map[string]map[string]string
+	kubeletFlags  map[string]string
+	kubeletConfig map[string]interface{}
+}
+
+func newNode() *node {
+	return &node{manifests: make(map[string]map[string]string)}
+}
+
+// isMaster tells whether the node runs the API server.
+func isMaster() bool {
+	_, err := os.Stat(manifestPath("kube-apiserver"))
+	return err == nil
+}
+
+func manifestPath(component string) string {
+	return manifestsDir + "/" + component + ".yaml"
+}
+
+// args returns the command line arguments of the static pod component, nil if the manifest does not exist.
+func (n *node) args(component string) (map[string]string, error) {
+	if args, ok := n.manifests[component]; ok {
+		return args, nil
+	}
+
+	b, err := ioutil.ReadFile(manifestPath(component))
+	if os.IsNotExist(err) {
+		n.manifests[component] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := parseManifestArgs(b)
+	if err != nil {
+		return nil, err
+	}
+	n.manifests[component] = args
+
+	return args, nil
+}
+
+// parseManifestArgs returns the flags of the first container of the static pod manifest.
+func parseManifestArgs(b []byte) (map[string]string, error) {
+	var pod struct {
+		Spec struct {
+			Containers []struct {
+				Command []string `json:"command"`
+				Args    []string `json:"args"`
+			} `json:"containers"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(b, &pod); err != nil {
+		return nil, err
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return map[string]string{}, nil
+	}
+
+	c := pod.Spec.Containers[0]
+	return parseArgs(append(c.Command, c.Args...)), nil
+}
+
+// parseArgs maps --flag=value and --flag arguments to their values, boolean flags without value are "true".
+func parseArgs(args []string) map[string]string {
+	m := make(map[string]string)
+	for _, a := range args {
+		if !strings.HasPrefix(a, "--") {
+			continue
+		}
+		a = strings.TrimPrefix(a, "--")
+		if i := strings.Index(a, "="); i >= 0 {
+			m[a[:i]] = strings.Trim(a[i+1:], `"`)
+		} else {
+			m[a] = "true"
+		}
+	}
+	return m
+}
+
+// kubelet returns a kubelet setting, command line flags take precedence over the configuration file.
+func (n *node) kubelet(flag string, path ...string) (string, bool, error) {
+	if n.kubeletFlags == nil {
+		n.kubeletFlags = make(map[string]string)
+		f, err := os.Open(kubeletFlagsEnv)
+		if err != nil && !os.IsNotExist(err) {
+			return "", false, err
+		}
+		if err == nil {
+			scn := bufio.NewScanner(f)
+			for scn.Scan() {
+				line := strings.TrimSpace(scn.Text())
+				if i := strings.Index(line, "="); i >= 0 && !strings.HasPrefix(line, "#") {
+					for k, v := range parseArgs(strings.Fields(strings.Trim(line[i+1:], `"`))) {
+						n.kubeletFlags[k] = v
+					}
+				}
+			}
+			_ = f.Close()
+		}
+	}
+	if v, ok := n.kubeletFlags[flag]; ok && flag != "" {
+		return v, true, nil
+	}
+
+	if n.kubeletConfig == nil {
+		b, err := ioutil.ReadFile(kubeletConfig)
+		if err != nil {
+			return "", false, err
+		}
+		if err := yaml.Unmarshal(b, &n.kubeletConfig); err != nil {
+			return "", false, err
+		}
+	}
+
+	v, ok := lookup(n.kubeletConfig, path...)
+	return v, ok, nil
+}
+
+// lookup returns the value at path in the YAML document formatted as string.
+func lookup(doc map[string]interface{}, path ...string) (string, bool) {
+	var v interface{} = doc
+	for _, p := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[p]; !ok {
+			return "", false
+		}
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case []interface{}:
+		s := make([]string, 0, len(t))
+		for _, e := range t {
+			s = append(s, fmt.Sprint(e))
+		}
+		return strings.Join(s, ","), true
+	default:
+		return fmt.Sprint(t), true
+	}
+}
+
+// permissions returns the permission bits of the file.
+func permissions(path string) (os.FileMode, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Mode().Perm(), nil
+}
+
+// listContains tells whether the comma separated list contains the value.
+func listContains(list, value string) bool {
+	for _, e := range strings.Split(list, ",") {
+		if strings.TrimSpace(e) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cischeck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "empty",
+			want: map[string]string{},
+		},
+		{
+			name: "flags",
+			args: []string{"kube-apiserver", "--profiling=false", "--allow-privileged", `--audit-log-path="/var/log/audit"`, "--a=b=c"},
+			want: map[string]string{
+				"profiling":        "false",
+				"allow-privileged": "true",
+				"audit-log-path":   "/var/log/audit",
+				"a":                "b=c",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, parseArgs(tt.args))
+		})
+	}
+}
+
+func TestParseManifestArgs(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-scheduler
+spec:
+  containers:
+  - command:
+    - kube-scheduler
+    - --bind-address=127.0.0.1
+    args:
+    - --profiling=false
+`
+	args, err := parseManifestArgs([]byte(manifest))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"bind-address": "127.0.0.1", "profiling": "false"}, args)
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"readOnlyPort": float64(0),
+		"authentication": map[string]interface{}{
+			"anonymous": map[string]interface{}{"enabled": false},
+		},
+		"tlsCipherSuites": []interface{}{"A", "B"},
+	}
+
+	tests := []struct {
+		path  []string
+		want  string
+		found bool
+	}{
+		{path: []string{"readOnlyPort"}, want: "0", found: true},
+		{path: []string{"authentication", "anonymous", "enabled"}, want: "false", found: true},
+		{path: []string{"tlsCipherSuites"}, want: "A,B", found: true},
+		{path: []string{"authentication", "webhook", "enabled"}},
+		{path: []string{"readOnlyPort", "x"}},
+	}
+	for _, tt := range tests {
+		v, ok := lookup(doc, tt.path...)
+		require.Equal(t, tt.found, ok, tt.path)
+		require.Equal(t, tt.want, v, tt.path)
+	}
+}
+
+func TestFilePermissions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cischeck")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tests := []struct {
+		name string
+		mode os.FileMode
+		want string
+	}{
+		{name: "restrictive", mode: 0600, want: statusPass},
+		{name: "equal", mode: 0644, want: statusPass},
+		{name: "permissive", mode: 0664, want: statusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			require.NoError(t, ioutil.WriteFile(path, nil, tt.mode))
+			require.NoError(t, os.Chmod(path, tt.mode))
+
+			status, _, err := filePermissions("0", false, path, 0644).audit(nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, status)
+		})
+	}
+
+	status, _, err := filePermissions("0", false, filepath.Join(dir, "missing"), 0644).audit(nil)
+	require.NoError(t, err)
+	require.Equal(t, statusSkip, status)
+}
+
+func TestAudit(t *testing.T) {
+	pass := func(*node) (string, string, error) { return statusPass, "", nil }
+	fail := func(*node) (string, string, error) { return statusFail, "x", nil }
+	cs := []check{
+		{id: "1", master: true, audit: pass},
+		{id: "2", remediation: "fix", audit: fail},
+		{id: "3", audit: pass},
+		expectFail(check{id: "4", remediation: "fix", audit: fail}, "by design"),
+	}
+
+	r, err := audit(newNode(), false, cs)
+	require.NoError(t, err)
+	require.Equal(t, Summary{Pass: 1, Fail: 1, Warn: 1}, r.Summary)
+	require.Equal(t, "fix", r.Results[0].Remediation)
+	require.Equal(t, statusWarn, r.Results[2].Status)
+	require.Equal(t, "by design", r.Results[2].Remediation)
+
+	r, err = audit(newNode(), true, cs)
+	require.NoError(t, err)
+	require.Equal(t, Summary{Pass: 2, Fail: 1, Warn: 1}, r.Summary)
+}
+
+func TestStrongCiphers(t *testing.T) {
+	require.True(t, strongCiphers("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_RSA_WITH_AES_256_GCM_SHA384"))
+	require.False(t, strongCiphers("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_RSA_WITH_RC4_128_SHA"))
+	require.False(t, strongCiphers(""))
+}
@@ -0,0 +1,475 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cischeck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	statusPass = "PASS"
+	statusFail = "FAIL"
+	statusSkip = "SKIP"
+	statusWarn = "WARN"
+)
+
+// check is a CIS Kubernetes Benchmark control.
+type check struct {
+	id          string
+	text        string
+	remediation string
+	// master checks are only run on master nodes
+	master bool
+	// expected explains why the check fails on PKE clusters by design, such failures are reported as warnings
+	expected string
+	audit    func(n *node) (status string, actual string, err error)
+}
+
+const (
+	apiServer         = "kube-apiserver"
+	controllerManager = "kube-controller-manager"
+	scheduler         = "kube-scheduler"
+	etcd              = "etcd"
+)
+
+// strongCipherSuites are the TLS cipher suites accepted by the benchmark.
+var strongCipherSuites = map[string]bool{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256":       true,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":         true,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":        true,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256": true,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":         true,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":          true,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256":   true,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384":       true,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":               true,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":               true,
+}
+
+func checks() []check {
+	return []check{
+		// 1.1 Control Plane Node Configuration Files
+		filePermissions("1.1.1", true, manifestPath(apiServer), 0644),
+		fileOwnership("1.1.2", true, manifestPath(apiServer), "root:root"),
+		filePermissions("1.1.3", true, manifestPath(controllerManager), 0644),
+		fileOwnership("1.1.4", true, manifestPath(controllerManager), "root:root"),
+		filePermissions("1.1.5", true, manifestPath(scheduler), 0644),
+		fileOwnership("1.1.6", true, manifestPath(scheduler), "root:root"),
+		filePermissions("1.1.7", true, manifestPath(etcd), 0644),
+		fileOwnership("1.1.8", true, manifestPath(etcd), "root:root"),
+		filePermissions("1.1.11", true, etcdDataDir, 0700),
+		fileOwnership("1.1.12", true, etcdDataDir, "etcd:etcd"),
+		filePermissions("1.1.13", true, "/etc/kubernetes/admin.conf", 0600),
+		fileOwnership("1.1.14", true, "/etc/kubernetes/admin.conf", "root:root"),
+		filePermissions("1.1.15", true, "/etc/kubernetes/scheduler.conf", 0600),
+		fileOwnership("1.1.16", true, "/etc/kubernetes/scheduler.conf", "root:root"),
+		filePermissions("1.1.17", true, "/etc/kubernetes/controller-manager.conf", 0600),
+		fileOwnership("1.1.18", true, "/etc/kubernetes/controller-manager.conf", "root:root"),
+		pkiOwnership("1.1.19"),
+		pkiPermissions("1.1.20", ".crt", 0644),
+		pkiPermissions("1.1.21", ".key", 0600),
+
+		// 1.2 API Server
+		expectFail(argEquals("1.2.1", apiServer, "anonymous-auth", "false"),
+			"anonymous requests are required by the kubelet liveness probes of the API server (/livez, /readyz) and by kubeadm join discovery, they are limited by RBAC"),
+		argNotSet("1.2.2", apiServer, "token-auth-file"),
+		argSet("1.2.5", apiServer, "kubelet-certificate-authority"),
+		argNotContains("1.2.6", apiServer, "authorization-mode", "AlwaysAllow"),
+		argContains("1.2.7", apiServer, "authorization-mode", "Node"),
+		argContains("1.2.8", apiServer, "authorization-mode", "RBAC"),
+		argContains("1.2.9", apiServer, "enable-admission-plugins", "EventRateLimit"),
+		argNotContains("1.2.10", apiServer, "enable-admission-plugins", "AlwaysAdmit"),
+		argContains("1.2.11", apiServer, "enable-admission-plugins", "AlwaysPullImages"),
+		argNotContains("1.2.14", apiServer, "disable-admission-plugins", "ServiceAccount"),
+		argNotContains("1.2.15", apiServer, "disable-admission-plugins", "NamespaceLifecycle"),
+		argContains("1.2.16", apiServer, "enable-admission-plugins", "NodeRestriction"),
+		argNotEquals("1.2.17", apiServer, "secure-port", "0"),
+		argEquals("1.2.18", apiServer, "profiling", "false"),
+		argSet("1.2.19", apiServer, "audit-log-path"),
+		argAtLeast("1.2.20", apiServer, "audit-log-maxage", 30),
+		argAtLeast("1.2.21", apiServer, "audit-log-maxbackup", 10),
+		argAtLeast("1.2.22", apiServer, "audit-log-maxsize", 100),
+		argEquals("1.2.24", apiServer, "service-account-lookup", "true"),
+		argSet("1.2.25", apiServer, "service-account-key-file"),
+		argSet("1.2.26", apiServer, "etcd-certfile", "etcd-keyfile"),
+		argSet("1.2.27", apiServer, "tls-cert-file", "tls-private-key-file"),
+		argSet("1.2.28", apiServer, "client-ca-file"),
+		argSet("1.2.29", apiServer, "etcd-cafile"),
+		argSet("1.2.31", apiServer, "encryption-provider-config"),
+		argStrongCiphers("1.2.33", apiServer),
+
+		// 1.3 Controller Manager
+		argSet("1.3.1", controllerManager, "terminated-pod-gc-threshold"),
+		argEquals("1.3.2", controllerManager, "profiling", "false"),
+		argEquals("1.3.3", controllerManager, "use-service-account-credentials", "true"),
+		argSet("1.3.4", controllerManager, "service-account-private-key-file"),
+		argSet("1.3.5", controllerManager, "root-ca-file"),
+		argContains("1.3.6", controllerManager, "feature-gates", "RotateKubeletServerCertificate=true"),
+		argEquals("1.3.7", controllerManager, "bind-address", "127.0.0.1"),
+
+		// 1.4 Scheduler
+		argEquals("1.4.1", scheduler, "profiling", "false"),
+		argEquals("1.4.2", scheduler, "bind-address", "127.0.0.1"),
+
+		// 2 Etcd
+		argSet("2.1", etcd, "cert-file", "key-file"),
+		argEquals("2.2", etcd, "client-cert-auth", "true"),
+		argNotEquals("2.3", etcd, "auto-tls", "true"),
+		argSet("2.4", etcd, "peer-cert-file", "peer-key-file"),
+		argEquals("2.5", etcd, "peer-client-cert-auth", "true"),
+		argNotEquals("2.6", etcd, "peer-auto-tls", "true"),
+
+		// 4.1 Worker Node Configuration Files
+		filePermissions("4.1.1", false, kubeletServiceDropIn, 0644),
+		fileOwnership("4.1.2", false, kubeletServiceDropIn, "root:root"),
+		filePermissions("4.1.5", false, kubeletKubeConfig, 0644),
+		fileOwnership("4.1.6", false, kubeletKubeConfig, "root:root"),
+		filePermissions("4.1.7", false, pkiDir+"/ca.crt", 0644),
+		fileOwnership("4.1.8", false, pkiDir+"/ca.crt", "root:root"),
+		filePermissions("4.1.9", false, kubeletConfig, 0644),
+		fileOwnership("4.1.10", false, kubeletConfig, "root:root"),
+
+		// 4.2 Kubelet
+		kubeletEquals("4.2.1", "anonymous-auth", "false", "authentication", "anonymous", "enabled"),
+		kubeletNotEquals("4.2.2", "authorization-mode", "AlwaysAllow", "authorization", "mode"),
+		kubeletSet("4.2.3", "client-ca-file", "authentication", "x509", "clientCAFile"),
+		kubeletReadOnlyPort("4.2.4"),
+		kubeletNotEquals("4.2.5", "streaming-connection-idle-timeout", "0", "streamingConnectionIdleTimeout"),
+		kubeletEquals("4.2.6", "protect-kernel-defaults", "true", "protectKernelDefaults"),
+		kubeletNotEquals("4.2.7", "make-iptables-util-chains", "false", "makeIPTablesUtilChains"),
+		kubeletNotEquals("4.2.11", "rotate-certificates", "false", "rotateCertificates"),
+		kubeletEquals("4.2.12", "", "true", "serverTLSBootstrap"),
+		kubeletStrongCiphers("4.2.13"),
+	}
+}
+
+// expectFail marks a check failing by design.
+func expectFail(c check, reason string) check {
+	c.expected = reason
+	return c
+}
+
+func filePermissions(id string, master bool, path string, max os.FileMode) check {
+	return check{
+		id:          id,
+		text:        fmt.Sprintf("Ensure that the %s permissions are set to %o or more restrictive", path, max),
+		remediation: fmt.Sprintf("chmod %o %s", max, path),
+		master:      master,
+		audit: func(*node) (string, string, error) {
+			perm, err := permissions(path)
+			if os.IsNotExist(err) {
+				return statusSkip, "not found", nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+			actual := fmt.Sprintf("%o", perm)
+			if perm&^max != 0 {
+				return statusFail, actual, nil
+			}
+			return statusPass, actual, nil
+		},
+	}
+}
+
+func fileOwnership(id string, master bool, path, want string) check {
+	return check{
+		id:          id,
+		text:        fmt.Sprintf("Ensure that the %s ownership is set to %s", path, want),
+		remediation: fmt.Sprintf("chown -R %s %s", want, path),
+		master:      master,
+		audit: func(*node) (string, string, error) {
+			actual, err := owner(path)
+			if os.IsNotExist(err) {
+				return statusSkip, "not found", nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if actual != want {
+				return statusFail, actual, nil
+			}
+			return statusPass, actual, nil
+		},
+	}
+}
+
+func pkiOwnership(id string) check {
+	return check{
+		id:          id,
+		text:        fmt.Sprintf("Ensure that the %s directory and file ownership is set to root:root", pkiDir),
+		remediation: fmt.Sprintf("chown -R root:root %s", pkiDir),
+		master:      true,
+		audit: func(*node) (string, string, error) {
+			var wrong []string
+			err := filepath.Walk(pkiDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				o, err := owner(path)
+				if err != nil {
+					return err
+				}
+				if o != "root:root" {
+					wrong = append(wrong, path+" "+o)
+				}
+				return nil
+			})
+			if os.IsNotExist(err) {
+				return statusSkip, "not found", nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if len(wrong) > 0 {
+				return statusFail, strings.Join(wrong, ", "), nil
+			}
+			return statusPass, "root:root", nil
+		},
+	}
+}
+
+func pkiPermissions(id, suffix string, max os.FileMode) check {
+	return check{
+		id:          id,
+		text:        fmt.Sprintf("Ensure that the %s/*%s file permissions are set to %o or more restrictive", pkiDir, suffix, max),
+		remediation: fmt.Sprintf("find %s -name '*%s' -exec chmod %o {} +", pkiDir, suffix, max),
+		master:      true,
+		audit: func(*node) (string, string, error) {
+			var wrong []string
+			err := filepath.Walk(pkiDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.Mode().IsRegular() && strings.HasSuffix(path, suffix) && info.Mode().Perm()&^max != 0 {
+					wrong = append(wrong, fmt.Sprintf("%s %o", path, info.Mode().Perm()))
+				}
+				return nil
+			})
+			if os.IsNotExist(err) {
+				return statusSkip, "not found", nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if len(wrong) > 0 {
+				return statusFail, strings.Join(wrong, ", "), nil
+			}
+			return statusPass, fmt.Sprintf("%o or more restrictive", max), nil
+		},
+	}
+}
+
+// argCheck checks the command line flags of a static pod component.
+func argCheck(id, component, text, remediation string, flags []string, test func(args map[string]string) bool) check {
+	return check{
+		id:          id,
+		text:        text,
+		remediation: remediation + " in " + manifestPath(component),
+		master:      true,
+		audit: func(n *node) (string, string, error) {
+			args, err := n.args(component)
+			if err != nil {
+				return "", "", err
+			}
+			if args == nil {
+				return statusSkip, component + " manifest not found", nil
+			}
+
+			var actual []string
+			for _, f := range flags {
+				if v, ok := args[f]; ok {
+					actual = append(actual, "--"+f+"="+v)
+				} else {
+					actual = append(actual, "--"+f+" not set")
+				}
+			}
+			if !test(args) {
+				return statusFail, strings.Join(actual, " "), nil
+			}
+			return statusPass, strings.Join(actual, " "), nil
+		},
+	}
+}
+
+func argEquals(id, component, flag, value string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument is set to %s", component, flag, value),
+		fmt.Sprintf("Set --%s=%s", flag, value),
+		[]string{flag},
+		func(args map[string]string) bool { return args[flag] == value },
+	)
+}
+
+func argNotEquals(id, component, flag, value string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument is not set to %s", component, flag, value),
+		fmt.Sprintf("Remove --%s=%s", flag, value),
+		[]string{flag},
+		func(args map[string]string) bool { return args[flag] != value },
+	)
+}
+
+func argSet(id, component string, flags ...string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument(s) are set as appropriate", component, strings.Join(flags, ", --")),
+		fmt.Sprintf("Set --%s", strings.Join(flags, ", --")),
+		flags,
+		func(args map[string]string) bool {
+			for _, f := range flags {
+				if args[f] == "" {
+					return false
+				}
+			}
+			return true
+		},
+	)
+}
+
+func argNotSet(id, component, flag string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument is not set", component, flag),
+		fmt.Sprintf("Remove --%s", flag),
+		[]string{flag},
+		func(args map[string]string) bool { _, ok := args[flag]; return !ok },
+	)
+}
+
+func argContains(id, component, flag, value string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument includes %s", component, flag, value),
+		fmt.Sprintf("Add %s to --%s", value, flag),
+		[]string{flag},
+		func(args map[string]string) bool { return listContains(args[flag], value) },
+	)
+}
+
+func argNotContains(id, component, flag, value string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument does not include %s", component, flag, value),
+		fmt.Sprintf("Remove %s from --%s", value, flag),
+		[]string{flag},
+		func(args map[string]string) bool { return !listContains(args[flag], value) },
+	)
+}
+
+func argAtLeast(id, component, flag string, min int) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s --%s argument is set to %d or as appropriate", component, flag, min),
+		fmt.Sprintf("Set --%s=%d or greater (pke install master --%s)", flag, min, flag),
+		[]string{flag},
+		func(args map[string]string) bool {
+			v, err := strconv.Atoi(args[flag])
+			return err == nil && v >= min
+		},
+	)
+}
+
+func argStrongCiphers(id, component string) check {
+	return argCheck(id, component,
+		fmt.Sprintf("Ensure that the %s only makes use of strong cryptographic ciphers", component),
+		"Set --tls-cipher-suites to strong ciphers only",
+		[]string{"tls-cipher-suites"},
+		func(args map[string]string) bool { return strongCiphers(args["tls-cipher-suites"]) },
+	)
+}
+
+func strongCiphers(list string) bool {
+	if list == "" {
+		return false
+	}
+	for _, c := range strings.Split(list, ",") {
+		if !strongCipherSuites[strings.TrimSpace(c)] {
+			return false
+		}
+	}
+	return true
+}
+
+// kubeletCheck checks a kubelet setting given either as a command line flag or in the configuration file.
+func kubeletCheck(id, text, remediation, flag string, path []string, test func(value string, set bool) bool) check {
+	return check{
+		id:          id,
+		text:        text,
+		remediation: remediation,
+		audit: func(n *node) (string, string, error) {
+			v, ok, err := n.kubelet(flag, path...)
+			if os.IsNotExist(err) {
+				return statusSkip, "kubelet configuration not found", nil
+			}
+			if err != nil {
+				return "", "", err
+			}
+			actual := strings.Join(path, ".") + "=" + v
+			if !ok {
+				actual = strings.Join(path, ".") + " not set"
+			}
+			if !test(v, ok) {
+				return statusFail, actual, nil
+			}
+			return statusPass, actual, nil
+		},
+	}
+}
+
+func kubeletEquals(id, flag, value string, path ...string) check {
+	return kubeletCheck(id,
+		fmt.Sprintf("Ensure that the kubelet %s is set to %s", strings.Join(path, "."), value),
+		fmt.Sprintf("Set %s: %s in %s", strings.Join(path, "."), value, kubeletConfig),
+		flag, path,
+		func(v string, set bool) bool { return set && v == value },
+	)
+}
+
+func kubeletNotEquals(id, flag, value string, path ...string) check {
+	return kubeletCheck(id,
+		fmt.Sprintf("Ensure that the kubelet %s is not set to %s", strings.Join(path, "."), value),
+		fmt.Sprintf("Remove %s: %s from %s", strings.Join(path, "."), value, kubeletConfig),
+		flag, path,
+		func(v string, set bool) bool { return v != value },
+	)
+}
+
+func kubeletSet(id, flag string, path ...string) check {
+	return kubeletCheck(id,
+		fmt.Sprintf("Ensure that the kubelet %s is set as appropriate", strings.Join(path, ".")),
+		fmt.Sprintf("Set %s in %s", strings.Join(path, "."), kubeletConfig),
+		flag, path,
+		func(v string, set bool) bool { return set && v != "" },
+	)
+}
+
+func kubeletReadOnlyPort(id string) check {
+	return kubeletCheck(id,
+		"Ensure that the kubelet readOnlyPort is set to 0",
+		fmt.Sprintf("Set readOnlyPort: 0 in %s", kubeletConfig),
+		"read-only-port", []string{"readOnlyPort"},
+		// the read-only port is disabled by default in the configuration file
+		func(v string, set bool) bool { return !set || v == "0" },
+	)
+}
+
+func kubeletStrongCiphers(id string) check {
+	return kubeletCheck(id,
+		"Ensure that the kubelet only makes use of strong cryptographic ciphers",
+		fmt.Sprintf("Set tlsCipherSuites to strong ciphers only in %s", kubeletConfig),
+		"tls-cipher-suites", []string{"tlsCipherSuites"},
+		func(v string, set bool) bool { return strongCiphers(v) },
+	)
+}
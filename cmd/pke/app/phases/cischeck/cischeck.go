@@ -0,0 +1,192 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cischeck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "cis-check"
+	short = "Audit the node against the CIS Kubernetes Benchmark"
+)
+
+var _ phases.Runnable = (*CISCheck)(nil)
+
+type CISCheck struct {
+	o      string
+	errOut io.Writer
+}
+
+// Result is the outcome of a single benchmark control.
+type Result struct {
+	ID          string `json:"id"`
+	Text        string `json:"text"`
+	Status      string `json:"status"`
+	Actual      string `json:"actual,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Summary counts the results by status.
+type Summary struct {
+	Pass int `json:"pass"`
+	Fail int `json:"fail"`
+	Skip int `json:"skip"`
+	Warn int `json:"warn"`
+}
+
+// Report is the outcome of the audit.
+type Report struct {
+	Master  bool     `json:"master"`
+	Results []Result `json:"results"`
+	Summary Summary  `json:"summary"`
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&CISCheck{})
+}
+
+func (*CISCheck) Use() string {
+	return use
+}
+
+func (*CISCheck) Short() string {
+	return short
+}
+
+func (*CISCheck) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringP(constants.FlagOutput, constants.FlagOutputShort, "", "Output format; available options are 'text' and 'json'. With 'json' failed checks are reported by the exit code only")
+}
+
+func (c *CISCheck) Validate(cmd *cobra.Command) error {
+	var err error
+	c.o, err = cmd.Flags().GetString(constants.FlagOutput)
+	if err != nil {
+		return err
+	}
+
+	switch c.o {
+	case "", "text", "json":
+	default:
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: unsupported output format %q", constants.FlagOutput, c.o)
+	}
+
+	if c.o == "json" {
+		// keep the output parseable, audit errors are printed by Run
+		cmd.SilenceErrors = true
+		c.errOut = cmd.ErrOrStderr()
+	}
+
+	return nil
+}
+
+func (c *CISCheck) Run(out io.Writer) error {
+	r, err := audit(newNode(), isMaster(), checks())
+	if err != nil {
+		if c.errOut != nil {
+			_, _ = fmt.Fprintf(c.errOut, "Error: %v\n", err)
+		}
+		return err
+	}
+
+	switch c.o {
+	case "json":
+		b, err := json.MarshalIndent(&r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(b))
+	default:
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "ID\tStatus\tCheck\tActual\n")
+		for _, res := range r.Results {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.ID, res.Status, res.Text, res.Actual)
+		}
+		_ = tw.Flush()
+
+		if r.Summary.Fail > 0 {
+			_, _ = fmt.Fprintf(out, "\nRemediations:\n")
+			for _, res := range r.Results {
+				if res.Status == statusFail {
+					_, _ = fmt.Fprintf(out, "%s %s\n", res.ID, res.Remediation)
+				}
+			}
+		}
+		if r.Summary.Warn > 0 {
+			_, _ = fmt.Fprintf(out, "\nExpected failures:\n")
+			for _, res := range r.Results {
+				if res.Status == statusWarn {
+					_, _ = fmt.Fprintf(out, "%s %s\n", res.ID, res.Remediation)
+				}
+			}
+		}
+		_, _ = fmt.Fprintf(out, "\n[%s] %d checks PASS, %d checks FAIL, %d checks WARN, %d checks SKIP\n", use, r.Summary.Pass, r.Summary.Fail, r.Summary.Warn, r.Summary.Skip)
+	}
+
+	if r.Summary.Fail > 0 {
+		return errors.Errorf("%d checks failed", r.Summary.Fail)
+	}
+
+	return nil
+}
+
+// audit runs the checks applicable to the node.
+func audit(n *node, master bool, cs []check) (Report, error) {
+	r := Report{Master: master}
+	for _, c := range cs {
+		if c.master && !master {
+			continue
+		}
+
+		status, actual, err := c.audit(n)
+		if err != nil {
+			return r, errors.Wrapf(err, "check %s", c.id)
+		}
+
+		res := Result{
+			ID:     c.id,
+			Text:   c.text,
+			Status: status,
+			Actual: actual,
+		}
+		switch status {
+		case statusPass:
+			r.Summary.Pass++
+		case statusFail:
+			if c.expected != "" {
+				res.Status = statusWarn
+				res.Remediation = c.expected
+				r.Summary.Warn++
+				break
+			}
+			r.Summary.Fail++
+			res.Remediation = c.remediation
+		default:
+			r.Summary.Skip++
+		}
+		r.Results = append(r.Results, res)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,48 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cischeck
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"emperror.dev/errors"
+)
+
+// owner returns the user and group owning the file in user:group format.
+func owner(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", errors.Errorf("unable to get owner of %q", path)
+	}
+
+	uid := strconv.FormatUint(uint64(st.Uid), 10)
+	gid := strconv.FormatUint(uint64(st.Gid), 10)
+	u, g := uid, gid
+	if usr, err := user.LookupId(uid); err == nil {
+		u = usr.Username
+	}
+	if grp, err := user.LookupGroupId(gid); err == nil {
+		g = grp.Name
+	}
+
+	return u + ":" + g, nil
+}
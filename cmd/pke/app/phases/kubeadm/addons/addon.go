@@ -0,0 +1,149 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addons
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	use        = "addons"
+	cmdKubectl = "kubectl"
+
+	// KubeConfig is the admin kubeconfig of the masters the addon commands use.
+	KubeConfig = "/etc/kubernetes/admin.conf"
+)
+
+// Addon is a cluster component installed from an embedded, versioned manifest.
+// The configuration of an addon is JSON, it is recorded next to the installed version,
+// so the addon can be upgraded with the same settings later.
+type Addon interface {
+	// Name identifies the addon.
+	Name() string
+	// Version of the embedded manifest.
+	Version() string
+	// Render returns the manifest of the addon.
+	Render(config []byte) (string, error)
+	// Apply installs the addon or updates it in place.
+	Apply(out io.Writer, kubeConfig string, config []byte) error
+	// Healthy waits until the workloads of the addon are ready.
+	Healthy(out io.Writer, kubeConfig string) error
+	// Upgrade replaces the installed version of the addon with the embedded one.
+	Upgrade(out io.Writer, kubeConfig string, config []byte, from string) error
+	// Delete removes the addon from the cluster.
+	Delete(out io.Writer, kubeConfig string, config []byte) error
+}
+
+// Workload is a DaemonSet or Deployment of an addon, for example kube-system daemonset/calico-node.
+type Workload struct {
+	Namespace string
+	Resource  string
+}
+
+// Manifest is an addon consisting of a single manifest applied with kubectl.
+type Manifest struct {
+	AddonName    string
+	AddonVersion string
+	RenderFunc   func(config []byte) (string, error)
+	// Workloads checked by Healthy.
+	Workloads []Workload
+	// Permanent addons can not be deleted, the reason is returned instead.
+	Permanent string
+}
+
+var _ Addon = Manifest{}
+
+func (m Manifest) Name() string {
+	return m.AddonName
+}
+
+func (m Manifest) Version() string {
+	return m.AddonVersion
+}
+
+func (m Manifest) Render(config []byte) (string, error) {
+	return m.RenderFunc(config)
+}
+
+func (m Manifest) Apply(out io.Writer, kubeConfig string, config []byte) error {
+	manifest, err := m.Render(config)
+	if err != nil {
+		return err
+	}
+
+	return ApplyManifest(out, kubeConfig, manifest)
+}
+
+func (m Manifest) Healthy(out io.Writer, kubeConfig string) error {
+	for _, w := range m.Workloads {
+		// kubectl -n <namespace> rollout status <resource> --timeout=5m
+		if err := Kubectl(out, kubeConfig, "", "-n", w.Namespace, "rollout", "status", w.Resource, "--timeout=5m"); err != nil {
+			return errors.Wrapf(err, "%s is not ready", w.Resource)
+		}
+	}
+	return nil
+}
+
+// Upgrade applies the embedded manifest, the resources of the addon are updated in place.
+func (m Manifest) Upgrade(out io.Writer, kubeConfig string, config []byte, from string) error {
+	return m.Apply(out, kubeConfig, config)
+}
+
+func (m Manifest) Delete(out io.Writer, kubeConfig string, config []byte) error {
+	if m.Permanent != "" {
+		return errors.Errorf("%s can not be disabled: %s", m.AddonName, m.Permanent)
+	}
+
+	manifest, err := m.Render(config)
+	if err != nil {
+		return err
+	}
+
+	// kubectl delete --ignore-not-found -f -
+	return Kubectl(out, kubeConfig, manifest, "delete", "--ignore-not-found", "-f", "-")
+}
+
+// ApplyManifest applies a manifest. Custom resources can only be created once their definitions
+// are established, so a manifest defining both is applied again after the definitions are ready.
+func ApplyManifest(out io.Writer, kubeConfig, manifest string) error {
+	// kubectl apply -f -
+	err := Kubectl(out, kubeConfig, manifest, "apply", "-f", "-")
+	if err == nil || !strings.Contains(manifest, "kind: CustomResourceDefinition") {
+		return err
+	}
+
+	// kubectl wait --for condition=established crd --all --timeout=1m
+	if err := Kubectl(out, kubeConfig, "", "wait", "--for", "condition=established", "crd", "--all", "--timeout=1m"); err != nil {
+		return err
+	}
+	return Kubectl(out, kubeConfig, manifest, "apply", "-f", "-")
+}
+
+// Kubectl runs kubectl with the given kubeconfig and standard input.
+func Kubectl(out io.Writer, kubeConfig, stdin string, args ...string) error {
+	cmd := runner.Cmd(out, cmdKubectl, args...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disable
+
+import (
+	"fmt"
+	"io"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+)
+
+const (
+	use   = "disable"
+	short = "Remove an addon"
+)
+
+var _ phases.Runnable = (*Disable)(nil)
+
+type Disable struct {
+	registry *addons.Registry
+	addon    addons.Addon
+}
+
+func NewCommand(r *addons.Registry) *cobra.Command {
+	cmd := phases.NewCommand(&Disable{registry: r})
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func (*Disable) Use() string {
+	return use + " <addon>"
+}
+
+func (*Disable) Short() string {
+	return short
+}
+
+func (*Disable) RegisterFlags(flags *pflag.FlagSet) {}
+
+func (d *Disable) Validate(cmd *cobra.Command) error {
+	var err error
+	d.addon, err = d.registry.Get(cmd.Flags().Arg(0))
+	if err != nil {
+		return errors.Wrap(constants.ErrInvalidInput, err.Error())
+	}
+
+	return nil
+}
+
+func (d *Disable) Run(out io.Writer) error {
+	s, err := addons.LoadState(addons.KubeConfig)
+	if err != nil {
+		return err
+	}
+	installed, ok := s[d.addon.Name()]
+	if !ok {
+		_, _ = fmt.Fprintf(out, "[%s] %s is not enabled\n", use, d.addon.Name())
+		return nil
+	}
+
+	// the resources are rendered with the installed configuration, so every one of them is deleted
+	if err := d.addon.Delete(out, addons.KubeConfig, installed.Config); err != nil {
+		return err
+	}
+	if err := addons.Forget(addons.KubeConfig, d.addon.Name()); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] disabled %s\n", use, d.addon.Name())
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enable
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"emperror.dev/errors"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/network/migrate"
+)
+
+const (
+	use   = "enable"
+	short = "Install or reconfigure an addon"
+)
+
+var _ phases.Runnable = (*Enable)(nil)
+
+type Enable struct {
+	registry *addons.Registry
+	addon    addons.Addon
+	config   []byte
+}
+
+func NewCommand(r *addons.Registry) *cobra.Command {
+	cmd := phases.NewCommand(&Enable{registry: r})
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func (*Enable) Use() string {
+	return use + " <addon>"
+}
+
+func (*Enable) Short() string {
+	return short
+}
+
+func (*Enable) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagAddonConfig, "", "YAML or JSON configuration file of the addon, the installed configuration is kept if not specified")
+}
+
+func (e *Enable) Validate(cmd *cobra.Command) error {
+	var err error
+	e.addon, err = e.registry.Get(cmd.Flags().Arg(0))
+	if err != nil {
+		return errors.Wrap(constants.ErrInvalidInput, err.Error())
+	}
+
+	filename, err := cmd.Flags().GetString(constants.FlagAddonConfig)
+	if err != nil {
+		return err
+	}
+	e.config = nil
+	if filename == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAddonConfig, err)
+	}
+	if e.config, err = yaml.YAMLToJSON(b); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAddonConfig, err)
+	}
+	// the manifest is rendered to report configuration errors before touching the cluster
+	if _, err := e.addon.Render(e.config); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAddonConfig, err)
+	}
+
+	return nil
+}
+
+func (e *Enable) Run(out io.Writer) error {
+	s, err := addons.LoadState(addons.KubeConfig)
+	if err != nil {
+		return err
+	}
+	if err := e.checkNetworkProvider(s); err != nil {
+		return err
+	}
+
+	config := e.config
+	if config == nil {
+		config = s[e.addon.Name()].Config
+	}
+	if config == nil {
+		config = []byte("{}")
+	}
+
+	if err := addons.Install(out, addons.KubeConfig, e.addon, config); err != nil {
+		return err
+	}
+	if err := e.addon.Healthy(out, addons.KubeConfig); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] enabled %s %s\n", use, e.addon.Name(), e.addon.Version())
+
+	return nil
+}
+
+// checkNetworkProvider refuses to install a network provider next to another one,
+// replacing the network provider of a cluster needs a node by node migration.
+func (e *Enable) checkNetworkProvider(s addons.State) error {
+	name := e.addon.Name()
+	if !migrate.IsProvider(name) {
+		return nil
+	}
+
+	detected, err := migrate.Detect()
+	if err != nil {
+		return err
+	}
+	for other := range s {
+		if migrate.IsProvider(other) {
+			detected = append(detected, other)
+		}
+	}
+
+	return conflictingProvider(name, detected)
+}
+
+func conflictingProvider(name string, installed []string) error {
+	for _, other := range installed {
+		if other != name {
+			return errors.Errorf("network provider %s is installed, use pke network migrate to replace it with %s", other, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictingProvider(t *testing.T) {
+	testCases := []struct {
+		name      string
+		installed []string
+		err       bool
+	}{
+		{name: "none", installed: nil},
+		{name: "same", installed: []string{"calico", "calico"}},
+		{name: "other", installed: []string{"weave"}, err: true},
+		{name: "recorded", installed: []string{"calico", "cilium"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := conflictingProvider("calico", tc.installed)
+			if tc.err {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "pke network migrate")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
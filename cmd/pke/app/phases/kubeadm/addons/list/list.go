@@ -0,0 +1,126 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+)
+
+const (
+	use   = "list"
+	short = "List built-in addons and their installed versions"
+)
+
+var _ phases.Runnable = (*List)(nil)
+
+type List struct {
+	registry *addons.Registry
+	o        string
+}
+
+// Addon is the embedded and the installed version of an addon.
+type Addon struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Installed string `json:"installed,omitempty"`
+}
+
+// Output is the list of addons.
+type Output struct {
+	Addons []Addon `json:"addons"`
+}
+
+func NewCommand(r *addons.Registry) *cobra.Command {
+	return phases.NewCommand(&List{registry: r})
+}
+
+func (*List) Use() string {
+	return use
+}
+
+func (*List) Short() string {
+	return short
+}
+
+func (*List) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringP(constants.FlagOutput, constants.FlagOutputShort, "", "Output format; available options are 'yaml' and 'json'")
+}
+
+func (l *List) Validate(cmd *cobra.Command) error {
+	var err error
+	l.o, err = cmd.Flags().GetString(constants.FlagOutput)
+
+	return err
+}
+
+func (l *List) Run(out io.Writer) error {
+	s, err := addons.LoadState(addons.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	var list Output
+	for _, name := range l.registry.Names() {
+		a, err := l.registry.Get(name)
+		if err != nil {
+			return err
+		}
+		list.Addons = append(list.Addons, Addon{
+			Name:      name,
+			Version:   a.Version(),
+			Installed: s[name].Version,
+		})
+	}
+
+	switch l.o {
+	default:
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Name\tVersion\tInstalled\n")
+		for _, row := range list.Addons {
+			installed := row.Installed
+			if installed == "" {
+				installed = "-"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", row.Name, row.Version, installed)
+		}
+		_ = tw.Flush()
+
+	case "yaml":
+		y, err := yaml.Marshal(&list)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(y))
+	case "json":
+		y, err := json.MarshalIndent(&list, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(y))
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addons
+
+import (
+	"sort"
+
+	"emperror.dev/errors"
+)
+
+// Registry holds the built-in addons.
+type Registry struct {
+	addons map[string]Addon
+}
+
+// NewRegistry creates a registry of the given addons.
+func NewRegistry(addons ...Addon) *Registry {
+	r := &Registry{addons: make(map[string]Addon, len(addons))}
+	for _, a := range addons {
+		r.addons[a.Name()] = a
+	}
+	return r
+}
+
+// Get returns the addon with the given name.
+func (r *Registry) Get(name string) (Addon, error) {
+	a, ok := r.addons[name]
+	if !ok {
+		return nil, errors.Errorf("unknown addon %q, available addons: %v", name, r.Names())
+	}
+	return a, nil
+}
+
+// Names returns the names of the addons in alphabetical order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.addons))
+	for name := range r.addons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,147 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addons
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	stateNamespace = "kube-system"
+	stateConfigMap = "pke-addons"
+)
+
+// Installed is the version and the configuration of an installed addon.
+type Installed struct {
+	Version string          `json:"version"`
+	Config  json.RawMessage `json:"config,omitempty"`
+}
+
+// State contains the installed addons by name, stored in a ConfigMap with a key for each addon.
+type State map[string]Installed
+
+// LoadState reads the installed addons.
+func LoadState(kubeConfig string) (State, error) {
+	// kubectl get configmap -n kube-system pke-addons --ignore-not-found -o jsonpath={.data}
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "configmap", "-n", stateNamespace, stateConfigMap, "--ignore-not-found", "-o", "jsonpath={.data}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get installed addons")
+	}
+
+	return parseState(o)
+}
+
+func parseState(b []byte) (State, error) {
+	s := make(State)
+	if len(bytes.TrimSpace(b)) == 0 {
+		return s, nil
+	}
+
+	var data map[string]string
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, errors.Wrap(err, "failed to parse installed addons")
+	}
+	for name, v := range data {
+		var i Installed
+		if err := json.Unmarshal([]byte(v), &i); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse installed addon %q", name)
+		}
+		s[name] = i
+	}
+
+	return s, nil
+}
+
+// patchState sets the record of a single addon, so concurrent changes of other addons are kept.
+func patchState(kubeConfig string, patch []byte) error {
+	// kubectl create configmap -n kube-system pke-addons
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "create", "configmap", "-n", stateNamespace, stateConfigMap)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if o, err := cmd.CombinedOutput(); err != nil && !bytes.Contains(o, []byte("AlreadyExists")) {
+		return errors.Wrapf(err, "failed to create installed addons: %s", o)
+	}
+
+	// kubectl patch configmap -n kube-system pke-addons --type merge -p <patch>
+	cmd = runner.Cmd(ioutil.Discard, cmdKubectl, "patch", "configmap", "-n", stateNamespace, stateConfigMap, "--type", "merge", "-p", string(patch))
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to save installed addons: %s", o)
+	}
+	return nil
+}
+
+// statePatch returns the merge patch of the ConfigMap key of an addon, a nil record removes the key.
+func statePatch(name string, i *Installed) ([]byte, error) {
+	var value interface{}
+	if i != nil {
+		b, err := json.Marshal(i)
+		if err != nil {
+			return nil, err
+		}
+		value = string(b)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			name: value,
+		},
+	})
+}
+
+// Install applies the addon and records its version and configuration.
+func Install(out io.Writer, kubeConfig string, a Addon, config []byte) error {
+	_, _ = fmt.Fprintf(out, "[%s] installing %s %s\n", use, a.Name(), a.Version())
+	if err := a.Apply(out, kubeConfig, config); err != nil {
+		return err
+	}
+
+	return Record(kubeConfig, a.Name(), a.Version(), config)
+}
+
+// Record stores the version and configuration of an installed addon.
+func Record(kubeConfig, name, version string, config []byte) error {
+	patch, err := statePatch(name, &Installed{Version: version, Config: config})
+	if err != nil {
+		return err
+	}
+	return patchState(kubeConfig, patch)
+}
+
+// Forget removes an addon from the installed ones.
+func Forget(kubeConfig, name string) error {
+	s, err := LoadState(kubeConfig)
+	if err != nil {
+		return err
+	}
+	if _, ok := s[name]; !ok {
+		return nil
+	}
+	patch, err := statePatch(name, nil)
+	if err != nil {
+		return err
+	}
+	return patchState(kubeConfig, patch)
+}
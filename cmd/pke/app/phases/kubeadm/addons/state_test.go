@@ -0,0 +1,90 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addons
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseState(t *testing.T) {
+	testCases := []struct {
+		name  string
+		data  string
+		state State
+		err   bool
+	}{
+		{name: "not found", data: "", state: State{}},
+		{name: "empty", data: "{}", state: State{}},
+		{
+			name: "installed",
+			data: `{"calico":"{\"version\":\"v3.10.1\",\"config\":{\"mtu\":1400}}","metallb":"{\"version\":\"v0.13.7\"}"}`,
+			state: State{
+				"calico":  {Version: "v3.10.1", Config: json.RawMessage(`{"mtu":1400}`)},
+				"metallb": {Version: "v0.13.7"},
+			},
+		},
+		{name: "invalid data", data: "[]", err: true},
+		{name: "invalid addon", data: `{"calico":"v3.10.1"}`, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := parseState([]byte(tc.data))
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.state, s)
+		})
+	}
+}
+
+func TestStatePatch(t *testing.T) {
+	b, err := statePatch("calico", &Installed{Version: "v3.10.1", Config: json.RawMessage(`{"mtu":1400}`)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":{"calico":"{\"version\":\"v3.10.1\",\"config\":{\"mtu\":1400}}"}}`, string(b))
+
+	// the patched data is what kubectl returns with jsonpath={.data}
+	var patch struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(b, &patch))
+	s, err := parseState(patch.Data)
+	require.NoError(t, err)
+	require.Equal(t, State{"calico": {Version: "v3.10.1", Config: json.RawMessage(`{"mtu":1400}`)}}, s)
+
+	b, err = statePatch("calico", nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":{"calico":null}}`, string(b))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(
+		Manifest{AddonName: "metallb", AddonVersion: "v0.13.7"},
+		Manifest{AddonName: "calico", AddonVersion: "v3.10.1"},
+	)
+	require.Equal(t, []string{"calico", "metallb"}, r.Names())
+
+	a, err := r.Get("metallb")
+	require.NoError(t, err)
+	require.Equal(t, "v0.13.7", a.Version())
+
+	_, err = r.Get("weave")
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+)
+
+const (
+	use   = "upgrade"
+	short = "Upgrade installed addons to the embedded versions"
+)
+
+var _ phases.Runnable = (*Upgrade)(nil)
+
+type Upgrade struct {
+	registry *addons.Registry
+	names    []string
+}
+
+func NewCommand(r *addons.Registry) *cobra.Command {
+	return phases.NewCommand(&Upgrade{registry: r})
+}
+
+func (*Upgrade) Use() string {
+	return use + " [addon...]"
+}
+
+func (*Upgrade) Short() string {
+	return short
+}
+
+func (*Upgrade) RegisterFlags(flags *pflag.FlagSet) {}
+
+func (u *Upgrade) Validate(cmd *cobra.Command) error {
+	u.names = cmd.Flags().Args()
+	for _, name := range u.names {
+		if _, err := u.registry.Get(name); err != nil {
+			return errors.Wrap(constants.ErrInvalidInput, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (u *Upgrade) Run(out io.Writer) error {
+	s, err := addons.LoadState(addons.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	names := u.names
+	if len(names) == 0 {
+		// every installed built-in addon
+		for name := range s {
+			if _, err := u.registry.Get(name); err == nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		a, err := u.registry.Get(name)
+		if err != nil {
+			return err
+		}
+		installed, ok := s[name]
+		if !ok {
+			return errors.Errorf("%s is not enabled", name)
+		}
+		if installed.Version == a.Version() {
+			_, _ = fmt.Fprintf(out, "[%s] %s %s is up to date\n", use, name, installed.Version)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "[%s] upgrading %s from %s to %s\n", use, name, installed.Version, a.Version())
+		if err := a.Upgrade(out, addons.KubeConfig, installed.Config, installed.Version); err != nil {
+			return errors.Wrapf(err, "failed to upgrade %s", name)
+		}
+		if err := a.Healthy(out, addons.KubeConfig); err != nil {
+			return err
+		}
+		if err := addons.Record(addons.KubeConfig, name, a.Version(), installed.Config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
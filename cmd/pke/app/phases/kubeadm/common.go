@@ -15,18 +15,14 @@
 package kubeadm
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"text/template"
 	"time"
 
 	"emperror.dev/errors"
-	"github.com/Masterminds/semver"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 )
@@ -167,63 +163,6 @@ func WriteKubeadmVsphereConfig(out io.Writer, filename, cloudProvider, server st
 	return file.WriteTemplate(filename, tmpl, d)
 }
 
-//go:generate templify -t ${GOTMPL} -p kubeadm -f encryptionProvider encryption_provider.yaml.tmpl
-
-// WriteEncryptionProviderConfig creates configuration to encrypt Kubernetes secrets.
-// If encryptionSecret is not provided, but the configuration is already in place
-// secret will NOT be replaced with a newly generated one.
-// Provided secret will always overwrite existing configuration.
-// Pipeline sourced encryption secret uses this behaviour.
-func WriteEncryptionProviderConfig(out io.Writer, filename, kubernetesVersion, encryptionSecret string) error {
-	if encryptionSecret == "" {
-		// check existing configuration
-		if _, err := os.Stat(filename); err == nil {
-			return nil
-		}
-
-		// generate encryption secret
-		var rnd = make([]byte, 32)
-		_, err := rand.Read(rnd)
-		if err != nil {
-			return err
-		}
-
-		encryptionSecret = base64.StdEncoding.EncodeToString(rnd)
-	}
-
-	var (
-		kind       = "EncryptionConfiguration"
-		apiVersion = "apiserver.config.k8s.io/v1"
-	)
-	ver, err := semver.NewVersion(kubernetesVersion)
-	if err != nil {
-		return err
-	}
-	if ver.LessThan(semver.MustParse("1.13.0")) {
-		kind = "EncryptionConfig"
-		apiVersion = "v1"
-	}
-
-	tmpl, err := template.New("admission-config").Parse(encryptionProviderTemplate())
-	if err != nil {
-		return err
-	}
-
-	type data struct {
-		Kind             string
-		APIVersion       string
-		EncryptionSecret string
-	}
-
-	d := data{
-		Kind:             kind,
-		APIVersion:       apiVersion,
-		EncryptionSecret: encryptionSecret,
-	}
-
-	return file.WriteTemplate(filename, tmpl, d)
-}
-
 func WriteKubeadmAmazonConfig(out io.Writer, filename, cloudProvider string) error {
 	if cloudProvider != constants.CloudProviderAmazon {
 		return nil
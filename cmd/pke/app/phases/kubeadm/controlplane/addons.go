@@ -0,0 +1,126 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"encoding/json"
+	"io"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
+)
+
+// calicoVersion is the version of the embedded Calico manifest.
+const calicoVersion = "v3.10.1"
+
+// Addons returns the registry of the built-in addons.
+func Addons() *addons.Registry {
+	return addons.NewRegistry(
+		networkProviderAddon(constants.NetworkProviderCalico),
+		networkProviderAddon(constants.NetworkProviderCilium),
+		networkProviderAddon(constants.NetworkProviderFlannel),
+		networkProviderAddon(constants.NetworkProviderAntrea),
+		metallb.NewAddon(),
+		newNodeLocalDNSAddon(),
+		newNetworkPolicyAddon(),
+		newLocalPathStorageAddon(),
+		newPodSecurityPolicyAddon(),
+	)
+}
+
+// installAddon installs a built-in addon with the given configuration and records it in the cluster.
+func installAddon(out io.Writer, a addons.Addon, config interface{}) error {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s configuration", a.Name())
+	}
+
+	return addons.Install(out, kubeConfig, a, b)
+}
+
+// networkProviderAddon returns the addon of an embedded network provider, its configuration is a NetworkOptions.
+// Network providers can not be disabled, only replaced by another one.
+func networkProviderAddon(provider string) addons.Addon {
+	a := addons.Manifest{
+		AddonName: provider,
+		RenderFunc: func(config []byte) (string, error) {
+			var o NetworkOptions
+			if err := json.Unmarshal(config, &o); err != nil {
+				return "", errors.Wrapf(err, "failed to parse %s configuration", provider)
+			}
+			return NetworkProviderManifest(provider, o)
+		},
+		Permanent: "switch the network provider with pke network migrate",
+	}
+
+	switch provider {
+	case constants.NetworkProviderCalico:
+		a.AddonVersion = calicoVersion
+		a.Workloads = []addons.Workload{
+			{Namespace: "kube-system", Resource: "daemonset/calico-node"},
+			{Namespace: "kube-system", Resource: "deployment/calico-kube-controllers"},
+		}
+	case constants.NetworkProviderCilium:
+		a.AddonVersion = ciliumVersion
+		a.Workloads = []addons.Workload{
+			{Namespace: "kube-system", Resource: "daemonset/cilium"},
+			{Namespace: "kube-system", Resource: "deployment/cilium-operator"},
+		}
+	case constants.NetworkProviderFlannel:
+		a.AddonVersion = flannelVersion
+		a.Workloads = []addons.Workload{
+			{Namespace: "kube-system", Resource: "daemonset/kube-flannel-ds"},
+		}
+	case constants.NetworkProviderAntrea:
+		a.AddonVersion = antreaVersion
+		a.Workloads = []addons.Workload{
+			{Namespace: "kube-system", Resource: "daemonset/antrea-agent"},
+			{Namespace: "kube-system", Resource: "deployment/antrea-controller"},
+		}
+	}
+
+	return a
+}
+
+// networkOptions returns the settings of the network provider manifest.
+func (c *ControlPlane) networkOptions() (NetworkOptions, error) {
+	o := NetworkOptions{
+		PodNetworkCIDR:       c.podNetworkCIDR,
+		ServiceCIDR:          c.serviceCIDR,
+		ImageRepository:      c.imageRepository,
+		MTU:                  c.mtu,
+		NodeCIDRMaskSize:     c.nodeCIDRMaskSize,
+		NodeCIDRMaskSizeIPv6: c.nodeCIDRMaskSizeIPv6,
+		Single:               c.clusterMode == singleMode,
+	}
+
+	switch c.networkProvider {
+	case constants.NetworkProviderFlannel:
+		o.FlannelBackend = c.flannelBackend
+		o.FlannelInterface = c.flannelInterface()
+	case constants.NetworkProviderCilium:
+		// API server endpoint of the kube-proxy replacement
+		host, port, err := c.kubeProxyReplacementEndpoint()
+		if err != nil {
+			return NetworkOptions{}, err
+		}
+		o.APIServerHost, o.APIServerPort = host, port
+	}
+
+	return o, nil
+}
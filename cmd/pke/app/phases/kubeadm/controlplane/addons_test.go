@@ -0,0 +1,68 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
+)
+
+func TestAddons(t *testing.T) {
+	network := NetworkOptions{
+		PodNetworkCIDR: "10.20.0.0/16",
+		ServiceCIDR:    "10.10.0.0/16",
+	}
+	testCases := []struct {
+		name   string
+		config interface{}
+	}{
+		{name: constants.NetworkProviderCalico, config: network},
+		{name: constants.NetworkProviderCilium, config: network},
+		{name: constants.NetworkProviderFlannel, config: network},
+		{name: constants.NetworkProviderAntrea, config: network},
+		{name: metallb.AddonName, config: metallb.RangeConfig("192.168.1.240-192.168.1.250")},
+		{name: nodeLocalDNSAddonName, config: nodeLocalDNSConfig{ServiceCIDR: "10.10.0.0/16"}},
+		{name: networkPolicyAddonName, config: networkPolicyConfig{NetworkProvider: constants.NetworkProviderCalico}},
+		{name: localPathStorageAddonName, config: localPathStorageConfig{}},
+		{name: podSecurityPolicyAddonName, config: struct{}{}},
+	}
+
+	r := Addons()
+	require.Len(t, r.Names(), len(testCases))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := r.Get(tc.name)
+			require.NoError(t, err)
+			require.NotEmpty(t, a.Version())
+
+			config, err := json.Marshal(tc.config)
+			require.NoError(t, err)
+			manifest, err := a.Render(config)
+			require.NoError(t, err)
+			require.NotEmpty(t, manifest)
+		})
+	}
+}
+
+func TestNetworkProviderAddonPermanent(t *testing.T) {
+	a := networkProviderAddon(constants.NetworkProviderCalico)
+	require.Error(t, a.Delete(nil, kubeConfig, []byte("{}")))
+}
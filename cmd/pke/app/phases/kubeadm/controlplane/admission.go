@@ -0,0 +1,315 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const (
+	imagePolicyWebhookConfig     = "/etc/kubernetes/admission-control/image-policy-webhook.yaml"
+	imagePolicyWebhookKubeConfig = "/etc/kubernetes/admission-control/image-policy-webhook.kubeconfig"
+
+	admissionPluginEventRateLimit     = "EventRateLimit"
+	admissionPluginImagePolicyWebhook = "ImagePolicyWebhook"
+	admissionPluginPodSecurity        = "PodSecurity"
+	admissionPluginPodSecurityPolicy  = "PodSecurityPolicy"
+)
+
+// defaultAdmissionPlugins are enabled unless disabled explicitly.
+var defaultAdmissionPlugins = []string{"AlwaysPullImages", admissionPluginEventRateLimit, "NodeRestriction", "ServiceAccount"}
+
+// defaultEventRateLimits are the EventRateLimit admission plugin limits in <type>:<qps>:<burst>[:<cache size>] format.
+var defaultEventRateLimits = []string{"Namespace:50:100:2000", "User:10:50"}
+
+// admission holds the admission chain of the API server.
+type admission struct {
+	enable                       []string
+	disable                      []string
+	eventRateLimits              []eventRateLimit
+	imagePolicyWebhookConfigFile string
+	imagePolicyDefaultAllow      bool
+}
+
+// eventRateLimit is a limit of the EventRateLimit admission plugin.
+type eventRateLimit struct {
+	Type      string
+	QPS       int
+	Burst     int
+	CacheSize int
+}
+
+// admissionPluginConfig is an entry of the AdmissionConfiguration.
+type admissionPluginConfig struct {
+	Name string
+	Path string
+}
+
+func (c *ControlPlane) admissionParameters(cmd *cobra.Command) (err error) {
+	c.admission.enable, err = cmd.Flags().GetStringSlice(constants.FlagAdmissionPlugins)
+	if err != nil {
+		return
+	}
+	c.admission.disable, err = cmd.Flags().GetStringSlice(constants.FlagDisableAdmissionPlugins)
+	if err != nil {
+		return
+	}
+	limits, err := cmd.Flags().GetStringSlice(constants.FlagEventRateLimits)
+	if err != nil {
+		return
+	}
+	c.admission.eventRateLimits, err = parseEventRateLimits(limits)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagEventRateLimits, err)
+	}
+	c.admission.imagePolicyWebhookConfigFile, err = cmd.Flags().GetString(constants.FlagImagePolicyWebhookConfigFile)
+	if err != nil {
+		return
+	}
+	c.admission.imagePolicyDefaultAllow, err = cmd.Flags().GetBool(constants.FlagImagePolicyDefaultAllow)
+	if err != nil {
+		return
+	}
+
+	// the ImagePolicyWebhook plugin is enabled by configuring its backend
+	if c.admission.imagePolicyWebhookConfigFile != "" && !contains(c.admission.enable, admissionPluginImagePolicyWebhook) {
+		c.admission.enable = append(c.admission.enable, admissionPluginImagePolicyWebhook)
+	}
+
+	return validateAdmission(c.admission)
+}
+
+func validateAdmission(a admission) error {
+	for _, p := range a.enable {
+		if p == "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: empty plugin name", constants.FlagAdmissionPlugins)
+		}
+		if contains(a.disable, p) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s and --%s: %s can not be both enabled and disabled", constants.FlagAdmissionPlugins, constants.FlagDisableAdmissionPlugins, p)
+		}
+	}
+	for _, p := range a.disable {
+		if p == "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: empty plugin name", constants.FlagDisableAdmissionPlugins)
+		}
+	}
+
+	if a.enabled(admissionPluginEventRateLimit) && len(a.eventRateLimits) == 0 {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: at least one limit is required by the %s admission plugin", constants.FlagEventRateLimits, admissionPluginEventRateLimit)
+	}
+
+	if contains(a.enable, admissionPluginImagePolicyWebhook) {
+		if a.imagePolicyWebhookConfigFile == "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: required by the %s admission plugin", constants.FlagImagePolicyWebhookConfigFile, admissionPluginImagePolicyWebhook)
+		}
+		if _, err := os.Stat(a.imagePolicyWebhookConfigFile); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagImagePolicyWebhookConfigFile, err)
+		}
+	}
+
+	return nil
+}
+
+// parseEventRateLimits parses limits in <type>:<qps>:<burst>[:<cache size>] format.
+func parseEventRateLimits(limits []string) ([]eventRateLimit, error) {
+	var (
+		result []eventRateLimit
+		seen   = make(map[string]bool)
+	)
+	for _, l := range limits {
+		parts := strings.Split(l, ":")
+		if len(parts) != 3 && len(parts) != 4 {
+			return nil, errors.Errorf("invalid limit %q, expected <type>:<qps>:<burst>[:<cache size>]", l)
+		}
+
+		limit := eventRateLimit{Type: parts[0]}
+		switch limit.Type {
+		case "Server", "Namespace", "User", "SourceAndObject":
+			// break
+		default:
+			return nil, errors.Errorf("unsupported limit type %q, possible values: Server, Namespace, User, SourceAndObject", limit.Type)
+		}
+		if seen[limit.Type] {
+			return nil, errors.Errorf("duplicate limit type %q", limit.Type)
+		}
+		seen[limit.Type] = true
+
+		values := []*int{&limit.QPS, &limit.Burst, &limit.CacheSize}
+		for i, p := range parts[1:] {
+			v, err := strconv.Atoi(p)
+			if err != nil || v <= 0 {
+				return nil, errors.Errorf("invalid limit %q, values must be positive integers", l)
+			}
+			*values[i] = v
+		}
+		if limit.Type == "Server" && limit.CacheSize > 0 {
+			return nil, errors.Errorf("invalid limit %q, cache size is not supported for type Server", l)
+		}
+
+		result = append(result, limit)
+	}
+
+	return result, nil
+}
+
+// plugins returns the enabled and disabled admission plugins of the API server.
+func (a admission) plugins(withPluginPSP, withPodSecurity bool) (enabled []string, disabled []string) {
+	candidates := append([]string{}, defaultAdmissionPlugins...)
+	if withPluginPSP {
+		candidates = append(candidates, admissionPluginPodSecurityPolicy)
+	}
+	if withPodSecurity {
+		candidates = append(candidates, admissionPluginPodSecurity)
+	}
+	candidates = append(candidates, a.enable...)
+
+	for _, p := range candidates {
+		if !contains(a.disable, p) && !contains(enabled, p) {
+			enabled = append(enabled, p)
+		}
+	}
+
+	return enabled, a.disable
+}
+
+// enabled tells whether the admission plugin is enabled, either by default or explicitly.
+func (a admission) enabled(plugin string) bool {
+	return (contains(defaultAdmissionPlugins, plugin) || contains(a.enable, plugin)) && !contains(a.disable, plugin)
+}
+
+// writeAdmissionConfig writes the configuration files of the admission plugins and the AdmissionConfiguration referencing them.
+func writeAdmissionConfig(out io.Writer, a admission, podSecurityConfigFile string) error {
+	var configs []admissionPluginConfig
+	if a.enabled(admissionPluginEventRateLimit) {
+		if err := writeEventRateLimitConfig(out, admissionEventRateLimitConfig, a.eventRateLimits); err != nil {
+			return errors.Wrap(err, "writing event limit config failed")
+		}
+		configs = append(configs, admissionPluginConfig{Name: admissionPluginEventRateLimit, Path: admissionEventRateLimitConfig})
+	}
+	if podSecurityConfigFile != "" {
+		configs = append(configs, admissionPluginConfig{Name: admissionPluginPodSecurity, Path: podSecurityConfigFile})
+	}
+	if a.enabled(admissionPluginImagePolicyWebhook) {
+		if err := writeImagePolicyWebhookConfig(out, imagePolicyWebhookConfig, a); err != nil {
+			return errors.Wrap(err, "writing image policy webhook config failed")
+		}
+		configs = append(configs, admissionPluginConfig{Name: admissionPluginImagePolicyWebhook, Path: imagePolicyWebhookConfig})
+	}
+
+	err := writeAdmissionConfiguration(out, admissionConfig, configs)
+	if err != nil {
+		return errors.Wrap(err, "writing admission config failed")
+	}
+
+	return nil
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f admissionConfiguration admission_configuration.yaml.tmpl
+
+func writeAdmissionConfiguration(out io.Writer, filename string, configs []admissionPluginConfig) error {
+	tmpl, err := template.New("admission-config").Parse(admissionConfigurationTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		Plugins []admissionPluginConfig
+	}
+
+	d := data{
+		Plugins: configs,
+	}
+
+	return file.WriteTemplate(filename, tmpl, d)
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f eventRateLimit event_rate_limit.yaml.tmpl
+
+func writeEventRateLimitConfig(out io.Writer, filename string, limits []eventRateLimit) error {
+	dir := filepath.Dir(filename)
+
+	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("event-rate-limit").Parse(eventRateLimitTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		Limits []eventRateLimit
+	}
+
+	d := data{
+		Limits: limits,
+	}
+
+	return file.WriteTemplate(filename, tmpl, d)
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f imagePolicyWebhook image_policy_webhook.yaml.tmpl
+
+// writeImagePolicyWebhookConfig writes the ImagePolicyWebhook configuration and copies the kubeconfig of its backend next to it.
+func writeImagePolicyWebhookConfig(out io.Writer, filename string, a admission) error {
+	dir := filepath.Dir(filename)
+
+	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(a.imagePolicyWebhookConfigFile)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] writing image policy webhook kubeconfig: %q\n", use, imagePolicyWebhookKubeConfig)
+	if err := file.Overwrite(imagePolicyWebhookKubeConfig, string(b)); err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("image-policy-webhook").Parse(imagePolicyWebhookTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		KubeConfigFile string
+		DefaultAllow   bool
+	}
+
+	d := data{
+		KubeConfigFile: imagePolicyWebhookKubeConfig,
+		DefaultAllow:   a.imagePolicyDefaultAllow,
+	}
+
+	return file.WriteTemplate(filename, tmpl, d)
+}
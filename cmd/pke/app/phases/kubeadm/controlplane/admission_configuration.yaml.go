@@ -18,9 +18,9 @@ package controlplane
 func admissionConfigurationTemplate() string {
 	var tmpl = "kind: AdmissionConfiguration\n" +
 		"apiVersion: apiserver.k8s.io/v1alpha1\n" +
-		"plugins:\n" +
-		"- name: EventRateLimit\n" +
-		"  path: {{ .RateLimitConfigFile }}\n" +
+		"plugins:{{ if not .Plugins }} []{{ end }}{{ range .Plugins }}\n" +
+		"- name: {{ .Name }}\n" +
+		"  path: {{ .Path }}{{ end }}\n" +
 		""
 	return tmpl
 }
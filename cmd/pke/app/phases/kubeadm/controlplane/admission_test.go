@@ -0,0 +1,140 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventRateLimits(t *testing.T) {
+	testCases := []struct {
+		name   string
+		limits []string
+		want   []eventRateLimit
+		err    bool
+	}{
+		{
+			name:   "default",
+			limits: defaultEventRateLimits,
+			want: []eventRateLimit{
+				{Type: "Namespace", QPS: 50, Burst: 100, CacheSize: 2000},
+				{Type: "User", QPS: 10, Burst: 50},
+			},
+		},
+		{
+			name:   "server",
+			limits: []string{"Server:500:1000", "SourceAndObject:5:10:4096"},
+			want: []eventRateLimit{
+				{Type: "Server", QPS: 500, Burst: 1000},
+				{Type: "SourceAndObject", QPS: 5, Burst: 10, CacheSize: 4096},
+			},
+		},
+		{name: "server cache size", limits: []string{"Server:500:1000:10"}, err: true},
+		{name: "type", limits: []string{"Pod:1:1"}, err: true},
+		{name: "duplicate", limits: []string{"User:1:1", "User:2:2"}, err: true},
+		{name: "format", limits: []string{"User:1"}, err: true},
+		{name: "value", limits: []string{"User:1:x"}, err: true},
+		{name: "zero", limits: []string{"User:0:1"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseEventRateLimits(tc.limits)
+		if tc.err {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.want, got, tc.name)
+	}
+}
+
+func TestAdmissionPlugins(t *testing.T) {
+	testCases := []struct {
+		name            string
+		admission       admission
+		withPluginPSP   bool
+		withPodSecurity bool
+		enabled         []string
+		disabled        []string
+	}{
+		{
+			name:    "default",
+			enabled: []string{"AlwaysPullImages", "EventRateLimit", "NodeRestriction", "ServiceAccount"},
+		},
+		{
+			name:            "pod security",
+			withPluginPSP:   true,
+			withPodSecurity: true,
+			enabled:         []string{"AlwaysPullImages", "EventRateLimit", "NodeRestriction", "ServiceAccount", "PodSecurityPolicy", "PodSecurity"},
+		},
+		{
+			name: "custom",
+			admission: admission{
+				enable:  []string{"ImagePolicyWebhook", "NodeRestriction", "DenyServiceExternalIPs"},
+				disable: []string{"AlwaysPullImages", "DefaultStorageClass"},
+			},
+			enabled:  []string{"EventRateLimit", "NodeRestriction", "ServiceAccount", "ImagePolicyWebhook", "DenyServiceExternalIPs"},
+			disabled: []string{"AlwaysPullImages", "DefaultStorageClass"},
+		},
+	}
+
+	for _, tc := range testCases {
+		enabled, disabled := tc.admission.plugins(tc.withPluginPSP, tc.withPodSecurity)
+		require.Equal(t, tc.enabled, enabled, tc.name)
+		require.Equal(t, tc.disabled, disabled, tc.name)
+	}
+}
+
+func TestValidateAdmission(t *testing.T) {
+	limits := []eventRateLimit{{Type: "User", QPS: 10, Burst: 50}}
+	require.NoError(t, validateAdmission(admission{disable: []string{"AlwaysPullImages"}, eventRateLimits: limits}))
+	require.NoError(t, validateAdmission(admission{disable: []string{"EventRateLimit"}}))
+	require.Error(t, validateAdmission(admission{}))
+	require.Error(t, validateAdmission(admission{enable: []string{"AlwaysPullImages"}, disable: []string{"AlwaysPullImages"}, eventRateLimits: limits}))
+	require.Error(t, validateAdmission(admission{enable: []string{"ImagePolicyWebhook"}, eventRateLimits: limits}))
+	require.Error(t, validateAdmission(admission{enable: []string{"ImagePolicyWebhook"}, imagePolicyWebhookConfigFile: "/nonexistent/kubeconfig", eventRateLimits: limits}))
+}
+
+func TestWriteEventRateLimitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-rate-limit")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "admission-control", "event-rate-limit.yaml")
+
+	err = writeEventRateLimitConfig(ioutil.Discard, filename, []eventRateLimit{
+		{Type: "Namespace", QPS: 200, Burst: 400, CacheSize: 5000},
+		{Type: "Server", QPS: 1000, Burst: 2000},
+	})
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, `kind: Configuration
+apiVersion: eventratelimit.admission.k8s.io/v1alpha1
+limits:
+- type: Namespace
+  qps: 200
+  burst: 400
+  cacheSize: 5000
+- type: Server
+  qps: 1000
+  burst: 2000
+`, string(b))
+}
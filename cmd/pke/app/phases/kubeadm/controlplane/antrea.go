@@ -0,0 +1,72 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+const (
+	// antreaVersion is the version of the embedded Antrea manifest.
+	antreaVersion = "v1.5.2"
+	// antreaImageRepository is the repository of the Antrea image without --image-repository.
+	antreaImageRepository = "projects.registry.vmware.com/antrea"
+)
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f antrea antrea.yaml.tmpl
+
+// antreaManifest renders the Antrea manifest. AntreaProxy needs the service network of each IP family
+// to handle ClusterIP traffic, the MTU is detected by the agent when it is 0.
+func antreaManifest(serviceCIDR string, mtu uint, imageRepository string) (string, error) {
+	tmpl, err := template.New("antrea").Parse(antreaTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	cidrs, err := network.ParseCIDRs(serviceCIDR)
+	if err != nil {
+		return "", err
+	}
+
+	if imageRepository == "" {
+		imageRepository = antreaImageRepository
+	}
+
+	type data struct {
+		IPv4ServiceCIDR string
+		IPv6ServiceCIDR string
+		MTU             uint
+		ImageRepository string
+		Version         string
+	}
+
+	d := data{
+		IPv4ServiceCIDR: cidrs.IPv4(),
+		IPv6ServiceCIDR: cidrs.IPv6(),
+		MTU:             mtu,
+		ImageRepository: imageRepository,
+		Version:         antreaVersion,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,740 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// antreaTemplate is a generated function returning the template as a string.
+func antreaTemplate() string {
+	var tmpl = "# Based on https://github.com/antrea-io/antrea/releases/download/{{ .Version }}/antrea.yml\n" +
+		"# Antrea-native policies, Traceflow and Egress are disabled, their CRDs are not installed.\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: antreaagentinfos.clusterinformation.antrea.io\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  group: clusterinformation.antrea.io\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        x-kubernetes-preserve-unknown-fields: true\n" +
+		"  scope: Cluster\n" +
+		"  names:\n" +
+		"    plural: antreaagentinfos\n" +
+		"    singular: antreaagentinfo\n" +
+		"    kind: AntreaAgentInfo\n" +
+		"    shortNames:\n" +
+		"    - aai\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: antreacontrollerinfos.clusterinformation.antrea.io\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  group: clusterinformation.antrea.io\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        x-kubernetes-preserve-unknown-fields: true\n" +
+		"  scope: Cluster\n" +
+		"  names:\n" +
+		"    plural: antreacontrollerinfos\n" +
+		"    singular: antreacontrollerinfo\n" +
+		"    kind: AntreaControllerInfo\n" +
+		"    shortNames:\n" +
+		"    - aci\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  name: antrea-agent\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  name: antrea-controller\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRole\n" +
+		"metadata:\n" +
+		"  name: antrea-agent\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - nodes\n" +
+		"  - pods\n" +
+		"  - namespaces\n" +
+		"  - services\n" +
+		"  - endpoints\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - pods/status\n" +
+		"  verbs:\n" +
+		"  - patch\n" +
+		"- apiGroups:\n" +
+		"  - discovery.k8s.io\n" +
+		"  resources:\n" +
+		"  - endpointslices\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - clusterinformation.antrea.io\n" +
+		"  resources:\n" +
+		"  - antreaagentinfos\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - create\n" +
+		"  - update\n" +
+		"  - delete\n" +
+		"- apiGroups:\n" +
+		"  - controlplane.antrea.io\n" +
+		"  resources:\n" +
+		"  - networkpolicies\n" +
+		"  - appliedtogroups\n" +
+		"  - addressgroups\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - controlplane.antrea.io\n" +
+		"  resources:\n" +
+		"  - nodestatssummaries\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - controlplane.antrea.io\n" +
+		"  resources:\n" +
+		"  - networkpolicies/status\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - get\n" +
+		"- apiGroups:\n" +
+		"  - authentication.k8s.io\n" +
+		"  resources:\n" +
+		"  - tokenreviews\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - authorization.k8s.io\n" +
+		"  resources:\n" +
+		"  - subjectaccessreviews\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - configmaps\n" +
+		"  resourceNames:\n" +
+		"  - extension-apiserver-authentication\n" +
+		"  - antrea-ca\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRole\n" +
+		"metadata:\n" +
+		"  name: antrea-controller\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - nodes\n" +
+		"  - pods\n" +
+		"  - namespaces\n" +
+		"  - services\n" +
+		"  - endpoints\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - networking.k8s.io\n" +
+		"  resources:\n" +
+		"  - networkpolicies\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - clusterinformation.antrea.io\n" +
+		"  resources:\n" +
+		"  - antreacontrollerinfos\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - create\n" +
+		"  - update\n" +
+		"  - delete\n" +
+		"- apiGroups:\n" +
+		"  - clusterinformation.antrea.io\n" +
+		"  resources:\n" +
+		"  - antreaagentinfos\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"  - delete\n" +
+		"- apiGroups:\n" +
+		"  - authentication.k8s.io\n" +
+		"  resources:\n" +
+		"  - tokenreviews\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - authorization.k8s.io\n" +
+		"  resources:\n" +
+		"  - subjectaccessreviews\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - configmaps\n" +
+		"  resourceNames:\n" +
+		"  - extension-apiserver-authentication\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - watch\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - configmaps\n" +
+		"  resourceNames:\n" +
+		"  - antrea-ca\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - update\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - configmaps\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"- apiGroups:\n" +
+		"  - apiregistration.k8s.io\n" +
+		"  resources:\n" +
+		"  - apiservices\n" +
+		"  resourceNames:\n" +
+		"  - v1alpha1.stats.antrea.io\n" +
+		"  - v1beta1.system.antrea.io\n" +
+		"  - v1beta2.controlplane.antrea.io\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - update\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRoleBinding\n" +
+		"metadata:\n" +
+		"  name: antrea-agent\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: ClusterRole\n" +
+		"  name: antrea-agent\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: antrea-agent\n" +
+		"  namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRoleBinding\n" +
+		"metadata:\n" +
+		"  name: antrea-controller\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: ClusterRole\n" +
+		"  name: antrea-controller\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: antrea-controller\n" +
+		"  namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: antrea-config\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"data:\n" +
+		"  antrea-agent.conf: |\n" +
+		"    featureGates:\n" +
+		"      AntreaProxy: true\n" +
+		"      EndpointSlice: false\n" +
+		"      AntreaPolicy: false\n" +
+		"      Traceflow: false\n" +
+		"      NetworkPolicyStats: false\n" +
+		"      Egress: false\n" +
+		"    ovsBridge: br-int\n" +
+		"    trafficEncapMode: encap\n" +
+		"    tunnelType: geneve\n" +
+		"    defaultMTU: {{ .MTU }}{{ if .IPv4ServiceCIDR }}\n" +
+		"    serviceCIDR: {{ .IPv4ServiceCIDR }}{{ end }}{{ if .IPv6ServiceCIDR }}\n" +
+		"    serviceCIDRv6: {{ .IPv6ServiceCIDR }}{{ end }}\n" +
+		"    hostProcPathPrefix: /host\n" +
+		"    enablePrometheusMetrics: true\n" +
+		"  antrea-cni.conflist: |\n" +
+		"    {\n" +
+		"        \"cniVersion\":\"0.3.0\",\n" +
+		"        \"name\": \"antrea\",\n" +
+		"        \"plugins\": [\n" +
+		"            {\n" +
+		"                \"type\": \"antrea\",\n" +
+		"                \"ipam\": {\n" +
+		"                    \"type\": \"host-local\"\n" +
+		"                }\n" +
+		"            },\n" +
+		"            {\n" +
+		"                \"type\": \"portmap\",\n" +
+		"                \"capabilities\": {\"portMappings\": true}\n" +
+		"            },\n" +
+		"            {\n" +
+		"                \"type\": \"bandwidth\",\n" +
+		"                \"capabilities\": {\"bandwidth\": true}\n" +
+		"            }\n" +
+		"        ]\n" +
+		"    }\n" +
+		"  antrea-controller.conf: |\n" +
+		"    featureGates:\n" +
+		"      AntreaPolicy: false\n" +
+		"      Traceflow: false\n" +
+		"      NetworkPolicyStats: false\n" +
+		"      Egress: false\n" +
+		"    enablePrometheusMetrics: true\n" +
+		"    selfSignedCert: true\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Service\n" +
+		"metadata:\n" +
+		"  name: antrea\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  ports:\n" +
+		"  - port: 443\n" +
+		"    protocol: TCP\n" +
+		"    targetPort: api\n" +
+		"  selector:\n" +
+		"    app: antrea\n" +
+		"    component: antrea-controller\n" +
+		"---\n" +
+		"apiVersion: apiregistration.k8s.io/v1\n" +
+		"kind: APIService\n" +
+		"metadata:\n" +
+		"  name: v1beta2.controlplane.antrea.io\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  group: controlplane.antrea.io\n" +
+		"  groupPriorityMinimum: 100\n" +
+		"  version: v1beta2\n" +
+		"  versionPriority: 100\n" +
+		"  service:\n" +
+		"    name: antrea\n" +
+		"    namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: apiregistration.k8s.io/v1\n" +
+		"kind: APIService\n" +
+		"metadata:\n" +
+		"  name: v1beta1.system.antrea.io\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  group: system.antrea.io\n" +
+		"  groupPriorityMinimum: 100\n" +
+		"  version: v1beta1\n" +
+		"  versionPriority: 100\n" +
+		"  service:\n" +
+		"    name: antrea\n" +
+		"    namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: apiregistration.k8s.io/v1\n" +
+		"kind: APIService\n" +
+		"metadata:\n" +
+		"  name: v1alpha1.stats.antrea.io\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"spec:\n" +
+		"  group: stats.antrea.io\n" +
+		"  groupPriorityMinimum: 100\n" +
+		"  version: v1alpha1\n" +
+		"  versionPriority: 100\n" +
+		"  service:\n" +
+		"    name: antrea\n" +
+		"    namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: Deployment\n" +
+		"metadata:\n" +
+		"  name: antrea-controller\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"    component: antrea-controller\n" +
+		"spec:\n" +
+		"  strategy:\n" +
+		"    # Ensure the existing Pod is stopped before the new one is created.\n" +
+		"    type: Recreate\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      app: antrea\n" +
+		"      component: antrea-controller\n" +
+		"  replicas: 1\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      labels:\n" +
+		"        app: antrea\n" +
+		"        component: antrea-controller\n" +
+		"    spec:\n" +
+		"      nodeSelector:\n" +
+		"        kubernetes.io/os: linux\n" +
+		"      hostNetwork: true\n" +
+		"      priorityClassName: system-cluster-critical\n" +
+		"      tolerations:\n" +
+		"      - key: CriticalAddonsOnly\n" +
+		"        operator: Exists\n" +
+		"      - key: node-role.kubernetes.io/master\n" +
+		"        effect: NoSchedule\n" +
+		"      - key: node-role.kubernetes.io/control-plane\n" +
+		"        effect: NoSchedule\n" +
+		"      serviceAccountName: antrea-controller\n" +
+		"      containers:\n" +
+		"      - name: antrea-controller\n" +
+		"        image: {{ .ImageRepository }}/antrea-ubuntu:{{ .Version }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: \"200m\"\n" +
+		"        command: [\"antrea-controller\"]\n" +
+		"        args:\n" +
+		"        - --config\n" +
+		"        - /etc/antrea/antrea-controller.conf\n" +
+		"        - --logtostderr=false\n" +
+		"        - --log_dir=/var/log/antrea\n" +
+		"        - --alsologtostderr\n" +
+		"        - --log_file_max_size=100\n" +
+		"        - --log_file_max_num=4\n" +
+		"        - --v=0\n" +
+		"        env:\n" +
+		"        - name: POD_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.name\n" +
+		"        - name: POD_NAMESPACE\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.namespace\n" +
+		"        - name: NODE_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: spec.nodeName\n" +
+		"        - name: SERVICEACCOUNT_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: spec.serviceAccountName\n" +
+		"        - name: ANTREA_CONFIG_MAP_NAME\n" +
+		"          value: antrea-config\n" +
+		"        ports:\n" +
+		"        - containerPort: 10349\n" +
+		"          name: api\n" +
+		"          protocol: TCP\n" +
+		"        readinessProbe:\n" +
+		"          httpGet:\n" +
+		"            host: localhost\n" +
+		"            path: /readyz\n" +
+		"            port: api\n" +
+		"            scheme: HTTPS\n" +
+		"          initialDelaySeconds: 5\n" +
+		"          timeoutSeconds: 5\n" +
+		"          periodSeconds: 10\n" +
+		"          failureThreshold: 5\n" +
+		"        livenessProbe:\n" +
+		"          httpGet:\n" +
+		"            host: localhost\n" +
+		"            path: /livez\n" +
+		"            port: api\n" +
+		"            scheme: HTTPS\n" +
+		"          timeoutSeconds: 5\n" +
+		"          periodSeconds: 10\n" +
+		"          failureThreshold: 5\n" +
+		"        volumeMounts:\n" +
+		"        - name: antrea-config\n" +
+		"          mountPath: /etc/antrea/antrea-controller.conf\n" +
+		"          subPath: antrea-controller.conf\n" +
+		"          readOnly: true\n" +
+		"        - name: host-var-log-antrea\n" +
+		"          mountPath: /var/log/antrea\n" +
+		"          subPath: antrea-controller\n" +
+		"      volumes:\n" +
+		"      - name: antrea-config\n" +
+		"        configMap:\n" +
+		"          name: antrea-config\n" +
+		"      - name: host-var-log-antrea\n" +
+		"        hostPath:\n" +
+		"          path: /var/log/antrea\n" +
+		"          type: DirectoryOrCreate\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: DaemonSet\n" +
+		"metadata:\n" +
+		"  name: antrea-agent\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    app: antrea\n" +
+		"    component: antrea-agent\n" +
+		"spec:\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      app: antrea\n" +
+		"      component: antrea-agent\n" +
+		"  updateStrategy:\n" +
+		"    type: RollingUpdate\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      labels:\n" +
+		"        app: antrea\n" +
+		"        component: antrea-agent\n" +
+		"    spec:\n" +
+		"      nodeSelector:\n" +
+		"        kubernetes.io/os: linux\n" +
+		"      hostNetwork: true\n" +
+		"      priorityClassName: system-node-critical\n" +
+		"      tolerations:\n" +
+		"      # Mark it as a critical add-on.\n" +
+		"      - key: CriticalAddonsOnly\n" +
+		"        operator: Exists\n" +
+		"      # Make sure it gets scheduled on all Nodes.\n" +
+		"      - effect: NoSchedule\n" +
+		"        operator: Exists\n" +
+		"      - effect: NoExecute\n" +
+		"        operator: Exists\n" +
+		"      serviceAccountName: antrea-agent\n" +
+		"      initContainers:\n" +
+		"      - name: install-cni\n" +
+		"        image: {{ .ImageRepository }}/antrea-ubuntu:{{ .Version }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: \"100m\"\n" +
+		"        command: [\"install_cni\"]\n" +
+		"        securityContext:\n" +
+		"          capabilities:\n" +
+		"            add:\n" +
+		"            # SYS_MODULE is required to load the OVS kernel module.\n" +
+		"            - SYS_MODULE\n" +
+		"        env:\n" +
+		"        # SKIP_CNI_BINARIES takes in values as a comma separated list of\n" +
+		"        # binaries that need to be skipped for installation, e.g. \"portmap, bandwidth\".\n" +
+		"        - name: SKIP_CNI_BINARIES\n" +
+		"          value: \"\"\n" +
+		"        volumeMounts:\n" +
+		"        - name: antrea-config\n" +
+		"          mountPath: /etc/antrea/antrea-cni.conflist\n" +
+		"          subPath: antrea-cni.conflist\n" +
+		"          readOnly: true\n" +
+		"        - name: host-cni-conf\n" +
+		"          mountPath: /host/etc/cni/net.d\n" +
+		"        - name: host-cni-bin\n" +
+		"          mountPath: /host/opt/cni/bin\n" +
+		"        - name: host-lib-modules\n" +
+		"          mountPath: /lib/modules\n" +
+		"          readOnly: true\n" +
+		"        - name: host-var-run-antrea\n" +
+		"          mountPath: /var/run/antrea\n" +
+		"      containers:\n" +
+		"      - name: antrea-agent\n" +
+		"        image: {{ .ImageRepository }}/antrea-ubuntu:{{ .Version }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: \"200m\"\n" +
+		"        command: [\"antrea-agent\"]\n" +
+		"        args:\n" +
+		"        - --config\n" +
+		"        - /etc/antrea/antrea-agent.conf\n" +
+		"        - --logtostderr=false\n" +
+		"        - --log_dir=/var/log/antrea\n" +
+		"        - --alsologtostderr\n" +
+		"        - --log_file_max_size=100\n" +
+		"        - --log_file_max_num=4\n" +
+		"        - --v=0\n" +
+		"        env:\n" +
+		"        - name: POD_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.name\n" +
+		"        - name: POD_NAMESPACE\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.namespace\n" +
+		"        - name: NODE_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: spec.nodeName\n" +
+		"        readinessProbe:\n" +
+		"          httpGet:\n" +
+		"            host: localhost\n" +
+		"            path: /readyz\n" +
+		"            port: 10350\n" +
+		"            scheme: HTTPS\n" +
+		"          initialDelaySeconds: 5\n" +
+		"          timeoutSeconds: 5\n" +
+		"          periodSeconds: 10\n" +
+		"          failureThreshold: 8\n" +
+		"        livenessProbe:\n" +
+		"          httpGet:\n" +
+		"            host: localhost\n" +
+		"            path: /livez\n" +
+		"            port: 10350\n" +
+		"            scheme: HTTPS\n" +
+		"          timeoutSeconds: 5\n" +
+		"          periodSeconds: 10\n" +
+		"          failureThreshold: 5\n" +
+		"        securityContext:\n" +
+		"          # antrea-agent needs to perform sysctl configuration.\n" +
+		"          privileged: true\n" +
+		"        volumeMounts:\n" +
+		"        - name: antrea-config\n" +
+		"          mountPath: /etc/antrea/antrea-agent.conf\n" +
+		"          subPath: antrea-agent.conf\n" +
+		"          readOnly: true\n" +
+		"        - name: host-var-run-antrea\n" +
+		"          mountPath: /var/run/antrea\n" +
+		"        - name: host-var-run-antrea\n" +
+		"          mountPath: /var/run/openvswitch\n" +
+		"          subPath: openvswitch\n" +
+		"        # host-local IPAM stores allocated IP addresses as files in /var/lib/cni/networks/$NETWORK_NAME.\n" +
+		"        # Mount a sub-directory of host-var-run-antrea to it for persistence of IP allocation.\n" +
+		"        - name: host-var-run-antrea\n" +
+		"          mountPath: /var/lib/cni\n" +
+		"          subPath: cni\n" +
+		"        # We need to mount both the /proc directory and the /var/run/netns directory so that\n" +
+		"        # antrea-agent can open the network namespace path when setting up Pod\n" +
+		"        # networking. Different container runtimes may use /proc or /var/run/netns when invoking\n" +
+		"        # the CNI commands.\n" +
+		"        - name: host-proc\n" +
+		"          mountPath: /host/proc\n" +
+		"          readOnly: true\n" +
+		"        - name: host-var-run-netns\n" +
+		"          mountPath: /host/var/run/netns\n" +
+		"          readOnly: true\n" +
+		"          # When a container is created, a mount point for the network namespace is added under\n" +
+		"          # /var/run/netns on the host, which needs to be propagated to the antrea-agent container.\n" +
+		"          mountPropagation: HostToContainer\n" +
+		"        - name: host-var-log-antrea\n" +
+		"          mountPath: /var/log/antrea\n" +
+		"          subPath: antrea-agent\n" +
+		"        - name: xtables-lock\n" +
+		"          mountPath: /run/xtables.lock\n" +
+		"      - name: antrea-ovs\n" +
+		"        image: {{ .ImageRepository }}/antrea-ubuntu:{{ .Version }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: \"200m\"\n" +
+		"        command: [\"start_ovs\"]\n" +
+		"        args:\n" +
+		"        - --log_file_max_size=100\n" +
+		"        - --log_file_max_num=4\n" +
+		"        securityContext:\n" +
+		"          # capabilities required by OVS\n" +
+		"          capabilities:\n" +
+		"            add:\n" +
+		"            - SYS_NICE\n" +
+		"            - NET_ADMIN\n" +
+		"            - SYS_ADMIN\n" +
+		"            - IPC_LOCK\n" +
+		"        livenessProbe:\n" +
+		"          exec:\n" +
+		"            # docker CRI doesn't honor timeoutSeconds, add \"timeout\" to the command as a workaround.\n" +
+		"            command: [\"/bin/sh\", \"-c\", \"timeout 10 container_liveness_probe ovs\"]\n" +
+		"          initialDelaySeconds: 5\n" +
+		"          timeoutSeconds: 10\n" +
+		"          periodSeconds: 10\n" +
+		"          failureThreshold: 5\n" +
+		"        volumeMounts:\n" +
+		"        - name: host-var-run-antrea\n" +
+		"          mountPath: /var/run/openvswitch\n" +
+		"          subPath: openvswitch\n" +
+		"        - name: host-var-log-antrea\n" +
+		"          mountPath: /var/log/openvswitch\n" +
+		"          subPath: openvswitch\n" +
+		"      volumes:\n" +
+		"      - name: antrea-config\n" +
+		"        configMap:\n" +
+		"          name: antrea-config\n" +
+		"      - name: host-cni-conf\n" +
+		"        hostPath:\n" +
+		"          path: /etc/cni/net.d\n" +
+		"      - name: host-cni-bin\n" +
+		"        hostPath:\n" +
+		"          path: /opt/cni/bin\n" +
+		"      - name: host-proc\n" +
+		"        hostPath:\n" +
+		"          path: /proc\n" +
+		"      - name: host-var-run-netns\n" +
+		"        hostPath:\n" +
+		"          path: /var/run/netns\n" +
+		"      - name: host-var-run-antrea\n" +
+		"        hostPath:\n" +
+		"          path: /var/run/antrea\n" +
+		"          # we use subPath to create run subdirectories for different component (e.g. OVS) and\n" +
+		"          # subPath requires the base volume to exist\n" +
+		"          type: DirectoryOrCreate\n" +
+		"      - name: host-var-log-antrea\n" +
+		"        hostPath:\n" +
+		"          path: /var/log/antrea\n" +
+		"          # we use subPath to create logging subdirectories for Antrea components\n" +
+		"          type: DirectoryOrCreate\n" +
+		"      - name: host-lib-modules\n" +
+		"        hostPath:\n" +
+		"          path: /lib/modules\n" +
+		"      - name: xtables-lock\n" +
+		"        hostPath:\n" +
+		"          path: /run/xtables.lock\n" +
+		"          type: FileOrCreate\n" +
+		""
+	return tmpl
+}
@@ -0,0 +1,188 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const (
+	auditWebhookConfigFile = "/etc/kubernetes/audit-webhook-config.yaml"
+	auditPolicyAPIVersion  = "audit.k8s.io/v1"
+
+	defaultAuditLogMaxAge    = 30
+	defaultAuditLogMaxBackup = 10
+	defaultAuditLogMaxSize   = 100
+	defaultAuditWebhookMode  = "batch"
+)
+
+// auditLog holds the API server audit settings.
+type auditLog struct {
+	policyFile        string
+	maxAge            int
+	maxBackup         int
+	maxSize           int
+	webhookConfigFile string
+	webhookMode       string
+}
+
+func defaultAuditLog() auditLog {
+	return auditLog{
+		maxAge:      defaultAuditLogMaxAge,
+		maxBackup:   defaultAuditLogMaxBackup,
+		maxSize:     defaultAuditLogMaxSize,
+		webhookMode: defaultAuditWebhookMode,
+	}
+}
+
+func (c *ControlPlane) auditLogParameters(cmd *cobra.Command) (err error) {
+	c.auditLog.policyFile, err = cmd.Flags().GetString(constants.FlagAuditPolicyFile)
+	if err != nil {
+		return
+	}
+	c.auditLog.maxAge, err = cmd.Flags().GetInt(constants.FlagAuditLogMaxAge)
+	if err != nil {
+		return
+	}
+	c.auditLog.maxBackup, err = cmd.Flags().GetInt(constants.FlagAuditLogMaxBackup)
+	if err != nil {
+		return
+	}
+	c.auditLog.maxSize, err = cmd.Flags().GetInt(constants.FlagAuditLogMaxSize)
+	if err != nil {
+		return
+	}
+	c.auditLog.webhookConfigFile, err = cmd.Flags().GetString(constants.FlagAuditWebhookConfigFile)
+	if err != nil {
+		return
+	}
+	c.auditLog.webhookMode, err = cmd.Flags().GetString(constants.FlagAuditWebhookMode)
+	if err != nil {
+		return
+	}
+
+	for flag, v := range map[string]int{
+		constants.FlagAuditLogMaxAge:    c.auditLog.maxAge,
+		constants.FlagAuditLogMaxBackup: c.auditLog.maxBackup,
+		constants.FlagAuditLogMaxSize:   c.auditLog.maxSize,
+	} {
+		if v < 0 {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be negative", flag)
+		}
+	}
+
+	if c.withoutAuditLog {
+		if c.auditLog.policyFile != "" || c.auditLog.webhookConfigFile != "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s and --%s can not be used with --%s", constants.FlagAuditPolicyFile, constants.FlagAuditWebhookConfigFile, constants.FlagAuditLog)
+		}
+		return
+	}
+
+	if c.auditLog.policyFile != "" {
+		b, err := ioutil.ReadFile(c.auditLog.policyFile)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAuditPolicyFile, err)
+		}
+		if err := validateAuditPolicy(b); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAuditPolicyFile, err)
+		}
+	}
+
+	if c.auditLog.webhookConfigFile != "" {
+		if _, err := os.Stat(c.auditLog.webhookConfigFile); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAuditWebhookConfigFile, err)
+		}
+		switch c.auditLog.webhookMode {
+		case "batch", "blocking", "blocking-strict":
+			// break
+		default:
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: unsupported mode %q, possible values: batch, blocking, blocking-strict", constants.FlagAuditWebhookMode, c.auditLog.webhookMode)
+		}
+	}
+
+	return
+}
+
+// validateAuditPolicy makes sure the policy uses the audit.k8s.io/v1 API, the only one served by every supported Kubernetes version.
+func validateAuditPolicy(b []byte) error {
+	var policy struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := yaml.Unmarshal(b, &policy); err != nil {
+		return errors.Wrap(err, "failed to parse audit policy")
+	}
+	if policy.Kind != "Policy" {
+		return errors.Errorf("unexpected kind %q, expected Policy", policy.Kind)
+	}
+	if policy.APIVersion != auditPolicyAPIVersion {
+		return errors.Errorf("unsupported apiVersion %q, expected %s", policy.APIVersion, auditPolicyAPIVersion)
+	}
+	return nil
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f auditV1 audit_v1.yaml.tmpl
+
+// writeAuditPolicyFile writes the audit policy consumed by the API server, either the user supplied or the default one.
+func writeAuditPolicyFile(out io.Writer, filename string, a auditLog) error {
+	dir := filepath.Dir(filename)
+
+	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+
+	policy := auditV1Template()
+	if a.policyFile != "" {
+		b, err := ioutil.ReadFile(a.policyFile)
+		if err != nil {
+			return err
+		}
+		if err := validateAuditPolicy(b); err != nil {
+			return err
+		}
+		policy = string(b)
+	}
+
+	return file.Overwrite(filename, policy)
+}
+
+// writeAuditWebhookConfig copies the kubeconfig of the audit webhook backend to the path mounted into the API server.
+func writeAuditWebhookConfig(out io.Writer, filename string, a auditLog) error {
+	if a.webhookConfigFile == "" {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing audit webhook config: %q\n", use, filename)
+
+	b, err := ioutil.ReadFile(a.webhookConfigFile)
+	if err != nil {
+		return err
+	}
+
+	return file.Overwrite(filename, string(b))
+}
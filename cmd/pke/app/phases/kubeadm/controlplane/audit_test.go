@@ -0,0 +1,44 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAuditPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		err    bool
+	}{
+		{name: "default", policy: auditV1Template()},
+		{name: "v1", policy: "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: Metadata\n"},
+		{name: "v1beta1", policy: "apiVersion: audit.k8s.io/v1beta1\nkind: Policy\nrules:\n- level: Metadata\n", err: true},
+		{name: "kind", policy: "apiVersion: audit.k8s.io/v1\nkind: Event\n", err: true},
+		{name: "invalid", policy: "apiVersion: [", err: true},
+	}
+
+	for _, tc := range testCases {
+		err := validateAuditPolicy([]byte(tc.policy))
+		if tc.err {
+			require.Error(t, err, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
+	}
+}
@@ -14,9 +14,9 @@
 
 package controlplane
 
-// auditV1Beta1Template is a generated function returning the template as a string.
-func auditV1Beta1Template() string {
-	var tmpl = "apiVersion: audit.k8s.io/v1beta1\n" +
+// auditV1Template is a generated function returning the template as a string.
+func auditV1Template() string {
+	var tmpl = "apiVersion: audit.k8s.io/v1\n" +
 		"kind: Policy\n" +
 		"rules:\n" +
 		"  - level: None\n" +
@@ -31,7 +31,7 @@ func calicoTemplate() string {
 		"  calico_backend: \"bird\"\n" +
 		"\n" +
 		"  # Configure the MTU to use\n" +
-		"  veth_mtu: \"1440\"\n" +
+		"  veth_mtu: \"{{ .MTU }}\"\n" +
 		"\n" +
 		"  # The CNI network configuration to install on each node.  The special\n" +
 		"  # values in this config will be automatically populated.\n" +
@@ -47,7 +47,9 @@ func calicoTemplate() string {
 		"          \"nodename\": \"__KUBERNETES_NODE_NAME__\",\n" +
 		"          \"mtu\": __CNI_MTU__,\n" +
 		"          \"ipam\": {\n" +
-		"              \"type\": \"calico-ipam\"\n" +
+		"              \"type\": \"calico-ipam\",\n" +
+		"              \"assign_ipv4\": \"{{ if .IPv4PoolCIDR }}true{{ else }}false{{ end }}\",\n" +
+		"              \"assign_ipv6\": \"{{ if .IPv6PoolCIDR }}true{{ else }}false{{ end }}\"\n" +
 		"          },\n" +
 		"          \"policy\": {\n" +
 		"              \"type\": \"k8s\"\n" +
@@ -553,7 +555,7 @@ func calicoTemplate() string {
 		"          env:\n" +
 		"            # Name of the CNI config file to create.\n" +
 		"            - name: CNI_CONF_NAME\n" +
-		"              value: \"10-calico.conflist\"\n" +
+		"              value: \"{{ .CNIConfName }}\"\n" +
 		"            # The CNI network config to install on each node.\n" +
 		"            - name: CNI_NETWORK_CONFIG\n" +
 		"              valueFrom:\n" +
@@ -615,7 +617,12 @@ func calicoTemplate() string {
 		"              value: \"k8s,bgp\"\n" +
 		"            # Auto-detect the BGP IP address.\n" +
 		"            - name: IP\n" +
-		"              value: \"autodetect\"\n" +
+		"              value: \"{{ if .IPv4PoolCIDR }}autodetect{{ else }}none{{ end }}\"{{ if .IPv6PoolCIDR }}\n" +
+		"            - name: IP6\n" +
+		"              value: \"autodetect\"{{ end }}{{ if not .IPv4PoolCIDR }}\n" +
+		"            # BIRD needs a router ID without an IPv4 address.\n" +
+		"            - name: CALICO_ROUTER_ID\n" +
+		"              value: \"hash\"{{ end }}\n" +
 		"            # Enable IPIP\n" +
 		"            - name: CALICO_IPV4POOL_IPIP\n" +
 		"              value: \"Always\"\n" +
@@ -625,20 +632,32 @@ func calicoTemplate() string {
 		"                configMapKeyRef:\n" +
 		"                  name: calico-config\n" +
 		"                  key: veth_mtu\n" +
+		"{{- if .IPv4PoolCIDR }}\n" +
 		"            # The default IPv4 pool to create on startup if none exists. Pod IPs will be\n" +
 		"            # chosen from this range. Changing this value after installation will have\n" +
 		"            # no effect. This should fall within `--cluster-cidr`.\n" +
 		"            - name: CALICO_IPV4POOL_CIDR\n" +
-		"              value: \"192.168.0.0/16\"\n" +
+		"              value: \"{{ .IPv4PoolCIDR }}\"{{ if .IPv6PoolCIDR }}\n" +
+		"            # The default IPv6 pool to create on startup if none exists.\n" +
+		"            - name: CALICO_IPV6POOL_CIDR\n" +
+		"              value: \"{{ .IPv6PoolCIDR }}\"\n" +
+		"            - name: CALICO_IPV6POOL_NAT_OUTGOING\n" +
+		"              value: \"true\"{{ end }}\n" +
+		"{{- else }}\n" +
+		"            # Without an IPv4 pod network no default IPv4 pool is created,\n" +
+		"            # the IPv6 pool is defined at the end of the manifest.\n" +
+		"            - name: NO_DEFAULT_POOLS\n" +
+		"              value: \"true\"\n" +
+		"{{- end }}\n" +
 		"            # Disable file logging so `kubectl logs` works.\n" +
 		"            - name: CALICO_DISABLE_FILE_LOGGING\n" +
 		"              value: \"true\"\n" +
 		"            # Set Felix endpoint to host default action to ACCEPT.\n" +
 		"            - name: FELIX_DEFAULTENDPOINTTOHOSTACTION\n" +
 		"              value: \"ACCEPT\"\n" +
-		"            # Disable IPv6 on Kubernetes.\n" +
+		"            # Enable IPv6 on Kubernetes if there is an IPv6 pool.\n" +
 		"            - name: FELIX_IPV6SUPPORT\n" +
-		"              value: \"false\"\n" +
+		"              value: \"{{ if .IPv6PoolCIDR }}true{{ else }}false{{ end }}\"\n" +
 		"            # Set Felix logging to \"info\"\n" +
 		"            - name: FELIX_LOGSEVERITYSCREEN\n" +
 		"              value: \"info\"\n" +
@@ -791,6 +810,22 @@ func calicoTemplate() string {
 		"\n" +
 		"---\n" +
 		"# Source: calico/templates/configure-canal.yaml\n" +
+		"{{- if not .IPv4PoolCIDR }}\n" +
+		"\n" +
+		"---\n" +
+		"# The IPv6 pool of an IPv6-only cluster.\n" +
+		"apiVersion: crd.projectcalico.org/v1\n" +
+		"kind: IPPool\n" +
+		"metadata:\n" +
+		"  name: default-ipv6-ippool\n" +
+		"spec:\n" +
+		"  cidr: {{ .IPv6PoolCIDR }}\n" +
+		"  blockSize: 122\n" +
+		"  ipipMode: Never\n" +
+		"  vxlanMode: Never\n" +
+		"  natOutgoing: true\n" +
+		"  nodeSelector: all()\n" +
+		"{{- end }}\n" +
 		""
 	return tmpl
 }
@@ -61,14 +61,14 @@ func ciliumTemplate() string {
 		"\n" +
 		"  # Enable IPv4 addressing. If enabled, all endpoints are allocated an IPv4\n" +
 		"  # address.\n" +
-		"  enable-ipv4: \"true\"\n" +
+		"  enable-ipv4: \"{{ if .IPv4PodCIDR }}true{{ else }}false{{ end }}\"\n" +
 		"\n" +
 		"  # Enable IPv6 addressing. If enabled, all endpoints are allocated an IPv6\n" +
 		"  # address.\n" +
-		"  enable-ipv6: \"false\"\n" +
+		"  enable-ipv6: \"{{ if .IPv6PodCIDR }}true{{ else }}false{{ end }}\"\n" +
 		"  # Users who wish to specify their own custom CNI configuration file must set\n" +
 		"  # custom-cni-conf to \"true\", otherwise Cilium may overwrite the configuration.\n" +
-		"  custom-cni-conf: \"false\"\n" +
+		"  custom-cni-conf: \"{{ if .CustomCNIConf }}true{{ else }}false{{ end }}\"\n" +
 		"  enable-bpf-clock-probe: \"true\"\n" +
 		"  # If you want cilium monitor to aggregate tracing for packets, set this level\n" +
 		"  # to \"low\", \"medium\", or \"maximum\". The higher the level, the less packets\n" +
@@ -140,7 +140,7 @@ func ciliumTemplate() string {
 		"  auto-direct-node-routes: \"false\"\n" +
 		"  enable-bandwidth-manager: \"false\"\n" +
 		"  enable-local-redirect-policy: \"false\"\n" +
-		"  kube-proxy-replacement:  \"disabled\"\n" +
+		"  kube-proxy-replacement:  \"{{ if .APIServerHost }}strict{{ else }}disabled{{ end }}\"\n" +
 		"  kube-proxy-replacement-healthz-bind-address: \"\"\n" +
 		"  enable-health-check-nodeport: \"true\"\n" +
 		"  node-port-bind-protection: \"true\"\n" +
@@ -156,8 +156,10 @@ func ciliumTemplate() string {
 		"  # UNIX domain socket for Hubble server to listen to.\n" +
 		"  hubble-socket-path:  \"/var/run/cilium/hubble.sock\"\n" +
 		"  ipam: \"cluster-pool\"\n" +
-		"  cluster-pool-ipv4-cidr: \"{{ .PodCIDR }}\"\n" +
-		"  cluster-pool-ipv4-mask-size: \"24\"\n" +
+		"  {{ if .IPv4PodCIDR }}cluster-pool-ipv4-cidr: \"{{ .IPv4PodCIDR }}\"\n" +
+		"  cluster-pool-ipv4-mask-size: \"{{ .NodeCIDRMaskSize }}\"{{ end }}\n" +
+		"  {{ if .IPv6PodCIDR }}cluster-pool-ipv6-cidr: \"{{ .IPv6PodCIDR }}\"\n" +
+		"  cluster-pool-ipv6-mask-size: \"{{ .NodeCIDRMaskSizeIPv6 }}\"{{ end }}\n" +
 		"  disable-cnp-status-updates: \"true\"\n" +
 		"---\n" +
 		"# Source: cilium/templates/cilium-agent-clusterrole.yaml\n" +
@@ -442,7 +444,11 @@ func ciliumTemplate() string {
 		"          periodSeconds: 30\n" +
 		"          successThreshold: 1\n" +
 		"          timeoutSeconds: 5\n" +
-		"        env:\n" +
+		"        env:{{ if .APIServerHost }}\n" +
+		"        - name: KUBERNETES_SERVICE_HOST\n" +
+		"          value: \"{{ .APIServerHost }}\"\n" +
+		"        - name: KUBERNETES_SERVICE_PORT\n" +
+		"          value: \"{{ .APIServerPort }}\"{{ end }}\n" +
 		"        - name: K8S_NODE_NAME\n" +
 		"          valueFrom:\n" +
 		"            fieldRef:\n" +
@@ -668,7 +674,11 @@ func ciliumTemplate() string {
 		"        - --debug=$(CILIUM_DEBUG)\n" +
 		"        command:\n" +
 		"        - cilium-operator\n" +
-		"        env:\n" +
+		"        env:{{ if .APIServerHost }}\n" +
+		"        - name: KUBERNETES_SERVICE_HOST\n" +
+		"          value: \"{{ .APIServerHost }}\"\n" +
+		"        - name: KUBERNETES_SERVICE_PORT\n" +
+		"          value: \"{{ .APIServerPort }}\"{{ end }}\n" +
 		"        - name: K8S_NODE_NAME\n" +
 		"          valueFrom:\n" +
 		"            fieldRef:\n" +
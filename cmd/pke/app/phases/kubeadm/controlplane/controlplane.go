@@ -23,11 +23,7 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
 	"text/template"
 	"time"
 
@@ -42,12 +38,16 @@ import (
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/node"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/flags"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
 	pipelineutil "github.com/banzaicloud/pke/cmd/pke/app/util/pipeline"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/proxy"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/transport"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/validator"
@@ -59,7 +59,6 @@ const (
 
 	cmdKubeadm                    = "kubeadm"
 	cmdKubectl                    = "kubectl"
-	weaveNetUrl                   = "https://cloud.weave.works/k8s/net"
 	kubeConfig                    = "/etc/kubernetes/admin.conf"
 	kubeProxyConfig               = "/var/lib/kube-proxy/config.conf"
 	kubeadmConfig                 = "/etc/kubernetes/kubeadm.conf"
@@ -70,8 +69,6 @@ const (
 	kubernetesCASigningCert       = "/etc/kubernetes/pki/cm-signing-ca.crt"
 	admissionConfig               = "/etc/kubernetes/admission-control.yaml"
 	admissionEventRateLimitConfig = "/etc/kubernetes/admission-control/event-rate-limit.yaml"
-	podSecurityPolicyConfig       = "/etc/kubernetes/admission-control/pod-security-policy.yaml"
-	certificateAutoApprover       = "/etc/kubernetes/admission-control/deploy-auto-approver.yaml"
 	cniDir                        = "/etc/cni/net.d"
 	etcdDir                       = "/var/lib/etcd"
 	auditPolicyFile               = "/etc/kubernetes/audit-policy-file.yaml"
@@ -89,6 +86,8 @@ type ControlPlane struct {
 
 	kubernetesVersion                string
 	containerRuntime                 string
+	cgroupDriver                     string
+	sandboxRuntimes                  []cri.SandboxRuntime
 	networkProvider                  string
 	advertiseAddress                 string
 	apiServerHostPort                string
@@ -96,7 +95,12 @@ type ControlPlane struct {
 	nodeName                         string
 	serviceCIDR                      string
 	podNetworkCIDR                   string
+	nodeCIDRMaskSize                 uint
+	nodeCIDRMaskSizeIPv6             uint
+	expectedNodeCount                uint
 	mtu                              uint
+	flannelBackend                   string
+	kubeProxy                        kubeadm.KubeProxyOptions
 	cloudProvider                    string
 	nodepool                         string
 	controllerManagerSigningCA       string
@@ -104,12 +108,16 @@ type ControlPlane struct {
 	joinControlPlane                 bool
 	apiServerCertSANs                []string
 	kubeletCertificateAuthority      string
-	oidcIssuerURL                    string
-	oidcClientID                     string
+	oidc                             oidc
+	dns                              dns
+	networkPolicy                    networkPolicy
 	imageRepository                  string
 	useImageRepositoryToK8s          bool
 	withPluginPSP                    bool
+	podSecurity                      podSecurity
+	admission                        admission
 	withoutAuditLog                  bool
+	auditLog                         auditLog
 	node                             *node.Node
 	azureTenantID                    string
 	azureSubnetName                  string
@@ -131,8 +139,11 @@ type ControlPlane struct {
 	vsphereFolder                    string
 	vsphereUsername                  string
 	vspherePassword                  string
-	cidr                             string
+	nodeIPSelector                   network.NodeIPSelector
+	nodeIPs                          []net.IP
 	lbRange                          string
+	lbConfig                         string
+	loadBalancer                     metallb.Config
 	disableDefaultStorageClass       bool
 	taints                           []string
 	labels                           []string
@@ -142,6 +153,8 @@ type ControlPlane struct {
 	etcdKeyFile                      string
 	etcdPrefix                       string
 	encryptionSecret                 string
+	encryption                       kubeadm.EncryptionOptions
+	proxy                            proxy.Config
 }
 
 func NewCommand(config config.Config) *cobra.Command {
@@ -154,7 +167,9 @@ func NewCommand(config config.Config) *cobra.Command {
 func NewDefault(kubernetesVersion, imageRepository string) *ControlPlane {
 	return &ControlPlane{
 		kubernetesVersion: kubernetesVersion,
+		cgroupDriver:      config.DefaultCgroupDriver,
 		imageRepository:   imageRepository,
+		auditLog:          defaultAuditLog(),
 		node:              &node.Node{},
 	}
 }
@@ -172,13 +187,24 @@ func (c *ControlPlane) RegisterFlags(flags *pflag.FlagSet) {
 	flags.String(constants.FlagKubernetesVersion, c.config.Kubernetes.Version, "Kubernetes version")
 	// Kubernetes container runtime
 	flags.String(constants.FlagContainerRuntime, c.config.ContainerRuntime.Type, "Kubernetes container runtime")
+	// cgroup driver
+	flags.String(constants.FlagCgroupDriver, c.config.ContainerRuntime.CgroupDriver, "cgroup driver used by the kubelet and the container runtime, possible values: systemd, cgroupfs")
+	// Sandboxed runtimes
+	flags.StringSlice(constants.FlagSandboxRuntimes, c.config.ContainerRuntime.Containerd.SandboxRuntimes, "Sandboxed runtimes to create RuntimeClasses for, possible values: gvisor, kata")
 	// Kubernetes network
-	flags.String(constants.FlagNetworkProvider, "calico", "Kubernetes network provider")
+	flags.String(constants.FlagNetworkProvider, "calico", "Kubernetes network provider, possible values: calico, cilium, flannel, antrea, none")
 	flags.String(constants.FlagAdvertiseAddress, "", "Kubernetes API Server advertise address")
 	flags.String(constants.FlagAPIServerHostPort, "", "Kubernetes API Server host port")
-	flags.String(constants.FlagServiceCIDR, "10.10.0.0/16", "range of IP address for service VIPs")
-	flags.String(constants.FlagPodNetworkCIDR, "10.20.0.0/16", "range of IP addresses for the pod network")
+	flags.String(constants.FlagServiceCIDR, "10.10.0.0/16", "range of IP address for service VIPs, comma separated IPv4 and IPv6 ranges for dual-stack clusters")
+	flags.String(constants.FlagPodNetworkCIDR, "10.20.0.0/16", "range of IP addresses for the pod network, comma separated IPv4 and IPv6 ranges for dual-stack clusters")
+	flags.Uint(constants.FlagNodeCIDRMaskSize, 24, "prefix length of the IPv4 pod network CIDR allocated to each node")
+	flags.Uint(constants.FlagNodeCIDRMaskSizeIPv6, 64, "prefix length of the IPv6 pod network CIDR allocated to each node")
+	flags.Uint(constants.FlagExpectedNodeCount, 0, "number of nodes the pod network should have room for. 0 means the size is not checked")
+	kubeadm.RegisterKubeProxyFlags(flags)
 	flags.Uint(constants.FlagMTU, 0, "maximum transmission unit. 0 means default value of the Kubernetes network provider is used")
+	flags.String(constants.FlagFlannelBackend, constants.FlannelBackendVXLAN, "backend of the flannel network provider, possible values: vxlan, host-gw")
+	flags.String(constants.FlagNetworkPolicyProfile, "", "Cluster-wide network policies to apply at bootstrap, possible values: baseline. baseline denies ingress in every namespace unless allowed by NetworkPolicies, allows cluster DNS and protects kube-system (calico and cilium only)")
+	flags.StringSlice(constants.FlagNetworkPolicyExemptNamespaces, []string{"metallb-system"}, "Namespaces the default-deny ingress policy of the network policy profile does not apply to")
 	// Kubernetes cluster name
 	flags.String(constants.FlagClusterName, "pke", "Kubernetes cluster name")
 	// Kubernetes kubadm init node name
@@ -194,17 +220,49 @@ func (c *ControlPlane) RegisterFlags(flags *pflag.FlagSet) {
 	// Pipeline nodepool name (optional)
 	flags.String(constants.FlagPipelineNodepool, "", "name of the nodepool the node belongs to")
 	// OIDC authentication parameters (optional)
-	flags.String(constants.FlagOIDCIssuerURL, "", "URL of the OIDC provider which allows the API server to discover public signing keys")
+	flags.String(constants.FlagOIDCIssuerURL, "", "URL of the OIDC provider which allows the API server to discover public signing keys. Multiple issuers are configured with --"+constants.FlagAuthenticationConfig)
 	flags.String(constants.FlagOIDCClientID, "", "A client ID that all OIDC tokens must be issued for")
+	flags.String(constants.FlagOIDCUsernameClaim, defaultOIDCUsernameClaim, "OIDC claim to use as the user name")
+	flags.String(constants.FlagOIDCUsernamePrefix, defaultOIDCUsernamePrefix, "Prefix prepended to OIDC user names, '-' disables prefixing")
+	flags.String(constants.FlagOIDCGroupsClaim, defaultOIDCGroupsClaim, "OIDC claim to use as the user's groups")
+	flags.String(constants.FlagOIDCGroupsPrefix, "", "Prefix prepended to OIDC group names")
+	flags.String(constants.FlagOIDCCAFile, "", "Path to the CA certificate of the OIDC issuer, defaults to the host's root CAs")
+	flags.StringToString(constants.FlagOIDCRequiredClaims, map[string]string{}, "Claims OIDC tokens must contain with the given values, example: hd=example.com")
+	flags.StringSlice(constants.FlagOIDCSigningAlgs, []string{defaultOIDCSigningAlg}, "Accepted OIDC token signing algorithms, not configurable on Kubernetes 1.30 or newer where every asymmetric algorithm is accepted")
+	// DNS
+	flags.Int(constants.FlagDNSReplicas, 0, "Number of CoreDNS replicas, 0 keeps the kubeadm default")
+	flags.StringSlice(constants.FlagDNSUpstreams, []string{}, "Resolvers CoreDNS forwards external queries to, defaults to the resolvers of the node, example: 10.0.0.2,10.0.0.3:5353")
+	flags.StringSlice(constants.FlagDNSStubDomains, []string{}, "Domains forwarded to dedicated resolvers in <domain>=<resolver> format, repeat a domain for multiple resolvers, example: corp.example.com=10.0.0.10")
+	flags.StringToString(constants.FlagDNSHosts, map[string]string{}, "Static host entries served by CoreDNS, example: registry.corp.example.com=10.0.0.20")
+	flags.Bool(constants.FlagDNSNodeLocalCache, false, "Deploy NodeLocal DNSCache and point the kubelets to it")
+	flags.String(constants.FlagDNSNodeLocalAddress, defaultNodeLocalDNSAddress, "Link-local address NodeLocal DNSCache listens on")
+	flags.String(constants.FlagAuthenticationConfig, "", "Path to a structured AuthenticationConfiguration file supporting multiple issuers, requires Kubernetes 1.30 or newer. It is installed as "+authenticationConfig)
 	// Image repository
 	flags.String(constants.FlagImageRepository, "", "Prefix for image repository")
 	// Use defined image repository for K8s images as well
 	flags.Bool(constants.FlagUseImageRepositoryToK8s, false, "Use defined image repository for K8s Images as well")
 	// PodSecurityPolicy admission plugin
 	flags.Bool(constants.FlagAdmissionPluginPodSecurityPolicy, false, "Enable PodSecurityPolicy admission plugin")
+	// PodSecurity admission plugin
+	flags.String(constants.FlagPodSecurityStandard, "", "Pod Security Standard to enforce cluster-wide, possible values: privileged, baseline, restricted")
+	flags.String(constants.FlagPodSecurityAudit, "", "Pod Security Standard to audit cluster-wide, defaults to the enforced one")
+	flags.String(constants.FlagPodSecurityWarn, "", "Pod Security Standard to warn about cluster-wide, defaults to the enforced one")
+	flags.StringSlice(constants.FlagPodSecurityExemptNamespaces, []string{"kube-system"}, "Namespaces exempted from Pod Security Admission")
+	// Admission chain
+	flags.StringSlice(constants.FlagAdmissionPlugins, []string{}, "Admission plugins to enable in addition to the default ones")
+	flags.StringSlice(constants.FlagDisableAdmissionPlugins, []string{}, "Admission plugins to disable, default ones included, example: AlwaysPullImages")
+	flags.StringSlice(constants.FlagEventRateLimits, defaultEventRateLimits, "EventRateLimit admission plugin limits in <type>:<qps>:<burst>[:<cache size>] format, possible types: Server, Namespace, User, SourceAndObject")
+	flags.String(constants.FlagImagePolicyWebhookConfigFile, "", "Path to a kubeconfig file defining the ImagePolicyWebhook backend, enables the ImagePolicyWebhook admission plugin")
+	flags.Bool(constants.FlagImagePolicyDefaultAllow, false, "Admit images when the ImagePolicyWebhook backend is unreachable")
 
 	// AuditLog enable
 	flags.Bool(constants.FlagAuditLog, false, "Disable apiserver audit log")
+	flags.String(constants.FlagAuditPolicyFile, "", "Path to an audit.k8s.io/v1 audit policy file replacing the default policy")
+	flags.Int(constants.FlagAuditLogMaxAge, defaultAuditLogMaxAge, "Maximum number of days to retain old audit log files")
+	flags.Int(constants.FlagAuditLogMaxBackup, defaultAuditLogMaxBackup, "Maximum number of old audit log files to retain")
+	flags.Int(constants.FlagAuditLogMaxSize, defaultAuditLogMaxSize, "Maximum size in megabytes of the audit log file before it gets rotated")
+	flags.String(constants.FlagAuditWebhookConfigFile, "", "Path to a kubeconfig file defining the audit webhook backend, credentials must be embedded")
+	flags.String(constants.FlagAuditWebhookMode, defaultAuditWebhookMode, "Strategy for sending audit events to the webhook backend, possible values: batch, blocking, blocking-strict")
 	// Azure cloud
 	flags.String(constants.FlagAzureTenantID, "", "The AAD Tenant ID for the Subscription that the cluster is deployed in")
 	flags.String(constants.FlagAzureSubnetName, "", "The name of the subnet that the cluster is deployed in")
@@ -234,10 +292,11 @@ func (c *ControlPlane) RegisterFlags(flags *pflag.FlagSet) {
 	flags.Bool(constants.FlagPipelineAPIInsecure, false, "If the Pipeline API should not verify the API's certificate")
 	flags.Int32(constants.FlagPipelineOrganizationID, 0, "Organization ID to use with Pipeline API")
 	flags.Int32(constants.FlagPipelineClusterID, 0, "Cluster ID to use with Pipeline API")
-	flags.String(constants.FlagInfrastructureCIDR, "192.168.64.0/20", "network CIDR for the actual machine")
+	network.RegisterNodeIPFlags(flags)
 	// Storage class
 	flags.Bool(constants.FlagDisableDefaultStorageClass, false, "Do not deploy a default storage class")
-	flags.String(constants.FlagLbRange, "", "Advertise the specified IPv4 range via ARP and allocate addresses for LoadBalancer Services (non-cloud only, example: 192.168.0.100-192.168.0.110)")
+	flags.String(constants.FlagLbRange, "", "Advertise the specified comma separated ranges via ARP and allocate addresses for LoadBalancer Services (non-cloud only, example: 192.168.0.100-192.168.0.110)")
+	flags.String(constants.FlagLbConfig, "", "MetalLB configuration file with address pools, BGP peers and node selectors (non-cloud only)")
 	// Taints
 	flags.StringSlice(constants.FlagTaints, []string{"node-role.kubernetes.io/master:NoSchedule"}, "Specifies the taints the Node should be registered with")
 	// Labels
@@ -249,6 +308,11 @@ func (c *ControlPlane) RegisterFlags(flags *pflag.FlagSet) {
 	flags.String(constants.FlagExternalEtcdKeyFile, "", "An SSL key file used to secure etcd communication")
 	flags.String(constants.FlagExternalEtcdPrefix, "", "The prefix to prepend to all resource paths in etcd")
 	flags.String(constants.FlagEncryptionSecret, "", "Use this key to encrypt secrets (32 byte base64 encoded)")
+	flags.String(constants.FlagEncryptionProvider, constants.EncryptionProviderAESCBC, "Provider used to encrypt secrets, possible values: aescbc, aesgcm, secretbox, kms")
+	flags.String(constants.FlagEncryptionKMSEndpoint, "unix:///var/run/kmsplugin/socket.sock", "Unix socket of the local KMS plugin, used with --encryption-provider=kms")
+	// Proxy
+	proxy.RegisterFlags(flags)
+	// Artifact verification
 
 	c.addHAControlPlaneFlags(flags)
 }
@@ -305,6 +369,7 @@ func (c *ControlPlane) Validate(cmd *cobra.Command) error {
 
 	switch c.containerRuntime {
 	case constants.ContainerRuntimeContainerd,
+		constants.ContainerRuntimeCRIO,
 		constants.ContainerRuntimeDocker:
 		// break
 	default:
@@ -312,10 +377,25 @@ func (c *ControlPlane) Validate(cmd *cobra.Command) error {
 	}
 
 	switch c.networkProvider {
-	case constants.NetworkProviderWeave,
-		constants.NetworkProviderCalico,
+	case constants.NetworkProviderCalico,
+		constants.NetworkProviderAntrea,
 		constants.NetworkProviderNone:
 		// break
+	case constants.NetworkProviderWeave:
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s: network provider %s is no longer supported, its manifest service is discontinued. use %s, %s or %s instead, existing clusters can be moved with pke network migrate",
+			constants.FlagNetworkProvider,
+			c.networkProvider,
+			constants.NetworkProviderCalico,
+			constants.NetworkProviderCilium,
+			constants.NetworkProviderFlannel,
+		)
+	case constants.NetworkProviderFlannel:
+		if err := validateFlannelBackend(c.flannelBackend); err != nil {
+			return err
+		}
+		// break
 	case constants.NetworkProviderCilium:
 		if err := linux.KernelVersionConstraint(cmd.OutOrStdout(), ">=4.9.17-0"); err != nil {
 			return err
@@ -325,6 +405,34 @@ func (c *ControlPlane) Validate(cmd *cobra.Command) error {
 		return errors.Wrapf(constants.ErrUnsupportedNetworkProvider, "network provider: %s", c.networkProvider)
 	}
 
+	// Node address
+	if c.nodeIPSelector.Explicit() {
+		ips, err := c.nodeIPSelector.Select()
+		if err != nil {
+			return err
+		}
+		c.nodeIPs = ips
+		if c.advertiseAddress == "" {
+			c.advertiseAddress = ips[0].String()
+		}
+	}
+
+	if err := c.validateNetwork(); err != nil {
+		return err
+	}
+	if err := c.validateKubeProxy(); err != nil {
+		return err
+	}
+	if err := c.validateDNS(); err != nil {
+		return err
+	}
+	if err := c.validateNetworkPolicy(); err != nil {
+		return err
+	}
+	if routes, err := network.Routes(); err == nil {
+		c.warnRouteOverlaps(cmd.OutOrStdout(), routes)
+	}
+
 	// Use Controller Manager Signing CA if present (pipeline-certificates step creates it).
 	if c.controllerManagerSigningCA == "" {
 		_, err := os.Stat(kubernetesCASigningCert)
@@ -344,7 +452,11 @@ func (c *ControlPlane) Validate(cmd *cobra.Command) error {
 		}
 
 		if c.joinControlPlane {
-			return c.node.Validate(cmd)
+			if err := c.node.Validate(cmd); err != nil {
+				return err
+			}
+			c.node.DefaultAdvertiseAddress(c.advertiseAddress)
+			return nil
 		}
 
 	default:
@@ -365,14 +477,11 @@ func (c *ControlPlane) pipelineJoin(cmd *cobra.Command) error {
 		}
 
 		// ip
-		ips, err := network.IPv4Addresses()
-		if err != nil {
-			return err
-		}
-		ip, err := network.ContainsFirst(c.cidr, ips)
+		ips, err := c.nodeIPSelector.Select()
 		if err != nil {
 			return err
 		}
+		ip := ips[0]
 
 		// Pipeline client
 		endpoint, token, insecure, orgID, clusterID, err := pipelineutil.CommandArgs(cmd)
@@ -438,7 +547,10 @@ func (c *ControlPlane) pipelineJoin(cmd *cobra.Command) error {
 }
 
 func (c *ControlPlane) appendAdvertiseAddressAsLoopback() error {
-	addr := strings.Split(c.apiServerHostPort, ":")[0]
+	addr, _, err := kubeadm.SplitHostPort(c.apiServerHostPort, "6443")
+	if err != nil {
+		return err
+	}
 
 	f, err := os.OpenFile("/etc/hosts", os.O_APPEND|os.O_WRONLY, 0666)
 	if err != nil {
@@ -456,6 +568,12 @@ func (c *ControlPlane) appendAdvertiseAddressAsLoopback() error {
 func (c *ControlPlane) Run(out io.Writer) error {
 	_, _ = fmt.Fprintf(out, "[%s] running\n", c.Use())
 
+	// Proxy settings for kubeadm and kubectl
+	c.proxy = c.proxy.WithNoProxy(c.serviceCIDR, c.podNetworkCIDR, c.nodeIPSelector.CIDR, c.apiServerHostPort, c.advertiseAddress, ".svc", ".cluster.local")
+	if err := c.proxy.Apply(); err != nil {
+		return err
+	}
+
 	if c.clusterMode == haMode {
 		// additional master node
 		if c.joinControlPlane {
@@ -466,11 +584,14 @@ func (c *ControlPlane) Run(out io.Writer) error {
 				return err
 			}
 			// install additional master node
-			if err := writeMasterConfig(out, !c.withoutAuditLog, c.kubernetesVersion, c.encryptionSecret); err != nil {
+			if err := writeMasterConfig(out, !c.withoutAuditLog, c.kubernetesVersion, c.encryptionSecret, c.encryption, c.auditLog, c.podSecurity, c.oidc, c.admission, c.kubeProxy); err != nil {
 				return err
 			}
 			_, _ = fmt.Fprintf(out, "[%s] installing additional master node\n", c.Use())
-			return c.node.Run(out)
+			if err := c.node.Run(out); err != nil {
+				return err
+			}
+			return installCSRApprover(out)
 		}
 
 		// initial master node
@@ -480,6 +601,11 @@ func (c *ControlPlane) Run(out io.Writer) error {
 		}
 	}
 
+	// Proxy settings for the container runtime and the kubelet
+	if err := c.proxy.ConfigureNode(out, c.containerRuntime); err != nil {
+		return err
+	}
+
 	if err := c.installMaster(out); err != nil {
 		if c.node.ResetOnFailure {
 			if rErr := kubeadm.Reset(out, c.containerRuntime); rErr != nil {
@@ -494,28 +620,40 @@ func (c *ControlPlane) Run(out io.Writer) error {
 	}
 
 	switch c.networkProvider {
-	case constants.NetworkProviderWeave:
-		if err := installWeave(out, c.cloudProvider, c.podNetworkCIDR, kubeConfig, c.mtu); err != nil {
+	case constants.NetworkProviderCilium:
+		if err := mountBPFFilesystem(out); err != nil {
 			return err
 		}
-	case constants.NetworkProviderCalico:
-		if err := installCalico(out, c.podNetworkCIDR, kubeConfig, c.mtu); err != nil {
+		fallthrough
+	case constants.NetworkProviderCalico, constants.NetworkProviderFlannel, constants.NetworkProviderAntrea:
+		o, err := c.networkOptions()
+		if err != nil {
 			return err
 		}
-	case constants.NetworkProviderCilium:
-		var single bool
-		if c.clusterMode == singleMode {
-			single = true
+		if err := installAddon(out, networkProviderAddon(c.networkProvider), o); err != nil {
+			return err
 		}
-		// TODO get cilium version from flag
-		version := "v1.11.1"
-		if err := installCilium(out, kubeConfig, c.podNetworkCIDR, c.imageRepository, version, c.mtu, single); err != nil {
+	}
+
+	// create RuntimeClasses for sandboxed runtimes
+	if err := applyRuntimeClasses(out, c.kubernetesVersion, c.sandboxRuntimes); err != nil {
+		return err
+	}
+
+	// apply cluster-wide network policies if specified
+	if c.networkPolicy.profile != "" {
+		if err := installAddon(out, newNetworkPolicyAddon(), networkPolicyConfig{NetworkProvider: c.networkProvider, ExemptNamespaces: c.networkPolicy.exemptNamespaces}); err != nil {
 			return err
 		}
 	}
 
+	// customize CoreDNS and deploy NodeLocal DNSCache if specified
+	if err := c.applyDNS(out); err != nil {
+		return err
+	}
+
 	// install MetalLB if specified
-	if err := applyLbRange(out, c.lbRange, c.cloudProvider); err != nil {
+	if err := applyLoadBalancer(out, c.loadBalancer, c.cloudProvider); err != nil {
 		return err
 	}
 
@@ -579,6 +717,23 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 		return
 	}
 
+	c.cgroupDriver, err = cmd.Flags().GetString(constants.FlagCgroupDriver)
+	if err != nil {
+		return
+	}
+	if err = cri.ValidateCgroupDriver(c.cgroupDriver); err != nil {
+		return
+	}
+
+	sandboxRuntimes, err := cmd.Flags().GetStringSlice(constants.FlagSandboxRuntimes)
+	if err != nil {
+		return
+	}
+	c.sandboxRuntimes, err = cri.SandboxRuntimes(sandboxRuntimes)
+	if err != nil {
+		return
+	}
+
 	c.networkProvider, err = cmd.Flags().GetString(constants.FlagNetworkProvider)
 	if err != nil {
 		return
@@ -599,10 +754,38 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
+	c.nodeCIDRMaskSize, err = cmd.Flags().GetUint(constants.FlagNodeCIDRMaskSize)
+	if err != nil {
+		return
+	}
+	c.nodeCIDRMaskSizeIPv6, err = cmd.Flags().GetUint(constants.FlagNodeCIDRMaskSizeIPv6)
+	if err != nil {
+		return
+	}
+	c.expectedNodeCount, err = cmd.Flags().GetUint(constants.FlagExpectedNodeCount)
+	if err != nil {
+		return
+	}
 	c.mtu, err = cmd.Flags().GetUint(constants.FlagMTU)
 	if err != nil {
 		return
 	}
+	c.flannelBackend, err = cmd.Flags().GetString(constants.FlagFlannelBackend)
+	if err != nil {
+		return
+	}
+	c.networkPolicy.profile, err = cmd.Flags().GetString(constants.FlagNetworkPolicyProfile)
+	if err != nil {
+		return
+	}
+	c.networkPolicy.exemptNamespaces, err = cmd.Flags().GetStringSlice(constants.FlagNetworkPolicyExemptNamespaces)
+	if err != nil {
+		return
+	}
+	c.kubeProxy, err = kubeadm.KubeProxyParameters(cmd)
+	if err != nil {
+		return
+	}
 	c.cloudProvider, err = cmd.Flags().GetString(constants.FlagCloudProvider)
 	if err != nil {
 		return
@@ -635,11 +818,11 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
-	c.oidcIssuerURL, err = cmd.Flags().GetString(constants.FlagOIDCIssuerURL)
+	err = c.oidcParameters(cmd)
 	if err != nil {
 		return
 	}
-	c.oidcClientID, err = cmd.Flags().GetString(constants.FlagOIDCClientID)
+	err = c.dnsParameters(cmd)
 	if err != nil {
 		return
 	}
@@ -659,6 +842,10 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
+	err = c.auditLogParameters(cmd)
+	if err != nil {
+		return
+	}
 	c.joinControlPlane, err = cmd.Flags().GetBool(constants.FlagControlPlaneJoin)
 	if err != nil {
 		return
@@ -671,7 +858,7 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
-	c.cidr, err = cmd.Flags().GetString(constants.FlagInfrastructureCIDR)
+	c.nodeIPSelector, err = network.NodeIPParameters(cmd)
 	if err != nil {
 		return
 	}
@@ -683,6 +870,10 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
+	c.lbConfig, err = cmd.Flags().GetString(constants.FlagLbConfig)
+	if err != nil {
+		return
+	}
 	c.taints, err = cmd.Flags().GetStringSlice(constants.FlagTaints)
 	if err != nil {
 		return
@@ -691,6 +882,22 @@ func (c *ControlPlane) masterBootstrapParameters(cmd *cobra.Command) (err error)
 	if err != nil {
 		return
 	}
+	c.proxy, err = proxy.Parameters(cmd)
+	if err != nil {
+		return
+	}
+	err = c.podSecurityParameters(cmd)
+	if err != nil {
+		return
+	}
+	err = c.admissionParameters(cmd)
+	if err != nil {
+		return
+	}
+	err = validatePodSecurityPolicy(c.kubernetesVersion, c.withPluginPSP, c.admission, c.podSecurity)
+	if err != nil {
+		return
+	}
 
 	return c.etcdParameters(cmd)
 }
@@ -793,6 +1000,26 @@ func (c *ControlPlane) etcdParameters(cmd *cobra.Command) (err error) {
 	if err != nil {
 		return
 	}
+	c.encryption.Provider, err = cmd.Flags().GetString(constants.FlagEncryptionProvider)
+	if err != nil {
+		return
+	}
+	c.encryption.KMSEndpoint, err = cmd.Flags().GetString(constants.FlagEncryptionKMSEndpoint)
+	if err != nil {
+		return
+	}
+
+	if err := kubeadm.ValidateEncryptionProvider(c.encryption.Provider); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagEncryptionProvider, err)
+	}
+	if c.encryption.Provider == constants.EncryptionProviderKMS {
+		if c.encryptionSecret != "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s can not be used with --%s=%s", constants.FlagEncryptionSecret, constants.FlagEncryptionProvider, constants.EncryptionProviderKMS)
+		}
+		if err := kubeadm.ValidateKMSEndpoint(c.encryption.KMSEndpoint); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagEncryptionKMSEndpoint, err)
+		}
+	}
 
 	return validateEncryptionSecret(c.encryptionSecret)
 }
@@ -839,7 +1066,7 @@ func (c *ControlPlane) installMaster(out io.Writer) error {
 	}
 
 	// write master config
-	if err := writeMasterConfig(out, !c.withoutAuditLog, c.kubernetesVersion, c.encryptionSecret); err != nil {
+	if err := writeMasterConfig(out, !c.withoutAuditLog, c.kubernetesVersion, c.encryptionSecret, c.encryption, c.auditLog, c.podSecurity, c.oidc, c.admission, c.kubeProxy); err != nil {
 		return err
 	}
 
@@ -870,6 +1097,9 @@ func (c *ControlPlane) installMaster(out io.Writer) error {
 	if c.cloudProvider == constants.CloudProviderAmazon && c.nodeName != "" {
 		args = append(args, "--node-name="+c.nodeName)
 	}
+	if !c.kubeProxy.Enabled() {
+		args = append(args, "--skip-phases=addon/kube-proxy")
+	}
 
 	_, err = runner.Cmd(out, cmdKubeadm, args...).CombinedOutputAsync()
 	if err != nil {
@@ -881,13 +1111,13 @@ func (c *ControlPlane) installMaster(out io.Writer) error {
 		return err
 	}
 
-	// apply AutoApprover
-	if err := writeCertificateAutoApprover(out, c.imageRepository); err != nil {
+	// kubelet serving certificate approver
+	if err := installCSRApprover(out); err != nil {
 		return err
 	}
 	// apply PSP
 	if c.withPluginPSP {
-		if err := writePodSecurityPolicyConfig(out); err != nil {
+		if err := installAddon(out, newPodSecurityPolicyAddon(), struct{}{}); err != nil {
 			return err
 		}
 	}
@@ -908,147 +1138,121 @@ func (c *ControlPlane) installMaster(out io.Writer) error {
 
 //go:generate templify -t ${GOTMPL} -p controlplane -f calico calico.yaml.tmpl
 
-func installCalico(out io.Writer, podNetworkCIDR, kubeConfig string, mtu uint) error {
-	input := calicoTemplate()
-	input = strings.ReplaceAll(input, "192.168.0.0/16", podNetworkCIDR)
-	if mtu > 0 {
-		input = strings.ReplaceAll(input, `veth_mtu: "1440"`, fmt.Sprintf(`veth_mtu: "%d"`, mtu))
-	}
-
-	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", "-")
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	cmd.Stdin = strings.NewReader(input)
-	_, err := cmd.CombinedOutputAsync()
-	return err
-}
-
-func installWeave(out io.Writer, cloudProvider, podNetworkCIDR, kubeConfig string, mtu uint) error {
-	// kubectl version
-	cmd := runner.Cmd(out, cmdKubectl, "version")
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	o, err := cmd.CombinedOutput()
+// calicoManifest renders the Calico manifest with an IP pool for each IP family of the pod network.
+// The CNI configuration of the nodes is written to cniConfName in the CNI configuration directory.
+func calicoManifest(podNetworkCIDR string, mtu uint, cniConfName string) (string, error) {
+	tmpl, err := template.New("calico").Parse(calicoTemplate())
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// base64
-	ver := base64.StdEncoding.EncodeToString(o)
-
-	// weave network url
-	u, err := url.Parse(weaveNetUrl)
+	cidrs, err := network.ParseCIDRs(podNetworkCIDR)
 	if err != nil {
-		return err
-	}
-	q := u.Query()
-	q.Set("k8s-version", ver)
-	if cloudProvider != constants.CloudProviderAzure {
-		q.Set("env.IPALLOC_RANGE", podNetworkCIDR)
+		return "", err
 	}
-	if mtu > 0 {
-		q.Set("env.WEAVE_MTU", strconv.FormatUint(uint64(mtu), 10))
-	}
-	u.RawQuery = q.Encode()
-
-	// kubectl apply -f "https://cloud.weave.works/k8s/net?k8s-version=$(kubectl version | base64 | tr -d '\n')&env.IPALLOC_RANGE=10.200.0.0/16"
-	cmd = runner.Cmd(out, cmdKubectl, "apply", "-f", u.String())
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	_, err = cmd.CombinedOutputAsync()
-	return err
-}
-
-//go:generate templify -t ${GOTMPL} -p controlplane -f cilium cilium.yaml.tmpl
-//go:generate templify -t ${GOTMPL} -p controlplane -f ciliumSysFsBpf cilium_sys_fs_bpf.mount.tmpl
-
-func installCilium(out io.Writer, kubeConfig, podNetworkCIDR, imageRepository, version string, mtu uint, single bool) error {
-	if _, err := os.Stat("/sys/fs/bpf"); err != nil {
-		// Mounting BPF filesystem
-		if err := file.Overwrite(ciliumBpfMountSystemd, ciliumSysFsBpfTemplate()); err != nil {
-			return err
-		}
-		if err := linux.SystemctlEnableAndStart(out, "sys-fs-bpf.mount"); err != nil {
-			return err
-		}
-	}
-
-	// https://raw.githubusercontent.com/cilium/cilium/v1.6/install/kubernetes/quick-install.yaml
-	tmpl, err := template.New("").Parse(ciliumTemplate())
-	if err != nil {
-		return err
+	if mtu == 0 {
+		mtu = 1440
 	}
 
 	type data struct {
-		UseImageRepositoryToK8s bool
-		ImageRepository         string
-		PodCIDR                 string
-		Single                  bool
-		Version                 string
+		IPv4PoolCIDR string
+		IPv6PoolCIDR string
+		MTU          uint
+		CNIConfName  string
 	}
 
 	d := data{
-		ImageRepository: imageRepository,
-		PodCIDR:         podNetworkCIDR,
-		Single:          single,
-		Version:         version,
+		IPv4PoolCIDR: cidrs.IPv4(),
+		IPv6PoolCIDR: cidrs.IPv6(),
+		MTU:          mtu,
+		CNIConfName:  cniConfName,
 	}
 
 	var b bytes.Buffer
-	err = tmpl.Execute(&b, d)
-	if err != nil {
-		return err
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
 	}
 
-	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", "-")
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	cmd.Stdin = strings.NewReader(b.String())
-	_, err = cmd.CombinedOutputAsync()
-	return err
+	return b.String(), nil
 }
 
-//go:generate templify -t ${GOTMPL} -p controlplane -f admissionConfiguration admission_configuration.yaml.tmpl
+// TODO get cilium version from flag
+const ciliumVersion = "v1.11.1"
 
-func writeAdmissionConfiguration(out io.Writer, filename, rateLimitConfigFile string) error {
-	tmpl, err := template.New("admission-config").Parse(admissionConfigurationTemplate())
-	if err != nil {
-		return err
-	}
+//go:generate templify -t ${GOTMPL} -p controlplane -f cilium cilium.yaml.tmpl
+//go:generate templify -t ${GOTMPL} -p controlplane -f ciliumSysFsBpf cilium_sys_fs_bpf.mount.tmpl
 
-	type data struct {
-		RateLimitConfigFile string
+// mountBPFFilesystem mounts the BPF filesystem required by Cilium.
+func mountBPFFilesystem(out io.Writer) error {
+	if _, err := os.Stat("/sys/fs/bpf"); err == nil {
+		return nil
 	}
 
-	d := data{
-		RateLimitConfigFile: rateLimitConfigFile,
+	if err := file.Overwrite(ciliumBpfMountSystemd, ciliumSysFsBpfTemplate()); err != nil {
+		return err
 	}
-
-	return file.WriteTemplate(filename, tmpl, d)
+	return linux.SystemctlEnableAndStart(out, "sys-fs-bpf.mount")
 }
 
-//go:generate templify -t ${GOTMPL} -p controlplane -f kubeProxyConfig kube_proxy_config.yaml.tmpl
-
-func writeKubeProxyConfig(out io.Writer, filename string) error {
-	dir := filepath.Dir(filename)
+// ciliumConfig contains the settings of the Cilium manifest.
+type ciliumConfig struct {
+	PodNetworkCIDR       string
+	ImageRepository      string
+	NodeCIDRMaskSize     uint
+	NodeCIDRMaskSizeIPv6 uint
+	Single               bool
+	APIServerHost        string
+	APIServerPort        string
+	// CustomCNIConf prevents the agents from writing the CNI configuration of the nodes.
+	CustomCNIConf bool
+}
 
-	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
-	err := os.MkdirAll(dir, 0750)
+// ciliumManifest renders the Cilium manifest with a cluster pool for each IP family of the pod network.
+func ciliumManifest(c ciliumConfig) (string, error) {
+	// https://raw.githubusercontent.com/cilium/cilium/v1.6/install/kubernetes/quick-install.yaml
+	tmpl, err := template.New("cilium").Parse(ciliumTemplate())
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return file.Overwrite(filename, kubeProxyConfigTemplate())
-}
+	cidrs, err := network.ParseCIDRs(c.PodNetworkCIDR)
+	if err != nil {
+		return "", err
+	}
 
-//go:generate templify -t ${GOTMPL} -p controlplane -f eventRateLimit event_rate_limit.yaml.tmpl
+	type data struct {
+		UseImageRepositoryToK8s bool
+		ImageRepository         string
+		IPv4PodCIDR             string
+		IPv6PodCIDR             string
+		NodeCIDRMaskSize        uint
+		NodeCIDRMaskSizeIPv6    uint
+		Single                  bool
+		Version                 string
+		APIServerHost           string
+		APIServerPort           string
+		CustomCNIConf           bool
+	}
 
-func writeEventRateLimitConfig(out io.Writer, filename string) error {
-	dir := filepath.Dir(filename)
+	d := data{
+		ImageRepository:      c.ImageRepository,
+		IPv4PodCIDR:          cidrs.IPv4(),
+		IPv6PodCIDR:          cidrs.IPv6(),
+		NodeCIDRMaskSize:     c.NodeCIDRMaskSize,
+		NodeCIDRMaskSizeIPv6: c.NodeCIDRMaskSizeIPv6,
+		Single:               c.Single,
+		Version:              ciliumVersion,
+		APIServerHost:        c.APIServerHost,
+		APIServerPort:        c.APIServerPort,
+		CustomCNIConf:        c.CustomCNIConf,
+	}
 
-	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
-	err := os.MkdirAll(dir, 0750)
-	if err != nil {
-		return err
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
 	}
 
-	return file.Overwrite(filename, eventRateLimitTemplate())
+	return b.String(), nil
 }
 
 func waitForAPIServer(out io.Writer) error {
@@ -1087,69 +1291,25 @@ func taintRemoveNoSchedule(out io.Writer, clusterMode, kubeConfig string) error
 	return err
 }
 
-//go:generate templify -t ${GOTMPL} -p controlplane -f certificateAutoApprover certificate_auto_approver.yaml.tmpl
-
-func writeCertificateAutoApprover(out io.Writer, imageRepository string) error {
-	filename := certificateAutoApprover
-	dir := filepath.Dir(filename)
-
-	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
-	err := os.MkdirAll(dir, 0750)
-	if err != nil {
-		return err
-	}
-
-	tmpl, err := template.New("").Parse(certificateAutoApproverTemplate())
-	if err != nil {
-		return err
-	}
-
-	type data struct {
-		ImageRepository string
-	}
-
-	d := data{
-		ImageRepository: imageRepository,
-	}
-
-	var b bytes.Buffer
-	err = tmpl.Execute(&b, d)
-	if err != nil {
-		return err
-	}
-
-	err = file.Overwrite(filename, b.String())
-	if err != nil {
-		return err
-	}
-
-	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", filename)
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	_, err = cmd.CombinedOutputAsync()
-	return err
-}
+const (
+	podSecurityPolicyAddonName = "pod-security-policy"
+	// podSecurityPolicyVersion is the version of the embedded PodSecurityPolicies.
+	podSecurityPolicyVersion = "v1.0.0"
+)
 
 //go:generate templify -t ${GOTMPL} -p controlplane -f podSecurityPolicy pod_security_policy.yaml.tmpl
 
-func writePodSecurityPolicyConfig(out io.Writer) error {
-	filename := podSecurityPolicyConfig
-	dir := filepath.Dir(filename)
-
-	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
-	err := os.MkdirAll(dir, 0750)
-	if err != nil {
-		return err
-	}
-
-	err = file.Overwrite(filename, podSecurityPolicyTemplate())
-	if err != nil {
-		return err
+// newPodSecurityPolicyAddon returns the default PodSecurityPolicies and their bindings. The policies can not be
+// removed while the admission plugin is enabled, pods without a usable policy are rejected.
+func newPodSecurityPolicyAddon() addons.Addon {
+	return addons.Manifest{
+		AddonName:    podSecurityPolicyAddonName,
+		AddonVersion: podSecurityPolicyVersion,
+		RenderFunc: func([]byte) (string, error) {
+			return podSecurityPolicyTemplate(), nil
+		},
+		Permanent: "pods are rejected without a PodSecurityPolicy while the admission plugin is enabled",
 	}
-
-	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", filename)
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	_, err = cmd.CombinedOutputAsync()
-	return err
 }
 
 func deleteKubeDNSReplicaSet(out io.Writer) error {
@@ -1159,33 +1319,45 @@ func deleteKubeDNSReplicaSet(out io.Writer) error {
 	return err
 }
 
-func writeMasterConfig(out io.Writer, a bool, kubernetesVersion, encryptionSecret string) error {
+func writeMasterConfig(out io.Writer, a bool, kubernetesVersion, encryptionSecret string, encryption kubeadm.EncryptionOptions, audit auditLog, p podSecurity, o oidc, adm admission, kubeProxy kubeadm.KubeProxyOptions) error {
 
 	if a {
-		if err := writeAuditPolicyFile(out); err != nil {
+		if err := writeAuditPolicyFile(out, auditPolicyFile, audit); err != nil {
 			return errors.Wrap(err, "writing audit policy file failed")
 		}
+		if err := writeAuditWebhookConfig(out, auditWebhookConfigFile, audit); err != nil {
+			return errors.Wrap(err, "writing audit webhook config failed")
+		}
 	}
 
-	err := writeAdmissionConfiguration(out, admissionConfig, admissionEventRateLimitConfig)
-	if err != nil {
-		return errors.Wrap(err, "writing admission config failed")
+	var podSecurityConfigFile string
+	// the plugin might be disabled explicitly even if a Pod Security Standard is set
+	if p.enabled() && !contains(adm.disable, admissionPluginPodSecurity) {
+		podSecurityConfigFile = podSecurityConfig
+		if err := writePodSecurityConfig(out, podSecurityConfigFile, kubernetesVersion, p); err != nil {
+			return errors.Wrap(err, "writing pod security config failed")
+		}
 	}
 
-	err = writeEventRateLimitConfig(out, admissionEventRateLimitConfig)
+	err := writeAdmissionConfig(out, adm, podSecurityConfigFile)
 	if err != nil {
-		return errors.Wrap(err, "writing event limit config failed")
+		return err
 	}
 
-	err = writeKubeProxyConfig(out, kubeProxyConfig)
+	err = writeKubeProxyConfig(out, kubeProxyConfig, kubeProxy)
 	if err != nil {
 		return errors.Wrap(err, "writing kube proxy config failed")
 	}
 
-	err = kubeadm.WriteEncryptionProviderConfig(out, kubeadm.EncryptionProviderConfig, kubernetesVersion, encryptionSecret)
+	err = kubeadm.WriteEncryptionProviderConfig(out, kubeadm.EncryptionProviderConfig, kubernetesVersion, encryptionSecret, encryption)
 	if err != nil {
 		return errors.Wrap(err, "writing encryption provider config failed")
 	}
 
+	err = writeOIDCConfig(out, kubernetesVersion, o)
+	if err != nil {
+		return errors.Wrap(err, "writing OIDC config failed")
+	}
+
 	return nil
 }
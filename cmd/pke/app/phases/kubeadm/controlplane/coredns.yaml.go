@@ -0,0 +1,59 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// corednsTemplate is a generated function returning the template as a string.
+func corednsTemplate() string {
+	var tmpl = "apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: coredns\n" +
+		"  namespace: kube-system\n" +
+		"data:\n" +
+		"  Corefile: |\n" +
+		"    .:53 {\n" +
+		"        errors\n" +
+		"        health {\n" +
+		"           lameduck 5s\n" +
+		"        }\n" +
+		"        ready\n" +
+		"        kubernetes {{ .Domain }} in-addr.arpa ip6.arpa {\n" +
+		"           pods insecure\n" +
+		"           fallthrough in-addr.arpa ip6.arpa\n" +
+		"           ttl 30\n" +
+		"        }{{ if .Hosts }}\n" +
+		"        hosts {{ \"{\" }}{{ range .Hosts }}\n" +
+		"           {{ .Address }} {{ .Name }}{{ end }}\n" +
+		"           fallthrough\n" +
+		"        }{{ end }}\n" +
+		"        prometheus :9153\n" +
+		"        forward . {{ .Upstreams }} {\n" +
+		"           max_concurrent 1000\n" +
+		"        }\n" +
+		"        cache 30\n" +
+		"        loop\n" +
+		"        reload\n" +
+		"        loadbalance\n" +
+		"    }{{ range .StubDomains }}\n" +
+		"    {{ .Domain }}:53 {\n" +
+		"        errors\n" +
+		"        cache 30\n" +
+		"        loop\n" +
+		"        reload\n" +
+		"        forward . {{ .Resolvers }}\n" +
+		"    }{{ end }}\n" +
+		""
+	return tmpl
+}
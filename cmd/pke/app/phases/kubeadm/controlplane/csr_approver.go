@@ -0,0 +1,127 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	csrApproverService        = "pke-csr-approver.service"
+	csrApproverServiceSystemd = "/etc/systemd/system/pke-csr-approver.service"
+	// csrApproverExecutable is where pke is installed for the service, os.Executable might point to a temporary copy.
+	csrApproverExecutable = "/usr/local/bin/pke"
+)
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f csrApprover csr_approver.service.tmpl
+
+// installCSRApprover runs `pke csr-approver` as a systemd service on the master. It approves only kubelet serving
+// certificate requests coming from the node itself with the addresses of the Node object.
+func installCSRApprover(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] installing kubelet serving certificate approver\n", use)
+
+	if err := installCSRApproverExecutable(out, csrApproverExecutable); err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("csr-approver").Parse(csrApproverTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		Executable string
+	}
+
+	d := data{
+		Executable: csrApproverExecutable,
+	}
+
+	if err := file.WriteTemplate(csrApproverServiceSystemd, tmpl, d); err != nil {
+		return err
+	}
+	if err := linux.SystemctlReload(out); err != nil {
+		return err
+	}
+	if err := linux.SystemctlEnableAndStart(out, csrApproverService); err != nil {
+		return err
+	}
+
+	active, err := linux.SystemctlActive(out, csrApproverService)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return errors.Errorf("%s is not active, keeping the auto-approver of earlier versions", csrApproverService)
+	}
+
+	// remove the auto-approver deployed by earlier versions, it approves any kubelet serving certificate request
+	cmd := runner.Cmd(out, cmdKubectl, "delete", "deployment,clusterrolebinding,clusterrole,serviceaccount", "-n", "kube-system", "auto-approver", "--ignore-not-found")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err = cmd.CombinedOutputAsync()
+	return err
+}
+
+// installCSRApproverExecutable copies the running pke binary to the given path unless pke is already installed there.
+func installCSRApproverExecutable(out io.Writer, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to stat %s", path)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "unable to find the pke executable")
+	}
+	_, _ = fmt.Fprintf(out, "[%s] copying %s to %s\n", use, exe, path)
+
+	src, err := os.Open(exe)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", exe)
+	}
+	defer func() { _ = src.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// copy to a temporary file first, the service must never start a partially written binary
+	tmp := path + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %s", tmp)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return errors.Wrapf(err, "unable to copy %s to %s", exe, tmp)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,33 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// csrApproverTemplate is a generated function returning the template as a string.
+func csrApproverTemplate() string {
+	var tmpl = "[Unit]\n" +
+		"Description=PKE kubelet serving certificate approver\n" +
+		"Documentation=https://github.com/banzaicloud/pke\n" +
+		"After=kubelet.service\n" +
+		"\n" +
+		"[Service]\n" +
+		"ExecStart={{ .Executable }} csr-approver\n" +
+		"Restart=always\n" +
+		"RestartSec=10\n" +
+		"\n" +
+		"[Install]\n" +
+		"WantedBy=multi-user.target\n" +
+		""
+	return tmpl
+}
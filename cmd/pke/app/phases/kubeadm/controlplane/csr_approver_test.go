@@ -0,0 +1,52 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallCSRApproverExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csr-approver")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	want, err := ioutil.ReadFile(exe)
+	require.NoError(t, err)
+
+	// missing executable is copied from the running binary
+	path := filepath.Join(dir, "bin", "pke")
+	require.NoError(t, installCSRApproverExecutable(ioutil.Discard, path))
+	got, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	// installed executable is kept
+	require.NoError(t, ioutil.WriteFile(path, []byte("installed"), 0755))
+	require.NoError(t, installCSRApproverExecutable(ioutil.Discard, path))
+	got, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "installed", string(got))
+}
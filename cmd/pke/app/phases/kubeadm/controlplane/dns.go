@@ -0,0 +1,416 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	dnsDomain = "cluster.local"
+
+	nodeLocalDNSAddonName = "nodelocaldns"
+	// nodeLocalDNSVersion is the version of the NodeLocal DNSCache image.
+	nodeLocalDNSVersion         = "1.22.13"
+	defaultNodeLocalDNSAddress  = "169.254.20.10"
+	kubeDNSServiceAddressOffset = 10
+)
+
+// dns holds the CoreDNS customizations and the NodeLocal DNSCache settings.
+type dns struct {
+	replicas    int
+	upstreams   []string
+	stubDomains []stubDomain
+	hosts       []dnsHost
+	// nodeLocalAddress is the address of NodeLocal DNSCache, empty if it is not deployed
+	nodeLocalAddress string
+}
+
+// stubDomain is a domain forwarded to dedicated resolvers.
+type stubDomain struct {
+	Domain    string
+	Resolvers []string
+}
+
+// dnsHost is a static host entry.
+type dnsHost struct {
+	Name    string
+	Address string
+}
+
+// customized tells whether the Corefile generated by kubeadm has to be replaced.
+func (d dns) customized() bool {
+	return len(d.upstreams) > 0 || len(d.stubDomains) > 0 || len(d.hosts) > 0
+}
+
+func (c *ControlPlane) dnsParameters(cmd *cobra.Command) (err error) {
+	c.dns.replicas, err = cmd.Flags().GetInt(constants.FlagDNSReplicas)
+	if err != nil {
+		return
+	}
+	if c.dns.replicas < 0 {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be negative", constants.FlagDNSReplicas)
+	}
+
+	c.dns.upstreams, err = cmd.Flags().GetStringSlice(constants.FlagDNSUpstreams)
+	if err != nil {
+		return
+	}
+	for _, u := range c.dns.upstreams {
+		if err := validateResolver(u); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagDNSUpstreams, err)
+		}
+	}
+
+	stubDomains, err := cmd.Flags().GetStringSlice(constants.FlagDNSStubDomains)
+	if err != nil {
+		return
+	}
+	c.dns.stubDomains, err = parseStubDomains(stubDomains)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagDNSStubDomains, err)
+	}
+
+	hosts, err := cmd.Flags().GetStringToString(constants.FlagDNSHosts)
+	if err != nil {
+		return
+	}
+	c.dns.hosts, err = parseDNSHosts(hosts)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagDNSHosts, err)
+	}
+
+	nodeLocal, err := cmd.Flags().GetBool(constants.FlagDNSNodeLocalCache)
+	if err != nil {
+		return
+	}
+	c.dns.nodeLocalAddress = ""
+	if nodeLocal {
+		c.dns.nodeLocalAddress, err = cmd.Flags().GetString(constants.FlagDNSNodeLocalAddress)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// validateDNS checks that NodeLocal DNSCache can intercept DNS traffic and that its address does not collide with cluster networks.
+func (c *ControlPlane) validateDNS() error {
+	if c.dns.nodeLocalAddress == "" {
+		return nil
+	}
+
+	ip := net.ParseIP(c.dns.nodeLocalAddress)
+	if ip == nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: invalid address %q", constants.FlagDNSNodeLocalAddress, c.dns.nodeLocalAddress)
+	}
+	kubeDNS, err := kubeDNSAddress(c.serviceCIDR)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagServiceCIDR, err)
+	}
+	if network.IsIPv4(ip) != network.IsIPv4(kubeDNS) {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s %q and the cluster DNS service address %q have different IP families", constants.FlagDNSNodeLocalAddress, ip, kubeDNS)
+	}
+	for _, n := range c.clusterNetworks() {
+		if n.cidr.Contains(ip) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s %q is part of --%s %q", constants.FlagDNSNodeLocalAddress, ip, n.flag, n.cidr)
+		}
+	}
+	if !c.kubeProxy.Enabled() {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s requires kube-proxy, got --%s=%s", constants.FlagDNSNodeLocalCache, constants.FlagKubeProxyMode, c.kubeProxy.Mode)
+	}
+
+	return nil
+}
+
+// validateResolver checks that a resolver is an IP address, optionally with a port.
+func validateResolver(r string) error {
+	host := r
+	if h, port, err := net.SplitHostPort(r); err == nil {
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return errors.Errorf("invalid port in resolver %q", r)
+		}
+		host = h
+	}
+	if net.ParseIP(host) == nil {
+		return errors.Errorf("resolver %q is not an IP address", r)
+	}
+	return nil
+}
+
+// parseStubDomains parses <domain>=<resolver> pairs, resolvers of the same domain are merged in the given order.
+func parseStubDomains(pairs []string) ([]stubDomain, error) {
+	var domains []stubDomain
+	index := make(map[string]int)
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("%q is not in <domain>=<resolver> format", p)
+		}
+		domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(kv[0])), ".")
+		resolver := strings.TrimSpace(kv[1])
+		if !validDomainName(domain) {
+			return nil, errors.Errorf("invalid domain %q", kv[0])
+		}
+		if domain == dnsDomain || strings.HasSuffix(domain, "."+dnsDomain) {
+			return nil, errors.Errorf("domain %q is served by the cluster", domain)
+		}
+		if err := validateResolver(resolver); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[domain]
+		if !ok {
+			i = len(domains)
+			index[domain] = i
+			domains = append(domains, stubDomain{Domain: domain})
+		}
+		domains[i].Resolvers = append(domains[i].Resolvers, resolver)
+	}
+	return domains, nil
+}
+
+// parseDNSHosts parses <host name>=<address> pairs sorted by host name.
+func parseDNSHosts(m map[string]string) ([]dnsHost, error) {
+	hosts := make([]dnsHost, 0, len(m))
+	for name, address := range m {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !validDomainName(name) {
+			return nil, errors.Errorf("invalid host name %q", name)
+		}
+		ip := net.ParseIP(strings.TrimSpace(address))
+		if ip == nil {
+			return nil, errors.Errorf("host %q: invalid address %q", name, address)
+		}
+		hosts = append(hosts, dnsHost{Name: name, Address: ip.String()})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts, nil
+}
+
+func validDomainName(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// kubeDNSAddress returns the address kubeadm assigns to the kube-dns Service, the tenth address of the primary service network.
+func kubeDNSAddress(serviceCIDR string) (net.IP, error) {
+	_, n, err := net.ParseCIDR(strings.TrimSpace(strings.Split(serviceCIDR, ",")[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := make(net.IP, len(n.IP))
+	copy(ip, n.IP)
+	carry := kubeDNSServiceAddressOffset
+	for i := len(ip) - 1; i >= 0 && carry > 0; i-- {
+		sum := int(ip[i]) + carry
+		ip[i] = byte(sum)
+		carry = sum >> 8
+	}
+	if !n.Contains(ip) {
+		return nil, errors.Errorf("service network %q is too small", n)
+	}
+
+	return ip, nil
+}
+
+// applyDNS replaces the Corefile generated by kubeadm, scales CoreDNS and deploys NodeLocal DNSCache as configured.
+func (c *ControlPlane) applyDNS(out io.Writer) error {
+	if c.dns.customized() {
+		manifest, err := corednsManifest(c.dns)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "[%s] configuring CoreDNS\n", use)
+		if err := kubectlApply(out, manifest); err != nil {
+			return err
+		}
+	}
+
+	if c.dns.replicas > 0 {
+		// kubectl -n kube-system scale deployment coredns --replicas=N
+		cmd := runner.Cmd(out, cmdKubectl, "-n", "kube-system", "scale", "deployment", "coredns", "--replicas="+strconv.Itoa(c.dns.replicas))
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		if _, err := cmd.CombinedOutputAsync(); err != nil {
+			return err
+		}
+	}
+
+	if c.dns.nodeLocalAddress != "" {
+		config := nodeLocalDNSConfig{
+			LocalAddress:    c.dns.nodeLocalAddress,
+			ServiceCIDR:     c.serviceCIDR,
+			ImageRepository: c.k8sImageRepository(),
+			KubeProxyMode:   c.kubeProxy.Mode,
+		}
+		if err := installAddon(out, newNodeLocalDNSAddon(), config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func kubectlApply(out io.Writer, manifest string) error {
+	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", "-")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = strings.NewReader(manifest)
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f coredns coredns.yaml.tmpl
+
+// corednsManifest renders the coredns ConfigMap with the kubeadm default Corefile extended by the customizations.
+func corednsManifest(d dns) (string, error) {
+	tmpl, err := template.New("coredns").Parse(corednsTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type stub struct {
+		Domain    string
+		Resolvers string
+	}
+	type data struct {
+		Domain      string
+		Upstreams   string
+		StubDomains []stub
+		Hosts       []dnsHost
+	}
+
+	td := data{
+		Domain:    dnsDomain,
+		Upstreams: "/etc/resolv.conf",
+		Hosts:     d.hosts,
+	}
+	if len(d.upstreams) > 0 {
+		td.Upstreams = strings.Join(d.upstreams, " ")
+	}
+	for _, s := range d.stubDomains {
+		td.StubDomains = append(td.StubDomains, stub{Domain: s.Domain, Resolvers: strings.Join(s.Resolvers, " ")})
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, td); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// nodeLocalDNSConfig is the configuration of the NodeLocal DNSCache addon.
+type nodeLocalDNSConfig struct {
+	LocalAddress    string `json:"localAddress,omitempty"`
+	ServiceCIDR     string `json:"serviceCIDR"`
+	ImageRepository string `json:"imageRepository,omitempty"`
+	KubeProxyMode   string `json:"kubeProxyMode,omitempty"`
+}
+
+// newNodeLocalDNSAddon returns the NodeLocal DNSCache addon, unset settings default to the ones of kubeadm.
+func newNodeLocalDNSAddon() addons.Addon {
+	return addons.Manifest{
+		AddonName:    nodeLocalDNSAddonName,
+		AddonVersion: nodeLocalDNSVersion,
+		RenderFunc: func(config []byte) (string, error) {
+			c := nodeLocalDNSConfig{
+				LocalAddress:    defaultNodeLocalDNSAddress,
+				ImageRepository: defaultImageRepository,
+				KubeProxyMode:   constants.KubeProxyModeIPTables,
+			}
+			if err := json.Unmarshal(config, &c); err != nil {
+				return "", errors.Wrap(err, "failed to parse NodeLocal DNSCache configuration")
+			}
+			return nodeLocalDNSManifest(c.LocalAddress, c.ServiceCIDR, c.ImageRepository, c.KubeProxyMode)
+		},
+		Workloads: []addons.Workload{{Namespace: "kube-system", Resource: "daemonset/node-local-dns"}},
+	}
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f nodeLocalDNS nodelocaldns.yaml.tmpl
+
+// nodeLocalDNSManifest renders the NodeLocal DNSCache manifest. In IPVS mode the kube-dns Service address is
+// already bound to the kube-ipvs0 interface, so the cache listens on its link-local address only.
+func nodeLocalDNSManifest(localAddress, serviceCIDR, imageRepository, kubeProxyMode string) (string, error) {
+	tmpl, err := template.New("nodelocaldns").Parse(nodeLocalDNSTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	addresses := []string{localAddress}
+	if kubeProxyMode != constants.KubeProxyModeIPVS {
+		kubeDNS, err := kubeDNSAddress(serviceCIDR)
+		if err != nil {
+			return "", err
+		}
+		addresses = append(addresses, kubeDNS.String())
+	}
+
+	type data struct {
+		Domain          string
+		LocalAddress    string
+		BindAddresses   string
+		LocalIPs        string
+		ImageRepository string
+		Version         string
+	}
+
+	d := data{
+		Domain:          dnsDomain,
+		LocalAddress:    localAddress,
+		BindAddresses:   strings.Join(addresses, " "),
+		LocalIPs:        strings.Join(addresses, ","),
+		ImageRepository: imageRepository,
+		Version:         nodeLocalDNSVersion,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
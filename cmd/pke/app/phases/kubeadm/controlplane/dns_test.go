@@ -0,0 +1,166 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+)
+
+func TestParseStubDomains(t *testing.T) {
+	domains, err := parseStubDomains([]string{"corp.example.com=10.0.0.10", "lab.example.com.=10.1.0.10:5353", "Corp.Example.com=10.0.0.11"})
+	require.NoError(t, err)
+	require.Equal(t, []stubDomain{
+		{Domain: "corp.example.com", Resolvers: []string{"10.0.0.10", "10.0.0.11"}},
+		{Domain: "lab.example.com", Resolvers: []string{"10.1.0.10:5353"}},
+	}, domains)
+
+	for _, pairs := range [][]string{
+		{"corp.example.com"},
+		{"corp..example.com=10.0.0.10"},
+		{"corp.example.com=ns.corp.example.com"},
+		{"corp.example.com=10.0.0.10:0"},
+		{"svc.cluster.local=10.0.0.10"},
+	} {
+		_, err := parseStubDomains(pairs)
+		require.Error(t, err, pairs)
+	}
+}
+
+func TestParseDNSHosts(t *testing.T) {
+	hosts, err := parseDNSHosts(map[string]string{"registry.corp.example.com": "10.0.0.20", "git.corp.example.com": "fd00::20"})
+	require.NoError(t, err)
+	require.Equal(t, []dnsHost{
+		{Name: "git.corp.example.com", Address: "fd00::20"},
+		{Name: "registry.corp.example.com", Address: "10.0.0.20"},
+	}, hosts)
+
+	_, err = parseDNSHosts(map[string]string{"registry.corp.example.com": "registry"})
+	require.Error(t, err)
+	_, err = parseDNSHosts(map[string]string{"-registry": "10.0.0.20"})
+	require.Error(t, err)
+}
+
+func TestKubeDNSAddress(t *testing.T) {
+	testCases := []struct {
+		serviceCIDR string
+		address     string
+		err         bool
+	}{
+		{serviceCIDR: "10.10.0.0/16", address: "10.10.0.10"},
+		{serviceCIDR: "10.32.0.248/29", err: true},
+		{serviceCIDR: "10.10.0.0/16,fd00:10:10::/112", address: "10.10.0.10"},
+		{serviceCIDR: "fd00:10:10::/112", address: "fd00:10:10::a"},
+		{serviceCIDR: "10.10.0.0", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.serviceCIDR, func(t *testing.T) {
+			ip, err := kubeDNSAddress(tc.serviceCIDR)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.address, ip.String())
+		})
+	}
+}
+
+func TestValidateDNS(t *testing.T) {
+	testCases := []struct {
+		name          string
+		address       string
+		serviceCIDR   string
+		kubeProxyMode string
+		err           bool
+	}{
+		{name: "disabled", kubeProxyMode: constants.KubeProxyModeNone},
+		{name: "link-local", address: defaultNodeLocalDNSAddress},
+		{name: "ipvs", address: defaultNodeLocalDNSAddress, kubeProxyMode: constants.KubeProxyModeIPVS},
+		{name: "invalid address", address: "nodelocal", err: true},
+		{name: "IP family mismatch", address: "fd00::a", err: true},
+		{name: "in service network", address: "10.10.0.53", err: true},
+		{name: "without kube-proxy", address: defaultNodeLocalDNSAddress, kubeProxyMode: constants.KubeProxyModeNone, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mode := tc.kubeProxyMode
+			if mode == "" {
+				mode = constants.KubeProxyModeIPTables
+			}
+			c := &ControlPlane{
+				serviceCIDR:    "10.10.0.0/16",
+				podNetworkCIDR: "10.20.0.0/16",
+				kubeProxy:      kubeadm.KubeProxyOptions{Mode: mode},
+				dns:            dns{nodeLocalAddress: tc.address},
+			}
+			err := c.validateDNS()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCorednsManifest(t *testing.T) {
+	corefile := func(d dns) string {
+		manifest, err := corednsManifest(d)
+		require.NoError(t, err)
+
+		var cm struct {
+			Data map[string]string `json:"data"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(manifest), &cm))
+		return cm.Data["Corefile"]
+	}
+
+	c := corefile(dns{})
+	require.Contains(t, c, "kubernetes cluster.local in-addr.arpa ip6.arpa {")
+	require.Contains(t, c, "forward . /etc/resolv.conf {")
+	require.NotContains(t, c, "hosts")
+
+	c = corefile(dns{
+		upstreams:   []string{"10.0.0.2", "10.0.0.3:5353"},
+		stubDomains: []stubDomain{{Domain: "corp.example.com", Resolvers: []string{"10.0.0.10", "10.0.0.11"}}},
+		hosts:       []dnsHost{{Name: "registry.corp.example.com", Address: "10.0.0.20"}},
+	})
+	require.Contains(t, c, "forward . 10.0.0.2 10.0.0.3:5353 {")
+	require.Contains(t, c, "    hosts {\n       10.0.0.20 registry.corp.example.com\n       fallthrough\n    }")
+	require.Contains(t, c, "corp.example.com:53 {\n    errors\n    cache 30\n    loop\n    reload\n    forward . 10.0.0.10 10.0.0.11\n}")
+}
+
+func TestNodeLocalDNSManifest(t *testing.T) {
+	manifest, err := nodeLocalDNSManifest(defaultNodeLocalDNSAddress, "10.10.0.0/16", "k8s.gcr.io", constants.KubeProxyModeIPTables)
+	require.NoError(t, err)
+	require.Contains(t, manifest, "bind 169.254.20.10 10.10.0.10\n")
+	require.Contains(t, manifest, `"-localip", "169.254.20.10,10.10.0.10"`)
+	require.Contains(t, manifest, "image: k8s.gcr.io/dns/k8s-dns-node-cache:"+nodeLocalDNSVersion)
+	require.Contains(t, manifest, "health 169.254.20.10:8080")
+
+	manifest, err = nodeLocalDNSManifest(defaultNodeLocalDNSAddress, "10.10.0.0/16", "k8s.gcr.io", constants.KubeProxyModeIPVS)
+	require.NoError(t, err)
+	require.Contains(t, manifest, "bind 169.254.20.10\n")
+	require.Contains(t, manifest, `"-localip", "169.254.20.10"`)
+	require.NotContains(t, manifest, "10.10.0.10")
+}
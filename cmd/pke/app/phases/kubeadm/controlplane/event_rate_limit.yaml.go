@@ -18,14 +18,11 @@ package controlplane
 func eventRateLimitTemplate() string {
 	var tmpl = "kind: Configuration\n" +
 		"apiVersion: eventratelimit.admission.k8s.io/v1alpha1\n" +
-		"limits:\n" +
-		"- type: Namespace\n" +
-		"  qps: 50\n" +
-		"  burst: 100\n" +
-		"  cacheSize: 2000\n" +
-		"- type: User\n" +
-		"  qps: 10\n" +
-		"  burst: 50\n" +
+		"limits:{{ range .Limits }}\n" +
+		"- type: {{ .Type }}\n" +
+		"  qps: {{ .QPS }}\n" +
+		"  burst: {{ .Burst }}{{ if .CacheSize }}\n" +
+		"  cacheSize: {{ .CacheSize }}{{ end }}{{ end }}\n" +
 		""
 	return tmpl
 }
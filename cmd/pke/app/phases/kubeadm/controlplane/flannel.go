@@ -0,0 +1,107 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"text/template"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+const (
+	// flannelVersion is the version of the embedded Flannel manifest.
+	flannelVersion = "v0.17.0"
+	// flannelImageRepository is the repository of the Flannel images without --image-repository.
+	flannelImageRepository = "rancher"
+)
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f flannel flannel.yaml.tmpl
+
+func validateFlannelBackend(backend string) error {
+	switch backend {
+	case constants.FlannelBackendVXLAN,
+		constants.FlannelBackendHostGW:
+		return nil
+	default:
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s: %q, possible values: %s, %s",
+			constants.FlagFlannelBackend,
+			backend,
+			constants.FlannelBackendVXLAN,
+			constants.FlannelBackendHostGW,
+		)
+	}
+}
+
+// flannelInterface returns the interface Flannel should use for inter-node traffic,
+// if the node address was selected by a network interface.
+func (c *ControlPlane) flannelInterface() string {
+	if c.nodeIPSelector.Interface == constants.InfrastructureInterfaceDefaultRoute {
+		return ""
+	}
+	return c.nodeIPSelector.Interface
+}
+
+// flannelManifest renders the Flannel manifest with the network of each IP family of the pod network.
+func flannelManifest(podNetworkCIDR, backend, iface string, mtu uint, imageRepository string) (string, error) {
+	tmpl, err := template.New("flannel").Parse(flannelTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	cidrs, err := network.ParseCIDRs(podNetworkCIDR)
+	if err != nil {
+		return "", err
+	}
+	if cidrs.IPv4() == "" {
+		return "", errors.Errorf("flannel requires an IPv4 pod network, got: %q", podNetworkCIDR)
+	}
+
+	if imageRepository == "" {
+		imageRepository = flannelImageRepository
+	}
+
+	type data struct {
+		IPv4PodCIDR     string
+		IPv6PodCIDR     string
+		Backend         string
+		Interface       string
+		MTU             uint
+		ImageRepository string
+		Version         string
+	}
+
+	d := data{
+		IPv4PodCIDR:     cidrs.IPv4(),
+		IPv6PodCIDR:     cidrs.IPv6(),
+		Backend:         backend,
+		Interface:       iface,
+		MTU:             mtu,
+		ImageRepository: imageRepository,
+		Version:         flannelVersion,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
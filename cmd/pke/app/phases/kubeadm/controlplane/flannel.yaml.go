@@ -0,0 +1,218 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// flannelTemplate is a generated function returning the template as a string.
+func flannelTemplate() string {
+	var tmpl = "# https://raw.githubusercontent.com/flannel-io/flannel/v0.17.0/Documentation/kube-flannel.yml\n" +
+		"---\n" +
+		"kind: ClusterRole\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"metadata:\n" +
+		"  name: flannel\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - pods\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - nodes\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - nodes/status\n" +
+		"  verbs:\n" +
+		"  - patch\n" +
+		"---\n" +
+		"kind: ClusterRoleBinding\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"metadata:\n" +
+		"  name: flannel\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: ClusterRole\n" +
+		"  name: flannel\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: flannel\n" +
+		"  namespace: kube-system\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  name: flannel\n" +
+		"  namespace: kube-system\n" +
+		"---\n" +
+		"kind: ConfigMap\n" +
+		"apiVersion: v1\n" +
+		"metadata:\n" +
+		"  name: kube-flannel-cfg\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    tier: node\n" +
+		"    app: flannel\n" +
+		"data:\n" +
+		"  cni-conf.json: |\n" +
+		"    {\n" +
+		"      \"name\": \"cbr0\",\n" +
+		"      \"cniVersion\": \"0.3.1\",\n" +
+		"      \"plugins\": [\n" +
+		"        {\n" +
+		"          \"type\": \"flannel\",\n" +
+		"          \"delegate\": {\n" +
+		"            \"hairpinMode\": true,\n" +
+		"            \"isDefaultGateway\": true{{ if .MTU }},\n" +
+		"            \"mtu\": {{ .MTU }}{{ end }}\n" +
+		"          }\n" +
+		"        },\n" +
+		"        {\n" +
+		"          \"type\": \"portmap\",\n" +
+		"          \"capabilities\": {\n" +
+		"            \"portMappings\": true\n" +
+		"          }\n" +
+		"        }\n" +
+		"      ]\n" +
+		"    }\n" +
+		"  net-conf.json: |\n" +
+		"    {\n" +
+		"      \"Network\": \"{{ .IPv4PodCIDR }}\",{{ if .IPv6PodCIDR }}\n" +
+		"      \"EnableIPv6\": true,\n" +
+		"      \"IPv6Network\": \"{{ .IPv6PodCIDR }}\",{{ end }}\n" +
+		"      \"Backend\": {\n" +
+		"        \"Type\": \"{{ .Backend }}\"\n" +
+		"      }\n" +
+		"    }\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: DaemonSet\n" +
+		"metadata:\n" +
+		"  name: kube-flannel-ds\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    tier: node\n" +
+		"    app: flannel\n" +
+		"spec:\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      app: flannel\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      labels:\n" +
+		"        tier: node\n" +
+		"        app: flannel\n" +
+		"    spec:\n" +
+		"      affinity:\n" +
+		"        nodeAffinity:\n" +
+		"          requiredDuringSchedulingIgnoredDuringExecution:\n" +
+		"            nodeSelectorTerms:\n" +
+		"            - matchExpressions:\n" +
+		"              - key: kubernetes.io/os\n" +
+		"                operator: In\n" +
+		"                values:\n" +
+		"                - linux\n" +
+		"      hostNetwork: true\n" +
+		"      priorityClassName: system-node-critical\n" +
+		"      tolerations:\n" +
+		"      - operator: Exists\n" +
+		"        effect: NoSchedule\n" +
+		"      serviceAccountName: flannel\n" +
+		"      initContainers:\n" +
+		"      - name: install-cni-plugin\n" +
+		"        image: {{ .ImageRepository }}/mirrored-flannelcni-flannel-cni-plugin:v1.0.1\n" +
+		"        command:\n" +
+		"        - cp\n" +
+		"        args:\n" +
+		"        - -f\n" +
+		"        - /flannel\n" +
+		"        - /opt/cni/bin/flannel\n" +
+		"        volumeMounts:\n" +
+		"        - name: cni-plugin\n" +
+		"          mountPath: /opt/cni/bin\n" +
+		"      - name: install-cni\n" +
+		"        image: {{ .ImageRepository }}/mirrored-flannelcni-flannel:{{ .Version }}\n" +
+		"        command:\n" +
+		"        - cp\n" +
+		"        args:\n" +
+		"        - -f\n" +
+		"        - /etc/kube-flannel/cni-conf.json\n" +
+		"        - /etc/cni/net.d/10-flannel.conflist\n" +
+		"        volumeMounts:\n" +
+		"        - name: cni\n" +
+		"          mountPath: /etc/cni/net.d\n" +
+		"        - name: flannel-cfg\n" +
+		"          mountPath: /etc/kube-flannel/\n" +
+		"      containers:\n" +
+		"      - name: kube-flannel\n" +
+		"        image: {{ .ImageRepository }}/mirrored-flannelcni-flannel:{{ .Version }}\n" +
+		"        command:\n" +
+		"        - /opt/bin/flanneld\n" +
+		"        args:\n" +
+		"        - --ip-masq\n" +
+		"        - --kube-subnet-mgr{{ if .Interface }}\n" +
+		"        - --iface={{ .Interface }}{{ end }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: \"100m\"\n" +
+		"            memory: \"50Mi\"\n" +
+		"          limits:\n" +
+		"            cpu: \"100m\"\n" +
+		"            memory: \"50Mi\"\n" +
+		"        securityContext:\n" +
+		"          privileged: false\n" +
+		"          capabilities:\n" +
+		"            add: [\"NET_ADMIN\", \"NET_RAW\"]\n" +
+		"        env:\n" +
+		"        - name: POD_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.name\n" +
+		"        - name: POD_NAMESPACE\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: metadata.namespace\n" +
+		"        volumeMounts:\n" +
+		"        - name: run\n" +
+		"          mountPath: /run/flannel\n" +
+		"        - name: flannel-cfg\n" +
+		"          mountPath: /etc/kube-flannel/\n" +
+		"        - name: xtables-lock\n" +
+		"          mountPath: /run/xtables.lock\n" +
+		"      volumes:\n" +
+		"      - name: run\n" +
+		"        hostPath:\n" +
+		"          path: /run/flannel\n" +
+		"      - name: cni-plugin\n" +
+		"        hostPath:\n" +
+		"          path: /opt/cni/bin\n" +
+		"      - name: cni\n" +
+		"        hostPath:\n" +
+		"          path: /etc/cni/net.d\n" +
+		"      - name: flannel-cfg\n" +
+		"        configMap:\n" +
+		"          name: kube-flannel-cfg\n" +
+		"      - name: xtables-lock\n" +
+		"        hostPath:\n" +
+		"          path: /run/xtables.lock\n" +
+		"          type: FileOrCreate\n" +
+		""
+	return tmpl
+}
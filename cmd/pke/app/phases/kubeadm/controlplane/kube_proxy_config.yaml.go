@@ -17,7 +17,16 @@ package controlplane
 // kubeProxyConfigTemplate is a generated function returning the template as a string.
 func kubeProxyConfigTemplate() string {
 	var tmpl = "apiVersion: kubeproxy.config.k8s.io/v1alpha1\n" +
-		"kind: KubeProxyConfiguration\n" +
+		"kind: KubeProxyConfiguration{{ if ne .Mode \"none\" }}\n" +
+		"mode: \"{{ .Mode }}\"{{ end }}{{ if eq .Mode \"ipvs\" }}\n" +
+		"ipvs:\n" +
+		"  scheduler: \"{{ .IPVSScheduler }}\"\n" +
+		"  strictARP: {{ .IPVSStrictARP }}{{ end }}{{ if or .ConntrackMaxPerCore .ConntrackMin .ConntrackTCPEstablishedTimeout .ConntrackTCPCloseWaitTimeout }}\n" +
+		"conntrack:{{ with .ConntrackMaxPerCore }}\n" +
+		"  maxPerCore: {{ . }}{{ end }}{{ with .ConntrackMin }}\n" +
+		"  min: {{ . }}{{ end }}{{ with .ConntrackTCPEstablishedTimeout }}\n" +
+		"  tcpEstablishedTimeout: {{ .String }}{{ end }}{{ with .ConntrackTCPCloseWaitTimeout }}\n" +
+		"  tcpCloseWaitTimeout: {{ .String }}{{ end }}{{ end }}\n" +
 		""
 	return tmpl
 }
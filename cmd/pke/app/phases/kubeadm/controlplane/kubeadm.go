@@ -18,8 +18,6 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"os"
-	"path/filepath"
 	"strings"
 	"text/template"
 
@@ -30,6 +28,7 @@ import (
 	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/kubernetes"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/podsecurity"
 	"github.com/pbnjay/memory"
 )
 
@@ -37,6 +36,22 @@ import (
 //go:generate templify -t ${GOTMPL} -p controlplane -f kubeadmConfigV1Beta3 kubeadm_v1beta3.yaml.tmpl
 
 func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
+	// Node addresses of a dual-stack or IPv6 cluster, or the explicitly selected node address
+	nodeIP, err := kubeadm.NodeIPs(c.serviceCIDR, c.nodeIPs, c.advertiseAddress)
+	if err != nil {
+		return err
+	}
+
+	nodeCIDRMaskSize, nodeCIDRMaskSizeIPv4, nodeCIDRMaskSizeIPv6 := c.nodeCIDRMaskSizeArgs()
+
+	// kube-proxy addon
+	var kubeProxyConfig string
+	if c.kubeProxy.Enabled() {
+		if kubeProxyConfig, err = kubeProxyConfiguration(c.kubeProxy); err != nil {
+			return err
+		}
+	}
+
 	// API server advertisement
 	bindPort := "6443"
 	if c.advertiseAddress != "" {
@@ -105,6 +120,29 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		kubeReservedMemory = kubeadm.KubeReservedMemory(memory.TotalMemory())
 	)
 
+	// KMS plugin socket
+	var encryptionKMSSocketDir string
+	if c.encryption.Provider == constants.EncryptionProviderKMS {
+		encryptionKMSSocketDir = kubeadm.KMSSocketDir(c.encryption.KMSEndpoint)
+	}
+
+	// Audit webhook backend
+	var auditWebhookConfig string
+	if c.auditLog.webhookConfigFile != "" {
+		auditWebhookConfig = auditWebhookConfigFile
+	}
+
+	// Admission chain
+	admissionPlugins, disabledAdmissionPlugins := c.admission.plugins(c.withPluginPSP, c.podSecurity.enabled())
+
+	// OIDC authentication
+	var oidcCA, authenticationConfigFile string
+	if c.oidc.structured(c.kubernetesVersion) {
+		authenticationConfigFile = authenticationConfig
+	} else if c.oidc.caFile != "" {
+		oidcCA = oidcCAFile
+	}
+
 	// Node labels
 	nodeLabels := c.labels
 	if c.nodepool != "" {
@@ -115,6 +153,7 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		APIServerAdvertiseAddress   string
 		APIServerBindPort           string
 		CRISocket                   string
+		CgroupDriver                string
 		ControlPlaneEndpoint        string
 		APIServerCertSANs           []string
 		KubeletCertificateAuthority string
@@ -127,16 +166,37 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		CloudConfig                 bool
 		KubeletCloudConfig          bool
 		NodeLabels                  string
+		NodeIP                      string
+		DualStackFeatureGate        bool
+		NodeCIDRMaskSize            string
+		NodeCIDRMaskSizeIPv4        string
+		NodeCIDRMaskSizeIPv6        string
 		ControllerManagerSigningCA  string
 		OIDCIssuerURL               string
 		OIDCClientID                string
+		OIDCUsernameClaim           string
+		OIDCUsernamePrefix          string
+		OIDCGroupsClaim             string
+		OIDCGroupsPrefix            string
+		OIDCCAFile                  string
+		OIDCRequiredClaims          string
+		OIDCSigningAlgs             string
+		AuthenticationConfig        string
 		ImageRepository             string
 		EncryptionProviderPrefix    string
-		WithPluginPSP               bool
+		EncryptionKMSSocketDir      string
+		AdmissionPlugins            string
+		DisabledAdmissionPlugins    string
+		PodSecurityFeatureGate      bool
 		WithAuditLog                bool
 		Taints                      []kubernetes.Taint
 		AuditLogDir                 string
 		AuditPolicyFile             string
+		AuditLogMaxAge              int
+		AuditLogMaxBackup           int
+		AuditLogMaxSize             int
+		AuditWebhookConfigFile      string
+		AuditWebhookMode            string
 		EtcdEndpoints               []string
 		EtcdCAFile                  string
 		EtcdCertFile                string
@@ -144,19 +204,17 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		EtcdPrefix                  string
 		KubeReservedCPU             string
 		KubeReservedMemory          string
+		KubeProxyConfig             string
+		ClusterDNS                  string
 	}
 
-	imageRepository := "k8s.gcr.io"
-	if c.useImageRepositoryToK8s {
-		if c.imageRepository != "" {
-			imageRepository = c.imageRepository
-		}
-	}
+	imageRepository := c.k8sImageRepository()
 
 	d := data{
 		APIServerAdvertiseAddress:   c.advertiseAddress,
 		APIServerBindPort:           bindPort,
 		CRISocket:                   cri.GetCRISocket(c.containerRuntime),
+		CgroupDriver:                c.cgroupDriver,
 		ControlPlaneEndpoint:        c.apiServerHostPort,
 		APIServerCertSANs:           c.apiServerCertSANs,
 		KubeletCertificateAuthority: c.kubeletCertificateAuthority,
@@ -169,16 +227,37 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		CloudConfig:                 cloudConfig,
 		KubeletCloudConfig:          kubeletCloudConfig,
 		NodeLabels:                  strings.Join(nodeLabels, ","),
+		NodeIP:                      nodeIP,
+		DualStackFeatureGate:        kubeadm.DualStackFeatureGate(c.kubernetesVersion, c.podNetworkCIDR),
+		NodeCIDRMaskSize:            nodeCIDRMaskSize,
+		NodeCIDRMaskSizeIPv4:        nodeCIDRMaskSizeIPv4,
+		NodeCIDRMaskSizeIPv6:        nodeCIDRMaskSizeIPv6,
 		ControllerManagerSigningCA:  c.controllerManagerSigningCA,
-		OIDCIssuerURL:               c.oidcIssuerURL,
-		OIDCClientID:                c.oidcClientID,
+		OIDCIssuerURL:               c.oidc.issuerURL,
+		OIDCClientID:                c.oidc.clientID,
+		OIDCUsernameClaim:           c.oidc.usernameClaim,
+		OIDCUsernamePrefix:          c.oidc.usernamePrefix,
+		OIDCGroupsClaim:             c.oidc.groupsClaim,
+		OIDCGroupsPrefix:            c.oidc.groupsPrefix,
+		OIDCCAFile:                  oidcCA,
+		OIDCRequiredClaims:          c.oidc.requiredClaimsArg(),
+		OIDCSigningAlgs:             strings.Join(c.oidc.signingAlgs, ","),
+		AuthenticationConfig:        authenticationConfigFile,
 		ImageRepository:             imageRepository,
 		EncryptionProviderPrefix:    encryptionProviderPrefix,
-		WithPluginPSP:               c.withPluginPSP,
+		EncryptionKMSSocketDir:      encryptionKMSSocketDir,
+		AdmissionPlugins:            strings.Join(admissionPlugins, ","),
+		DisabledAdmissionPlugins:    strings.Join(disabledAdmissionPlugins, ","),
+		PodSecurityFeatureGate:      c.podSecurity.enabled() && podsecurity.FeatureGateRequired(c.kubernetesVersion),
 		WithAuditLog:                !c.withoutAuditLog,
 		Taints:                      taints,
 		AuditLogDir:                 auditLogDir,
 		AuditPolicyFile:             auditPolicyFile,
+		AuditLogMaxAge:              c.auditLog.maxAge,
+		AuditLogMaxBackup:           c.auditLog.maxBackup,
+		AuditLogMaxSize:             c.auditLog.maxSize,
+		AuditWebhookConfigFile:      auditWebhookConfig,
+		AuditWebhookMode:            c.auditLog.webhookMode,
 		EtcdEndpoints:               c.etcdEndpoints,
 		EtcdCAFile:                  c.etcdCAFile,
 		EtcdCertFile:                c.etcdCertFile,
@@ -186,26 +265,20 @@ func (c ControlPlane) WriteKubeadmConfig(out io.Writer, filename string) error {
 		EtcdPrefix:                  c.etcdPrefix,
 		KubeReservedCPU:             kubeReservedCPU,
 		KubeReservedMemory:          kubeReservedMemory,
+		KubeProxyConfig:             kubeProxyConfig,
+		ClusterDNS:                  c.dns.nodeLocalAddress,
 	}
 
 	return file.WriteTemplate(filename, tmpl, d)
 }
 
-//go:generate templify -t ${GOTMPL} -p controlplane -f auditV1Beta1 audit_v1beta1.yaml.tmpl
-
-func writeAuditPolicyFile(out io.Writer) error {
-	filename := auditPolicyFile
-	dir := filepath.Dir(filename)
+// defaultImageRepository is the repository of the Kubernetes images used by kubeadm by default.
+const defaultImageRepository = "k8s.gcr.io"
 
-	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
-	err := os.MkdirAll(dir, 0750)
-	if err != nil {
-		return err
-	}
-
-	err = file.Overwrite(filename, auditV1Beta1Template())
-	if err != nil {
-		return err
+// k8sImageRepository returns the repository of the Kubernetes images.
+func (c ControlPlane) k8sImageRepository() string {
+	if c.useImageRepositoryToK8s && c.imageRepository != "" {
+		return c.imageRepository
 	}
-	return nil
+	return defaultImageRepository
 }
@@ -30,6 +30,7 @@ func kubeadmConfigV1Beta3Template() string {
 		"      effect: \"{{.Effect}}\"{{end}}\n" +
 		"  kubeletExtraArgs:\n" +
 		"    {{ if .NodeLabels }}node-labels: \"{{ .NodeLabels }}\"{{end}}\n" +
+		"    {{ if .NodeIP }}node-ip: \"{{ .NodeIP }}\"{{end}}\n" +
 		"    # pod-infra-container-image: {{ .ImageRepository }}/pause:3.1 # only needed by docker\n" +
 		"    {{ if .CloudProvider }}cloud-provider: \"{{ .CloudProvider }}\"\n" +
 		"    {{ if .KubeletCloudConfig }}cloud-config: \"/etc/kubernetes/{{ .CloudProvider }}.conf\"{{end}}{{end}}\n" +
@@ -63,25 +64,32 @@ func kubeadmConfigV1Beta3Template() string {
 		"  extraArgs:\n" +
 		"    # anonymous-auth: \"false\"\n" +
 		"    profiling: \"false\"\n" +
-		"    enable-admission-plugins: \"AlwaysPullImages,EventRateLimit,NodeRestriction,ServiceAccount{{ if .WithPluginPSP }},PodSecurityPolicy{{end}}\"\n" +
-		"    disable-admission-plugins: \"\"\n" +
+		"    enable-admission-plugins: \"{{ .AdmissionPlugins }}\"\n" +
+		"    disable-admission-plugins: \"{{ .DisabledAdmissionPlugins }}\"\n" +
 		"    admission-control-config-file: \"{{ .AdmissionConfig }}\"\n" +
 		"    audit-log-path: \"{{ .AuditLogDir }}/apiserver.log\"\n" +
-		"    audit-log-maxage: \"30\"\n" +
-		"    audit-log-maxbackup: \"10\"\n" +
-		"    audit-log-maxsize: \"100\"\n" +
+		"    audit-log-maxage: \"{{ .AuditLogMaxAge }}\"\n" +
+		"    audit-log-maxbackup: \"{{ .AuditLogMaxBackup }}\"\n" +
+		"    audit-log-maxsize: \"{{ .AuditLogMaxSize }}\"\n" +
 		"    {{ if .WithAuditLog }}audit-policy-file: \"{{ .AuditPolicyFile }}\"{{ end }}\n" +
+		"    {{ if and .WithAuditLog .AuditWebhookConfigFile }}audit-webhook-config-file: \"{{ .AuditWebhookConfigFile }}\"\n" +
+		"    audit-webhook-mode: \"{{ .AuditWebhookMode }}\"{{ end }}\n" +
+		"    {{ if .PodSecurityFeatureGate }}feature-gates: \"PodSecurity=true\"{{end}}\n" +
 		"    {{ if .EtcdPrefix }}etcd-prefix: \"{{ .EtcdPrefix }}\"{{end}}\n" +
 		"    service-account-lookup: \"true\"\n" +
 		"    kubelet-certificate-authority: \"{{ .KubeletCertificateAuthority }}\"\n" +
 		"    tls-cipher-suites: \"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_128_GCM_SHA256\"\n" +
 		"    {{ .EncryptionProviderPrefix }}encryption-provider-config: \"/etc/kubernetes/admission-control/encryption-provider-config.yaml\"\n" +
-		"    {{ if (and .OIDCIssuerURL .OIDCClientID) }}\n" +
+		"    {{ if .AuthenticationConfig }}authentication-config: \"{{ .AuthenticationConfig }}\"{{ else if (and .OIDCIssuerURL .OIDCClientID) }}\n" +
 		"    oidc-issuer-url: \"{{ .OIDCIssuerURL }}\"\n" +
 		"    oidc-client-id: \"{{ .OIDCClientID }}\"\n" +
-		"    oidc-username-claim: \"email\"\n" +
-		"    oidc-username-prefix: \"oidc:\"\n" +
-		"    oidc-groups-claim: \"groups\"{{end}}\n" +
+		"    oidc-username-claim: \"{{ .OIDCUsernameClaim }}\"\n" +
+		"    oidc-username-prefix: \"{{ .OIDCUsernamePrefix }}\"\n" +
+		"    {{ if .OIDCGroupsClaim }}oidc-groups-claim: \"{{ .OIDCGroupsClaim }}\"{{end}}\n" +
+		"    {{ if .OIDCGroupsPrefix }}oidc-groups-prefix: \"{{ .OIDCGroupsPrefix }}\"{{end}}\n" +
+		"    {{ if .OIDCCAFile }}oidc-ca-file: \"{{ .OIDCCAFile }}\"{{end}}\n" +
+		"    {{ if .OIDCRequiredClaims }}oidc-required-claim: \"{{ .OIDCRequiredClaims }}\"{{end}}\n" +
+		"    {{ if .OIDCSigningAlgs }}oidc-signing-algs: \"{{ .OIDCSigningAlgs }}\"{{end}}{{end}}\n" +
 		"    {{ if .CloudProvider }}cloud-provider: \"{{ .CloudProvider }}\"\n" +
 		"    {{ if .CloudConfig }}cloud-config: /etc/kubernetes/{{ .CloudProvider }}.conf{{end}}{{end}}\n" +
 		"  extraVolumes:\n" +
@@ -94,7 +102,12 @@ func kubeadmConfigV1Beta3Template() string {
 		"      hostPath: {{ .AuditPolicyFile }}\n" +
 		"      mountPath: {{ .AuditPolicyFile }}\n" +
 		"      readOnly: true\n" +
-		"      pathType: File{{ end }}\n" +
+		"      pathType: File{{ if .AuditWebhookConfigFile }}\n" +
+		"    - name: audit-webhook-config-file\n" +
+		"      hostPath: {{ .AuditWebhookConfigFile }}\n" +
+		"      mountPath: {{ .AuditWebhookConfigFile }}\n" +
+		"      readOnly: true\n" +
+		"      pathType: File{{ end }}{{ end }}\n" +
 		"    - name: admission-control-config-file\n" +
 		"      hostPath: {{ .AdmissionConfig }}\n" +
 		"      mountPath: {{ .AdmissionConfig }}\n" +
@@ -105,6 +118,11 @@ func kubeadmConfigV1Beta3Template() string {
 		"      mountPath: /etc/kubernetes/admission-control/\n" +
 		"      readOnly: true\n" +
 		"      pathType: Directory\n" +
+		"    {{ if .EncryptionKMSSocketDir }}\n" +
+		"    - name: kms-plugin-socket-dir\n" +
+		"      hostPath: {{ .EncryptionKMSSocketDir }}\n" +
+		"      mountPath: {{ .EncryptionKMSSocketDir }}\n" +
+		"      pathType: DirectoryOrCreate{{end}}\n" +
 		"    {{ if and .CloudProvider .CloudConfig }}\n" +
 		"    - name: cloud-config\n" +
 		"      hostPath: /etc/kubernetes/{{ .CloudProvider }}.conf\n" +
@@ -116,7 +134,10 @@ func kubeadmConfigV1Beta3Template() string {
 		"  extraArgs:\n" +
 		"    cluster-name: \"{{ .ClusterName }}\"\n" +
 		"    profiling: \"false\"\n" +
-		"    terminated-pod-gc-threshold: \"10\"\n" +
+		"    terminated-pod-gc-threshold: \"10\"{{ if .NodeCIDRMaskSize }}\n" +
+		"    node-cidr-mask-size: \"{{ .NodeCIDRMaskSize }}\"{{ end }}{{ if .NodeCIDRMaskSizeIPv4 }}\n" +
+		"    node-cidr-mask-size-ipv4: \"{{ .NodeCIDRMaskSizeIPv4 }}\"\n" +
+		"    node-cidr-mask-size-ipv6: \"{{ .NodeCIDRMaskSizeIPv6 }}\"{{ end }}\n" +
 		"    feature-gates: \"RotateKubeletServerCertificate=true\"\n" +
 		"    {{ if .ControllerManagerSigningCA }}cluster-signing-cert-file: {{ .ControllerManagerSigningCA }}{{end}}\n" +
 		"    {{ if .CloudProvider }}cloud-provider: \"{{ .CloudProvider }}\"\n" +
@@ -142,6 +163,7 @@ func kubeadmConfigV1Beta3Template() string {
 		"---\n" +
 		"apiVersion: kubelet.config.k8s.io/v1beta1\n" +
 		"kind: KubeletConfiguration\n" +
+		"cgroupDriver: {{ .CgroupDriver }}\n" +
 		"serverTLSBootstrap: true\n" +
 		"systemReserved:\n" +
 		"  cpu: 50m\n" +
@@ -156,7 +178,11 @@ func kubeadmConfigV1Beta3Template() string {
 		"  memory.available: 100Mi\n" +
 		"  nodefs.available: 10%\n" +
 		"  nodefs.inodesFree: 5%\n" +
-		"protectKernelDefaults: true\n" +
+		"protectKernelDefaults: true{{ if .ClusterDNS }}\n" +
+		"clusterDNS:\n" +
+		"  - \"{{ .ClusterDNS }}\"{{ end }}{{ if .KubeProxyConfig }}\n" +
+		"---\n" +
+		"{{ .KubeProxyConfig }}{{ end }}\n" +
 		""
 	return tmpl
 }
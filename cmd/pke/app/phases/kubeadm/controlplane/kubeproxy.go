@@ -0,0 +1,112 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+// validateKubeProxy validates the kube-proxy options, without kube-proxy Cilium has to replace it.
+func (c *ControlPlane) validateKubeProxy() error {
+	if err := c.kubeProxy.Validate(c.kubernetesVersion); err != nil {
+		return err
+	}
+	if c.kubeProxy.Enabled() {
+		return nil
+	}
+
+	if c.networkProvider != constants.NetworkProviderCilium {
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s=%s requires --%s=%s",
+			constants.FlagKubeProxyMode,
+			constants.KubeProxyModeNone,
+			constants.FlagNetworkProvider,
+			constants.NetworkProviderCilium,
+		)
+	}
+	if c.apiServerHostPort == "" && c.advertiseAddress == "" {
+		// Cilium cannot reach the API server through the kubernetes service without kube-proxy
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s=%s requires --%s or --%s",
+			constants.FlagKubeProxyMode,
+			constants.KubeProxyModeNone,
+			constants.FlagAPIServerHostPort,
+			constants.FlagAdvertiseAddress,
+		)
+	}
+
+	return nil
+}
+
+// kubeProxyReplacementEndpoint returns the API server address Cilium uses when it replaces kube-proxy.
+func (c *ControlPlane) kubeProxyReplacementEndpoint() (host, port string, err error) {
+	if c.kubeProxy.Enabled() {
+		return "", "", nil
+	}
+	endpoint := c.apiServerHostPort
+	if endpoint == "" {
+		endpoint = c.advertiseAddress
+	}
+
+	return kubeadm.SplitHostPort(endpoint, "6443")
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f kubeProxyConfig kube_proxy_config.yaml.tmpl
+
+// kubeProxyConfiguration renders the KubeProxyConfiguration passed to kubeadm.
+func kubeProxyConfiguration(o kubeadm.KubeProxyOptions) (string, error) {
+	tmpl, err := template.New("kube-proxy-config").Parse(kubeProxyConfigTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, o); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+func writeKubeProxyConfig(out io.Writer, filename string, o kubeadm.KubeProxyOptions) error {
+	dir := filepath.Dir(filename)
+
+	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+
+	config, err := kubeProxyConfiguration(o)
+	if err != nil {
+		return err
+	}
+
+	return file.Overwrite(filename, config+"\n")
+}
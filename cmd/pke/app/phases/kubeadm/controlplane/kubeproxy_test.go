@@ -0,0 +1,110 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+)
+
+func TestValidateKubeProxy(t *testing.T) {
+	testCases := []struct {
+		name              string
+		kubeProxy         kubeadm.KubeProxyOptions
+		networkProvider   string
+		apiServerHostPort string
+		err               bool
+	}{
+		{name: "iptables", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeIPTables}},
+		{name: "ipvs", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeIPVS, IPVSScheduler: "wrr"}},
+		{name: "unsupported IPVS scheduler", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeIPVS, IPVSScheduler: "lblcr"}, err: true},
+		{name: "nftables", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeNFTables}, err: true},
+		{name: "unknown mode", kubeProxy: kubeadm.KubeProxyOptions{Mode: "userspace"}, err: true},
+		{name: "negative conntrack", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeIPTables, ConntrackMin: int32Ptr(-1)}, err: true},
+		{name: "none with cilium", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeNone}, networkProvider: constants.NetworkProviderCilium, apiServerHostPort: "192.168.64.11:6443"},
+		{name: "none with calico", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeNone}, networkProvider: constants.NetworkProviderCalico, apiServerHostPort: "192.168.64.11:6443", err: true},
+		{name: "none without API server", kubeProxy: kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeNone}, networkProvider: constants.NetworkProviderCilium, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ControlPlane{
+				kubernetesVersion: "1.23.4",
+				kubeProxy:         tc.kubeProxy,
+				networkProvider:   tc.networkProvider,
+				apiServerHostPort: tc.apiServerHostPort,
+			}
+			err := c.validateKubeProxy()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKubeProxyConfiguration(t *testing.T) {
+	type config struct {
+		Mode string `json:"mode"`
+		IPVS *struct {
+			Scheduler string `json:"scheduler"`
+			StrictARP bool   `json:"strictARP"`
+		} `json:"ipvs"`
+		Conntrack *struct {
+			MaxPerCore            *int32 `json:"maxPerCore"`
+			Min                   *int32 `json:"min"`
+			TCPEstablishedTimeout string `json:"tcpEstablishedTimeout"`
+			TCPCloseWaitTimeout   string `json:"tcpCloseWaitTimeout"`
+		} `json:"conntrack"`
+	}
+
+	s, err := kubeProxyConfiguration(kubeadm.KubeProxyOptions{Mode: constants.KubeProxyModeIPTables, IPVSScheduler: "rr"})
+	require.NoError(t, err)
+	var c config
+	require.NoError(t, yaml.Unmarshal([]byte(s), &c))
+	require.Equal(t, "iptables", c.Mode)
+	require.Nil(t, c.IPVS)
+	require.Nil(t, c.Conntrack)
+
+	established := 2 * time.Hour
+	s, err = kubeProxyConfiguration(kubeadm.KubeProxyOptions{
+		Mode:                           constants.KubeProxyModeIPVS,
+		IPVSScheduler:                  "sh",
+		IPVSStrictARP:                  true,
+		ConntrackMaxPerCore:            int32Ptr(0),
+		ConntrackTCPEstablishedTimeout: &established,
+	})
+	require.NoError(t, err)
+	c = config{}
+	require.NoError(t, yaml.Unmarshal([]byte(s), &c))
+	require.Equal(t, "ipvs", c.Mode)
+	require.Equal(t, "sh", c.IPVS.Scheduler)
+	require.True(t, c.IPVS.StrictARP)
+	require.Equal(t, int32(0), *c.Conntrack.MaxPerCore)
+	require.Nil(t, c.Conntrack.Min)
+	require.Equal(t, "2h0m0s", c.Conntrack.TCPEstablishedTimeout)
+	require.Empty(t, c.Conntrack.TCPCloseWaitTimeout)
+}
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
@@ -16,57 +16,15 @@ package controlplane
 
 import (
 	"io"
-	"os"
-	"text/template"
 
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
-	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
-	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
 )
 
-const (
-	metalLbConfig   = "/etc/kubernetes/metallb-config.yaml"
-	metalLbManifest = "https://raw.githubusercontent.com/google/metallb/v0.8.1/manifests/metallb.yaml"
-)
-
-func applyLbRange(out io.Writer, lbRange, cloudProvider string) error {
-	if (cloudProvider != "" && cloudProvider != constants.CloudProviderVsphere) || lbRange == "" {
+func applyLoadBalancer(out io.Writer, lb metallb.Config, cloudProvider string) error {
+	if (cloudProvider != "" && cloudProvider != constants.CloudProviderVsphere) || lb.Empty() {
 		return nil
 	}
 
-	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", metalLbManifest)
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	_, err := cmd.CombinedOutputAsync()
-	if err != nil {
-		return err
-	}
-
-	err = writeLbRangeConfig(out, metalLbConfig, lbRange)
-	if err != nil {
-		return err
-	}
-
-	cmd = runner.Cmd(out, cmdKubectl, "apply", "-f", metalLbConfig)
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	_, err = cmd.CombinedOutputAsync()
-	return err
-}
-
-//go:generate templify -t ${GOTMPL} -p controlplane -f lbRangeConfig lb_range_config.yaml.tmpl
-
-func writeLbRangeConfig(out io.Writer, filename, lbRange string) error {
-	tmpl, err := template.New("metallb-config").Parse(lbRangeConfigTemplate())
-	if err != nil {
-		return err
-	}
-
-	type data struct {
-		Range string
-	}
-
-	d := data{
-		Range: lbRange,
-	}
-
-	return file.WriteTemplate(filename, tmpl, d)
+	return installAddon(out, metallb.NewAddon(), lb)
 }
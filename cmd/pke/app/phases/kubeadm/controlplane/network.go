@@ -0,0 +1,254 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+// maxNodeCIDRMaskDiff is the largest difference between the pod network and the node CIDR prefix lengths
+// the node IPAM controller of kube-controller-manager accepts.
+const maxNodeCIDRMaskDiff = 16
+
+// namedNetwork is a network given by a command line flag.
+type namedNetwork struct {
+	flag string
+	cidr *net.IPNet
+}
+
+// validateNetwork validates the service and pod CIDRs of a single-stack or dual-stack cluster,
+// the size of the pod network and that the service, pod, node and load balancer ranges do not overlap.
+func (c *ControlPlane) validateNetwork() error {
+	services, err := network.ParseCIDRs(c.serviceCIDR)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagServiceCIDR, err)
+	}
+	pods, err := network.ParseCIDRs(c.podNetworkCIDR)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagPodNetworkCIDR, err)
+	}
+	if !services.SameFamilies(pods) {
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s and --%s must have the same IP families in the same order, got: %q and %q",
+			constants.FlagServiceCIDR,
+			constants.FlagPodNetworkCIDR,
+			c.serviceCIDR,
+			c.podNetworkCIDR,
+		)
+	}
+	if pods.IPv4() == "" && c.networkProvider == constants.NetworkProviderFlannel {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %s requires an IPv4 pod network", constants.FlagNetworkProvider, c.networkProvider)
+	}
+	c.serviceCIDR = services.String()
+	c.podNetworkCIDR = pods.String()
+
+	for _, n := range pods {
+		if err := c.validateNodeCIDRs(n); err != nil {
+			return err
+		}
+	}
+
+	networks := c.clusterNetworks()
+	clusterNetworks := len(networks)
+	// the infrastructure CIDR is only used when the node address is not selected explicitly
+	if c.nodeIPSelector.CIDR != "" && !c.nodeIPSelector.Explicit() {
+		_, n, err := net.ParseCIDR(c.nodeIPSelector.CIDR)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagInfrastructureCIDR, err)
+		}
+		networks = append(networks, namedNetwork{flag: constants.FlagInfrastructureCIDR, cidr: n})
+	}
+	for i := range networks {
+		for j := i + 1; j < len(networks); j++ {
+			a, b := networks[i], networks[j]
+			if a.flag != b.flag && network.Overlaps(a.cidr, b.cidr) {
+				return errors.Wrapf(constants.ErrInvalidInput, "--%s %q overlaps --%s %q", a.flag, a.cidr, b.flag, b.cidr)
+			}
+		}
+	}
+
+	var advertiseAddress net.IP
+	if c.advertiseAddress != "" {
+		host, _, err := kubeadm.SplitHostPort(c.advertiseAddress, "6443")
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAdvertiseAddress, err)
+		}
+		// the advertise address may be a host name
+		advertiseAddress = net.ParseIP(host)
+	}
+	for _, n := range networks[:clusterNetworks] {
+		if advertiseAddress != nil && n.cidr.Contains(advertiseAddress) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s %q contains --%s %q", n.flag, n.cidr, constants.FlagAdvertiseAddress, advertiseAddress)
+		}
+	}
+
+	if err := c.loadLoadBalancerConfig(); err != nil {
+		return err
+	}
+	ranges, err := c.loadBalancer.Ranges()
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", c.lbFlag(), err)
+	}
+	for _, r := range ranges {
+		// The ranges are usually part of the node network as MetalLB announces them via ARP,
+		// but they must not contain the address of the node itself.
+		for _, n := range networks[:clusterNetworks] {
+			if r.Overlaps(n.cidr) {
+				return errors.Wrapf(constants.ErrInvalidInput, "--%s %q overlaps --%s %q", c.lbFlag(), r, n.flag, n.cidr)
+			}
+		}
+		if advertiseAddress != nil && r.Contains(advertiseAddress) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s %q contains --%s %q", c.lbFlag(), r, constants.FlagAdvertiseAddress, advertiseAddress)
+		}
+	}
+
+	return nil
+}
+
+// clusterNetworks returns the service and pod networks of the cluster.
+func (c *ControlPlane) clusterNetworks() []namedNetwork {
+	var networks []namedNetwork
+	services, _ := network.ParseCIDRs(c.serviceCIDR)
+	for _, n := range services {
+		networks = append(networks, namedNetwork{flag: constants.FlagServiceCIDR, cidr: n})
+	}
+	pods, _ := network.ParseCIDRs(c.podNetworkCIDR)
+	for _, n := range pods {
+		networks = append(networks, namedNetwork{flag: constants.FlagPodNetworkCIDR, cidr: n})
+	}
+
+	return networks
+}
+
+// validateNodeCIDRs checks that the pod network can be divided into node CIDRs for the expected number of nodes.
+func (c *ControlPlane) validateNodeCIDRs(pods *net.IPNet) error {
+	flag, maskSize := constants.FlagNodeCIDRMaskSize, c.nodeCIDRMaskSize
+	if network.IsIPv6(pods.IP) {
+		flag, maskSize = constants.FlagNodeCIDRMaskSizeIPv6, c.nodeCIDRMaskSizeIPv6
+	}
+
+	subnets, err := network.Subnets(pods, int(maskSize))
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", flag, err)
+	}
+	if ones, _ := pods.Mask.Size(); int(maskSize)-ones > maxNodeCIDRMaskDiff {
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s: /%d node CIDRs are too small for the %q pod network, use at most /%d",
+			flag,
+			maskSize,
+			pods,
+			ones+maxNodeCIDRMaskDiff,
+		)
+	}
+	if c.expectedNodeCount > 0 && uint(subnets) < c.expectedNodeCount {
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s: pod network %q has room for %d nodes with /%d node CIDRs, but %d nodes are expected",
+			constants.FlagPodNetworkCIDR,
+			pods,
+			subnets,
+			maskSize,
+			c.expectedNodeCount,
+		)
+	}
+
+	return nil
+}
+
+// nodeCIDRMaskSizeArgs returns the node CIDR mask size of a single-stack cluster,
+// or the IPv4 and IPv6 mask sizes of a dual-stack cluster for kube-controller-manager.
+func (c *ControlPlane) nodeCIDRMaskSizeArgs() (maskSize, ipv4MaskSize, ipv6MaskSize string) {
+	pods, err := network.ParseCIDRs(c.podNetworkCIDR)
+	if err != nil {
+		return
+	}
+	switch {
+	case pods.DualStack():
+		return "", strconv.FormatUint(uint64(c.nodeCIDRMaskSize), 10), strconv.FormatUint(uint64(c.nodeCIDRMaskSizeIPv6), 10)
+	case pods.IPv4Only():
+		return strconv.FormatUint(uint64(c.nodeCIDRMaskSize), 10), "", ""
+	default:
+		return strconv.FormatUint(uint64(c.nodeCIDRMaskSizeIPv6), 10), "", ""
+	}
+}
+
+// warnRouteOverlaps warns about host routes covering the service, pod or load balancer ranges,
+// as traffic to these addresses may leave the cluster instead of reaching services and pods.
+func (c *ControlPlane) warnRouteOverlaps(out io.Writer, routes []network.Route) {
+	for _, n := range c.clusterNetworks() {
+		for _, r := range routes {
+			if network.Overlaps(n.cidr, r.Destination) {
+				_, _ = fmt.Fprintf(out, "[%s] warning: host route %q overlaps --%s %q\n", use, r, n.flag, n.cidr)
+			}
+		}
+	}
+
+	ranges, err := c.loadBalancer.Ranges()
+	if err != nil {
+		return
+	}
+	for _, lbRange := range ranges {
+		for _, r := range routes {
+			// routes of the node network cover the range when it is announced via ARP
+			if lbRange.Overlaps(r.Destination) && !(r.Destination.Contains(lbRange.First) && r.Destination.Contains(lbRange.Last)) {
+				_, _ = fmt.Fprintf(out, "[%s] warning: host route %q overlaps --%s %q\n", use, r, c.lbFlag(), lbRange)
+			}
+		}
+	}
+}
+
+// loadLoadBalancerConfig builds the MetalLB configuration from either --lb-range or --lb-config.
+func (c *ControlPlane) loadLoadBalancerConfig() error {
+	switch {
+	case c.lbRange != "" && c.lbConfig != "":
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s and --%s are mutually exclusive", constants.FlagLbRange, constants.FlagLbConfig)
+	case c.lbConfig != "":
+		lb, err := metallb.LoadConfig(c.lbConfig)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagLbConfig, err)
+		}
+		c.loadBalancer = lb
+	case c.lbRange != "":
+		c.loadBalancer = metallb.RangeConfig(c.lbRange)
+	default:
+		c.loadBalancer = metallb.Config{}
+		return nil
+	}
+
+	if err := c.loadBalancer.Validate(); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", c.lbFlag(), err)
+	}
+	return nil
+}
+
+// lbFlag returns the flag the load balancer configuration was given with.
+func (c *ControlPlane) lbFlag() string {
+	if c.lbConfig != "" {
+		return constants.FlagLbConfig
+	}
+	return constants.FlagLbRange
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"text/template"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	networkPolicyAddonName = "network-policy-baseline"
+	networkPolicyVersion   = "v1.0.0"
+
+	// the CRD of Cilium cluster-wide policies is registered by the operator after it starts
+	networkPolicyCRDRetries       = 60
+	networkPolicyCRDRetryInterval = 5 * time.Second
+)
+
+var namespaceRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// networkPolicy holds the cluster-wide network policy profile applied at bootstrap.
+type networkPolicy struct {
+	profile          string
+	exemptNamespaces []string
+}
+
+// validateNetworkPolicy checks the profile and that the network provider enforces the cluster-wide policies it consists of.
+func (c *ControlPlane) validateNetworkPolicy() error {
+	switch c.networkPolicy.profile {
+	case "":
+		return nil
+	case constants.NetworkPolicyProfileBaseline:
+	default:
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %q, possible values: %s", constants.FlagNetworkPolicyProfile, c.networkPolicy.profile, constants.NetworkPolicyProfileBaseline)
+	}
+
+	switch c.networkProvider {
+	case constants.NetworkProviderCalico, constants.NetworkProviderCilium:
+	default:
+		return errors.Wrapf(
+			constants.ErrInvalidInput,
+			"--%s=%s requires --%s=%s or %s",
+			constants.FlagNetworkPolicyProfile,
+			c.networkPolicy.profile,
+			constants.FlagNetworkProvider,
+			constants.NetworkProviderCalico,
+			constants.NetworkProviderCilium,
+		)
+	}
+
+	for _, ns := range c.networkPolicy.exemptNamespaces {
+		if !namespaceRegexp.MatchString(ns) || len(ns) > 63 {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: invalid namespace %q", constants.FlagNetworkPolicyExemptNamespaces, ns)
+		}
+	}
+
+	return nil
+}
+
+// networkPolicyConfig is the configuration of the network policy addon.
+type networkPolicyConfig struct {
+	NetworkProvider  string   `json:"networkProvider"`
+	ExemptNamespaces []string `json:"exemptNamespaces,omitempty"`
+}
+
+// networkPolicyAddon consists of the default-deny ingress, cluster DNS and kube-system policies of the baseline profile.
+// The policies are cluster-wide resources of the network provider, so they cover namespaces created later as well.
+type networkPolicyAddon struct {
+	addons.Manifest
+}
+
+func newNetworkPolicyAddon() addons.Addon {
+	return networkPolicyAddon{
+		Manifest: addons.Manifest{
+			AddonName:    networkPolicyAddonName,
+			AddonVersion: networkPolicyVersion,
+			RenderFunc: func(config []byte) (string, error) {
+				var c networkPolicyConfig
+				if err := json.Unmarshal(config, &c); err != nil {
+					return "", errors.Wrap(err, "failed to parse network policy configuration")
+				}
+				return networkPolicyManifest(c.NetworkProvider, c.ExemptNamespaces)
+			},
+		},
+	}
+}
+
+// Apply waits for the policy CRD of the network provider before applying the policies.
+func (a networkPolicyAddon) Apply(out io.Writer, kubeConfig string, config []byte) error {
+	var c networkPolicyConfig
+	if err := json.Unmarshal(config, &c); err != nil {
+		return errors.Wrap(err, "failed to parse network policy configuration")
+	}
+
+	var crd string
+	switch c.NetworkProvider {
+	case constants.NetworkProviderCalico:
+		crd = "globalnetworkpolicies.crd.projectcalico.org"
+	case constants.NetworkProviderCilium:
+		crd = "ciliumclusterwidenetworkpolicies.cilium.io"
+	default:
+		return errors.Errorf("network provider %q does not support cluster-wide network policies", c.NetworkProvider)
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] waiting for %s\n", use, crd)
+	for i := 0; ; i++ {
+		// kubectl get crd <crd>
+		cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "crd", crd)
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		if _, err := cmd.CombinedOutput(); err == nil {
+			break
+		}
+		if i == networkPolicyCRDRetries {
+			return errors.Errorf("custom resource definition %s is not registered", crd)
+		}
+		time.Sleep(networkPolicyCRDRetryInterval)
+	}
+
+	return a.Manifest.Apply(out, kubeConfig, config)
+}
+
+func (a networkPolicyAddon) Upgrade(out io.Writer, kubeConfig string, config []byte, from string) error {
+	return a.Apply(out, kubeConfig, config)
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f networkPolicyCalico network_policy_calico.yaml.tmpl
+//go:generate templify -t ${GOTMPL} -p controlplane -f networkPolicyCilium network_policy_cilium.yaml.tmpl
+
+// networkPolicyManifest renders the baseline policies for the network provider, kube-system is always exempted
+// from the default-deny policy as it is protected by a dedicated one.
+func networkPolicyManifest(networkProvider string, exemptNamespaces []string) (string, error) {
+	namespaces := []string{"kube-system"}
+	for _, ns := range exemptNamespaces {
+		if !contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	var source string
+	switch networkProvider {
+	case constants.NetworkProviderCalico:
+		source = networkPolicyCalicoTemplate()
+	case constants.NetworkProviderCilium:
+		source = networkPolicyCiliumTemplate()
+	default:
+		return "", errors.Errorf("network provider %q does not support cluster-wide network policies", networkProvider)
+	}
+	tmpl, err := template.New("network-policy").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	type data struct {
+		ExemptNamespaces []string
+	}
+
+	d := data{
+		ExemptNamespaces: namespaces,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,60 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// networkPolicyCalicoTemplate is a generated function returning the template as a string.
+func networkPolicyCalicoTemplate() string {
+	var tmpl = "apiVersion: crd.projectcalico.org/v1\n" +
+		"kind: GlobalNetworkPolicy\n" +
+		"metadata:\n" +
+		"  name: pke-kube-system-ingress\n" +
+		"spec:\n" +
+		"  order: 100\n" +
+		"  selector: projectcalico.org/namespace == 'kube-system'\n" +
+		"  types:\n" +
+		"  - Ingress\n" +
+		"  ingress:\n" +
+		"  # cluster DNS is reachable from every namespace\n" +
+		"  - action: Allow\n" +
+		"    protocol: UDP\n" +
+		"    destination:\n" +
+		"      selector: k8s-app == 'kube-dns'\n" +
+		"      ports:\n" +
+		"      - 53\n" +
+		"  - action: Allow\n" +
+		"    protocol: TCP\n" +
+		"    destination:\n" +
+		"      selector: k8s-app == 'kube-dns'\n" +
+		"      ports:\n" +
+		"      - 53\n" +
+		"  # pods of other namespaces can not reach kube-system\n" +
+		"  - action: Deny\n" +
+		"    source:\n" +
+		"      selector: has(projectcalico.org/namespace) && projectcalico.org/namespace != 'kube-system'\n" +
+		"  - action: Allow\n" +
+		"---\n" +
+		"apiVersion: crd.projectcalico.org/v1\n" +
+		"kind: GlobalNetworkPolicy\n" +
+		"metadata:\n" +
+		"  name: pke-default-deny-ingress\n" +
+		"spec:\n" +
+		"  # evaluated after Kubernetes NetworkPolicies (order 1000), which allow traffic in the namespaces\n" +
+		"  order: 2000\n" +
+		"  selector: has(projectcalico.org/namespace) && projectcalico.org/namespace not in { {{ range $i, $ns := .ExemptNamespaces }}{{ if $i }}, {{ end }}'{{ $ns }}'{{ end }} }\n" +
+		"  types:\n" +
+		"  - Ingress\n" +
+		""
+	return tmpl
+}
@@ -0,0 +1,74 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// networkPolicyCiliumTemplate is a generated function returning the template as a string.
+func networkPolicyCiliumTemplate() string {
+	var tmpl = "apiVersion: cilium.io/v2\n" +
+		"kind: CiliumClusterwideNetworkPolicy\n" +
+		"metadata:\n" +
+		"  name: pke-kube-system-ingress\n" +
+		"spec:\n" +
+		"  endpointSelector:\n" +
+		"    matchLabels:\n" +
+		"      k8s:io.kubernetes.pod.namespace: kube-system\n" +
+		"  ingress:\n" +
+		"  # pods of other namespaces can not reach kube-system\n" +
+		"  - fromEndpoints:\n" +
+		"    - matchLabels:\n" +
+		"        k8s:io.kubernetes.pod.namespace: kube-system\n" +
+		"  - fromEntities:\n" +
+		"    - host\n" +
+		"    - remote-node\n" +
+		"    - health\n" +
+		"    - world\n" +
+		"---\n" +
+		"apiVersion: cilium.io/v2\n" +
+		"kind: CiliumClusterwideNetworkPolicy\n" +
+		"metadata:\n" +
+		"  name: pke-cluster-dns-ingress\n" +
+		"spec:\n" +
+		"  # cluster DNS is reachable from every namespace\n" +
+		"  endpointSelector:\n" +
+		"    matchLabels:\n" +
+		"      k8s:io.kubernetes.pod.namespace: kube-system\n" +
+		"      k8s:k8s-app: kube-dns\n" +
+		"  ingress:\n" +
+		"  - fromEntities:\n" +
+		"    - cluster\n" +
+		"    toPorts:\n" +
+		"    - ports:\n" +
+		"      - port: \"53\"\n" +
+		"        protocol: UDP\n" +
+		"      - port: \"53\"\n" +
+		"        protocol: TCP\n" +
+		"---\n" +
+		"apiVersion: cilium.io/v2\n" +
+		"kind: CiliumClusterwideNetworkPolicy\n" +
+		"metadata:\n" +
+		"  name: pke-default-deny-ingress\n" +
+		"spec:\n" +
+		"  # an empty ingress rule selects no peers, Kubernetes NetworkPolicies allow traffic in the namespaces\n" +
+		"  endpointSelector:\n" +
+		"    matchExpressions:\n" +
+		"    - key: k8s:io.kubernetes.pod.namespace\n" +
+		"      operator: NotIn\n" +
+		"      values:{{ range .ExemptNamespaces }}\n" +
+		"      - {{ . }}{{ end }}\n" +
+		"  ingress:\n" +
+		"  - {}\n" +
+		""
+	return tmpl
+}
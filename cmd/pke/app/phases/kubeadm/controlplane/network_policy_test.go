@@ -0,0 +1,102 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+func TestValidateNetworkPolicy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		profile          string
+		networkProvider  string
+		exemptNamespaces []string
+		err              bool
+	}{
+		{name: "disabled", networkProvider: constants.NetworkProviderWeave},
+		{name: "calico", profile: constants.NetworkPolicyProfileBaseline, networkProvider: constants.NetworkProviderCalico, exemptNamespaces: []string{"metallb-system"}},
+		{name: "cilium", profile: constants.NetworkPolicyProfileBaseline, networkProvider: constants.NetworkProviderCilium},
+		{name: "flannel", profile: constants.NetworkPolicyProfileBaseline, networkProvider: constants.NetworkProviderFlannel, err: true},
+		{name: "unknown profile", profile: "strict", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "invalid namespace", profile: constants.NetworkPolicyProfileBaseline, networkProvider: constants.NetworkProviderCalico, exemptNamespaces: []string{"MetalLB"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ControlPlane{
+				networkProvider: tc.networkProvider,
+				networkPolicy:   networkPolicy{profile: tc.profile, exemptNamespaces: tc.exemptNamespaces},
+			}
+			err := c.validateNetworkPolicy()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNetworkPolicyManifest(t *testing.T) {
+	type policy struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec map[string]interface{} `json:"spec"`
+	}
+	parse := func(manifest string) map[string]policy {
+		policies := make(map[string]policy)
+		for _, doc := range strings.Split(manifest, "---\n") {
+			var p policy
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &p), doc)
+			policies[p.Metadata.Name] = p
+		}
+		return policies
+	}
+
+	manifest, err := networkPolicyManifest(constants.NetworkProviderCalico, []string{"metallb-system", "kube-system"})
+	require.NoError(t, err)
+	policies := parse(manifest)
+	require.Len(t, policies, 2)
+	require.Equal(t, "GlobalNetworkPolicy", policies["pke-default-deny-ingress"].Kind)
+	require.Equal(t, "has(projectcalico.org/namespace) && projectcalico.org/namespace not in { 'kube-system', 'metallb-system' }", policies["pke-default-deny-ingress"].Spec["selector"])
+	require.NotContains(t, policies["pke-default-deny-ingress"].Spec, "ingress")
+	require.Len(t, policies["pke-kube-system-ingress"].Spec["ingress"], 4)
+
+	manifest, err = networkPolicyManifest(constants.NetworkProviderCilium, nil)
+	require.NoError(t, err)
+	policies = parse(manifest)
+	require.Len(t, policies, 3)
+	require.Equal(t, "CiliumClusterwideNetworkPolicy", policies["pke-cluster-dns-ingress"].Kind)
+	require.Equal(t, map[string]interface{}{
+		"matchExpressions": []interface{}{map[string]interface{}{
+			"key":      "k8s:io.kubernetes.pod.namespace",
+			"operator": "NotIn",
+			"values":   []interface{}{"kube-system"},
+		}},
+	}, policies["pke-default-deny-ingress"].Spec["endpointSelector"])
+	require.Equal(t, []interface{}{map[string]interface{}{}}, policies["pke-default-deny-ingress"].Spec["ingress"])
+
+	_, err = networkPolicyManifest(constants.NetworkProviderFlannel, nil)
+	require.Error(t, err)
+}
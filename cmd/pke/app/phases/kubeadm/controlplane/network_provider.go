@@ -0,0 +1,85 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+const (
+	calicoCNIConf = "10-calico.conflist"
+
+	// PendingCNIConfSuffix is appended to the name of inactive CNI configuration files.
+	// Container runtimes only load files with .conf, .conflist and .json extensions.
+	PendingCNIConfSuffix = ".pending"
+)
+
+// NetworkOptions contains the settings of the network provider manifests.
+type NetworkOptions struct {
+	PodNetworkCIDR       string `json:"podNetworkCIDR"`
+	ServiceCIDR          string `json:"serviceCIDR"`
+	ImageRepository      string `json:"imageRepository,omitempty"`
+	MTU                  uint   `json:"mtu,omitempty"`
+	NodeCIDRMaskSize     uint   `json:"nodeCIDRMaskSize,omitempty"`
+	NodeCIDRMaskSizeIPv6 uint   `json:"nodeCIDRMaskSizeIPv6,omitempty"`
+	Single               bool   `json:"single,omitempty"`
+	FlannelBackend       string `json:"flannelBackend,omitempty"`
+	FlannelInterface     string `json:"flannelInterface,omitempty"`
+	// APIServerHost and APIServerPort are used by the kube-proxy replacement of Cilium.
+	APIServerHost string `json:"apiServerHost,omitempty"`
+	APIServerPort string `json:"apiServerPort,omitempty"`
+	// PendingCNIConf keeps the CNI configuration of the provider inactive, so it can run alongside
+	// another provider until the configuration of the nodes is switched one by one.
+	PendingCNIConf bool `json:"pendingCNIConf,omitempty"`
+}
+
+// NetworkProviderManifest renders the manifest of an embedded network provider.
+func NetworkProviderManifest(provider string, o NetworkOptions) (string, error) {
+	if o.PendingCNIConf && provider != constants.NetworkProviderCalico && provider != constants.NetworkProviderCilium {
+		return "", errors.Errorf("network provider %s does not support pending CNI configuration", provider)
+	}
+
+	switch provider {
+	case constants.NetworkProviderCalico:
+		cniConfName := calicoCNIConf
+		if o.PendingCNIConf {
+			cniConfName += PendingCNIConfSuffix
+		}
+		return calicoManifest(o.PodNetworkCIDR, o.MTU, cniConfName)
+	case constants.NetworkProviderCilium:
+		return ciliumManifest(ciliumConfig{
+			PodNetworkCIDR:       o.PodNetworkCIDR,
+			ImageRepository:      o.ImageRepository,
+			NodeCIDRMaskSize:     o.NodeCIDRMaskSize,
+			NodeCIDRMaskSizeIPv6: o.NodeCIDRMaskSizeIPv6,
+			Single:               o.Single,
+			APIServerHost:        o.APIServerHost,
+			APIServerPort:        o.APIServerPort,
+			CustomCNIConf:        o.PendingCNIConf,
+		})
+	case constants.NetworkProviderFlannel:
+		backend := o.FlannelBackend
+		if backend == "" {
+			backend = constants.FlannelBackendVXLAN
+		}
+		return flannelManifest(o.PodNetworkCIDR, backend, o.FlannelInterface, o.MTU, o.ImageRepository)
+	case constants.NetworkProviderAntrea:
+		return antreaManifest(o.ServiceCIDR, o.MTU, o.ImageRepository)
+	default:
+		return "", errors.Wrapf(constants.ErrUnsupportedNetworkProvider, "network provider: %s", provider)
+	}
+}
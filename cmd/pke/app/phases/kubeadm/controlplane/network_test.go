@@ -0,0 +1,250 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	testCases := []struct {
+		name            string
+		serviceCIDR     string
+		podNetworkCIDR  string
+		networkProvider string
+		cidr            string
+		advertise       string
+		lbRange         string
+		nodeCount       uint
+		maskSize        uint
+		err             bool
+	}{
+		{name: "IPv4", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", networkProvider: constants.NetworkProviderCalico},
+		{name: "IPv6", serviceCIDR: "fd00:10:10::/112", podNetworkCIDR: "fd00:10:20::/56", networkProvider: constants.NetworkProviderCalico},
+		{name: "dual-stack", serviceCIDR: "10.10.0.0/16,fd00:10:10::/112", podNetworkCIDR: "10.20.0.0/16,fd00:10:20::/56", networkProvider: constants.NetworkProviderCilium},
+		{name: "different primary families", serviceCIDR: "fd00:10:10::/112,10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16,fd00:10:20::/56", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "single-stack services", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16,fd00:10:20::/56", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "IPv6 with flannel", serviceCIDR: "fd00:10:10::/112", podNetworkCIDR: "fd00:10:20::/56", networkProvider: constants.NetworkProviderFlannel, err: true},
+		{name: "dual-stack with flannel", serviceCIDR: "10.10.0.0/16,fd00:10:10::/112", podNetworkCIDR: "10.20.0.0/16,fd00:10:20::/56", networkProvider: constants.NetworkProviderFlannel},
+		{name: "invalid service CIDR", serviceCIDR: "10.10.0.0", podNetworkCIDR: "10.20.0.0/16", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "service and pod overlap", serviceCIDR: "10.20.0.0/24", podNetworkCIDR: "10.20.0.0/16", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "pod and node overlap", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "192.168.0.0/16", cidr: "192.168.64.0/20", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "node network", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", cidr: "192.168.64.0/20", advertise: "192.168.64.11:6443", networkProvider: constants.NetworkProviderCalico},
+		{name: "advertise address in pod network", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", advertise: "10.20.0.11", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "LB range in node network", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", cidr: "192.168.64.0/20", advertise: "192.168.64.11", lbRange: "192.168.64.100-192.168.64.110", networkProvider: constants.NetworkProviderCalico},
+		{name: "LB range and service overlap", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", lbRange: "10.10.0.100-10.10.0.110", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "LB range contains advertise address", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", advertise: "192.168.64.105", lbRange: "192.168.64.100-192.168.64.110", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "invalid LB range", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", lbRange: "192.168.64.100", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "multiple LB ranges", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", advertise: "192.168.64.11", lbRange: "192.168.64.100-192.168.64.110,192.168.65.0/28", networkProvider: constants.NetworkProviderCalico},
+		{name: "overlapping LB ranges", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", lbRange: "192.168.64.100-192.168.64.110,192.168.64.96/28", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "second LB range and pod network overlap", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", lbRange: "192.168.64.100-192.168.64.110,10.20.0.0/28", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "enough nodes", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", nodeCount: 256, networkProvider: constants.NetworkProviderCalico},
+		{name: "too many nodes", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/16", nodeCount: 257, networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "pod network smaller than node CIDR", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.20.0.0/25", networkProvider: constants.NetworkProviderCalico, err: true},
+		{name: "node CIDR too small", serviceCIDR: "10.10.0.0/16", podNetworkCIDR: "10.0.0.0/8", maskSize: 25, networkProvider: constants.NetworkProviderCalico, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ControlPlane{
+				serviceCIDR:          tc.serviceCIDR,
+				podNetworkCIDR:       tc.podNetworkCIDR,
+				networkProvider:      tc.networkProvider,
+				nodeIPSelector:       network.NodeIPSelector{CIDR: tc.cidr},
+				advertiseAddress:     tc.advertise,
+				lbRange:              tc.lbRange,
+				expectedNodeCount:    tc.nodeCount,
+				nodeCIDRMaskSize:     24,
+				nodeCIDRMaskSizeIPv6: 64,
+			}
+			if tc.maskSize != 0 {
+				c.nodeCIDRMaskSize = tc.maskSize
+			}
+			err := c.validateNetwork()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNodeCIDRMaskSizeArgs(t *testing.T) {
+	c := &ControlPlane{podNetworkCIDR: "10.20.0.0/16", nodeCIDRMaskSize: 24, nodeCIDRMaskSizeIPv6: 64}
+	maskSize, ipv4, ipv6 := c.nodeCIDRMaskSizeArgs()
+	require.Equal(t, []string{"24", "", ""}, []string{maskSize, ipv4, ipv6})
+
+	c.podNetworkCIDR = "fd00:10:20::/56"
+	maskSize, ipv4, ipv6 = c.nodeCIDRMaskSizeArgs()
+	require.Equal(t, []string{"64", "", ""}, []string{maskSize, ipv4, ipv6})
+
+	c.podNetworkCIDR = "10.20.0.0/16,fd00:10:20::/56"
+	maskSize, ipv4, ipv6 = c.nodeCIDRMaskSizeArgs()
+	require.Equal(t, []string{"", "24", "64"}, []string{maskSize, ipv4, ipv6})
+}
+
+func TestWarnRouteOverlaps(t *testing.T) {
+	route := func(cidr, iface string) network.Route {
+		_, n, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		return network.Route{Interface: iface, Destination: n}
+	}
+	c := &ControlPlane{
+		serviceCIDR:    "10.10.0.0/16",
+		podNetworkCIDR: "10.20.0.0/16",
+		loadBalancer:   metallb.RangeConfig("192.168.64.100-192.168.64.110"),
+	}
+
+	var out bytes.Buffer
+	c.warnRouteOverlaps(&out, []network.Route{route("192.168.64.0/20", "eth0"), route("172.17.0.0/16", "docker0")})
+	require.Empty(t, out.String())
+
+	c.warnRouteOverlaps(&out, []network.Route{route("10.0.0.0/8", "vpn0"), route("192.168.64.104/29", "eth1")})
+	require.Equal(t, 3, strings.Count(out.String(), "warning"))
+	require.Contains(t, out.String(), `host route "10.0.0.0/8 dev vpn0" overlaps --kubernetes-service-cidr "10.10.0.0/16"`)
+	require.Contains(t, out.String(), `host route "10.0.0.0/8 dev vpn0" overlaps --kubernetes-pod-network-cidr "10.20.0.0/16"`)
+	require.Contains(t, out.String(), `host route "192.168.64.104/29 dev eth1" overlaps --lb-range`)
+}
+
+func TestCalicoManifest(t *testing.T) {
+	// env collects the name/value pairs of the container environment variables
+	env := func(manifest string) map[string]string {
+		vars := make(map[string]string)
+		lines := strings.Split(manifest, "\n")
+		for i := 0; i < len(lines)-1; i++ {
+			name := strings.TrimSpace(lines[i])
+			value := strings.TrimSpace(lines[i+1])
+			if !strings.HasPrefix(name, "- name: ") || !strings.HasPrefix(value, "value: ") {
+				continue
+			}
+			vars[strings.TrimPrefix(name, "- name: ")] = strings.Trim(strings.TrimPrefix(value, "value: "), `"`)
+		}
+		return vars
+	}
+
+	manifest, err := calicoManifest("10.20.0.0/16", 0, calicoCNIConf)
+	require.NoError(t, err)
+	vars := env(manifest)
+	require.Equal(t, "10.20.0.0/16", vars["CALICO_IPV4POOL_CIDR"])
+	require.Equal(t, "autodetect", vars["IP"])
+	require.Equal(t, "false", vars["FELIX_IPV6SUPPORT"])
+	require.NotContains(t, vars, "CALICO_IPV6POOL_CIDR")
+	require.NotContains(t, vars, "NO_DEFAULT_POOLS")
+	require.NotContains(t, manifest, "name: default-ipv6-ippool")
+	require.Contains(t, manifest, `veth_mtu: "1440"`)
+
+	manifest, err = calicoManifest("10.20.0.0/16,fd00:10:20::/56", 1400, calicoCNIConf)
+	require.NoError(t, err)
+	vars = env(manifest)
+	require.Equal(t, "10.20.0.0/16", vars["CALICO_IPV4POOL_CIDR"])
+	require.Equal(t, "fd00:10:20::/56", vars["CALICO_IPV6POOL_CIDR"])
+	require.Equal(t, "autodetect", vars["IP6"])
+	require.Equal(t, "true", vars["FELIX_IPV6SUPPORT"])
+	require.Contains(t, manifest, `"assign_ipv6": "true"`)
+	require.Contains(t, manifest, `veth_mtu: "1400"`)
+
+	manifest, err = calicoManifest("fd00:10:20::/56", 0, calicoCNIConf)
+	require.NoError(t, err)
+	vars = env(manifest)
+	require.Equal(t, "none", vars["IP"])
+	require.Equal(t, "hash", vars["CALICO_ROUTER_ID"])
+	require.Contains(t, manifest, `"assign_ipv4": "false"`)
+	require.Equal(t, "true", vars["NO_DEFAULT_POOLS"])
+	require.NotContains(t, vars, "CALICO_IPV4POOL_CIDR")
+	require.NotContains(t, vars, "CALICO_IPV6POOL_CIDR")
+	require.NotContains(t, manifest, "192.168.0.0/16")
+	require.Contains(t, manifest, "name: default-ipv6-ippool")
+	require.Contains(t, manifest, "cidr: fd00:10:20::/56")
+}
+
+func TestFlannelManifest(t *testing.T) {
+	manifest, err := flannelManifest("10.20.0.0/16", constants.FlannelBackendVXLAN, "", 0, "")
+	require.NoError(t, err)
+	require.Contains(t, manifest, `"Network": "10.20.0.0/16",`)
+	require.Contains(t, manifest, `"Type": "vxlan"`)
+	require.Contains(t, manifest, "rancher/mirrored-flannelcni-flannel:"+flannelVersion)
+	require.NotContains(t, manifest, "EnableIPv6")
+	require.NotContains(t, manifest, `"mtu"`)
+	require.NotContains(t, manifest, "--iface")
+
+	manifest, err = flannelManifest("10.20.0.0/16,fd00:10:20::/56", constants.FlannelBackendHostGW, "eth1", 1400, "registry.example.com/mirror")
+	require.NoError(t, err)
+	require.Contains(t, manifest, `"IPv6Network": "fd00:10:20::/56",`)
+	require.Contains(t, manifest, `"Type": "host-gw"`)
+	require.Contains(t, manifest, `"mtu": 1400`)
+	require.Contains(t, manifest, "- --iface=eth1")
+	require.Contains(t, manifest, "registry.example.com/mirror/mirrored-flannelcni-flannel:"+flannelVersion)
+	require.NotContains(t, manifest, "rancher/")
+
+	_, err = flannelManifest("fd00:10:20::/56", constants.FlannelBackendVXLAN, "", 0, "")
+	require.Error(t, err)
+}
+
+func TestValidateFlannelBackend(t *testing.T) {
+	require.NoError(t, validateFlannelBackend(constants.FlannelBackendVXLAN))
+	require.NoError(t, validateFlannelBackend(constants.FlannelBackendHostGW))
+	require.Error(t, validateFlannelBackend("udp"))
+}
+
+func TestAntreaManifest(t *testing.T) {
+	manifest, err := antreaManifest("10.10.0.0/16", 0, "")
+	require.NoError(t, err)
+	require.Contains(t, manifest, "    defaultMTU: 0\n    serviceCIDR: 10.10.0.0/16\n    hostProcPathPrefix")
+	require.Contains(t, manifest, "projects.registry.vmware.com/antrea/antrea-ubuntu:"+antreaVersion)
+
+	manifest, err = antreaManifest("10.10.0.0/16,fd00:10:10::/112", 1400, "registry.example.com/mirror")
+	require.NoError(t, err)
+	require.Contains(t, manifest, "    defaultMTU: 1400\n    serviceCIDR: 10.10.0.0/16\n    serviceCIDRv6: fd00:10:10::/112\n")
+	require.Contains(t, manifest, "registry.example.com/mirror/antrea-ubuntu:"+antreaVersion)
+	require.NotContains(t, manifest, "projects.registry.vmware.com")
+
+	manifest, err = antreaManifest("fd00:10:10::/112", 0, "")
+	require.NoError(t, err)
+	require.Contains(t, manifest, "    defaultMTU: 0\n    serviceCIDRv6: fd00:10:10::/112\n")
+}
+
+func TestNetworkProviderManifest(t *testing.T) {
+	o := NetworkOptions{PodNetworkCIDR: "10.20.0.0/16", ServiceCIDR: "10.10.0.0/16", NodeCIDRMaskSize: 24}
+
+	manifest, err := NetworkProviderManifest(constants.NetworkProviderCalico, o)
+	require.NoError(t, err)
+	require.Contains(t, manifest, `value: "10-calico.conflist"`)
+	manifest, err = NetworkProviderManifest(constants.NetworkProviderCilium, o)
+	require.NoError(t, err)
+	require.Contains(t, manifest, `custom-cni-conf: "false"`)
+
+	o.PendingCNIConf = true
+	manifest, err = NetworkProviderManifest(constants.NetworkProviderCalico, o)
+	require.NoError(t, err)
+	require.Contains(t, manifest, `value: "10-calico.conflist`+PendingCNIConfSuffix+`"`)
+	manifest, err = NetworkProviderManifest(constants.NetworkProviderCilium, o)
+	require.NoError(t, err)
+	require.Contains(t, manifest, `custom-cni-conf: "true"`)
+	_, err = NetworkProviderManifest(constants.NetworkProviderFlannel, o)
+	require.Error(t, err)
+
+	_, err = NetworkProviderManifest(constants.NetworkProviderWeave, NetworkOptions{PodNetworkCIDR: "10.20.0.0/16"})
+	require.Error(t, err)
+}
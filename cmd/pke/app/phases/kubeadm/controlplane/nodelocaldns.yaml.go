@@ -0,0 +1,211 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// nodeLocalDNSTemplate is a generated function returning the template as a string.
+func nodeLocalDNSTemplate() string {
+	var tmpl = "apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  name: node-local-dns\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    kubernetes.io/cluster-service: \"true\"\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Service\n" +
+		"metadata:\n" +
+		"  name: kube-dns-upstream\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    k8s-app: kube-dns\n" +
+		"    kubernetes.io/cluster-service: \"true\"\n" +
+		"    kubernetes.io/name: \"KubeDNSUpstream\"\n" +
+		"spec:\n" +
+		"  ports:\n" +
+		"  - name: dns\n" +
+		"    port: 53\n" +
+		"    protocol: UDP\n" +
+		"    targetPort: 53\n" +
+		"  - name: dns-tcp\n" +
+		"    port: 53\n" +
+		"    protocol: TCP\n" +
+		"    targetPort: 53\n" +
+		"  selector:\n" +
+		"    k8s-app: kube-dns\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: node-local-dns\n" +
+		"  namespace: kube-system\n" +
+		"data:\n" +
+		"  # every zone is forwarded to CoreDNS, so its stub domains, hosts and upstreams apply to cached queries too\n" +
+		"  Corefile: |\n" +
+		"    {{ .Domain }}:53 {\n" +
+		"        errors\n" +
+		"        cache {\n" +
+		"                success 9984 30\n" +
+		"                denial 9984 5\n" +
+		"        }\n" +
+		"        reload\n" +
+		"        loop\n" +
+		"        bind {{ .BindAddresses }}\n" +
+		"        forward . __PILLAR__CLUSTER__DNS__ {\n" +
+		"                force_tcp\n" +
+		"        }\n" +
+		"        prometheus :9253\n" +
+		"        health {{ .LocalAddress }}:8080\n" +
+		"        }\n" +
+		"    in-addr.arpa:53 {\n" +
+		"        errors\n" +
+		"        cache 30\n" +
+		"        reload\n" +
+		"        loop\n" +
+		"        bind {{ .BindAddresses }}\n" +
+		"        forward . __PILLAR__CLUSTER__DNS__ {\n" +
+		"                force_tcp\n" +
+		"        }\n" +
+		"        prometheus :9253\n" +
+		"        }\n" +
+		"    ip6.arpa:53 {\n" +
+		"        errors\n" +
+		"        cache 30\n" +
+		"        reload\n" +
+		"        loop\n" +
+		"        bind {{ .BindAddresses }}\n" +
+		"        forward . __PILLAR__CLUSTER__DNS__ {\n" +
+		"                force_tcp\n" +
+		"        }\n" +
+		"        prometheus :9253\n" +
+		"        }\n" +
+		"    .:53 {\n" +
+		"        errors\n" +
+		"        cache 30\n" +
+		"        reload\n" +
+		"        loop\n" +
+		"        bind {{ .BindAddresses }}\n" +
+		"        forward . __PILLAR__CLUSTER__DNS__\n" +
+		"        prometheus :9253\n" +
+		"        }\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: DaemonSet\n" +
+		"metadata:\n" +
+		"  name: node-local-dns\n" +
+		"  namespace: kube-system\n" +
+		"  labels:\n" +
+		"    k8s-app: node-local-dns\n" +
+		"    kubernetes.io/cluster-service: \"true\"\n" +
+		"spec:\n" +
+		"  updateStrategy:\n" +
+		"    rollingUpdate:\n" +
+		"      maxUnavailable: 10%\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      k8s-app: node-local-dns\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      labels:\n" +
+		"        k8s-app: node-local-dns\n" +
+		"      annotations:\n" +
+		"        prometheus.io/port: \"9253\"\n" +
+		"        prometheus.io/scrape: \"true\"\n" +
+		"    spec:\n" +
+		"      priorityClassName: system-node-critical\n" +
+		"      serviceAccountName: node-local-dns\n" +
+		"      hostNetwork: true\n" +
+		"      dnsPolicy: Default  # Don't use cluster DNS.\n" +
+		"      tolerations:\n" +
+		"      - key: \"CriticalAddonsOnly\"\n" +
+		"        operator: \"Exists\"\n" +
+		"      - effect: \"NoExecute\"\n" +
+		"        operator: \"Exists\"\n" +
+		"      - effect: \"NoSchedule\"\n" +
+		"        operator: \"Exists\"\n" +
+		"      containers:\n" +
+		"      - name: node-cache\n" +
+		"        image: {{ .ImageRepository }}/dns/k8s-dns-node-cache:{{ .Version }}\n" +
+		"        resources:\n" +
+		"          requests:\n" +
+		"            cpu: 25m\n" +
+		"            memory: 5Mi\n" +
+		"        args: [ \"-localip\", \"{{ .LocalIPs }}\", \"-conf\", \"/etc/Corefile\", \"-upstreamsvc\", \"kube-dns-upstream\" ]\n" +
+		"        securityContext:\n" +
+		"          capabilities:\n" +
+		"            add:\n" +
+		"            - NET_ADMIN\n" +
+		"        ports:\n" +
+		"        - containerPort: 53\n" +
+		"          name: dns\n" +
+		"          protocol: UDP\n" +
+		"        - containerPort: 53\n" +
+		"          name: dns-tcp\n" +
+		"          protocol: TCP\n" +
+		"        - containerPort: 9253\n" +
+		"          name: metrics\n" +
+		"          protocol: TCP\n" +
+		"        livenessProbe:\n" +
+		"          httpGet:\n" +
+		"            host: {{ .LocalAddress }}\n" +
+		"            path: /health\n" +
+		"            port: 8080\n" +
+		"          initialDelaySeconds: 60\n" +
+		"          timeoutSeconds: 5\n" +
+		"        volumeMounts:\n" +
+		"        - mountPath: /run/xtables.lock\n" +
+		"          name: xtables-lock\n" +
+		"          readOnly: false\n" +
+		"        - name: config-volume\n" +
+		"          mountPath: /etc/coredns\n" +
+		"        - name: kube-dns-config\n" +
+		"          mountPath: /etc/kube-dns\n" +
+		"      volumes:\n" +
+		"      - name: xtables-lock\n" +
+		"        hostPath:\n" +
+		"          path: /run/xtables.lock\n" +
+		"          type: FileOrCreate\n" +
+		"      - name: kube-dns-config\n" +
+		"        configMap:\n" +
+		"          name: kube-dns\n" +
+		"          optional: true\n" +
+		"      - name: config-volume\n" +
+		"        configMap:\n" +
+		"          name: node-local-dns\n" +
+		"          items:\n" +
+		"            - key: Corefile\n" +
+		"              path: Corefile.base\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Service\n" +
+		"metadata:\n" +
+		"  annotations:\n" +
+		"    prometheus.io/port: \"9253\"\n" +
+		"    prometheus.io/scrape: \"true\"\n" +
+		"  labels:\n" +
+		"    k8s-app: node-local-dns\n" +
+		"  name: node-local-dns\n" +
+		"  namespace: kube-system\n" +
+		"spec:\n" +
+		"  clusterIP: None\n" +
+		"  ports:\n" +
+		"    - name: metrics\n" +
+		"      port: 9253\n" +
+		"      targetPort: 9253\n" +
+		"  selector:\n" +
+		"    k8s-app: node-local-dns\n" +
+		""
+	return tmpl
+}
@@ -0,0 +1,366 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const (
+	oidcCAFile           = "/etc/kubernetes/pki/oidc-ca.crt"
+	authenticationConfig = "/etc/kubernetes/admission-control/authentication-config.yaml"
+
+	defaultOIDCUsernameClaim  = "email"
+	defaultOIDCUsernamePrefix = "oidc:"
+	defaultOIDCGroupsClaim    = "groups"
+	defaultOIDCSigningAlg     = "RS256"
+)
+
+// oidcSigningAlgs are the token signing algorithms supported by the API server.
+var oidcSigningAlgs = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512"}
+
+// oidc holds the OIDC authentication settings of the API server.
+type oidc struct {
+	issuerURL      string
+	clientID       string
+	usernameClaim  string
+	usernamePrefix string
+	groupsClaim    string
+	groupsPrefix   string
+	caFile         string
+	requiredClaims map[string]string
+	signingAlgs    []string
+	// configFile is a user supplied AuthenticationConfiguration
+	configFile string
+}
+
+func (o oidc) enabled() bool {
+	return o.issuerURL != "" && o.clientID != ""
+}
+
+// structured tells whether the API server is configured with an AuthenticationConfiguration file instead of --oidc-* flags.
+func (o oidc) structured(kubernetesVersion string) bool {
+	if o.configFile != "" {
+		return true
+	}
+	if !o.enabled() {
+		return false
+	}
+	_, err := authenticationConfigAPIVersion(kubernetesVersion)
+	return err == nil
+}
+
+// requiredClaimsArg formats the required claims as the value of the --oidc-required-claim flag.
+func (o oidc) requiredClaimsArg() string {
+	claims := make([]string, 0, len(o.requiredClaims))
+	for k, v := range o.requiredClaims {
+		claims = append(claims, k+"="+v)
+	}
+	sort.Strings(claims)
+	return strings.Join(claims, ",")
+}
+
+func (c *ControlPlane) oidcParameters(cmd *cobra.Command) (err error) {
+	c.oidc.issuerURL, err = cmd.Flags().GetString(constants.FlagOIDCIssuerURL)
+	if err != nil {
+		return
+	}
+	c.oidc.clientID, err = cmd.Flags().GetString(constants.FlagOIDCClientID)
+	if err != nil {
+		return
+	}
+	c.oidc.usernameClaim, err = cmd.Flags().GetString(constants.FlagOIDCUsernameClaim)
+	if err != nil {
+		return
+	}
+	c.oidc.usernamePrefix, err = cmd.Flags().GetString(constants.FlagOIDCUsernamePrefix)
+	if err != nil {
+		return
+	}
+	c.oidc.groupsClaim, err = cmd.Flags().GetString(constants.FlagOIDCGroupsClaim)
+	if err != nil {
+		return
+	}
+	c.oidc.groupsPrefix, err = cmd.Flags().GetString(constants.FlagOIDCGroupsPrefix)
+	if err != nil {
+		return
+	}
+	c.oidc.caFile, err = cmd.Flags().GetString(constants.FlagOIDCCAFile)
+	if err != nil {
+		return
+	}
+	c.oidc.requiredClaims, err = cmd.Flags().GetStringToString(constants.FlagOIDCRequiredClaims)
+	if err != nil {
+		return
+	}
+	c.oidc.signingAlgs, err = cmd.Flags().GetStringSlice(constants.FlagOIDCSigningAlgs)
+	if err != nil {
+		return
+	}
+	c.oidc.configFile, err = cmd.Flags().GetString(constants.FlagAuthenticationConfig)
+	if err != nil {
+		return
+	}
+
+	return validateOIDC(c.oidc, c.kubernetesVersion)
+}
+
+func validateOIDC(o oidc, kubernetesVersion string) error {
+	if o.configFile != "" {
+		if o.issuerURL != "" || o.clientID != "" {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s can not be used with --%s and --%s", constants.FlagAuthenticationConfig, constants.FlagOIDCIssuerURL, constants.FlagOIDCClientID)
+		}
+		b, err := ioutil.ReadFile(o.configFile)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAuthenticationConfig, err)
+		}
+		if err := validateAuthenticationConfig(b, kubernetesVersion); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagAuthenticationConfig, err)
+		}
+		return nil
+	}
+
+	if o.issuerURL == "" && o.clientID == "" {
+		return nil
+	}
+	if o.issuerURL == "" || o.clientID == "" {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s and --%s must be set together", constants.FlagOIDCIssuerURL, constants.FlagOIDCClientID)
+	}
+
+	u, err := url.Parse(o.issuerURL)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagOIDCIssuerURL, err)
+	}
+	if u.Scheme != "https" {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: issuer URL must use the https scheme", constants.FlagOIDCIssuerURL)
+	}
+	if o.usernameClaim == "" {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be empty", constants.FlagOIDCUsernameClaim)
+	}
+	if o.caFile != "" {
+		if _, err := ioutil.ReadFile(o.caFile); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagOIDCCAFile, err)
+		}
+	}
+	for _, alg := range o.signingAlgs {
+		if !contains(oidcSigningAlgs, alg) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: unsupported signing algorithm %q, possible values: %s", constants.FlagOIDCSigningAlgs, alg, strings.Join(oidcSigningAlgs, ", "))
+		}
+	}
+	// the structured configuration has no signing algorithm setting, restricting them would be silently dropped
+	if o.structured(kubernetesVersion) && (len(o.signingAlgs) != 1 || o.signingAlgs[0] != defaultOIDCSigningAlg) {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: not supported on Kubernetes 1.30 or newer, the API server accepts every asymmetric algorithm", constants.FlagOIDCSigningAlgs)
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticationConfigAPIVersion returns the AuthenticationConfiguration API version to use, the structured
+// configuration is enabled by default since Kubernetes 1.30 and GA since 1.32.
+func authenticationConfigAPIVersion(kubernetesVersion string) (string, error) {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse Kubernetes version %q", kubernetesVersion)
+	}
+	switch {
+	case ver.LessThan(semver.MustParse("1.30.0")):
+		return "", errors.Errorf("structured authentication configuration requires Kubernetes 1.30 or newer, got %s", kubernetesVersion)
+	case ver.LessThan(semver.MustParse("1.32.0")):
+		return "apiserver.config.k8s.io/v1beta1", nil
+	default:
+		return "apiserver.config.k8s.io/v1", nil
+	}
+}
+
+type authenticationConfiguration struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	JWT        []jwtAuthenticator `json:"jwt"`
+}
+
+type jwtAuthenticator struct {
+	Issuer               jwtIssuer             `json:"issuer"`
+	ClaimValidationRules []claimValidationRule `json:"claimValidationRules,omitempty"`
+	ClaimMappings        claimMappings         `json:"claimMappings"`
+}
+
+type jwtIssuer struct {
+	URL                  string   `json:"url"`
+	Audiences            []string `json:"audiences"`
+	CertificateAuthority string   `json:"certificateAuthority,omitempty"`
+}
+
+type claimValidationRule struct {
+	Claim         string `json:"claim"`
+	RequiredValue string `json:"requiredValue"`
+}
+
+type claimMappings struct {
+	Username prefixedClaim  `json:"username"`
+	Groups   *prefixedClaim `json:"groups,omitempty"`
+}
+
+type prefixedClaim struct {
+	Claim  string `json:"claim"`
+	Prefix string `json:"prefix"`
+}
+
+// validateAuthenticationConfig makes sure the user supplied configuration is usable with the Kubernetes version.
+func validateAuthenticationConfig(b []byte, kubernetesVersion string) error {
+	if _, err := authenticationConfigAPIVersion(kubernetesVersion); err != nil {
+		return err
+	}
+
+	var conf authenticationConfiguration
+	if err := yaml.Unmarshal(b, &conf); err != nil {
+		return errors.Wrap(err, "failed to parse authentication configuration")
+	}
+	if conf.Kind != "AuthenticationConfiguration" {
+		return errors.Errorf("unexpected kind %q, expected AuthenticationConfiguration", conf.Kind)
+	}
+	if !strings.HasPrefix(conf.APIVersion, "apiserver.config.k8s.io/") {
+		return errors.Errorf("unsupported apiVersion %q", conf.APIVersion)
+	}
+	if len(conf.JWT) == 0 {
+		return errors.New("no jwt authenticator configured")
+	}
+	issuers := make(map[string]bool)
+	for _, jwt := range conf.JWT {
+		if jwt.Issuer.URL == "" {
+			return errors.New("jwt authenticator without issuer url")
+		}
+		if issuers[jwt.Issuer.URL] {
+			return errors.Errorf("duplicate issuer url %q", jwt.Issuer.URL)
+		}
+		issuers[jwt.Issuer.URL] = true
+	}
+
+	return nil
+}
+
+// authenticationConfigFor renders the OIDC flags as an AuthenticationConfiguration with a single issuer.
+// Signing algorithms are not configurable, the API server accepts every asymmetric algorithm.
+func authenticationConfigFor(kubernetesVersion string, o oidc) (authenticationConfiguration, error) {
+	apiVersion, err := authenticationConfigAPIVersion(kubernetesVersion)
+	if err != nil {
+		return authenticationConfiguration{}, err
+	}
+
+	jwt := jwtAuthenticator{
+		Issuer: jwtIssuer{
+			URL:       o.issuerURL,
+			Audiences: []string{o.clientID},
+		},
+		ClaimMappings: claimMappings{
+			Username: prefixedClaim{Claim: o.usernameClaim, Prefix: o.usernamePrefix},
+		},
+	}
+	// "-" disables the prefix of the --oidc-username-prefix flag
+	if o.usernamePrefix == "-" {
+		jwt.ClaimMappings.Username.Prefix = ""
+	}
+	if o.groupsClaim != "" {
+		jwt.ClaimMappings.Groups = &prefixedClaim{Claim: o.groupsClaim, Prefix: o.groupsPrefix}
+	}
+	if o.caFile != "" {
+		ca, err := ioutil.ReadFile(o.caFile)
+		if err != nil {
+			return authenticationConfiguration{}, err
+		}
+		jwt.Issuer.CertificateAuthority = string(ca)
+	}
+	claims := make([]string, 0, len(o.requiredClaims))
+	for k := range o.requiredClaims {
+		claims = append(claims, k)
+	}
+	sort.Strings(claims)
+	for _, k := range claims {
+		jwt.ClaimValidationRules = append(jwt.ClaimValidationRules, claimValidationRule{Claim: k, RequiredValue: o.requiredClaims[k]})
+	}
+
+	return authenticationConfiguration{
+		APIVersion: apiVersion,
+		Kind:       "AuthenticationConfiguration",
+		JWT:        []jwtAuthenticator{jwt},
+	}, nil
+}
+
+// writeOIDCConfig writes the files referenced by the OIDC settings of the API server.
+func writeOIDCConfig(out io.Writer, kubernetesVersion string, o oidc) error {
+	var filename, contents string
+	switch {
+	case o.configFile != "":
+		b, err := ioutil.ReadFile(o.configFile)
+		if err != nil {
+			return err
+		}
+		filename, contents = authenticationConfig, string(b)
+
+	case o.structured(kubernetesVersion):
+		conf, err := authenticationConfigFor(kubernetesVersion, o)
+		if err != nil {
+			return err
+		}
+		b, err := yaml.Marshal(conf)
+		if err != nil {
+			return err
+		}
+		filename, contents = authenticationConfig, string(b)
+
+	case o.enabled() && o.caFile != "":
+		// only the PKI directory is mounted into the API server
+		b, err := ioutil.ReadFile(o.caFile)
+		if err != nil {
+			return err
+		}
+		filename, contents = oidcCAFile, string(b)
+
+	default:
+		return nil
+	}
+
+	dir := filepath.Dir(filename)
+	_, _ = fmt.Fprintf(out, "[%s] creating directory: %q\n", use, dir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing OIDC authentication config: %q\n", use, filename)
+	return file.Overwrite(filename, contents)
+}
@@ -0,0 +1,163 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOIDC(t *testing.T) {
+	valid := oidc{
+		issuerURL:     "https://accounts.example.com",
+		clientID:      "kubernetes",
+		usernameClaim: "preferred_username",
+		signingAlgs:   []string{"RS256", "ES256"},
+	}
+
+	testCases := []struct {
+		name              string
+		oidc              func(o oidc) oidc
+		kubernetesVersion string
+		err               bool
+	}{
+		{name: "disabled", oidc: func(oidc) oidc { return oidc{} }},
+		{name: "valid", oidc: func(o oidc) oidc { return o }},
+		{name: "client id missing", oidc: func(o oidc) oidc { o.clientID = ""; return o }, err: true},
+		{name: "http issuer", oidc: func(o oidc) oidc { o.issuerURL = "http://accounts.example.com"; return o }, err: true},
+		{name: "username claim missing", oidc: func(o oidc) oidc { o.usernameClaim = ""; return o }, err: true},
+		{name: "signing algorithm", oidc: func(o oidc) oidc { o.signingAlgs = []string{"HS256"}; return o }, err: true},
+		{name: "ca file missing", oidc: func(o oidc) oidc { o.caFile = "/nonexistent/ca.crt"; return o }, err: true},
+		{name: "config file with flags", oidc: func(o oidc) oidc { o.configFile = "/nonexistent/auth.yaml"; return o }, err: true},
+		{name: "structured", oidc: func(o oidc) oidc { o.signingAlgs = []string{"RS256"}; return o }, kubernetesVersion: "1.30.0"},
+		{name: "structured signing algorithms", oidc: func(o oidc) oidc { return o }, kubernetesVersion: "1.30.0", err: true},
+	}
+
+	for _, tc := range testCases {
+		kubernetesVersion := tc.kubernetesVersion
+		if kubernetesVersion == "" {
+			kubernetesVersion = "1.23.0"
+		}
+		err := validateOIDC(tc.oidc(valid), kubernetesVersion)
+		if tc.err {
+			require.Error(t, err, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestValidateAuthenticationConfig(t *testing.T) {
+	multiple := `apiVersion: apiserver.config.k8s.io/v1beta1
+kind: AuthenticationConfiguration
+jwt:
+- issuer:
+    url: https://a.example.com
+    audiences: [kubernetes]
+  claimMappings:
+    username:
+      claim: preferred_username
+      prefix: "a:"
+- issuer:
+    url: https://b.example.com
+    audiences: [kubernetes]
+  claimMappings:
+    username:
+      claim: email
+      prefix: "b:"
+`
+	duplicate := `apiVersion: apiserver.config.k8s.io/v1beta1
+kind: AuthenticationConfiguration
+jwt:
+- issuer:
+    url: https://a.example.com
+- issuer:
+    url: https://a.example.com
+`
+
+	testCases := []struct {
+		name    string
+		version string
+		config  string
+		err     bool
+	}{
+		{name: "multiple issuers", version: "1.30.0", config: multiple},
+		{name: "old version", version: "1.23.0", config: multiple, err: true},
+		{name: "duplicate issuer", version: "1.30.0", config: duplicate, err: true},
+		{name: "no issuer", version: "1.30.0", config: "apiVersion: apiserver.config.k8s.io/v1\nkind: AuthenticationConfiguration\n", err: true},
+		{name: "kind", version: "1.30.0", config: "apiVersion: apiserver.config.k8s.io/v1\nkind: Policy\n", err: true},
+	}
+
+	for _, tc := range testCases {
+		err := validateAuthenticationConfig([]byte(tc.config), tc.version)
+		if tc.err {
+			require.Error(t, err, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestAuthenticationConfigFor(t *testing.T) {
+	o := oidc{
+		issuerURL:      "https://accounts.example.com",
+		clientID:       "kubernetes",
+		usernameClaim:  "preferred_username",
+		usernamePrefix: "-",
+		groupsClaim:    "roles",
+		groupsPrefix:   "oidc:",
+		requiredClaims: map[string]string{"hd": "example.com"},
+	}
+
+	_, err := authenticationConfigFor("1.23.0", o)
+	require.Error(t, err)
+
+	conf, err := authenticationConfigFor("1.30.2", o)
+	require.NoError(t, err)
+
+	b, err := yaml.Marshal(conf)
+	require.NoError(t, err)
+	require.Equal(t, `apiVersion: apiserver.config.k8s.io/v1beta1
+jwt:
+- claimMappings:
+    groups:
+      claim: roles
+      prefix: 'oidc:'
+    username:
+      claim: preferred_username
+      prefix: ""
+  claimValidationRules:
+  - claim: hd
+    requiredValue: example.com
+  issuer:
+    audiences:
+    - kubernetes
+    url: https://accounts.example.com
+kind: AuthenticationConfiguration
+`, string(b))
+	require.NoError(t, validateAuthenticationConfig(b, "1.30.2"))
+
+	conf, err = authenticationConfigFor("1.32.0", o)
+	require.NoError(t, err)
+	require.Equal(t, "apiserver.config.k8s.io/v1", conf.APIVersion)
+}
+
+func TestOIDCRequiredClaimsArg(t *testing.T) {
+	o := oidc{requiredClaims: map[string]string{"hd": "example.com", "aud": "kubernetes"}}
+	require.Equal(t, "aud=kubernetes,hd=example.com", o.requiredClaimsArg())
+	require.Equal(t, "", oidc{}.requiredClaimsArg())
+}
@@ -0,0 +1,141 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"io"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/podsecurity"
+)
+
+const (
+	podSecurityConfig = "/etc/kubernetes/admission-control/pod-security.yaml"
+)
+
+// podSecurity holds the cluster-wide defaults of the PodSecurity admission plugin.
+type podSecurity struct {
+	enforce          string
+	audit            string
+	warn             string
+	exemptNamespaces []string
+}
+
+func (p podSecurity) enabled() bool {
+	return p.enforce != ""
+}
+
+func (c *ControlPlane) podSecurityParameters(cmd *cobra.Command) (err error) {
+	c.podSecurity.enforce, err = cmd.Flags().GetString(constants.FlagPodSecurityStandard)
+	if err != nil {
+		return
+	}
+	c.podSecurity.audit, err = cmd.Flags().GetString(constants.FlagPodSecurityAudit)
+	if err != nil {
+		return
+	}
+	c.podSecurity.warn, err = cmd.Flags().GetString(constants.FlagPodSecurityWarn)
+	if err != nil {
+		return
+	}
+	c.podSecurity.exemptNamespaces, err = cmd.Flags().GetStringSlice(constants.FlagPodSecurityExemptNamespaces)
+	if err != nil {
+		return
+	}
+
+	if !c.podSecurity.enabled() {
+		return
+	}
+	// audit and warn follow the enforced level unless set explicitly
+	if c.podSecurity.audit == "" {
+		c.podSecurity.audit = c.podSecurity.enforce
+	}
+	if c.podSecurity.warn == "" {
+		c.podSecurity.warn = c.podSecurity.enforce
+	}
+
+	for flag, level := range map[string]string{
+		constants.FlagPodSecurityStandard: c.podSecurity.enforce,
+		constants.FlagPodSecurityAudit:    c.podSecurity.audit,
+		constants.FlagPodSecurityWarn:     c.podSecurity.warn,
+	} {
+		if err := podsecurity.ValidateLevel(level); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", flag, err)
+		}
+	}
+	if _, err := podsecurity.ConfigAPIVersion(c.kubernetesVersion); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagPodSecurityStandard, err)
+	}
+
+	return
+}
+
+// validatePodSecurityPolicy rejects the PodSecurityPolicy admission plugin where it can not work.
+func validatePodSecurityPolicy(kubernetesVersion string, withPluginPSP bool, a admission, p podSecurity) error {
+	if !withPluginPSP && !contains(a.enable, admissionPluginPodSecurityPolicy) {
+		return nil
+	}
+
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagKubernetesVersion, err)
+	}
+	if !ver.LessThan(semver.MustParse("1.25.0")) {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: PodSecurityPolicy was removed in Kubernetes 1.25, use --%s instead", constants.FlagAdmissionPluginPodSecurityPolicy, constants.FlagPodSecurityStandard)
+	}
+	if p.enabled() {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s and --%s: PodSecurityPolicy and PodSecurity admission are mutually exclusive", constants.FlagAdmissionPluginPodSecurityPolicy, constants.FlagPodSecurityStandard)
+	}
+
+	return nil
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f podSecurity pod_security.yaml.tmpl
+
+func writePodSecurityConfig(out io.Writer, filename, kubernetesVersion string, p podSecurity) error {
+	apiVersion, err := podsecurity.ConfigAPIVersion(kubernetesVersion)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("pod-security").Parse(podSecurityTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		APIVersion       string
+		Enforce          string
+		Audit            string
+		Warn             string
+		ExemptNamespaces []string
+	}
+
+	d := data{
+		APIVersion:       apiVersion,
+		Enforce:          p.enforce,
+		Audit:            p.audit,
+		Warn:             p.warn,
+		ExemptNamespaces: p.exemptNamespaces,
+	}
+
+	return file.WriteTemplate(filename, tmpl, d)
+}
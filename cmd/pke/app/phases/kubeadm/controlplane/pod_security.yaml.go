@@ -0,0 +1,34 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+// podSecurityTemplate is a generated function returning the template as a string.
+func podSecurityTemplate() string {
+	var tmpl = "apiVersion: {{ .APIVersion }}\n" +
+		"kind: PodSecurityConfiguration\n" +
+		"defaults:\n" +
+		"  enforce: \"{{ .Enforce }}\"\n" +
+		"  enforce-version: \"latest\"\n" +
+		"  audit: \"{{ .Audit }}\"\n" +
+		"  audit-version: \"latest\"\n" +
+		"  warn: \"{{ .Warn }}\"\n" +
+		"  warn-version: \"latest\"\n" +
+		"exemptions:\n" +
+		"  usernames: []\n" +
+		"  runtimeClasses: []\n" +
+		"  namespaces: [{{ range $i, $ns := .ExemptNamespaces }}{{ if $i }}, {{ end }}\"{{ $ns }}\"{{ end }}]\n" +
+		""
+	return tmpl
+}
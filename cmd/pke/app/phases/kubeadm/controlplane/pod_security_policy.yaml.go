@@ -149,6 +149,18 @@ func podSecurityPolicyTemplate() string {
 		"  - kind: ServiceAccount\n" +
 		"    name: weave-net\n" +
 		"    namespace: kube-system\n" +
+		"  - kind: ServiceAccount\n" +
+		"    name: flannel\n" +
+		"    namespace: kube-system\n" +
+		"  - kind: ServiceAccount\n" +
+		"    name: antrea-agent\n" +
+		"    namespace: kube-system\n" +
+		"  - kind: ServiceAccount\n" +
+		"    name: antrea-controller\n" +
+		"    namespace: kube-system\n" +
+		"  - kind: ServiceAccount\n" +
+		"    name: node-local-dns\n" +
+		"    namespace: kube-system\n" +
 		"\n" +
 		"---\n" +
 		"apiVersion: rbac.authorization.k8s.io/v1\n" +
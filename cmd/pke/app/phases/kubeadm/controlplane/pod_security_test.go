@@ -0,0 +1,93 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePodSecurityConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pod-security")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "admission-control", "pod-security.yaml")
+
+	err = writePodSecurityConfig(ioutil.Discard, filename, "1.23.3", podSecurity{
+		enforce:          "baseline",
+		audit:            "restricted",
+		warn:             "restricted",
+		exemptNamespaces: []string{"kube-system", "pipeline-system"},
+	})
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, `apiVersion: pod-security.admission.config.k8s.io/v1beta1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: "baseline"
+  enforce-version: "latest"
+  audit: "restricted"
+  audit-version: "latest"
+  warn: "restricted"
+  warn-version: "latest"
+exemptions:
+  usernames: []
+  runtimeClasses: []
+  namespaces: ["kube-system", "pipeline-system"]
+`, string(b))
+
+	filename = filepath.Join(dir, "admission-control.yaml")
+	err = writeAdmissionConfiguration(ioutil.Discard, filename, []admissionPluginConfig{
+		{Name: admissionPluginEventRateLimit, Path: "/etc/kubernetes/admission-control/event-rate-limit.yaml"},
+		{Name: admissionPluginPodSecurity, Path: podSecurityConfig},
+	})
+	require.NoError(t, err)
+
+	b, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "- name: EventRateLimit\n  path: /etc/kubernetes/admission-control/event-rate-limit.yaml\n- name: PodSecurity\n  path: "+podSecurityConfig)
+}
+
+func TestValidatePodSecurityPolicy(t *testing.T) {
+	testCases := []struct {
+		name              string
+		kubernetesVersion string
+		withPluginPSP     bool
+		admission         admission
+		podSecurity       podSecurity
+		err               bool
+	}{
+		{name: "disabled", kubernetesVersion: "1.25.4", podSecurity: podSecurity{enforce: "baseline"}},
+		{name: "psp", kubernetesVersion: "1.24.8", withPluginPSP: true},
+		{name: "psp removed", kubernetesVersion: "1.25.4", withPluginPSP: true, err: true},
+		{name: "psp enabled explicitly", kubernetesVersion: "1.25.4", admission: admission{enable: []string{"PodSecurityPolicy"}}, err: true},
+		{name: "psp and pod security", kubernetesVersion: "1.24.8", withPluginPSP: true, podSecurity: podSecurity{enforce: "baseline"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		err := validatePodSecurityPolicy(tc.kubernetesVersion, tc.withPluginPSP, tc.admission, tc.podSecurity)
+		if tc.err {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+	}
+}
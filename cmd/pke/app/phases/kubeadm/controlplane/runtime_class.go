@@ -0,0 +1,94 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	runtimeClassConfig = "/etc/kubernetes/runtime-class.yaml"
+)
+
+// applyRuntimeClasses creates a RuntimeClass for each sandboxed runtime registered in the containerd configuration.
+func applyRuntimeClasses(out io.Writer, kubernetesVersion string, sandboxRuntimes []cri.SandboxRuntime) error {
+	if len(sandboxRuntimes) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] creating RuntimeClasses\n", use)
+
+	manifest, err := runtimeClassManifest(kubernetesVersion, sandboxRuntimes)
+	if err != nil {
+		return err
+	}
+	if err := file.Overwrite(runtimeClassConfig, manifest); err != nil {
+		return err
+	}
+
+	cmd := runner.Cmd(out, cmdKubectl, "apply", "-f", runtimeClassConfig)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err = cmd.CombinedOutputAsync()
+	return err
+}
+
+//go:generate templify -t ${GOTMPL} -p controlplane -f runtimeClass runtime_class.yaml.tmpl
+
+// runtimeClassManifest renders a RuntimeClass for each sandboxed runtime.
+func runtimeClassManifest(kubernetesVersion string, sandboxRuntimes []cri.SandboxRuntime) (string, error) {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse Kubernetes version %q", kubernetesVersion)
+	}
+
+	// node.k8s.io/v1 is available since Kubernetes 1.20
+	apiVersion := "node.k8s.io/v1"
+	if ver.Minor() < 20 {
+		apiVersion = "node.k8s.io/v1beta1"
+	}
+
+	tmpl, err := template.New("runtime-class").Parse(runtimeClassTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type data struct {
+		APIVersion      string
+		SandboxRuntimes []cri.SandboxRuntime
+	}
+
+	d := data{
+		APIVersion:      apiVersion,
+		SandboxRuntimes: sandboxRuntimes,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
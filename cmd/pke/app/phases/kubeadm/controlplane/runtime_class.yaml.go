@@ -14,20 +14,16 @@
 
 package controlplane
 
-// lbRangeConfigTemplate is a generated function returning the template as a string.
-func lbRangeConfigTemplate() string {
-	var tmpl = "apiVersion: v1\n" +
-		"kind: ConfigMap\n" +
+// runtimeClassTemplate is a generated function returning the template as a string.
+func runtimeClassTemplate() string {
+	var tmpl = "{{- range .SandboxRuntimes }}\n" +
+		"---\n" +
+		"apiVersion: {{ $.APIVersion }}\n" +
+		"kind: RuntimeClass\n" +
 		"metadata:\n" +
-		"  name: config\n" +
-		"  namespace: metallb-system\n" +
-		"data:\n" +
-		"  config: |\n" +
-		"    address-pools:\n" +
-		"    - name: default\n" +
-		"      protocol: layer2\n" +
-		"      addresses:\n" +
-		"      - {{ .Range }}\n" +
+		"  name: {{ .Name }}\n" +
+		"handler: {{ .Handler }}\n" +
+		"{{- end }}\n" +
 		""
 	return tmpl
 }
@@ -0,0 +1,78 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+)
+
+func TestRuntimeClassManifest(t *testing.T) {
+	runtimes, err := cri.SandboxRuntimes([]string{constants.SandboxRuntimeGVisor, constants.SandboxRuntimeKata})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name              string
+		kubernetesVersion string
+		apiVersion        string
+		err               bool
+	}{
+		{name: "v1", kubernetesVersion: "1.24.4", apiVersion: "node.k8s.io/v1"},
+		{name: "v1beta1", kubernetesVersion: "1.19.16", apiVersion: "node.k8s.io/v1beta1"},
+		{name: "invalid version", kubernetesVersion: "latest", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest, err := runtimeClassManifest(tc.kubernetesVersion, runtimes)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			type runtimeClass struct {
+				APIVersion string `json:"apiVersion"`
+				Kind       string `json:"kind"`
+				Metadata   struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Handler string `json:"handler"`
+			}
+			var classes []runtimeClass
+			for _, doc := range strings.Split(manifest, "---\n") {
+				if strings.TrimSpace(doc) == "" {
+					continue
+				}
+				var rc runtimeClass
+				require.NoError(t, yaml.Unmarshal([]byte(doc), &rc))
+				classes = append(classes, rc)
+			}
+			require.Len(t, classes, 2)
+			for i, rc := range classes {
+				require.Equal(t, tc.apiVersion, rc.APIVersion)
+				require.Equal(t, "RuntimeClass", rc.Kind)
+				require.Equal(t, runtimes[i].Name, rc.Metadata.Name)
+				require.Equal(t, runtimes[i].Handler, rc.Handler)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@
 package controlplane
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +25,7 @@ import (
 
 	"emperror.dev/errors"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 )
@@ -44,7 +47,8 @@ func applyDefaultStorageClass(out io.Writer, disableDefaultStorageClass bool, cl
 		// TODO: out-of-tree CSI volume plugins
 		return nil
 	default:
-		err = writeStorageClassLocalPathStorage(out, storageClassConfig, imageRepository)
+		_, _ = fmt.Fprintf(out, "[%s] creating local default storage class\n", use)
+		return installAddon(out, newLocalPathStorageAddon(), localPathStorageConfig{ImageRepository: imageRepository})
 	}
 	if err != nil {
 		return err
@@ -146,14 +150,39 @@ func writeStorageClassAzure(out io.Writer, filename string, storageAccountType,
 	return file.WriteTemplate(filename, tmpl, d)
 }
 
+const (
+	localPathStorageAddonName = "local-path-storage"
+	// localPathStorageVersion is the version of the embedded local path provisioner.
+	localPathStorageVersion = "v0.0.21"
+)
+
 //go:generate templify -t ${GOTMPL} -p controlplane -f storageClassLocalPathStorage storage_class_local_path_storage.yaml.tmpl
 
-func writeStorageClassLocalPathStorage(out io.Writer, filename string, imageRepository string) error {
-	_, _ = fmt.Fprintf(out, "[%s] creating local default storage class\n", use)
+// localPathStorageConfig is the configuration of the local path storage addon.
+type localPathStorageConfig struct {
+	ImageRepository string `json:"imageRepository,omitempty"`
+}
+
+// newLocalPathStorageAddon returns the addon of the default storage class of clusters without a cloud provider.
+func newLocalPathStorageAddon() addons.Addon {
+	return addons.Manifest{
+		AddonName:    localPathStorageAddonName,
+		AddonVersion: localPathStorageVersion,
+		RenderFunc: func(config []byte) (string, error) {
+			var c localPathStorageConfig
+			if err := json.Unmarshal(config, &c); err != nil {
+				return "", errors.Wrap(err, "failed to parse local path storage configuration")
+			}
+			return localPathStorageManifest(c.ImageRepository)
+		},
+		Workloads: []addons.Workload{{Namespace: "kube-system", Resource: "deployment/local-path-provisioner"}},
+	}
+}
 
+func localPathStorageManifest(imageRepository string) (string, error) {
 	tmpl, err := template.New("storage-class-local-path").Parse(storageClassLocalPathStorageTemplate())
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	type data struct {
@@ -164,5 +193,10 @@ func writeStorageClassLocalPathStorage(out io.Writer, filename string, imageRepo
 		ImageRepository: imageRepository,
 	}
 
-	return file.WriteTemplate(filename, tmpl, d)
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
 }
@@ -0,0 +1,163 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrapprover
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "csr-approver"
+	short = "Approve kubelet serving certificate requests matching the addresses of the requesting node"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+)
+
+var _ phases.Runnable = (*Approver)(nil)
+
+type Approver struct {
+	interval time.Duration
+	rejected map[string]bool
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Approver{})
+}
+
+func (*Approver) Use() string {
+	return use
+}
+
+func (*Approver) Short() string {
+	return short
+}
+
+func (*Approver) RegisterFlags(flags *pflag.FlagSet) {
+	flags.Duration(constants.FlagCSRApproverInterval, 10*time.Second, "Interval between checks for pending certificate signing requests")
+}
+
+func (a *Approver) Validate(cmd *cobra.Command) error {
+	var err error
+	a.interval, err = cmd.Flags().GetDuration(constants.FlagCSRApproverInterval)
+	if err != nil {
+		return err
+	}
+	if a.interval <= 0 {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must be positive", constants.FlagCSRApproverInterval)
+	}
+
+	return nil
+}
+
+// Run checks pending kubelet serving certificate requests periodically until the process is stopped.
+func (a *Approver) Run(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] running\n", use)
+
+	a.rejected = make(map[string]bool)
+	tick := time.NewTicker(a.interval)
+	defer tick.Stop()
+	for {
+		if err := a.approve(out); err != nil {
+			_, _ = fmt.Fprintf(out, "[%s] %v\n", use, err)
+		}
+		<-tick.C
+	}
+}
+
+func (a *Approver) approve(out io.Writer) error {
+	var csrs struct {
+		Items []CertificateSigningRequest `json:"items"`
+	}
+	if err := kubectlGet(&csrs, "csr"); err != nil {
+		return errors.Wrap(err, "failed to list certificate signing requests")
+	}
+
+	pending := a.pending(csrs.Items)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var list struct {
+		Items []Node `json:"items"`
+	}
+	if err := kubectlGet(&list, "nodes"); err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+	nodes := make(map[string]Node, len(list.Items))
+	for _, n := range list.Items {
+		nodes[n.Metadata.Name] = n
+	}
+
+	for _, r := range pending {
+		if err := ValidateKubeletServing(r, nodes); err != nil {
+			// rejected requests are left pending for manual review
+			_, _ = fmt.Fprintf(out, "[%s] not approving %q: %v\n", use, r.Metadata.Name, err)
+			a.rejected[r.Metadata.Name] = true
+			continue
+		}
+
+		// kubectl certificate approve <name>
+		cmd := runner.Cmd(out, cmdKubectl, "certificate", "approve", r.Metadata.Name)
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		if _, err := cmd.CombinedOutputAsync(); err != nil {
+			return errors.Wrapf(err, "failed to approve %q", r.Metadata.Name)
+		}
+	}
+
+	return nil
+}
+
+// pending returns the kubelet serving requests to check, rejected ones are remembered only until the request is deleted.
+func (a *Approver) pending(csrs []CertificateSigningRequest) []CertificateSigningRequest {
+	existing := make(map[string]bool, len(csrs))
+	var pending []CertificateSigningRequest
+	for _, r := range csrs {
+		existing[r.Metadata.Name] = true
+		if r.Pending() && IsKubeletServing(r) && !a.rejected[r.Metadata.Name] {
+			pending = append(pending, r)
+		}
+	}
+	for name := range a.rejected {
+		if !existing[name] {
+			delete(a.rejected, name)
+		}
+	}
+
+	return pending
+}
+
+func kubectlGet(v interface{}, resource string) error {
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", resource, "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(o, v)
+}
@@ -0,0 +1,37 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrapprover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPending(t *testing.T) {
+	csr := func(name string) CertificateSigningRequest {
+		var r CertificateSigningRequest
+		r.Metadata.Name = name
+		r.Spec.SignerName = kubeletServingSigner
+		return r
+	}
+
+	a := &Approver{rejected: map[string]bool{"csr-rejected": true, "csr-deleted": true}}
+	pending := a.pending([]CertificateSigningRequest{csr("csr-new"), csr("csr-rejected")})
+
+	require.Len(t, pending, 1)
+	require.Equal(t, "csr-new", pending[0].Metadata.Name)
+	require.Equal(t, map[string]bool{"csr-rejected": true}, a.rejected)
+}
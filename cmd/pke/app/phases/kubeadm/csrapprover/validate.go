@@ -0,0 +1,177 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrapprover
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+const (
+	kubeletServingSigner = "kubernetes.io/kubelet-serving"
+	nodeUserPrefix       = "system:node:"
+	nodesGroup           = "system:nodes"
+)
+
+// CertificateSigningRequest is the subset of a certificates.k8s.io CertificateSigningRequest used by the approver.
+type CertificateSigningRequest struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Request    []byte   `json:"request"`
+		SignerName string   `json:"signerName"`
+		Username   string   `json:"username"`
+		Groups     []string `json:"groups"`
+		Usages     []string `json:"usages"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type string `json:"type"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// Pending tells whether the request is neither approved, denied nor failed.
+func (r CertificateSigningRequest) Pending() bool {
+	return len(r.Status.Conditions) == 0
+}
+
+// Node is the subset of a Node used by the approver.
+type Node struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Addresses []NodeAddress `json:"addresses"`
+	} `json:"status"`
+}
+
+// NodeAddress is an address of a Node.
+type NodeAddress struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// allowed key usages of kubelet serving certificates, server auth is mandatory.
+var allowedUsages = map[string]bool{
+	"digital signature": true,
+	"key encipherment":  true,
+	"server auth":       true,
+}
+
+// IsKubeletServing tells whether the request is for a kubelet serving certificate.
+func IsKubeletServing(r CertificateSigningRequest) bool {
+	return r.Spec.SignerName == kubeletServingSigner
+}
+
+// ValidateKubeletServing checks that the kubelet serving certificate request was created by the node itself
+// and that it only contains the addresses of the node.
+func ValidateKubeletServing(r CertificateSigningRequest, nodes map[string]Node) error {
+	if !strings.HasPrefix(r.Spec.Username, nodeUserPrefix) {
+		return errors.Errorf("requester %q is not a node", r.Spec.Username)
+	}
+	if !contains(r.Spec.Groups, nodesGroup) {
+		return errors.Errorf("requester %q is not in the %s group", r.Spec.Username, nodesGroup)
+	}
+	nodeName := strings.TrimPrefix(r.Spec.Username, nodeUserPrefix)
+	node, ok := nodes[nodeName]
+	if !ok {
+		return errors.Errorf("node %q not found", nodeName)
+	}
+
+	if !contains(r.Spec.Usages, "server auth") {
+		return errors.New("server auth usage is missing")
+	}
+	for _, u := range r.Spec.Usages {
+		if !allowedUsages[u] {
+			return errors.Errorf("usage %q is not allowed", u)
+		}
+	}
+
+	block, _ := pem.Decode(r.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return errors.New("request is not a PEM encoded certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse certificate request")
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return errors.Wrap(err, "invalid certificate request signature")
+	}
+
+	if csr.Subject.CommonName != r.Spec.Username {
+		return errors.Errorf("common name %q does not match requester %q", csr.Subject.CommonName, r.Spec.Username)
+	}
+	if len(csr.Subject.Organization) != 1 || csr.Subject.Organization[0] != nodesGroup {
+		return errors.Errorf("organization %v is not [%s]", csr.Subject.Organization, nodesGroup)
+	}
+	if len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return errors.New("email and URI subject alternative names are not allowed")
+	}
+	if len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 {
+		return errors.New("no DNS or IP subject alternative names")
+	}
+
+	var (
+		dnsNames []string
+		ips      []net.IP
+	)
+	for _, a := range node.Status.Addresses {
+		switch a.Type {
+		case "Hostname", "InternalDNS", "ExternalDNS":
+			dnsNames = append(dnsNames, strings.ToLower(a.Address))
+		case "InternalIP", "ExternalIP":
+			if ip := net.ParseIP(a.Address); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	for _, n := range csr.DNSNames {
+		if !contains(dnsNames, strings.ToLower(n)) {
+			return errors.Errorf("DNS name %q is not an address of node %q", n, nodeName)
+		}
+	}
+	for _, ip := range csr.IPAddresses {
+		if !containsIP(ips, ip) {
+			return errors.Errorf("IP address %q is not an address of node %q", ip, nodeName)
+		}
+	}
+
+	return nil
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, e := range ips {
+		if e.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
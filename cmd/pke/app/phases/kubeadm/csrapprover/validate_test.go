@@ -0,0 +1,152 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrapprover
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRequest(t *testing.T, cn string, org []string, dnsNames []string, ips []string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: cn, Organization: org},
+		DNSNames: dnsNames,
+	}
+	for _, ip := range ips {
+		tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(ip))
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestValidateKubeletServing(t *testing.T) {
+	var node Node
+	node.Metadata.Name = "worker-0"
+	node.Status.Addresses = []NodeAddress{
+		{Type: "InternalIP", Address: "192.168.64.12"},
+		{Type: "Hostname", Address: "worker-0"},
+	}
+	nodes := map[string]Node{"worker-0": node}
+
+	testCases := []struct {
+		name     string
+		username string
+		cn       string
+		org      []string
+		dnsNames []string
+		ips      []string
+		usages   []string
+		err      bool
+	}{
+		{
+			name:     "valid",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-0",
+			org:      []string{"system:nodes"},
+			dnsNames: []string{"worker-0"},
+			ips:      []string{"192.168.64.12"},
+		},
+		{
+			name:     "foreign IP",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-0",
+			org:      []string{"system:nodes"},
+			dnsNames: []string{"worker-0"},
+			ips:      []string{"192.168.64.11"},
+			err:      true,
+		},
+		{
+			name:     "foreign DNS name",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-0",
+			org:      []string{"system:nodes"},
+			dnsNames: []string{"kubernetes.default.svc"},
+			err:      true,
+		},
+		{
+			name:     "requester is another node",
+			username: "system:node:worker-1",
+			cn:       "system:node:worker-1",
+			org:      []string{"system:nodes"},
+			ips:      []string{"192.168.64.12"},
+			err:      true,
+		},
+		{
+			name:     "common name mismatch",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-1",
+			org:      []string{"system:nodes"},
+			ips:      []string{"192.168.64.12"},
+			err:      true,
+		},
+		{
+			name:     "requester is not a node",
+			username: "admin",
+			cn:       "admin",
+			org:      []string{"system:nodes"},
+			ips:      []string{"192.168.64.12"},
+			err:      true,
+		},
+		{
+			name:     "client auth usage",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-0",
+			org:      []string{"system:nodes"},
+			ips:      []string{"192.168.64.12"},
+			usages:   []string{"digital signature", "server auth", "client auth"},
+			err:      true,
+		},
+		{
+			name:     "no SANs",
+			username: "system:node:worker-0",
+			cn:       "system:node:worker-0",
+			org:      []string{"system:nodes"},
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		var r CertificateSigningRequest
+		r.Spec.SignerName = kubeletServingSigner
+		r.Spec.Username = tc.username
+		r.Spec.Groups = []string{"system:nodes", "system:authenticated"}
+		r.Spec.Usages = tc.usages
+		if r.Spec.Usages == nil {
+			r.Spec.Usages = []string{"digital signature", "key encipherment", "server auth"}
+		}
+		r.Spec.Request = testRequest(t, tc.cn, tc.org, tc.dnsNames, tc.ips)
+
+		require.True(t, IsKubeletServing(r), tc.name)
+		err := ValidateKubeletServing(r, nodes)
+		if tc.err {
+			require.Error(t, err, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	encryptionSecretLength = 32
+	encryptionKMSName      = "pke-kms"
+)
+
+// EncryptionOptions selects the provider used to encrypt Kubernetes secrets.
+type EncryptionOptions struct {
+	// Provider is one of aescbc, aesgcm, secretbox or kms. Defaults to aescbc.
+	Provider string
+	// KMSEndpoint is the unix socket of the local KMS plugin.
+	KMSEndpoint string
+}
+
+// EncryptionKey is a named key of an aescbc, aesgcm or secretbox provider.
+type EncryptionKey struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+// KMSConfig is the configuration of a kms provider.
+type KMSConfig struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Name       string `json:"name"`
+	Endpoint   string `json:"endpoint"`
+}
+
+// EncryptionProvider is a provider of the secrets resource, the first one encrypts, all of them decrypt.
+type EncryptionProvider struct {
+	Type string
+	Keys []EncryptionKey
+	KMS  *KMSConfig
+}
+
+// EncryptionConfig is the encryption configuration of the API server.
+type EncryptionConfig struct {
+	Kind       string
+	APIVersion string
+	Providers  []EncryptionProvider
+}
+
+// ValidateEncryptionProvider checks whether the provider is supported.
+func ValidateEncryptionProvider(provider string) error {
+	switch provider {
+	case constants.EncryptionProviderAESCBC,
+		constants.EncryptionProviderAESGCM,
+		constants.EncryptionProviderSecretbox,
+		constants.EncryptionProviderKMS:
+		return nil
+	}
+
+	return errors.Errorf("unsupported encryption provider %q, possible values: aescbc, aesgcm, secretbox, kms", provider)
+}
+
+// ValidateKMSEndpoint checks that the KMS plugin listens on a local unix socket.
+func ValidateKMSEndpoint(endpoint string) error {
+	if !strings.HasPrefix(endpoint, "unix:///") {
+		return errors.Errorf("KMS plugin endpoint %q must be a unix socket, e.g. unix:///var/run/kmsplugin/socket.sock", endpoint)
+	}
+	return nil
+}
+
+// KMSSocketDir returns the directory of the KMS plugin socket which has to be mounted into the API server.
+func KMSSocketDir(endpoint string) string {
+	path := strings.TrimPrefix(endpoint, "unix://")
+	return path[:strings.LastIndex(path, "/")]
+}
+
+// KMSAPIVersion returns the KMS plugin API version to use, v2 is enabled by default since Kubernetes 1.27.
+func KMSAPIVersion(kubernetesVersion string) (string, error) {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return "", err
+	}
+	if ver.LessThan(semver.MustParse("1.27.0")) {
+		return "v1", nil
+	}
+	return "v2", nil
+}
+
+// GenerateEncryptionSecret returns a new random base64 encoded 32 byte key.
+func GenerateEncryptionSecret() (string, error) {
+	var rnd = make([]byte, encryptionSecretLength)
+	_, err := rand.Read(rnd)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(rnd), nil
+}
+
+// WriteEncryptionProviderConfig creates configuration to encrypt Kubernetes secrets.
+// If encryptionSecret is not provided, but the configuration is already in place
+// secret will NOT be replaced with a newly generated one.
+// Provided secret will always overwrite existing configuration.
+// Pipeline sourced encryption secret uses this behaviour.
+func WriteEncryptionProviderConfig(out io.Writer, filename, kubernetesVersion, encryptionSecret string, opts EncryptionOptions) error {
+	if encryptionSecret == "" {
+		// check existing configuration
+		if _, err := os.Stat(filename); err == nil {
+			return nil
+		}
+	}
+
+	var (
+		kind       = "EncryptionConfiguration"
+		apiVersion = "apiserver.config.k8s.io/v1"
+	)
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return err
+	}
+	if ver.LessThan(semver.MustParse("1.13.0")) {
+		kind = "EncryptionConfig"
+		apiVersion = "v1"
+	}
+
+	provider := opts.Provider
+	if provider == "" {
+		provider = constants.EncryptionProviderAESCBC
+	}
+
+	var p EncryptionProvider
+	if provider == constants.EncryptionProviderKMS {
+		kmsAPIVersion, err := KMSAPIVersion(kubernetesVersion)
+		if err != nil {
+			return err
+		}
+		if kmsAPIVersion == "v1" {
+			// apiVersion of the kms provider is only recognized since Kubernetes 1.25
+			kmsAPIVersion = ""
+		}
+		p = EncryptionProvider{
+			Type: provider,
+			KMS: &KMSConfig{
+				APIVersion: kmsAPIVersion,
+				Name:       encryptionKMSName,
+				Endpoint:   opts.KMSEndpoint,
+			},
+		}
+	} else {
+		if encryptionSecret == "" {
+			// generate encryption secret
+			encryptionSecret, err = GenerateEncryptionSecret()
+			if err != nil {
+				return err
+			}
+		}
+		p = EncryptionProvider{
+			Type: provider,
+			Keys: []EncryptionKey{{Name: "key1", Secret: encryptionSecret}},
+		}
+	}
+
+	return WriteEncryptionConfig(filename, EncryptionConfig{
+		Kind:       kind,
+		APIVersion: apiVersion,
+		Providers:  []EncryptionProvider{p},
+	})
+}
+
+//go:generate templify -t ${GOTMPL} -p kubeadm -f encryptionProvider encryption_provider.yaml.tmpl
+
+// WriteEncryptionConfig writes the encryption configuration of the API server.
+func WriteEncryptionConfig(filename string, c EncryptionConfig) error {
+	tmpl, err := template.New("encryption-config").Parse(encryptionProviderTemplate())
+	if err != nil {
+		return err
+	}
+
+	return file.WriteTemplate(filename, tmpl, c)
+}
+
+// ReadEncryptionConfig parses the encryption configuration of the API server. The identity provider is omitted.
+func ReadEncryptionConfig(filename string) (EncryptionConfig, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return EncryptionConfig{}, err
+	}
+
+	return ParseEncryptionConfig(b)
+}
+
+// ParseEncryptionConfig parses the encryption configuration of the API server. The identity provider is omitted.
+func ParseEncryptionConfig(b []byte) (EncryptionConfig, error) {
+	type keys struct {
+		Keys []EncryptionKey `json:"keys"`
+	}
+	var conf struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+		Resources  []struct {
+			Resources []string `json:"resources"`
+			Providers []struct {
+				AESCBC    *keys      `json:"aescbc"`
+				AESGCM    *keys      `json:"aesgcm"`
+				Secretbox *keys      `json:"secretbox"`
+				KMS       *KMSConfig `json:"kms"`
+			} `json:"providers"`
+		} `json:"resources"`
+	}
+	if err := yaml.Unmarshal(b, &conf); err != nil {
+		return EncryptionConfig{}, errors.Wrap(err, "failed to parse encryption configuration")
+	}
+
+	c := EncryptionConfig{
+		Kind:       conf.Kind,
+		APIVersion: conf.APIVersion,
+	}
+	for _, r := range conf.Resources {
+		for _, p := range r.Providers {
+			switch {
+			case p.AESCBC != nil:
+				c.Providers = append(c.Providers, EncryptionProvider{Type: constants.EncryptionProviderAESCBC, Keys: p.AESCBC.Keys})
+			case p.AESGCM != nil:
+				c.Providers = append(c.Providers, EncryptionProvider{Type: constants.EncryptionProviderAESGCM, Keys: p.AESGCM.Keys})
+			case p.Secretbox != nil:
+				c.Providers = append(c.Providers, EncryptionProvider{Type: constants.EncryptionProviderSecretbox, Keys: p.Secretbox.Keys})
+			case p.KMS != nil:
+				c.Providers = append(c.Providers, EncryptionProvider{Type: constants.EncryptionProviderKMS, KMS: p.KMS})
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// NextKeyName returns a key name which is not used by any of the providers.
+func (c EncryptionConfig) NextKeyName() string {
+	used := make(map[string]bool)
+	for _, p := range c.Providers {
+		for _, k := range p.Keys {
+			used[k.Name] = true
+		}
+	}
+	for i := 1; ; i++ {
+		name := "key" + strconv.Itoa(i)
+		if !used[name] {
+			return name
+		}
+	}
+}
+
+// Key returns the named key of the provider.
+func (c EncryptionConfig) Key(provider, name string) (EncryptionKey, bool) {
+	for _, p := range c.Providers {
+		if p.Type != provider {
+			continue
+		}
+		for _, k := range p.Keys {
+			if k.Name == name {
+				return k, true
+			}
+		}
+	}
+	return EncryptionKey{}, false
+}
+
+// AddKey adds the key as the last key of the provider, creating the provider after the existing ones if needed.
+// The key is only used for decryption until it gets promoted.
+func (c *EncryptionConfig) AddKey(provider string, key EncryptionKey) {
+	for i, p := range c.Providers {
+		if p.Type != provider {
+			continue
+		}
+		for _, k := range p.Keys {
+			if k.Name == key.Name {
+				return
+			}
+		}
+		c.Providers[i].Keys = append(c.Providers[i].Keys, key)
+		return
+	}
+
+	c.Providers = append(c.Providers, EncryptionProvider{Type: provider, Keys: []EncryptionKey{key}})
+}
+
+// PromoteKey makes the key of the provider the one used for encryption.
+func (c *EncryptionConfig) PromoteKey(provider, name string) {
+	for i, p := range c.Providers {
+		if p.Type != provider {
+			continue
+		}
+		for j, k := range p.Keys {
+			if k.Name != name {
+				continue
+			}
+			keys := append([]EncryptionKey{k}, p.Keys[:j]...)
+			p.Keys = append(keys, p.Keys[j+1:]...)
+
+			providers := append([]EncryptionProvider{p}, c.Providers[:i]...)
+			c.Providers = append(providers, c.Providers[i+1:]...)
+			return
+		}
+	}
+}
+
+// PruneKeys removes every key except the given one of the provider. KMS providers are kept.
+func (c *EncryptionConfig) PruneKeys(provider, name string) {
+	var providers []EncryptionProvider
+	for _, p := range c.Providers {
+		switch {
+		case p.Type == constants.EncryptionProviderKMS:
+			providers = append(providers, p)
+		case p.Type == provider:
+			var keys []EncryptionKey
+			for _, k := range p.Keys {
+				if k.Name == name {
+					keys = append(keys, k)
+				}
+			}
+			if len(keys) > 0 {
+				p.Keys = keys
+				providers = append(providers, p)
+			}
+		}
+	}
+	c.Providers = providers
+}
@@ -22,10 +22,24 @@ func encryptionProviderTemplate() string {
 		"  - resources:\n" +
 		"    - secrets\n" +
 		"    providers:\n" +
-		"    - aescbc:\n" +
+		"{{- range .Providers }}\n" +
+		"{{- if .KMS }}\n" +
+		"    - kms:\n" +
+		"        {{- if .KMS.APIVersion }}\n" +
+		"        apiVersion: {{ .KMS.APIVersion }}\n" +
+		"        {{- end }}\n" +
+		"        name: {{ .KMS.Name }}\n" +
+		"        endpoint: {{ .KMS.Endpoint }}\n" +
+		"        timeout: 3s\n" +
+		"{{- else }}\n" +
+		"    - {{ .Type }}:\n" +
 		"        keys:\n" +
-		"        - name: key1\n" +
-		"          secret: \"{{ .EncryptionSecret }}\"\n" +
+		"{{- range .Keys }}\n" +
+		"        - name: {{ .Name }}\n" +
+		"          secret: \"{{ .Secret }}\"\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
 		"    - identity: {}\n" +
 		""
 	return tmpl
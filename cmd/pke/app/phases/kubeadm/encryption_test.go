@@ -0,0 +1,109 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEncryptionProviderConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "aesgcm.yaml")
+	err = WriteEncryptionProviderConfig(ioutil.Discard, filename, "1.22.6", "c2VjcmV0", EncryptionOptions{Provider: "aesgcm"})
+	require.NoError(t, err)
+
+	c, err := ReadEncryptionConfig(filename)
+	require.NoError(t, err)
+	require.Equal(t, EncryptionConfig{
+		Kind:       "EncryptionConfiguration",
+		APIVersion: "apiserver.config.k8s.io/v1",
+		Providers: []EncryptionProvider{
+			{Type: "aesgcm", Keys: []EncryptionKey{{Name: "key1", Secret: "c2VjcmV0"}}},
+		},
+	}, c)
+
+	filename = filepath.Join(dir, "kms.yaml")
+	err = WriteEncryptionProviderConfig(ioutil.Discard, filename, "1.27.1", "", EncryptionOptions{Provider: "kms", KMSEndpoint: "unix:///var/run/kmsplugin/socket.sock"})
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, `kind: EncryptionConfiguration
+apiVersion: apiserver.config.k8s.io/v1
+resources:
+  - resources:
+    - secrets
+    providers:
+    - kms:
+        apiVersion: v2
+        name: pke-kms
+        endpoint: unix:///var/run/kmsplugin/socket.sock
+        timeout: 3s
+    - identity: {}
+`, string(b))
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	c := EncryptionConfig{
+		Providers: []EncryptionProvider{
+			{Type: "aescbc", Keys: []EncryptionKey{{Name: "key1", Secret: "a"}}},
+		},
+	}
+	require.Equal(t, "key2", c.NextKeyName())
+
+	key := EncryptionKey{Name: "key2", Secret: "b"}
+
+	c.AddKey("aescbc", key)
+	c.AddKey("aescbc", key)
+	require.Equal(t, []EncryptionProvider{
+		{Type: "aescbc", Keys: []EncryptionKey{{Name: "key1", Secret: "a"}, key}},
+	}, c.Providers)
+
+	k, ok := c.Key("aescbc", "key2")
+	require.True(t, ok)
+	require.Equal(t, key, k)
+	_, ok = c.Key("secretbox", "key2")
+	require.False(t, ok)
+
+	c.PromoteKey("aescbc", "key2")
+	require.Equal(t, []EncryptionProvider{
+		{Type: "aescbc", Keys: []EncryptionKey{key, {Name: "key1", Secret: "a"}}},
+	}, c.Providers)
+
+	c.PruneKeys("aescbc", "key2")
+	require.Equal(t, []EncryptionProvider{
+		{Type: "aescbc", Keys: []EncryptionKey{key}},
+	}, c.Providers)
+
+	// switching provider
+	key = EncryptionKey{Name: c.NextKeyName(), Secret: "c"}
+	c.AddKey("secretbox", key)
+	require.Equal(t, "aescbc", c.Providers[0].Type)
+	c.PromoteKey("secretbox", key.Name)
+	require.Equal(t, "secretbox", c.Providers[0].Type)
+	require.Equal(t, "aescbc", c.Providers[1].Type)
+	c.PruneKeys("secretbox", key.Name)
+	require.Equal(t, []EncryptionProvider{
+		{Type: "secretbox", Keys: []EncryptionKey{{Name: "key1", Secret: "c"}}},
+	}, c.Providers)
+}
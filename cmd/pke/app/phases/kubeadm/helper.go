@@ -18,9 +18,15 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strings"
 )
 
 func SplitHostPort(hostport, defaultPort string) (host, port string, err error) {
+	// IPv6 address without port, e.g. 2001:db8::1 or [2001:db8::1]
+	if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")); ip != nil && ip.To4() == nil {
+		return ip.String(), defaultPort, nil
+	}
+
 	host, port, err = net.SplitHostPort(hostport)
 	if aerr, ok := err.(*net.AddrError); ok {
 		if aerr.Err == "missing port in address" {
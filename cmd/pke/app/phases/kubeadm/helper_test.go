@@ -46,3 +46,29 @@ func TestKubeReservedMemory(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		hostport string
+		host     string
+		port     string
+	}{
+		{"192.168.64.11", "192.168.64.11", "6443"},
+		{"192.168.64.11:443", "192.168.64.11", "443"},
+		{"api.example.com", "api.example.com", "6443"},
+		{"2001:db8::11", "2001:db8::11", "6443"},
+		{"[2001:db8::11]", "2001:db8::11", "6443"},
+		{"[2001:db8::11]:443", "2001:db8::11", "443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hostport, func(t *testing.T) {
+			host, port, err := SplitHostPort(tt.hostport, "6443")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if host != tt.host || port != tt.port {
+				t.Errorf("SplitHostPort() = %s, %s, want %s, %s", host, port, tt.host, tt.port)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// clockSkew is subtracted from the start of the validity of issued certificates.
+const clockSkew = 5 * time.Minute
+
+// NewClientKey generates the private key of a client certificate.
+func NewClientKey() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate private key")
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal private key")
+	}
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// CertificateRequest creates a PEM encoded certificate request for the user and groups.
+func CertificateRequest(key crypto.Signer, user string, groups []string) ([]byte, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: user, Organization: groups},
+	}, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create certificate request")
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// Sign issues a client certificate for the user and groups signed by the CA, valid for ttl but not longer than the CA.
+func Sign(caCert *x509.Certificate, caKey crypto.Signer, pub crypto.PublicKey, user string, groups []string, ttl time.Duration, now time.Time) ([]byte, *x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate serial number")
+	}
+
+	notAfter := now.Add(ttl)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: user, Organization: groups},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, pub, caKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to sign certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse signed certificate")
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, nil
+}
+
+// LoadCA reads the certificate and private key of the cluster CA.
+func LoadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	b, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := ParseCertificate(b)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse %q", certFile)
+	}
+
+	b, err = ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKey(b)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse %q", keyFile)
+	}
+
+	return cert, key, nil
+}
+
+// ParseCertificate parses the first PEM encoded certificate.
+func ParseCertificate(b []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(b []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, errors.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// Serial formats the serial number of a certificate the way it is recorded.
+func Serial(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", cert.SerialNumber)
+}
+
+// ValidateIdentity refuses identities which can not be expressed in a kubeconfig or which belong to the system.
+// Members of system groups, e.g. system:masters, can not be revoked through RBAC.
+func ValidateIdentity(user string, groups []string) error {
+	if user == "" {
+		return errors.New("user must not be empty")
+	}
+	for _, name := range append([]string{user}, groups...) {
+		if name == "" {
+			return errors.New("group must not be empty")
+		}
+		if strings.ContainsAny(name, "\"\\\n\r,") {
+			return errors.Errorf("%q contains invalid characters", name)
+		}
+		if strings.HasPrefix(name, "system:") {
+			return errors.Errorf("%q is reserved for Kubernetes components, bind the required roles with RBAC instead", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,342 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package create
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "create"
+	short = "Issue a client certificate and write a kubeconfig using it"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+	caCertFile = "/etc/kubernetes/pki/ca.crt"
+	caKeyFile  = "/etc/kubernetes/pki/ca.key"
+
+	apiServerClientSigner = "kubernetes.io/kube-apiserver-client"
+	// minimum expirationSeconds accepted by the CertificateSigningRequest API
+	minCSRExpiration = 10 * time.Minute
+	csrTimeout       = time.Minute
+	// maximum length of the user name part of certificate signing request names
+	maxNameLength = 200
+	// maximum length of object names
+	maxObjectNameLength = 253
+)
+
+var _ phases.Runnable = (*Create)(nil)
+
+type Create struct {
+	user        string
+	groups      []string
+	ttl         time.Duration
+	csrAPI      bool
+	clusterRole string
+	server      string
+	outputFile  string
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Create{})
+}
+
+func (*Create) Use() string {
+	return use
+}
+
+func (*Create) Short() string {
+	return short
+}
+
+func (*Create) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagKubeconfigUser, "", "User name of the client certificate")
+	flags.StringSlice(constants.FlagKubeconfigGroups, []string{}, "Groups of the client certificate")
+	flags.Duration(constants.FlagKubeconfigTTL, 24*time.Hour, "Validity of the client certificate")
+	flags.Bool(constants.FlagKubeconfigCSRAPI, false, "Sign the client certificate through the CertificateSigningRequest API instead of the local CA key")
+	flags.String(constants.FlagKubeconfigClusterRole, "", "Bind the cluster role to the user, the binding is removed when the user's last certificate is revoked")
+	flags.String(constants.FlagAPIServerHostPort, "", "Kubernetes API Server URL, defaults to the server of the admin kubeconfig")
+	flags.String(constants.FlagOutputFile, "", "Write the kubeconfig to the file instead of the standard output")
+}
+
+func (c *Create) Validate(cmd *cobra.Command) error {
+	var err error
+	c.user, err = cmd.Flags().GetString(constants.FlagKubeconfigUser)
+	if err != nil {
+		return err
+	}
+	c.groups, err = cmd.Flags().GetStringSlice(constants.FlagKubeconfigGroups)
+	if err != nil {
+		return err
+	}
+	c.ttl, err = cmd.Flags().GetDuration(constants.FlagKubeconfigTTL)
+	if err != nil {
+		return err
+	}
+	c.csrAPI, err = cmd.Flags().GetBool(constants.FlagKubeconfigCSRAPI)
+	if err != nil {
+		return err
+	}
+	c.clusterRole, err = cmd.Flags().GetString(constants.FlagKubeconfigClusterRole)
+	if err != nil {
+		return err
+	}
+	c.server, err = cmd.Flags().GetString(constants.FlagAPIServerHostPort)
+	if err != nil {
+		return err
+	}
+	c.outputFile, err = cmd.Flags().GetString(constants.FlagOutputFile)
+	if err != nil {
+		return err
+	}
+
+	if err := kubeconfig.ValidateIdentity(c.user, c.groups); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s, --%s: %v", constants.FlagKubeconfigUser, constants.FlagKubeconfigGroups, err)
+	}
+	if c.ttl <= 0 {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must be positive", constants.FlagKubeconfigTTL)
+	}
+	if c.csrAPI && c.ttl < minCSRExpiration {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must be at least %s with --%s", constants.FlagKubeconfigTTL, minCSRExpiration, constants.FlagKubeconfigCSRAPI)
+	}
+
+	return nil
+}
+
+func (c *Create) Run(out io.Writer) error {
+	cluster, err := kubeconfig.AdminCluster()
+	if err != nil {
+		return errors.Wrap(err, "failed to read admin kubeconfig")
+	}
+	if c.server != "" {
+		cluster.Server = c.server
+	}
+
+	key, keyPEM, err := kubeconfig.NewClientKey()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var (
+		certPEM []byte
+		signer  string
+	)
+	if c.csrAPI {
+		signer = kubeconfig.SignerCSR
+		certPEM, err = c.signCSR(key)
+	} else {
+		signer = kubeconfig.SignerLocal
+		certPEM, err = c.signLocal(key, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	cert, err := kubeconfig.ParseCertificate(certPEM)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse issued certificate")
+	}
+	r := kubeconfig.Record{
+		Serial:  kubeconfig.Serial(cert),
+		User:    c.user,
+		Groups:  c.groups,
+		Signer:  signer,
+		Issued:  now.UTC().Truncate(time.Second),
+		Expires: cert.NotAfter.UTC(),
+	}
+	if err := kubeconfig.SaveRecord(r); err != nil {
+		return err
+	}
+	if c.clusterRole != "" {
+		if err := c.bindClusterRole(); err != nil {
+			return err
+		}
+	}
+
+	w := out
+	if c.outputFile != "" {
+		f, err := os.OpenFile(c.outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := kubeconfig.Write(w, cluster, c.user, certPEM, keyPEM); err != nil {
+		return errors.Wrap(err, "failed to write kubeconfig")
+	}
+
+	// the standard output is reserved for the kubeconfig
+	if c.outputFile != "" {
+		_, _ = fmt.Fprintf(out, "[%s] kubeconfig of %q written to %q, certificate serial: %s, expires: %s\n", use, c.user, c.outputFile, r.Serial, r.Expires.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (c *Create) signLocal(key crypto.Signer, now time.Time) ([]byte, error) {
+	caCert, caKey, err := kubeconfig.LoadCA(caCertFile, caKeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load cluster CA, use --%s on nodes without the CA key", constants.FlagKubeconfigCSRAPI)
+	}
+
+	certPEM, _, err := kubeconfig.Sign(caCert, caKey, key.Public(), c.user, c.groups, c.ttl, now)
+	return certPEM, err
+}
+
+// signCSR requests, approves and waits for a certificate signed by the kube-apiserver-client signer.
+// The requested expiration is honored since Kubernetes 1.22, earlier versions use the cluster signing duration.
+func (c *Create) signCSR(key crypto.Signer) ([]byte, error) {
+	req, err := kubeconfig.CertificateRequest(key, c.user, c.groups)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	name := "pke-kubeconfig-" + sanitize(c.user) + "-" + hex.EncodeToString(suffix)
+
+	csr := map[string]interface{}{
+		"apiVersion": "certificates.k8s.io/v1",
+		"kind":       "CertificateSigningRequest",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"request":           base64.StdEncoding.EncodeToString(req),
+			"signerName":        apiServerClientSigner,
+			"expirationSeconds": int64(c.ttl.Seconds()),
+			"usages":            []string{"digital signature", "key encipherment", "client auth"},
+		},
+	}
+	manifest, err := json.Marshal(csr)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "create", "-f", "-")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = bytes.NewReader(manifest)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.Wrapf(err, "failed to create certificate signing request: %s", o)
+	}
+
+	// kubectl certificate approve <name>
+	cmd = runner.Cmd(ioutil.Discard, cmdKubectl, "certificate", "approve", name)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.Wrapf(err, "failed to approve certificate signing request %q: %s", name, o)
+	}
+
+	timeout := time.After(csrTimeout)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		// kubectl get csr <name> -o jsonpath={.status.certificate}
+		cmd = runner.Cmd(ioutil.Discard, cmdKubectl, "get", "csr", name, "-o", "jsonpath={.status.certificate}")
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		o, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get certificate signing request %q", name)
+		}
+		if s := strings.TrimSpace(string(o)); s != "" {
+			return base64.StdEncoding.DecodeString(s)
+		}
+
+		select {
+		case <-tick.C:
+		case <-timeout:
+			return nil, errors.Errorf("certificate signing request %q was not signed in %s", name, csrTimeout)
+		}
+	}
+}
+
+// bindClusterRole binds the cluster role to the user with a binding labelled for removal on revocation.
+func (c *Create) bindClusterRole() error {
+	manifest, err := json.Marshal(clusterRoleBinding(c.user, c.clusterRole))
+	if err != nil {
+		return err
+	}
+
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "apply", "-f", "-")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = bytes.NewReader(manifest)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to bind cluster role %q: %s", c.clusterRole, o)
+	}
+	return nil
+}
+
+func clusterRoleBinding(user, clusterRole string) map[string]interface{} {
+	name := "pke-kubeconfig-" + sanitize(user) + "-" + sanitize(clusterRole)
+	if len(name) > maxObjectNameLength {
+		name = strings.TrimRight(name[:maxObjectNameLength], "-.")
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRoleBinding",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]string{
+				kubeconfig.BindingLabel: kubeconfig.BindingLabelValue,
+			},
+		},
+		"roleRef": map[string]string{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "ClusterRole",
+			"name":     clusterRole,
+		},
+		"subjects": []map[string]string{
+			{"apiGroup": "rbac.authorization.k8s.io", "kind": "User", "name": user},
+		},
+	}
+}
+
+// sanitize makes the user name usable in an object name.
+func sanitize(user string) string {
+	s := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(user))
+	if len(s) > maxNameLength {
+		s = s[:maxNameLength]
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/ghodss/yaml"
+)
+
+// Cluster is the cluster access information of a kubeconfig.
+type Cluster struct {
+	Name   string
+	Server string
+	// CAData is the PEM encoded CA certificate
+	CAData []byte
+}
+
+// AdminCluster returns the cluster of the admin kubeconfig, its server is the configured API endpoint.
+func AdminCluster() (Cluster, error) {
+	b, err := ioutil.ReadFile(kubeConfig)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	return parseCluster(b)
+}
+
+func parseCluster(b []byte) (Cluster, error) {
+	var conf struct {
+		Clusters []struct {
+			Name    string `json:"name"`
+			Cluster struct {
+				Server                   string `json:"server"`
+				CertificateAuthorityData string `json:"certificate-authority-data"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+	}
+	if err := yaml.Unmarshal(b, &conf); err != nil {
+		return Cluster{}, errors.Wrap(err, "failed to parse kubeconfig")
+	}
+	if len(conf.Clusters) == 0 {
+		return Cluster{}, errors.New("no cluster found in kubeconfig")
+	}
+
+	c := conf.Clusters[0]
+	ca, err := base64.StdEncoding.DecodeString(c.Cluster.CertificateAuthorityData)
+	if err != nil {
+		return Cluster{}, errors.Wrap(err, "failed to decode certificate authority data")
+	}
+
+	return Cluster{
+		Name:   c.Name,
+		Server: c.Cluster.Server,
+		CAData: ca,
+	}, nil
+}
+
+//go:generate templify -t ${GOTMPL} -p kubeconfig -f kubeconfig kubeconfig.yaml.tmpl
+
+// Write writes a kubeconfig authenticating the user with the client certificate.
+func Write(w io.Writer, c Cluster, user string, certPEM, keyPEM []byte) error {
+	tmpl, err := template.New("kubeconfig").Parse(kubeconfigTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		ClusterName    string
+		Server         string
+		CAData         string
+		User           string
+		ClientCertData string
+		ClientKeyData  string
+	}
+
+	d := data{
+		ClusterName:    c.Name,
+		Server:         c.Server,
+		CAData:         base64.StdEncoding.EncodeToString(c.CAData),
+		User:           user,
+		ClientCertData: base64.StdEncoding.EncodeToString(certPEM),
+		ClientKeyData:  base64.StdEncoding.EncodeToString(keyPEM),
+	}
+
+	return tmpl.Execute(w, d)
+}
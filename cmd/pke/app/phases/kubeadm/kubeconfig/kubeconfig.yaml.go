@@ -0,0 +1,39 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+// kubeconfigTemplate is a generated function returning the template as a string.
+func kubeconfigTemplate() string {
+	var tmpl = "apiVersion: v1\n" +
+		"kind: Config\n" +
+		"clusters:\n" +
+		"- name: \"{{ .ClusterName }}\"\n" +
+		"  cluster:\n" +
+		"    server: \"{{ .Server }}\"\n" +
+		"    certificate-authority-data: {{ .CAData }}\n" +
+		"users:\n" +
+		"- name: \"{{ .User }}\"\n" +
+		"  user:\n" +
+		"    client-certificate-data: {{ .ClientCertData }}\n" +
+		"    client-key-data: {{ .ClientKeyData }}\n" +
+		"contexts:\n" +
+		"- name: \"{{ .User }}@{{ .ClusterName }}\"\n" +
+		"  context:\n" +
+		"    cluster: \"{{ .ClusterName }}\"\n" +
+		"    user: \"{{ .User }}\"\n" +
+		"current-context: \"{{ .User }}@{{ .ClusterName }}\"\n" +
+		""
+	return tmpl
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	c := Cluster{
+		Name:   "my-cluster",
+		Server: "https://192.168.64.11:6443",
+		CAData: []byte("-----BEGIN CERTIFICATE-----\n"),
+	}
+
+	var b bytes.Buffer
+	err := Write(&b, c, "alice", []byte("cert"), []byte("key"))
+	require.NoError(t, err)
+
+	parsed, err := parseCluster(b.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, c, parsed)
+
+	var conf struct {
+		CurrentContext string `json:"current-context"`
+		Users          []struct {
+			Name string `json:"name"`
+			User struct {
+				ClientCertificateData string `json:"client-certificate-data"`
+				ClientKeyData         string `json:"client-key-data"`
+			} `json:"user"`
+		} `json:"users"`
+	}
+	require.NoError(t, yaml.Unmarshal(b.Bytes(), &conf))
+	require.Equal(t, "alice@my-cluster", conf.CurrentContext)
+	require.Len(t, conf.Users, 1)
+	require.Equal(t, "alice", conf.Users[0].Name)
+	require.Equal(t, "Y2VydA==", conf.Users[0].User.ClientCertificateData)
+	require.Equal(t, "a2V5", conf.Users[0].User.ClientKeyData)
+}
+
+func TestRecords(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	records, err := parseRecords(map[string]string{
+		"b2": `{"serial":"b2","user":"bob","signer":"csr","issued":"2022-02-01T00:00:00Z","expires":"2022-02-02T00:00:00Z"}`,
+		"a1": `{"serial":"a1","user":"alice","groups":["dev"],"signer":"local","issued":"2022-03-01T00:00:00Z","expires":"2022-03-02T00:00:00Z"}`,
+		"c3": `{"serial":"c3","user":"alice","signer":"local","issued":"2022-03-01T00:00:00Z","expires":"2022-03-02T00:00:00Z","revoked":"2022-03-01T06:00:00Z"}`,
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	var got []string
+	for _, r := range records {
+		got = append(got, r.Serial+" "+r.Status(now))
+	}
+	require.Equal(t, []string{"b2 expired", "a1 valid", "c3 revoked"}, got)
+
+	_, err = parseRecords(map[string]string{"x": "{"})
+	require.Error(t, err)
+}
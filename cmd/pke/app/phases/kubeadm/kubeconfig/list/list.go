@@ -0,0 +1,114 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "list"
+	short = "List issued client certificates"
+)
+
+var _ phases.Runnable = (*List)(nil)
+
+type List struct {
+	o string
+}
+
+// Output is the list of issued client certificates.
+type Output struct {
+	Certificates []Certificate `json:"certificates"`
+}
+
+// Certificate is an issued client certificate with its current status.
+type Certificate struct {
+	kubeconfig.Record
+	Status string `json:"status"`
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&List{})
+}
+
+func (*List) Use() string {
+	return use
+}
+
+func (*List) Short() string {
+	return short
+}
+
+func (*List) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringP(constants.FlagOutput, constants.FlagOutputShort, "", "Output format; available options are 'yaml' and 'json'")
+}
+
+func (l *List) Validate(cmd *cobra.Command) error {
+	var err error
+	l.o, err = cmd.Flags().GetString(constants.FlagOutput)
+
+	return err
+}
+
+func (l *List) Run(out io.Writer) error {
+	records, err := kubeconfig.LoadRecords()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	list := Output{Certificates: []Certificate{}}
+	for _, r := range records {
+		list.Certificates = append(list.Certificates, Certificate{Record: r, Status: r.Status(now)})
+	}
+
+	switch l.o {
+	default:
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Serial\tUser\tGroups\tSigner\tIssued\tExpires\tStatus\n")
+		for _, row := range list.Certificates {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.Serial, row.User, strings.Join(row.Groups, ","), row.Signer, row.Issued.Format(time.RFC3339), row.Expires.Format(time.RFC3339), row.Status)
+		}
+		_ = tw.Flush()
+
+	case "yaml":
+		y, err := yaml.Marshal(&list)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(y))
+	case "json":
+		y, err := json.MarshalIndent(&list, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(y))
+	}
+
+	return nil
+}
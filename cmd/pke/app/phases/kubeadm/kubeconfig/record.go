@@ -0,0 +1,155 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+
+	recordNamespace = "kube-system"
+	recordConfigMap = "pke-kubeconfigs"
+
+	SignerLocal = "local"
+	SignerCSR   = "csr"
+
+	StatusValid   = "valid"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+
+	// BindingLabel marks the RBAC bindings created for issued certificates, only these are changed on revocation.
+	BindingLabel      = "kubeconfig.banzaicloud.io/managed-by"
+	BindingLabelValue = "pke"
+)
+
+// Record describes an issued client certificate. Records are kept in a ConfigMap keyed by serial number.
+type Record struct {
+	Serial  string     `json:"serial"`
+	User    string     `json:"user"`
+	Groups  []string   `json:"groups,omitempty"`
+	Signer  string     `json:"signer"`
+	Issued  time.Time  `json:"issued"`
+	Expires time.Time  `json:"expires"`
+	Revoked *time.Time `json:"revoked,omitempty"`
+}
+
+// Status tells whether the certificate is valid, expired or revoked.
+func (r Record) Status(now time.Time) string {
+	switch {
+	case r.Revoked != nil:
+		return StatusRevoked
+	case !now.Before(r.Expires):
+		return StatusExpired
+	default:
+		return StatusValid
+	}
+}
+
+// LoadRecords returns the issued client certificates ordered by issue time.
+func LoadRecords() ([]Record, error) {
+	// kubectl get configmap -n kube-system pke-kubeconfigs --ignore-not-found -o json
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "configmap", "-n", recordNamespace, recordConfigMap, "--ignore-not-found", "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get issued certificates")
+	}
+	if len(bytes.TrimSpace(o)) == 0 {
+		return nil, nil
+	}
+
+	var cm struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(o, &cm); err != nil {
+		return nil, errors.Wrap(err, "failed to parse issued certificates")
+	}
+
+	return parseRecords(cm.Data)
+}
+
+func parseRecords(data map[string]string) ([]Record, error) {
+	records := make([]Record, 0, len(data))
+	for serial, v := range data {
+		var r Record
+		if err := json.Unmarshal([]byte(v), &r); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse record of certificate %s", serial)
+		}
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Issued.Equal(records[j].Issued) {
+			return records[i].Serial < records[j].Serial
+		}
+		return records[i].Issued.Before(records[j].Issued)
+	})
+
+	return records, nil
+}
+
+// SaveRecord adds or updates the record of an issued client certificate.
+func SaveRecord(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	cm := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      recordConfigMap,
+			"namespace": recordNamespace,
+		},
+	}
+	manifest, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+
+	// make sure the ConfigMap exists, then merge the record into it leaving the others untouched
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "apply", "-f", "-")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = bytes.NewReader(manifest)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to create %s ConfigMap: %s", recordConfigMap, o)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{r.Serial: string(b)},
+	})
+	if err != nil {
+		return err
+	}
+	cmd = runner.Cmd(ioutil.Discard, cmdKubectl, "patch", "configmap", "-n", recordNamespace, recordConfigMap, "--type", "merge", "-p", string(patch))
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to record certificate %s: %s", r.Serial, o)
+	}
+
+	return nil
+}
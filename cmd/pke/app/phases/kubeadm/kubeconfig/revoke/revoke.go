@@ -0,0 +1,228 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revoke
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "revoke"
+	short = "Mark an issued client certificate revoked and remove its user from the RBAC bindings created by pke, the certificate remains valid until it expires"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+)
+
+var _ phases.Runnable = (*Revoke)(nil)
+
+type Revoke struct {
+	serial string
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Revoke{})
+}
+
+func (*Revoke) Use() string {
+	return use
+}
+
+func (*Revoke) Short() string {
+	return short
+}
+
+func (*Revoke) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagKubeconfigSerial, "", "Serial number of the client certificate, see pke kubeconfig list")
+}
+
+func (r *Revoke) Validate(cmd *cobra.Command) error {
+	var err error
+	r.serial, err = cmd.Flags().GetString(constants.FlagKubeconfigSerial)
+	if err != nil {
+		return err
+	}
+	r.serial = strings.ToLower(strings.TrimPrefix(strings.ReplaceAll(r.serial, ":", ""), "0x"))
+	if r.serial == "" {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be empty", constants.FlagKubeconfigSerial)
+	}
+
+	return nil
+}
+
+// Run marks the certificate revoked and, unless the user holds other valid certificates, removes the user from the RBAC bindings
+// created by pke kubeconfig create. The API server does not check certificate revocation, the certificate keeps authenticating
+// until it expires, with the permissions of its groups and of bindings not created by pke.
+func (r *Revoke) Run(out io.Writer) error {
+	records, err := kubeconfig.LoadRecords()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var (
+		record *kubeconfig.Record
+		others int
+	)
+	for i := range records {
+		if records[i].Serial == r.serial {
+			record = &records[i]
+		}
+	}
+	if record == nil {
+		return errors.Errorf("no issued certificate with serial %s", r.serial)
+	}
+	if record.Revoked != nil {
+		_, _ = fmt.Fprintf(out, "[%s] certificate %s has already been revoked at %s\n", use, r.serial, record.Revoked.Format(time.RFC3339))
+		return nil
+	}
+	for _, o := range records {
+		if o.Serial != r.serial && o.User == record.User && o.Status(now) == kubeconfig.StatusValid {
+			others++
+		}
+	}
+
+	expired := record.Status(now) == kubeconfig.StatusExpired
+	revoked := now.UTC().Truncate(time.Second)
+	record.Revoked = &revoked
+	if err := kubeconfig.SaveRecord(*record); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] certificate %s of %q revoked\n", use, r.serial, record.User)
+
+	if others > 0 {
+		_, _ = fmt.Fprintf(out, "[%s] %q holds %d other valid certificate(s), keeping RBAC bindings\n", use, record.User, others)
+	} else if err := removeUserBindings(out, record.User); err != nil {
+		return err
+	}
+
+	if !expired {
+		_, _ = fmt.Fprintf(out, "[%s] warning: the API server does not check certificate revocation, the certificate remains valid until it expires at %s\n", use, record.Expires.Format(time.RFC3339))
+		if len(record.Groups) > 0 {
+			_, _ = fmt.Fprintf(out, "[%s] warning: permissions of groups %s and of RBAC bindings not created by pke remain until then\n", use, strings.Join(record.Groups, ","))
+		}
+	}
+
+	return nil
+}
+
+// binding is the subset of a RoleBinding or ClusterRoleBinding used for revocation.
+type binding struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Subjects []struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"subjects"`
+}
+
+// change removes the user from a binding, deleting the binding if the user is its only subject.
+type change struct {
+	binding binding
+	delete  bool
+	// indices of the user in the subjects, in descending order
+	indices []int
+}
+
+// userBindingChanges returns the changes of the bindings created by pke kubeconfig create, other bindings are left intact.
+func userBindingChanges(bindings []binding, user string) []change {
+	var changes []change
+	for _, b := range bindings {
+		if b.Metadata.Labels[kubeconfig.BindingLabel] != kubeconfig.BindingLabelValue {
+			continue
+		}
+		var indices []int
+		for i := len(b.Subjects) - 1; i >= 0; i-- {
+			if b.Subjects[i].Kind == "User" && b.Subjects[i].Name == user {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) == 0 {
+			continue
+		}
+		changes = append(changes, change{
+			binding: b,
+			delete:  len(indices) == len(b.Subjects),
+			indices: indices,
+		})
+	}
+	return changes
+}
+
+func removeUserBindings(out io.Writer, user string) error {
+	// kubectl get clusterrolebindings,rolebindings --all-namespaces -l kubeconfig.banzaicloud.io/managed-by=pke -o json
+	selector := kubeconfig.BindingLabel + "=" + kubeconfig.BindingLabelValue
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "clusterrolebindings,rolebindings", "--all-namespaces", "-l", selector, "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list RBAC bindings")
+	}
+	var list struct {
+		Items []binding `json:"items"`
+	}
+	if err := json.Unmarshal(o, &list); err != nil {
+		return errors.Wrap(err, "failed to parse RBAC bindings")
+	}
+
+	for _, c := range userBindingChanges(list.Items, user) {
+		args := []string{strings.ToLower(c.binding.Kind), c.binding.Metadata.Name}
+		if c.binding.Metadata.Namespace != "" {
+			args = append(args, "-n", c.binding.Metadata.Namespace)
+		}
+
+		if c.delete {
+			cmd = runner.Cmd(out, cmdKubectl, append([]string{"delete"}, args...)...)
+		} else {
+			var ops []map[string]interface{}
+			for _, i := range c.indices {
+				path := fmt.Sprintf("/subjects/%d", i)
+				ops = append(ops,
+					map[string]interface{}{"op": "test", "path": path + "/name", "value": user},
+					map[string]interface{}{"op": "remove", "path": path},
+				)
+			}
+			patch, err := json.Marshal(ops)
+			if err != nil {
+				return err
+			}
+			cmd = runner.Cmd(out, cmdKubectl, append(append([]string{"patch"}, args...), "--type", "json", "-p", string(patch))...)
+		}
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		if _, err := cmd.CombinedOutputAsync(); err != nil {
+			return errors.Wrapf(err, "failed to remove %q from %s %q", user, c.binding.Kind, c.binding.Metadata.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revoke
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserBindingChanges(t *testing.T) {
+	var list struct {
+		Items []binding `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"items": [
+		{"kind": "ClusterRoleBinding", "metadata": {"name": "alice-admin", "labels": {"kubeconfig.banzaicloud.io/managed-by": "pke"}}, "subjects": [{"kind": "User", "name": "alice"}]},
+		{"kind": "RoleBinding", "metadata": {"name": "dev", "namespace": "dev", "labels": {"kubeconfig.banzaicloud.io/managed-by": "pke"}}, "subjects": [
+			{"kind": "User", "name": "alice"}, {"kind": "Group", "name": "dev"}, {"kind": "User", "name": "alice"}
+		]},
+		{"kind": "RoleBinding", "metadata": {"name": "bob", "namespace": "dev", "labels": {"kubeconfig.banzaicloud.io/managed-by": "pke"}}, "subjects": [{"kind": "User", "name": "bob"}]},
+		{"kind": "RoleBinding", "metadata": {"name": "group", "namespace": "dev"}, "subjects": [{"kind": "Group", "name": "alice"}]},
+		{"kind": "ClusterRoleBinding", "metadata": {"name": "cluster-admin"}, "subjects": [{"kind": "User", "name": "alice"}]},
+		{"kind": "ClusterRoleBinding", "metadata": {"name": "empty", "labels": {"kubeconfig.banzaicloud.io/managed-by": "pke"}}}
+	]}`), &list))
+
+	changes := userBindingChanges(list.Items, "alice")
+	require.Len(t, changes, 2)
+
+	require.Equal(t, "alice-admin", changes[0].binding.Metadata.Name)
+	require.True(t, changes[0].delete)
+
+	require.Equal(t, "dev", changes[1].binding.Metadata.Name)
+	require.False(t, changes[1].delete)
+	require.Equal(t, []int{2, 0}, changes[1].indices)
+}
@@ -0,0 +1,151 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// KubeProxyOptions configures kube-proxy, unset conntrack options keep the kube-proxy defaults.
+type KubeProxyOptions struct {
+	Mode                           string
+	IPVSScheduler                  string
+	IPVSStrictARP                  bool
+	ConntrackMaxPerCore            *int32
+	ConntrackMin                   *int32
+	ConntrackTCPEstablishedTimeout *time.Duration
+	ConntrackTCPCloseWaitTimeout   *time.Duration
+}
+
+// RegisterKubeProxyFlags registers the kube-proxy flags.
+func RegisterKubeProxyFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagKubeProxyMode, constants.KubeProxyModeIPTables, "kube-proxy mode, possible values: iptables, ipvs, nftables, none. none skips the kube-proxy addon, used with Cilium kube-proxy replacement")
+	flags.String(constants.FlagKubeProxyIPVSScheduler, "rr", "IPVS scheduler of kube-proxy, possible values: rr, wrr, sh")
+	flags.Bool(constants.FlagKubeProxyIPVSStrictARP, false, "Answer ARP requests for local addresses only in IPVS mode, enabled automatically when --"+constants.FlagLbRange+" or --"+constants.FlagLbConfig+" is set")
+	flags.Int32(constants.FlagKubeProxyConntrackMaxPerCore, 0, "Maximum number of NAT connections to track per CPU core, 0 leaves the limit as-is. kube-proxy default (32768) when unset")
+	flags.Int32(constants.FlagKubeProxyConntrackMin, 0, "Minimum number of conntrack entries to allocate. kube-proxy default (131072) when unset")
+	flags.Duration(constants.FlagKubeProxyConntrackTCPEstablishedTimeout, 0, "Idle timeout of established TCP connections, 0 leaves the timeout as-is. kube-proxy default (24h) when unset")
+	flags.Duration(constants.FlagKubeProxyConntrackTCPCloseWaitTimeout, 0, "Timeout of TCP connections in CLOSE_WAIT state, 0 leaves the timeout as-is. kube-proxy default (1h) when unset")
+}
+
+// KubeProxyParameters reads the kube-proxy flags.
+func KubeProxyParameters(cmd *cobra.Command) (o KubeProxyOptions, err error) {
+	o.Mode, err = cmd.Flags().GetString(constants.FlagKubeProxyMode)
+	if err != nil {
+		return
+	}
+	o.IPVSScheduler, err = cmd.Flags().GetString(constants.FlagKubeProxyIPVSScheduler)
+	if err != nil {
+		return
+	}
+	o.IPVSStrictARP, err = cmd.Flags().GetBool(constants.FlagKubeProxyIPVSStrictARP)
+	if err != nil {
+		return
+	}
+	if !cmd.Flags().Changed(constants.FlagKubeProxyIPVSStrictARP) {
+		for _, name := range []string{constants.FlagLbRange, constants.FlagLbConfig} {
+			if f := cmd.Flags().Lookup(name); f != nil && f.Value.String() != "" {
+				// MetalLB requires strict ARP in IPVS mode
+				o.IPVSStrictARP = true
+			}
+		}
+	}
+
+	if cmd.Flags().Changed(constants.FlagKubeProxyConntrackMaxPerCore) {
+		var v int32
+		if v, err = cmd.Flags().GetInt32(constants.FlagKubeProxyConntrackMaxPerCore); err != nil {
+			return
+		}
+		o.ConntrackMaxPerCore = &v
+	}
+	if cmd.Flags().Changed(constants.FlagKubeProxyConntrackMin) {
+		var v int32
+		if v, err = cmd.Flags().GetInt32(constants.FlagKubeProxyConntrackMin); err != nil {
+			return
+		}
+		o.ConntrackMin = &v
+	}
+	if cmd.Flags().Changed(constants.FlagKubeProxyConntrackTCPEstablishedTimeout) {
+		var v time.Duration
+		if v, err = cmd.Flags().GetDuration(constants.FlagKubeProxyConntrackTCPEstablishedTimeout); err != nil {
+			return
+		}
+		o.ConntrackTCPEstablishedTimeout = &v
+	}
+	if cmd.Flags().Changed(constants.FlagKubeProxyConntrackTCPCloseWaitTimeout) {
+		var v time.Duration
+		if v, err = cmd.Flags().GetDuration(constants.FlagKubeProxyConntrackTCPCloseWaitTimeout); err != nil {
+			return
+		}
+		o.ConntrackTCPCloseWaitTimeout = &v
+	}
+
+	return
+}
+
+// Validate checks the kube-proxy options against the Kubernetes version.
+func (o KubeProxyOptions) Validate(kubernetesVersion string) error {
+	switch o.Mode {
+	case constants.KubeProxyModeIPTables, constants.KubeProxyModeNone:
+	case constants.KubeProxyModeIPVS:
+		switch o.IPVSScheduler {
+		case "rr", "wrr", "sh":
+			// the kernel modules of these schedulers are loaded on every node
+		default:
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: unsupported IPVS scheduler %q", constants.FlagKubeProxyIPVSScheduler, o.IPVSScheduler)
+		}
+	case constants.KubeProxyModeNFTables:
+		ver, err := semver.NewVersion(kubernetesVersion)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagKubernetesVersion, err)
+		}
+		if ver.LessThan(semver.MustParse("1.29.0")) {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: nftables mode requires Kubernetes 1.29 or newer, got: %s", constants.FlagKubeProxyMode, kubernetesVersion)
+		}
+	default:
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: unsupported mode %q", constants.FlagKubeProxyMode, o.Mode)
+	}
+
+	for flag, v := range map[string]*int32{
+		constants.FlagKubeProxyConntrackMaxPerCore: o.ConntrackMaxPerCore,
+		constants.FlagKubeProxyConntrackMin:        o.ConntrackMin,
+	} {
+		if v != nil && *v < 0 {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be negative", flag)
+		}
+	}
+	for flag, v := range map[string]*time.Duration{
+		constants.FlagKubeProxyConntrackTCPEstablishedTimeout: o.ConntrackTCPEstablishedTimeout,
+		constants.FlagKubeProxyConntrackTCPCloseWaitTimeout:   o.ConntrackTCPCloseWaitTimeout,
+	} {
+		if v != nil && *v < 0 {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: must not be negative", flag)
+		}
+	}
+
+	return nil
+}
+
+// Enabled tells if the kube-proxy addon is installed.
+func (o KubeProxyOptions) Enabled() bool {
+	return o.Mode != constants.KubeProxyModeNone
+}
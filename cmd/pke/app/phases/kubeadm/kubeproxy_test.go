@@ -0,0 +1,60 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+func TestKubeProxyParametersConntrack(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		expected KubeProxyOptions
+	}{
+		{
+			name:     "unset",
+			expected: KubeProxyOptions{Mode: constants.KubeProxyModeIPTables, IPVSScheduler: "rr"},
+		},
+		{
+			name: "set",
+			args: []string{"--" + constants.FlagKubeProxyConntrackMaxPerCore + "=0", "--" + constants.FlagKubeProxyConntrackTCPCloseWaitTimeout + "=30m"},
+			expected: KubeProxyOptions{
+				Mode:                         constants.KubeProxyModeIPTables,
+				IPVSScheduler:                "rr",
+				ConntrackMaxPerCore:          new(int32),
+				ConntrackTCPCloseWaitTimeout: func() *time.Duration { d := 30 * time.Minute; return &d }(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			RegisterKubeProxyFlags(cmd.Flags())
+			require.NoError(t, cmd.Flags().Parse(tc.args))
+
+			o, err := KubeProxyParameters(cmd)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, o)
+		})
+	}
+}
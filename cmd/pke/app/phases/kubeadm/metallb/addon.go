@@ -0,0 +1,89 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+import (
+	"encoding/json"
+	"io"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+)
+
+// AddonName is the name of the MetalLB addon, its configuration is a Config.
+const AddonName = "metallb"
+
+// addon applies the configuration once the controller is ready to validate it.
+type addon struct {
+	addons.Manifest
+}
+
+// NewAddon returns the MetalLB addon.
+func NewAddon() addons.Addon {
+	return addon{
+		Manifest: addons.Manifest{
+			AddonName:    AddonName,
+			AddonVersion: Version,
+			RenderFunc:   render,
+			Workloads: []addons.Workload{
+				{Namespace: namespace, Resource: "deployment/controller"},
+				{Namespace: namespace, Resource: "daemonset/speaker"},
+			},
+		},
+	}
+}
+
+func (a addon) Apply(out io.Writer, kubeConfig string, config []byte) error {
+	c, err := addonConfig(config)
+	if err != nil {
+		return err
+	}
+	return Apply(out, kubeConfig, ConfigFile, c)
+}
+
+func (a addon) Upgrade(out io.Writer, kubeConfig string, config []byte, from string) error {
+	return a.Apply(out, kubeConfig, config)
+}
+
+func render(config []byte) (string, error) {
+	c, err := addonConfig(config)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := Manifest()
+	if err != nil {
+		return "", err
+	}
+	resources, err := c.Resources()
+	if err != nil {
+		return "", err
+	}
+	// every resource starts with a document separator
+	return manifest + "\n" + resources, nil
+}
+
+func addonConfig(config []byte) (Config, error) {
+	var c Config
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &c); err != nil {
+			return Config{}, errors.Wrap(err, "failed to parse MetalLB configuration")
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, errors.Wrap(err, "invalid MetalLB configuration")
+	}
+	return c, nil
+}
@@ -0,0 +1,358 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+import (
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ghodss/yaml"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+// Address announcement protocols.
+const (
+	ProtocolLayer2 = "layer2"
+	ProtocolBGP    = "bgp"
+)
+
+// DefaultPool is the name of the pool created from the ranges given on the command line.
+const DefaultPool = "default"
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Config is the load balancer configuration of MetalLB.
+type Config struct {
+	Pools    []Pool    `json:"pools"`
+	BGPPeers []BGPPeer `json:"bgpPeers,omitempty"`
+}
+
+// Pool is a set of addresses allocated for LoadBalancer Services and announced with the same protocol.
+type Pool struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+	// Protocol announcing the addresses, layer2 if empty.
+	Protocol      string `json:"protocol,omitempty"`
+	AutoAssign    *bool  `json:"autoAssign,omitempty"`
+	AvoidBuggyIPs bool   `json:"avoidBuggyIPs,omitempty"`
+	// NodeSelectors limit the nodes announcing the addresses.
+	NodeSelectors     []NodeSelector     `json:"nodeSelectors,omitempty"`
+	BGPAdvertisements []BGPAdvertisement `json:"bgpAdvertisements,omitempty"`
+}
+
+// BGPAdvertisement controls how the addresses of a BGP pool are advertised.
+type BGPAdvertisement struct {
+	AggregationLength *int32   `json:"aggregationLength,omitempty"`
+	LocalPref         uint32   `json:"localPref,omitempty"`
+	Communities       []string `json:"communities,omitempty"`
+}
+
+// BGPPeer is a router the speakers establish BGP sessions with.
+type BGPPeer struct {
+	Name     string `json:"name,omitempty"`
+	Address  string `json:"address"`
+	ASN      uint32 `json:"asn"`
+	MyASN    uint32 `json:"myASN"`
+	Port     uint16 `json:"port,omitempty"`
+	HoldTime string `json:"holdTime,omitempty"`
+	RouterID string `json:"routerID,omitempty"`
+	Password string `json:"password,omitempty"`
+	// NodeSelectors limit the nodes connecting to the peer.
+	NodeSelectors []NodeSelector `json:"nodeSelectors,omitempty"`
+}
+
+// NodeSelector selects nodes by their labels.
+type NodeSelector struct {
+	MatchLabels      map[string]string `json:"matchLabels,omitempty"`
+	MatchExpressions []Requirement     `json:"matchExpressions,omitempty"`
+}
+
+// Requirement is a node label selector requirement.
+type Requirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// LoadConfig reads the configuration from a YAML file.
+func LoadConfig(filename string) (Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return Config{}, errors.Wrapf(err, "failed to parse %s", filename)
+	}
+
+	return c, nil
+}
+
+// RangeConfig returns a configuration announcing the comma separated ranges via ARP.
+func RangeConfig(ranges string) Config {
+	var addresses []string
+	for _, r := range strings.Split(ranges, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			addresses = append(addresses, r)
+		}
+	}
+
+	return Config{
+		Pools: []Pool{{
+			Name:      DefaultPool,
+			Addresses: addresses,
+			Protocol:  ProtocolLayer2,
+		}},
+	}
+}
+
+// Empty tells if the configuration has no pools.
+func (c Config) Empty() bool {
+	return len(c.Pools) == 0
+}
+
+// Ranges returns the address ranges of every pool.
+func (c Config) Ranges() ([]network.IPRange, error) {
+	var ranges []network.IPRange
+	for _, p := range c.Pools {
+		for _, a := range p.Addresses {
+			r, err := network.ParseIPRange(a)
+			if err != nil {
+				return nil, errors.Wrapf(err, "pool %q", p.Name)
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges, nil
+}
+
+// Validate checks the pools and peers, and that the pools do not overlap.
+func (c Config) Validate() error {
+	if c.Empty() {
+		return errors.New("no address pools")
+	}
+
+	pools := make(map[string]bool)
+	var (
+		ranges []network.IPRange
+		bgp    bool
+	)
+	for _, p := range c.Pools {
+		if !nameRegexp.MatchString(p.Name) {
+			return errors.Errorf("invalid pool name %q", p.Name)
+		}
+		if pools[p.Name] {
+			return errors.Errorf("duplicate pool %q", p.Name)
+		}
+		pools[p.Name] = true
+
+		if len(p.Addresses) == 0 {
+			return errors.Errorf("pool %q has no addresses", p.Name)
+		}
+		for _, a := range p.Addresses {
+			r, err := network.ParseIPRange(a)
+			if err != nil {
+				return errors.Wrapf(err, "pool %q", p.Name)
+			}
+			for _, o := range ranges {
+				if r.OverlapsRange(o) {
+					return errors.Errorf("pool %q: %q overlaps %q", p.Name, a, o)
+				}
+			}
+			ranges = append(ranges, r)
+		}
+
+		switch p.Protocol {
+		case "", ProtocolLayer2:
+			if len(p.BGPAdvertisements) > 0 {
+				return errors.Errorf("pool %q: BGP advertisements require the %s protocol", p.Name, ProtocolBGP)
+			}
+		case ProtocolBGP:
+			bgp = true
+			for _, a := range p.BGPAdvertisements {
+				if a.AggregationLength != nil && (*a.AggregationLength < 1 || *a.AggregationLength > 128) {
+					return errors.Errorf("pool %q: invalid aggregation length %d", p.Name, *a.AggregationLength)
+				}
+			}
+		default:
+			return errors.Errorf("pool %q: unsupported protocol %q, possible values: %s, %s", p.Name, p.Protocol, ProtocolLayer2, ProtocolBGP)
+		}
+
+		if err := validateNodeSelectors(p.NodeSelectors); err != nil {
+			return errors.Wrapf(err, "pool %q", p.Name)
+		}
+	}
+
+	if bgp && len(c.BGPPeers) == 0 {
+		return errors.Errorf("pools announced with the %s protocol require BGP peers", ProtocolBGP)
+	}
+
+	peers := make(map[string]bool)
+	for i, p := range c.BGPPeers {
+		name := p.name(i)
+		if !nameRegexp.MatchString(name) {
+			return errors.Errorf("invalid BGP peer name %q", name)
+		}
+		if peers[name] {
+			return errors.Errorf("duplicate BGP peer %q", name)
+		}
+		peers[name] = true
+
+		if net.ParseIP(p.Address) == nil {
+			return errors.Errorf("BGP peer %q: invalid address %q", name, p.Address)
+		}
+		if p.ASN == 0 || p.MyASN == 0 {
+			return errors.Errorf("BGP peer %q: asn and myASN are required", name)
+		}
+		if p.HoldTime != "" {
+			if _, err := time.ParseDuration(p.HoldTime); err != nil {
+				return errors.Wrapf(err, "BGP peer %q: invalid hold time", name)
+			}
+		}
+		if p.RouterID != "" && net.ParseIP(p.RouterID).To4() == nil {
+			return errors.Errorf("BGP peer %q: router ID %q must be an IPv4 address", name, p.RouterID)
+		}
+		if err := validateNodeSelectors(p.NodeSelectors); err != nil {
+			return errors.Wrapf(err, "BGP peer %q", name)
+		}
+	}
+
+	return nil
+}
+
+func validateNodeSelectors(selectors []NodeSelector) error {
+	for _, s := range selectors {
+		for _, r := range s.MatchExpressions {
+			if r.Key == "" {
+				return errors.New("node selector requirement without key")
+			}
+			switch r.Operator {
+			case "In", "NotIn":
+				if len(r.Values) == 0 {
+					return errors.Errorf("node selector requirement %q: operator %s requires values", r.Key, r.Operator)
+				}
+			case "Exists", "DoesNotExist":
+				if len(r.Values) > 0 {
+					return errors.Errorf("node selector requirement %q: operator %s does not take values", r.Key, r.Operator)
+				}
+			default:
+				return errors.Errorf("node selector requirement %q: unsupported operator %q", r.Key, r.Operator)
+			}
+		}
+	}
+	return nil
+}
+
+// name returns the name of the peer, peers without a name are named after their position.
+func (p BGPPeer) name(i int) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return "peer-" + strconv.Itoa(i)
+}
+
+// legacyConfig is the ConfigMap based configuration of MetalLB before v0.13.
+type legacyConfig struct {
+	Peers []struct {
+		PeerAddress   string               `json:"peer-address"`
+		PeerASN       uint32               `json:"peer-asn"`
+		MyASN         uint32               `json:"my-asn"`
+		PeerPort      uint16               `json:"peer-port"`
+		HoldTime      string               `json:"hold-time"`
+		RouterID      string               `json:"router-id"`
+		Password      string               `json:"password"`
+		NodeSelectors []legacyNodeSelector `json:"node-selectors"`
+	} `json:"peers"`
+	BGPCommunities map[string]string `json:"bgp-communities"`
+	AddressPools   []struct {
+		Name              string   `json:"name"`
+		Protocol          string   `json:"protocol"`
+		Addresses         []string `json:"addresses"`
+		AvoidBuggyIPs     bool     `json:"avoid-buggy-ips"`
+		AutoAssign        *bool    `json:"auto-assign"`
+		BGPAdvertisements []struct {
+			AggregationLength *int32   `json:"aggregation-length"`
+			LocalPref         uint32   `json:"localpref"`
+			Communities       []string `json:"communities"`
+		} `json:"bgp-advertisements"`
+	} `json:"address-pools"`
+}
+
+type legacyNodeSelector struct {
+	MatchLabels      map[string]string `json:"match-labels"`
+	MatchExpressions []Requirement     `json:"match-expressions"`
+}
+
+// ParseLegacyConfig converts the configuration stored in the config ConfigMap of MetalLB before v0.13.
+func ParseLegacyConfig(b []byte) (Config, error) {
+	var l legacyConfig
+	if err := yaml.Unmarshal(b, &l); err != nil {
+		return Config{}, errors.Wrap(err, "failed to parse legacy MetalLB configuration")
+	}
+
+	var c Config
+	for _, p := range l.AddressPools {
+		pool := Pool{
+			Name:          p.Name,
+			Addresses:     p.Addresses,
+			Protocol:      p.Protocol,
+			AutoAssign:    p.AutoAssign,
+			AvoidBuggyIPs: p.AvoidBuggyIPs,
+		}
+		for _, a := range p.BGPAdvertisements {
+			adv := BGPAdvertisement{
+				AggregationLength: a.AggregationLength,
+				LocalPref:         a.LocalPref,
+			}
+			for _, community := range a.Communities {
+				// communities may refer to the aliases defined in bgp-communities
+				if v, ok := l.BGPCommunities[community]; ok {
+					community = v
+				}
+				adv.Communities = append(adv.Communities, community)
+			}
+			pool.BGPAdvertisements = append(pool.BGPAdvertisements, adv)
+		}
+		c.Pools = append(c.Pools, pool)
+	}
+
+	for _, p := range l.Peers {
+		peer := BGPPeer{
+			Address:  p.PeerAddress,
+			ASN:      p.PeerASN,
+			MyASN:    p.MyASN,
+			Port:     p.PeerPort,
+			HoldTime: p.HoldTime,
+			RouterID: p.RouterID,
+			Password: p.Password,
+		}
+		for _, s := range p.NodeSelectors {
+			peer.NodeSelectors = append(peer.NodeSelectors, NodeSelector{
+				MatchLabels:      s.MatchLabels,
+				MatchExpressions: s.MatchExpressions,
+			})
+		}
+		c.BGPPeers = append(c.BGPPeers, peer)
+	}
+
+	return c, nil
+}
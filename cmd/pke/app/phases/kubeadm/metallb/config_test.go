@@ -0,0 +1,148 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeConfig(t *testing.T) {
+	c := RangeConfig("192.168.64.100-192.168.64.110, 192.168.65.0/28,")
+	require.Equal(t, Config{
+		Pools: []Pool{{
+			Name:      DefaultPool,
+			Addresses: []string{"192.168.64.100-192.168.64.110", "192.168.65.0/28"},
+			Protocol:  ProtocolLayer2,
+		}},
+	}, c)
+	require.NoError(t, c.Validate())
+
+	ranges, err := c.Ranges()
+	require.NoError(t, err)
+	require.Len(t, ranges, 2)
+}
+
+func TestValidate(t *testing.T) {
+	pool := func(name, protocol string, addresses ...string) Pool {
+		return Pool{Name: name, Protocol: protocol, Addresses: addresses}
+	}
+	peer := BGPPeer{Address: "192.168.64.1", ASN: 64501, MyASN: 64500}
+	length := int32(200)
+
+	testCases := []struct {
+		name   string
+		config Config
+		err    bool
+	}{
+		{name: "layer2", config: Config{Pools: []Pool{pool("default", "", "192.168.64.100-192.168.64.110")}}},
+		{name: "bgp", config: Config{Pools: []Pool{pool("default", ProtocolLayer2, "192.168.64.100-192.168.64.110"), pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{peer}}},
+		{name: "no pools", config: Config{}, err: true},
+		{name: "invalid pool name", config: Config{Pools: []Pool{pool("Default", "", "192.168.64.100-192.168.64.110")}}, err: true},
+		{name: "duplicate pool", config: Config{Pools: []Pool{pool("default", "", "192.168.64.100-192.168.64.110"), pool("default", "", "192.168.65.0/28")}}, err: true},
+		{name: "no addresses", config: Config{Pools: []Pool{pool("default", "")}}, err: true},
+		{name: "overlapping pools", config: Config{Pools: []Pool{pool("default", "", "192.168.64.100-192.168.64.110"), pool("other", "", "192.168.64.96/28")}}, err: true},
+		{name: "unsupported protocol", config: Config{Pools: []Pool{pool("default", "arp", "192.168.64.100-192.168.64.110")}}, err: true},
+		{name: "bgp without peers", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}}, err: true},
+		{name: "layer2 with bgp advertisements", config: Config{Pools: []Pool{{Name: "default", Addresses: []string{"192.168.64.100-192.168.64.110"}, BGPAdvertisements: []BGPAdvertisement{{LocalPref: 100}}}}}, err: true},
+		{name: "invalid aggregation length", config: Config{Pools: []Pool{{Name: "public", Protocol: ProtocolBGP, Addresses: []string{"203.0.113.0/24"}, BGPAdvertisements: []BGPAdvertisement{{AggregationLength: &length}}}}, BGPPeers: []BGPPeer{peer}}, err: true},
+		{name: "invalid peer address", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{{Address: "router", ASN: 64501, MyASN: 64500}}}, err: true},
+		{name: "peer without asn", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{{Address: "192.168.64.1", MyASN: 64500}}}, err: true},
+		{name: "duplicate peer", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{peer, {Name: "peer-0", Address: "192.168.64.2", ASN: 64501, MyASN: 64500}}}, err: true},
+		{name: "invalid hold time", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{{Address: "192.168.64.1", ASN: 64501, MyASN: 64500, HoldTime: "90"}}}, err: true},
+		{name: "IPv6 router ID", config: Config{Pools: []Pool{pool("public", ProtocolBGP, "203.0.113.0/24")}, BGPPeers: []BGPPeer{{Address: "fd00::1", ASN: 64501, MyASN: 64500, RouterID: "fd00::2"}}}, err: true},
+		{name: "node selector", config: Config{Pools: []Pool{{Name: "default", Addresses: []string{"192.168.64.100-192.168.64.110"}, NodeSelectors: []NodeSelector{{MatchExpressions: []Requirement{{Key: "zone", Operator: "In", Values: []string{"a"}}}}}}}}},
+		{name: "node selector without values", config: Config{Pools: []Pool{{Name: "default", Addresses: []string{"192.168.64.100-192.168.64.110"}, NodeSelectors: []NodeSelector{{MatchExpressions: []Requirement{{Key: "zone", Operator: "In"}}}}}}}, err: true},
+		{name: "unsupported node selector operator", config: Config{Pools: []Pool{{Name: "default", Addresses: []string{"192.168.64.100-192.168.64.110"}, NodeSelectors: []NodeSelector{{MatchExpressions: []Requirement{{Key: "zone", Operator: "Gt", Values: []string{"1"}}}}}}}}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseLegacyConfig(t *testing.T) {
+	c, err := ParseLegacyConfig([]byte(`
+peers:
+- peer-address: 192.168.64.1
+  peer-asn: 64501
+  my-asn: 64500
+  hold-time: 120s
+  node-selectors:
+  - match-labels:
+      rack: frontend
+    match-expressions:
+    - key: network-speed
+      operator: NotIn
+      values: [slow]
+bgp-communities:
+  no-advertise: 65535:65282
+address-pools:
+- name: default
+  protocol: layer2
+  addresses:
+  - 192.168.64.100-192.168.64.110
+- name: public
+  protocol: bgp
+  auto-assign: false
+  avoid-buggy-ips: true
+  addresses:
+  - 203.0.113.0/24
+  bgp-advertisements:
+  - aggregation-length: 32
+    localpref: 100
+    communities: [no-advertise, "64500:1"]
+`))
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+
+	autoAssign := false
+	aggregationLength := int32(32)
+	require.Equal(t, Config{
+		Pools: []Pool{
+			{Name: "default", Protocol: ProtocolLayer2, Addresses: []string{"192.168.64.100-192.168.64.110"}},
+			{
+				Name:          "public",
+				Protocol:      ProtocolBGP,
+				Addresses:     []string{"203.0.113.0/24"},
+				AutoAssign:    &autoAssign,
+				AvoidBuggyIPs: true,
+				BGPAdvertisements: []BGPAdvertisement{{
+					AggregationLength: &aggregationLength,
+					LocalPref:         100,
+					Communities:       []string{"65535:65282", "64500:1"},
+				}},
+			},
+		},
+		BGPPeers: []BGPPeer{{
+			Address:  "192.168.64.1",
+			ASN:      64501,
+			MyASN:    64500,
+			HoldTime: "120s",
+			NodeSelectors: []NodeSelector{{
+				MatchLabels:      map[string]string{"rack": "frontend"},
+				MatchExpressions: []Requirement{{Key: "network-speed", Operator: "NotIn", Values: []string{"slow"}}},
+			}},
+		}},
+	}, c)
+}
@@ -0,0 +1,238 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	use = "metallb"
+
+	// Version of the embedded MetalLB manifest.
+	Version = "v0.13.7"
+	// ConfigFile contains the MetalLB resources of the load balancer configuration.
+	ConfigFile = "/etc/kubernetes/metallb-config.yaml"
+
+	cmdKubectl      = "kubectl"
+	namespace       = "metallb-system"
+	legacyConfigMap = "config"
+
+	// the validating webhook of the controller rejects the configuration until its certificate is ready
+	configRetries       = 30
+	configRetryInterval = 10 * time.Second
+)
+
+//go:generate templify -t ${GOTMPL} -p metallb -f metallb metallb.yaml.tmpl
+
+// Manifest renders the MetalLB installation manifest.
+func Manifest() (string, error) {
+	tmpl, err := template.New("metallb").Parse(metallbTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type data struct {
+		Version string
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data{Version: Version}); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+//go:generate templify -t ${GOTMPL} -p metallb -f metallbConfig metallb_config.yaml.tmpl
+
+// Resources renders the IPAddressPool, L2Advertisement, BGPAdvertisement and BGPPeer resources of the configuration.
+func (c Config) Resources() (string, error) {
+	tmpl, err := template.New("metallb-config").Parse(metallbConfigTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type pool struct {
+		Name          string
+		Addresses     []string
+		AutoAssign    bool
+		AvoidBuggyIPs bool
+	}
+	type advertisement struct {
+		Name              string
+		Pool              string
+		AggregationLength int32
+		LocalPref         uint32
+		Communities       []string
+		NodeSelectors     []NodeSelector
+	}
+	type peer struct {
+		BGPPeer
+		Name string
+	}
+	type data struct {
+		Pools             []pool
+		L2Advertisements  []advertisement
+		BGPAdvertisements []advertisement
+		BGPPeers          []peer
+	}
+
+	var d data
+	for _, p := range c.Pools {
+		d.Pools = append(d.Pools, pool{
+			Name:          p.Name,
+			Addresses:     p.Addresses,
+			AutoAssign:    p.AutoAssign == nil || *p.AutoAssign,
+			AvoidBuggyIPs: p.AvoidBuggyIPs,
+		})
+
+		if p.Protocol != ProtocolBGP {
+			d.L2Advertisements = append(d.L2Advertisements, advertisement{
+				Name:          p.Name,
+				Pool:          p.Name,
+				NodeSelectors: p.NodeSelectors,
+			})
+			continue
+		}
+
+		advertisements := p.BGPAdvertisements
+		if len(advertisements) == 0 {
+			advertisements = []BGPAdvertisement{{}}
+		}
+		for i, a := range advertisements {
+			adv := advertisement{
+				Name:          p.Name,
+				Pool:          p.Name,
+				LocalPref:     a.LocalPref,
+				Communities:   a.Communities,
+				NodeSelectors: p.NodeSelectors,
+			}
+			if len(advertisements) > 1 {
+				adv.Name += "-" + strconv.Itoa(i)
+			}
+			if a.AggregationLength != nil {
+				adv.AggregationLength = *a.AggregationLength
+			}
+			d.BGPAdvertisements = append(d.BGPAdvertisements, adv)
+		}
+	}
+	for i, p := range c.BGPPeers {
+		d.BGPPeers = append(d.BGPPeers, peer{BGPPeer: p, Name: p.name(i)})
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(b.String(), "\n"), nil
+}
+
+// Apply installs MetalLB and applies the load balancer configuration once the controller is ready.
+func Apply(out io.Writer, kubeConfig, filename string, c Config) error {
+	manifest, err := Manifest()
+	if err != nil {
+		return err
+	}
+	resources, err := c.Resources()
+	if err != nil {
+		return err
+	}
+
+	if err := kubectl(out, kubeConfig, manifest, "apply", "-f", "-"); err != nil {
+		return err
+	}
+	// kubectl -n metallb-system rollout status deployment/controller --timeout=5m
+	if err := kubectl(out, kubeConfig, "", "-n", namespace, "rollout", "status", "deployment/controller", "--timeout=5m"); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing load balancer configuration: %s\n", use, filename)
+	if err := file.Overwrite(filename, resources); err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		err = kubectl(out, kubeConfig, "", "apply", "-f", filename)
+		if err == nil || i == configRetries {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "[%s] applying load balancer configuration failed, retrying in %s\n", use, configRetryInterval)
+		time.Sleep(configRetryInterval)
+	}
+}
+
+// Upgrade converts the ConfigMap based configuration of MetalLB installations before v0.13,
+// upgrades MetalLB to the embedded version and removes the legacy configuration.
+func Upgrade(out io.Writer, kubeConfig, filename string) error {
+	// kubectl -n metallb-system get configmap config --ignore-not-found -o jsonpath={.data.config}
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "-n", namespace, "get", "configmap", legacyConfigMap, "--ignore-not-found", "-o", "jsonpath={.data.config}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to get legacy MetalLB configuration")
+	}
+	if len(bytes.TrimSpace(o)) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] upgrading MetalLB to %s\n", use, Version)
+	c, err := ParseLegacyConfig(o)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return errors.Wrap(err, "invalid legacy MetalLB configuration")
+	}
+	if err := Apply(out, kubeConfig, filename, c); err != nil {
+		return err
+	}
+	config, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := addons.Record(kubeConfig, AddonName, Version, config); err != nil {
+		return err
+	}
+
+	// the config-watcher role of the speakers is not used since v0.13
+	// kubectl -n metallb-system delete configmap/config role/config-watcher rolebinding/config-watcher --ignore-not-found
+	return kubectl(out, kubeConfig, "", "-n", namespace, "delete", "configmap/"+legacyConfigMap, "role/config-watcher", "rolebinding/config-watcher", "--ignore-not-found")
+}
+
+func kubectl(out io.Writer, kubeConfig, stdin string, args ...string) error {
+	cmd := runner.Cmd(out, cmdKubectl, args...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
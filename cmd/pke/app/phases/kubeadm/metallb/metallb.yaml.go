@@ -0,0 +1,1210 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+// metallbTemplate is a generated function returning the template as a string.
+func metallbTemplate() string {
+	var tmpl = "# Based on https://raw.githubusercontent.com/metallb/metallb/{{ .Version }}/config/manifests/metallb-native.yaml\n" +
+		"# PodSecurityPolicies are added for clusters running the PodSecurityPolicy admission plugin.\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Namespace\n" +
+		"metadata:\n" +
+		"  name: metallb-system\n" +
+		"  labels:\n" +
+		"    pod-security.kubernetes.io/audit: privileged\n" +
+		"    pod-security.kubernetes.io/enforce: privileged\n" +
+		"    pod-security.kubernetes.io/warn: privileged\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: addresspools.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: AddressPool\n" +
+		"    listKind: AddressPoolList\n" +
+		"    plural: addresspools\n" +
+		"    singular: addresspool\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - deprecated: true\n" +
+		"    deprecationWarning: metallb.io v1beta1 AddressPool is deprecated, consider using IPAddressPool\n" +
+		"    name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            required:\n" +
+		"            - addresses\n" +
+		"            - protocol\n" +
+		"            properties:\n" +
+		"              addresses:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              autoAssign:\n" +
+		"                type: boolean\n" +
+		"                default: true\n" +
+		"              avoidBuggyIPs:\n" +
+		"                type: boolean\n" +
+		"                default: false\n" +
+		"              bgpAdvertisements:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"              protocol:\n" +
+		"                type: string\n" +
+		"                enum:\n" +
+		"                - layer2\n" +
+		"                - bgp\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: bfdprofiles.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: BFDProfile\n" +
+		"    listKind: BFDProfileList\n" +
+		"    plural: bfdprofiles\n" +
+		"    singular: bfdprofile\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            properties:\n" +
+		"              detectMultiplier:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 2\n" +
+		"                maximum: 255\n" +
+		"              echoInterval:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 10\n" +
+		"                maximum: 60000\n" +
+		"              echoMode:\n" +
+		"                type: boolean\n" +
+		"              minimumTtl:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 1\n" +
+		"                maximum: 254\n" +
+		"              passiveMode:\n" +
+		"                type: boolean\n" +
+		"              receiveInterval:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 10\n" +
+		"                maximum: 60000\n" +
+		"              transmitInterval:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 10\n" +
+		"                maximum: 60000\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: bgpadvertisements.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: BGPAdvertisement\n" +
+		"    listKind: BGPAdvertisementList\n" +
+		"    plural: bgpadvertisements\n" +
+		"    singular: bgpadvertisement\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            properties:\n" +
+		"              aggregationLength:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                default: 32\n" +
+		"                minimum: 1\n" +
+		"              aggregationLengthV6:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                default: 128\n" +
+		"                minimum: 1\n" +
+		"              communities:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              ipAddressPoolSelectors:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"              ipAddressPools:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              localPref:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"              nodeSelectors:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"              peers:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: bgppeers.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: BGPPeer\n" +
+		"    listKind: BGPPeerList\n" +
+		"    plural: bgppeers\n" +
+		"    singular: bgppeer\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta2\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            required:\n" +
+		"            - myASN\n" +
+		"            - peerASN\n" +
+		"            - peerAddress\n" +
+		"            properties:\n" +
+		"              bfdProfile:\n" +
+		"                type: string\n" +
+		"              ebgpMultiHop:\n" +
+		"                type: boolean\n" +
+		"              holdTime:\n" +
+		"                type: string\n" +
+		"              keepaliveTime:\n" +
+		"                type: string\n" +
+		"              myASN:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 0\n" +
+		"                maximum: 4294967295\n" +
+		"              nodeSelectors:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"              password:\n" +
+		"                type: string\n" +
+		"              passwordSecret:\n" +
+		"                type: object\n" +
+		"                x-kubernetes-preserve-unknown-fields: true\n" +
+		"              peerASN:\n" +
+		"                type: integer\n" +
+		"                format: int32\n" +
+		"                minimum: 0\n" +
+		"                maximum: 4294967295\n" +
+		"              peerAddress:\n" +
+		"                type: string\n" +
+		"              peerPort:\n" +
+		"                type: integer\n" +
+		"                default: 179\n" +
+		"                minimum: 0\n" +
+		"                maximum: 16384\n" +
+		"              routerID:\n" +
+		"                type: string\n" +
+		"              sourceAddress:\n" +
+		"                type: string\n" +
+		"              vrf:\n" +
+		"                type: string\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: communities.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: Community\n" +
+		"    listKind: CommunityList\n" +
+		"    plural: communities\n" +
+		"    singular: community\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            properties:\n" +
+		"              communities:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  properties:\n" +
+		"                    name:\n" +
+		"                      type: string\n" +
+		"                    value:\n" +
+		"                      type: string\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: ipaddresspools.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: IPAddressPool\n" +
+		"    listKind: IPAddressPoolList\n" +
+		"    plural: ipaddresspools\n" +
+		"    singular: ipaddresspool\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        required:\n" +
+		"        - spec\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            required:\n" +
+		"            - addresses\n" +
+		"            properties:\n" +
+		"              addresses:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              autoAssign:\n" +
+		"                type: boolean\n" +
+		"                default: true\n" +
+		"              avoidBuggyIPs:\n" +
+		"                type: boolean\n" +
+		"                default: false\n" +
+		"              serviceAllocation:\n" +
+		"                type: object\n" +
+		"                x-kubernetes-preserve-unknown-fields: true\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: l2advertisements.metallb.io\n" +
+		"spec:\n" +
+		"  group: metallb.io\n" +
+		"  names:\n" +
+		"    kind: L2Advertisement\n" +
+		"    listKind: L2AdvertisementList\n" +
+		"    plural: l2advertisements\n" +
+		"    singular: l2advertisement\n" +
+		"  scope: Namespaced\n" +
+		"  versions:\n" +
+		"  - name: v1beta1\n" +
+		"    served: true\n" +
+		"    storage: true\n" +
+		"    schema:\n" +
+		"      openAPIV3Schema:\n" +
+		"        type: object\n" +
+		"        properties:\n" +
+		"          apiVersion:\n" +
+		"            type: string\n" +
+		"          kind:\n" +
+		"            type: string\n" +
+		"          metadata:\n" +
+		"            type: object\n" +
+		"          spec:\n" +
+		"            type: object\n" +
+		"            properties:\n" +
+		"              interfaces:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              ipAddressPoolSelectors:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"              ipAddressPools:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: string\n" +
+		"              nodeSelectors:\n" +
+		"                type: array\n" +
+		"                items:\n" +
+		"                  type: object\n" +
+		"                  x-kubernetes-preserve-unknown-fields: true\n" +
+		"          status:\n" +
+		"            type: object\n" +
+		"            x-kubernetes-preserve-unknown-fields: true\n" +
+		"    subresources:\n" +
+		"      status: {}\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ServiceAccount\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: speaker\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: policy/v1beta1\n" +
+		"kind: PodSecurityPolicy\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: controller\n" +
+		"spec:\n" +
+		"  allowPrivilegeEscalation: false\n" +
+		"  allowedCapabilities: []\n" +
+		"  allowedHostPaths: []\n" +
+		"  defaultAddCapabilities: []\n" +
+		"  defaultAllowPrivilegeEscalation: false\n" +
+		"  fsGroup:\n" +
+		"    ranges:\n" +
+		"    - max: 65535\n" +
+		"      min: 1\n" +
+		"    rule: MustRunAs\n" +
+		"  hostIPC: false\n" +
+		"  hostNetwork: false\n" +
+		"  hostPID: false\n" +
+		"  privileged: false\n" +
+		"  readOnlyRootFilesystem: true\n" +
+		"  requiredDropCapabilities:\n" +
+		"  - ALL\n" +
+		"  runAsUser:\n" +
+		"    ranges:\n" +
+		"    - max: 65535\n" +
+		"      min: 1\n" +
+		"    rule: MustRunAs\n" +
+		"  seLinux:\n" +
+		"    rule: RunAsAny\n" +
+		"  supplementalGroups:\n" +
+		"    ranges:\n" +
+		"    - max: 65535\n" +
+		"      min: 1\n" +
+		"    rule: MustRunAs\n" +
+		"  volumes:\n" +
+		"  - configMap\n" +
+		"  - secret\n" +
+		"  - emptyDir\n" +
+		"---\n" +
+		"apiVersion: policy/v1beta1\n" +
+		"kind: PodSecurityPolicy\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: speaker\n" +
+		"spec:\n" +
+		"  allowPrivilegeEscalation: false\n" +
+		"  allowedCapabilities:\n" +
+		"  - NET_RAW\n" +
+		"  allowedHostPaths: []\n" +
+		"  defaultAddCapabilities: []\n" +
+		"  defaultAllowPrivilegeEscalation: false\n" +
+		"  fsGroup:\n" +
+		"    rule: RunAsAny\n" +
+		"  hostIPC: false\n" +
+		"  hostNetwork: true\n" +
+		"  hostPID: false\n" +
+		"  hostPorts:\n" +
+		"  - max: 7472\n" +
+		"    min: 7472\n" +
+		"  - max: 7946\n" +
+		"    min: 7946\n" +
+		"  privileged: true\n" +
+		"  readOnlyRootFilesystem: true\n" +
+		"  requiredDropCapabilities:\n" +
+		"  - ALL\n" +
+		"  runAsUser:\n" +
+		"    rule: RunAsAny\n" +
+		"  seLinux:\n" +
+		"    rule: RunAsAny\n" +
+		"  supplementalGroups:\n" +
+		"    rule: RunAsAny\n" +
+		"  volumes:\n" +
+		"  - configMap\n" +
+		"  - secret\n" +
+		"  - emptyDir\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: Role\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - secrets\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - delete\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - patch\n" +
+		"  - update\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resourceNames:\n" +
+		"  - memberlist\n" +
+		"  resources:\n" +
+		"  - secrets\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - apps\n" +
+		"  resourceNames:\n" +
+		"  - controller\n" +
+		"  resources:\n" +
+		"  - deployments\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"- apiGroups:\n" +
+		"  - metallb.io\n" +
+		"  resources:\n" +
+		"  - bgppeers\n" +
+		"  - bfdprofiles\n" +
+		"  - bgpadvertisements\n" +
+		"  - l2advertisements\n" +
+		"  - addresspools\n" +
+		"  - ipaddresspools\n" +
+		"  - communities\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - metallb.io\n" +
+		"  resources:\n" +
+		"  - addresspools/status\n" +
+		"  - ipaddresspools/status\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - patch\n" +
+		"  - update\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: Role\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: pod-lister\n" +
+		"  namespace: metallb-system\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - pods\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - secrets\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - configmaps\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - metallb.io\n" +
+		"  resources:\n" +
+		"  - addresspools\n" +
+		"  - bfdprofiles\n" +
+		"  - bgppeers\n" +
+		"  - l2advertisements\n" +
+		"  - bgpadvertisements\n" +
+		"  - ipaddresspools\n" +
+		"  - communities\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRole\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: metallb-system:controller\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - services\n" +
+		"  - namespaces\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - services/status\n" +
+		"  verbs:\n" +
+		"  - update\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - events\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - patch\n" +
+		"- apiGroups:\n" +
+		"  - policy\n" +
+		"  resourceNames:\n" +
+		"  - controller\n" +
+		"  resources:\n" +
+		"  - podsecuritypolicies\n" +
+		"  verbs:\n" +
+		"  - use\n" +
+		"- apiGroups:\n" +
+		"  - admissionregistration.k8s.io\n" +
+		"  resourceNames:\n" +
+		"  - metallb-webhook-configuration\n" +
+		"  resources:\n" +
+		"  - validatingwebhookconfigurations\n" +
+		"  - mutatingwebhookconfigurations\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - delete\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - patch\n" +
+		"  - update\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - admissionregistration.k8s.io\n" +
+		"  resources:\n" +
+		"  - validatingwebhookconfigurations\n" +
+		"  - mutatingwebhookconfigurations\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - apiextensions.k8s.io\n" +
+		"  resourceNames:\n" +
+		"  - addresspools.metallb.io\n" +
+		"  - bfdprofiles.metallb.io\n" +
+		"  - bgpadvertisements.metallb.io\n" +
+		"  - bgppeers.metallb.io\n" +
+		"  - ipaddresspools.metallb.io\n" +
+		"  - l2advertisements.metallb.io\n" +
+		"  - communities.metallb.io\n" +
+		"  resources:\n" +
+		"  - customresourcedefinitions\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - delete\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - patch\n" +
+		"  - update\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - apiextensions.k8s.io\n" +
+		"  resources:\n" +
+		"  - customresourcedefinitions\n" +
+		"  verbs:\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRole\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: metallb-system:speaker\n" +
+		"rules:\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - services\n" +
+		"  - endpoints\n" +
+		"  - nodes\n" +
+		"  - namespaces\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - discovery.k8s.io\n" +
+		"  resources:\n" +
+		"  - endpointslices\n" +
+		"  verbs:\n" +
+		"  - get\n" +
+		"  - list\n" +
+		"  - watch\n" +
+		"- apiGroups:\n" +
+		"  - \"\"\n" +
+		"  resources:\n" +
+		"  - events\n" +
+		"  verbs:\n" +
+		"  - create\n" +
+		"  - patch\n" +
+		"- apiGroups:\n" +
+		"  - policy\n" +
+		"  resourceNames:\n" +
+		"  - speaker\n" +
+		"  resources:\n" +
+		"  - podsecuritypolicies\n" +
+		"  verbs:\n" +
+		"  - use\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: RoleBinding\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: Role\n" +
+		"  name: controller\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: RoleBinding\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: pod-lister\n" +
+		"  namespace: metallb-system\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: Role\n" +
+		"  name: pod-lister\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: speaker\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRoleBinding\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: metallb-system:controller\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: ClusterRole\n" +
+		"  name: metallb-system:controller\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: rbac.authorization.k8s.io/v1\n" +
+		"kind: ClusterRoleBinding\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"  name: metallb-system:speaker\n" +
+		"roleRef:\n" +
+		"  apiGroup: rbac.authorization.k8s.io\n" +
+		"  kind: ClusterRole\n" +
+		"  name: metallb-system:speaker\n" +
+		"subjects:\n" +
+		"- kind: ServiceAccount\n" +
+		"  name: speaker\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: metallb-excludel2\n" +
+		"  namespace: metallb-system\n" +
+		"data:\n" +
+		"  excludel2.yaml: |\n" +
+		"    announcedInterfacesToExclude: [\"docker.*\", \"cbr.*\", \"dummy.*\", \"virbr.*\", \"lxcbr.*\", \"veth.*\", \"lo\", \"^cali.*\", \"^tunl.*\", \"flannel.*\", \"kube-ipvs.*\", \"cni.*\", \"^nodelocaldns.*\"]\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Secret\n" +
+		"metadata:\n" +
+		"  name: webhook-server-cert\n" +
+		"  namespace: metallb-system\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Service\n" +
+		"metadata:\n" +
+		"  name: webhook-service\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  ports:\n" +
+		"  - port: 443\n" +
+		"    targetPort: 9443\n" +
+		"  selector:\n" +
+		"    component: controller\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: Deployment\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"    component: controller\n" +
+		"  name: controller\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  revisionHistoryLimit: 3\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      app: metallb\n" +
+		"      component: controller\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      annotations:\n" +
+		"        prometheus.io/port: \"7472\"\n" +
+		"        prometheus.io/scrape: \"true\"\n" +
+		"      labels:\n" +
+		"        app: metallb\n" +
+		"        component: controller\n" +
+		"    spec:\n" +
+		"      containers:\n" +
+		"      - args:\n" +
+		"        - --port=7472\n" +
+		"        - --log-level=info\n" +
+		"        env:\n" +
+		"        - name: METALLB_ML_SECRET_NAME\n" +
+		"          value: memberlist\n" +
+		"        - name: METALLB_DEPLOYMENT\n" +
+		"          value: controller\n" +
+		"        image: quay.io/metallb/controller:{{ .Version }}\n" +
+		"        livenessProbe:\n" +
+		"          failureThreshold: 3\n" +
+		"          httpGet:\n" +
+		"            path: /metrics\n" +
+		"            port: monitoring\n" +
+		"          initialDelaySeconds: 10\n" +
+		"          periodSeconds: 10\n" +
+		"          successThreshold: 1\n" +
+		"          timeoutSeconds: 1\n" +
+		"        name: controller\n" +
+		"        ports:\n" +
+		"        - containerPort: 7472\n" +
+		"          name: monitoring\n" +
+		"        - containerPort: 9443\n" +
+		"          name: webhook-server\n" +
+		"          protocol: TCP\n" +
+		"        readinessProbe:\n" +
+		"          failureThreshold: 3\n" +
+		"          httpGet:\n" +
+		"            path: /metrics\n" +
+		"            port: monitoring\n" +
+		"          initialDelaySeconds: 10\n" +
+		"          periodSeconds: 10\n" +
+		"          successThreshold: 1\n" +
+		"          timeoutSeconds: 1\n" +
+		"        securityContext:\n" +
+		"          allowPrivilegeEscalation: false\n" +
+		"          capabilities:\n" +
+		"            drop:\n" +
+		"            - all\n" +
+		"          readOnlyRootFilesystem: true\n" +
+		"        volumeMounts:\n" +
+		"        - mountPath: /tmp/k8s-webhook-server/serving-certs\n" +
+		"          name: cert\n" +
+		"          readOnly: true\n" +
+		"      nodeSelector:\n" +
+		"        kubernetes.io/os: linux\n" +
+		"      securityContext:\n" +
+		"        fsGroup: 65534\n" +
+		"        runAsNonRoot: true\n" +
+		"        runAsUser: 65534\n" +
+		"      serviceAccountName: controller\n" +
+		"      terminationGracePeriodSeconds: 0\n" +
+		"      volumes:\n" +
+		"      - name: cert\n" +
+		"        secret:\n" +
+		"          defaultMode: 420\n" +
+		"          secretName: webhook-server-cert\n" +
+		"---\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: DaemonSet\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: metallb\n" +
+		"    component: speaker\n" +
+		"  name: speaker\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  selector:\n" +
+		"    matchLabels:\n" +
+		"      app: metallb\n" +
+		"      component: speaker\n" +
+		"  template:\n" +
+		"    metadata:\n" +
+		"      annotations:\n" +
+		"        prometheus.io/port: \"7472\"\n" +
+		"        prometheus.io/scrape: \"true\"\n" +
+		"      labels:\n" +
+		"        app: metallb\n" +
+		"        component: speaker\n" +
+		"    spec:\n" +
+		"      containers:\n" +
+		"      - args:\n" +
+		"        - --port=7472\n" +
+		"        - --log-level=info\n" +
+		"        env:\n" +
+		"        - name: METALLB_NODE_NAME\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: spec.nodeName\n" +
+		"        - name: METALLB_HOST\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: status.hostIP\n" +
+		"        - name: METALLB_ML_BIND_ADDR\n" +
+		"          valueFrom:\n" +
+		"            fieldRef:\n" +
+		"              fieldPath: status.podIP\n" +
+		"        - name: METALLB_ML_LABELS\n" +
+		"          value: app=metallb,component=speaker\n" +
+		"        - name: METALLB_ML_SECRET_KEY_PATH\n" +
+		"          value: /etc/ml_secret_key\n" +
+		"        image: quay.io/metallb/speaker:{{ .Version }}\n" +
+		"        livenessProbe:\n" +
+		"          failureThreshold: 3\n" +
+		"          httpGet:\n" +
+		"            path: /metrics\n" +
+		"            port: monitoring\n" +
+		"          initialDelaySeconds: 10\n" +
+		"          periodSeconds: 10\n" +
+		"          successThreshold: 1\n" +
+		"          timeoutSeconds: 1\n" +
+		"        name: speaker\n" +
+		"        ports:\n" +
+		"        - containerPort: 7472\n" +
+		"          name: monitoring\n" +
+		"        - containerPort: 7946\n" +
+		"          name: memberlist-tcp\n" +
+		"        - containerPort: 7946\n" +
+		"          name: memberlist-udp\n" +
+		"          protocol: UDP\n" +
+		"        readinessProbe:\n" +
+		"          failureThreshold: 3\n" +
+		"          httpGet:\n" +
+		"            path: /metrics\n" +
+		"            port: monitoring\n" +
+		"          initialDelaySeconds: 10\n" +
+		"          periodSeconds: 10\n" +
+		"          successThreshold: 1\n" +
+		"          timeoutSeconds: 1\n" +
+		"        securityContext:\n" +
+		"          allowPrivilegeEscalation: false\n" +
+		"          capabilities:\n" +
+		"            add:\n" +
+		"            - NET_RAW\n" +
+		"            drop:\n" +
+		"            - ALL\n" +
+		"          readOnlyRootFilesystem: true\n" +
+		"        volumeMounts:\n" +
+		"        - mountPath: /etc/ml_secret_key\n" +
+		"          name: memberlist\n" +
+		"          readOnly: true\n" +
+		"        - mountPath: /etc/metallb\n" +
+		"          name: metallb-excludel2\n" +
+		"          readOnly: true\n" +
+		"      hostNetwork: true\n" +
+		"      nodeSelector:\n" +
+		"        kubernetes.io/os: linux\n" +
+		"      serviceAccountName: speaker\n" +
+		"      terminationGracePeriodSeconds: 2\n" +
+		"      tolerations:\n" +
+		"      - effect: NoSchedule\n" +
+		"        key: node-role.kubernetes.io/master\n" +
+		"        operator: Exists\n" +
+		"      - effect: NoSchedule\n" +
+		"        key: node-role.kubernetes.io/control-plane\n" +
+		"        operator: Exists\n" +
+		"      volumes:\n" +
+		"      - name: memberlist\n" +
+		"        secret:\n" +
+		"          defaultMode: 420\n" +
+		"          secretName: memberlist\n" +
+		"      - configMap:\n" +
+		"          defaultMode: 256\n" +
+		"          name: metallb-excludel2\n" +
+		"        name: metallb-excludel2\n" +
+		"---\n" +
+		"apiVersion: admissionregistration.k8s.io/v1\n" +
+		"kind: ValidatingWebhookConfiguration\n" +
+		"metadata:\n" +
+		"  name: metallb-webhook-configuration\n" +
+		"webhooks:\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-addresspool\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: addresspoolvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - addresspools\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-bfdprofile\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: bfdprofilevalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - bfdprofiles\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-bgpadvertisement\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: bgpadvertisementvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - bgpadvertisements\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta2-bgppeer\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: bgppeersvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta2\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - bgppeers\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-community\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: communityvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - communities\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-ipaddresspool\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: ipaddresspoolvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - ipaddresspools\n" +
+		"  sideEffects: None\n" +
+		"- admissionReviewVersions:\n" +
+		"  - v1\n" +
+		"  clientConfig:\n" +
+		"    service:\n" +
+		"      name: webhook-service\n" +
+		"      namespace: metallb-system\n" +
+		"      path: /validate-metallb-io-v1beta1-l2advertisement\n" +
+		"  failurePolicy: Fail\n" +
+		"  name: l2advertisementvalidationwebhook.metallb.io\n" +
+		"  rules:\n" +
+		"  - apiGroups:\n" +
+		"    - metallb.io\n" +
+		"    apiVersions:\n" +
+		"    - v1beta1\n" +
+		"    operations:\n" +
+		"    - CREATE\n" +
+		"    - UPDATE\n" +
+		"    resources:\n" +
+		"    - l2advertisements\n" +
+		"  sideEffects: None\n" +
+		""
+	return tmpl
+}
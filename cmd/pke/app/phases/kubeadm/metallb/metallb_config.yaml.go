@@ -0,0 +1,85 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+// metallbConfigTemplate is a generated function returning the template as a string.
+func metallbConfigTemplate() string {
+	var tmpl = "{{ define \"nodeSelectors\" }}{{ if . }}\n" +
+		"  nodeSelectors:{{ range . }}\n" +
+		"  - matchLabels:{{ if not .MatchLabels }} {}{{ end }}{{ range $k, $v := .MatchLabels }}\n" +
+		"      {{ printf \"%q\" $k }}: {{ printf \"%q\" $v }}{{ end }}{{ if .MatchExpressions }}\n" +
+		"    matchExpressions:{{ range .MatchExpressions }}\n" +
+		"    - key: {{ printf \"%q\" .Key }}\n" +
+		"      operator: {{ .Operator }}{{ if .Values }}\n" +
+		"      values:{{ range .Values }}\n" +
+		"      - {{ printf \"%q\" . }}{{ end }}{{ end }}{{ end }}{{ end }}{{ end }}{{ end }}{{ end }}\n" +
+		"{{- range .Pools }}\n" +
+		"---\n" +
+		"apiVersion: metallb.io/v1beta1\n" +
+		"kind: IPAddressPool\n" +
+		"metadata:\n" +
+		"  name: {{ .Name }}\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  addresses:{{ range .Addresses }}\n" +
+		"  - {{ . }}{{ end }}\n" +
+		"  autoAssign: {{ .AutoAssign }}\n" +
+		"  avoidBuggyIPs: {{ .AvoidBuggyIPs }}\n" +
+		"{{- end }}\n" +
+		"{{- range .L2Advertisements }}\n" +
+		"---\n" +
+		"apiVersion: metallb.io/v1beta1\n" +
+		"kind: L2Advertisement\n" +
+		"metadata:\n" +
+		"  name: {{ .Name }}\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  ipAddressPools:\n" +
+		"  - {{ .Pool }}{{ template \"nodeSelectors\" .NodeSelectors }}\n" +
+		"{{- end }}\n" +
+		"{{- range .BGPAdvertisements }}\n" +
+		"---\n" +
+		"apiVersion: metallb.io/v1beta1\n" +
+		"kind: BGPAdvertisement\n" +
+		"metadata:\n" +
+		"  name: {{ .Name }}\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  ipAddressPools:\n" +
+		"  - {{ .Pool }}{{ if .AggregationLength }}\n" +
+		"  aggregationLength: {{ .AggregationLength }}{{ end }}{{ if .LocalPref }}\n" +
+		"  localPref: {{ .LocalPref }}{{ end }}{{ if .Communities }}\n" +
+		"  communities:{{ range .Communities }}\n" +
+		"  - {{ printf \"%q\" . }}{{ end }}{{ end }}{{ template \"nodeSelectors\" .NodeSelectors }}\n" +
+		"{{- end }}\n" +
+		"{{- range .BGPPeers }}\n" +
+		"---\n" +
+		"apiVersion: metallb.io/v1beta2\n" +
+		"kind: BGPPeer\n" +
+		"metadata:\n" +
+		"  name: {{ .Name }}\n" +
+		"  namespace: metallb-system\n" +
+		"spec:\n" +
+		"  peerAddress: {{ .Address }}\n" +
+		"  peerASN: {{ .ASN }}\n" +
+		"  myASN: {{ .MyASN }}{{ if .Port }}\n" +
+		"  peerPort: {{ .Port }}{{ end }}{{ if .HoldTime }}\n" +
+		"  holdTime: {{ .HoldTime }}{{ end }}{{ if .RouterID }}\n" +
+		"  routerID: {{ .RouterID }}{{ end }}{{ if .Password }}\n" +
+		"  password: {{ printf \"%q\" .Password }}{{ end }}{{ template \"nodeSelectors\" .NodeSelectors }}\n" +
+		"{{- end }}\n" +
+		""
+	return tmpl
+}
@@ -0,0 +1,105 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metallb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	m, err := Manifest()
+	require.NoError(t, err)
+	require.Contains(t, m, "image: quay.io/metallb/controller:"+Version)
+	require.Contains(t, m, "image: quay.io/metallb/speaker:"+Version)
+	require.NotContains(t, m, "{{")
+}
+
+func TestResources(t *testing.T) {
+	autoAssign := false
+	c := Config{
+		Pools: []Pool{
+			{
+				Name:          "default",
+				Addresses:     []string{"192.168.64.100-192.168.64.110"},
+				NodeSelectors: []NodeSelector{{MatchLabels: map[string]string{"zone": "a"}}},
+			},
+			{
+				Name:              "public",
+				Protocol:          ProtocolBGP,
+				Addresses:         []string{"203.0.113.0/24"},
+				AutoAssign:        &autoAssign,
+				BGPAdvertisements: []BGPAdvertisement{{LocalPref: 100}, {Communities: []string{"65535:65282"}}},
+			},
+		},
+		BGPPeers: []BGPPeer{
+			{Address: "192.168.64.1", ASN: 64501, MyASN: 64500, HoldTime: "120s"},
+			{
+				Name:          "backup",
+				Address:       "192.168.64.2",
+				ASN:           64501,
+				MyASN:         64500,
+				Password:      "secret",
+				NodeSelectors: []NodeSelector{{MatchExpressions: []Requirement{{Key: "zone", Operator: "Exists"}}}},
+			},
+		},
+	}
+	require.NoError(t, c.Validate())
+
+	r, err := c.Resources()
+	require.NoError(t, err)
+
+	type object struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec map[string]interface{} `json:"spec"`
+	}
+	objects := make(map[string]object)
+	for _, doc := range strings.Split(r, "---\n")[1:] {
+		var o object
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &o), doc)
+		require.Equal(t, namespace, o.Metadata.Namespace)
+		objects[o.Kind+"/"+o.Metadata.Name] = o
+	}
+	require.Len(t, objects, 7)
+
+	require.Equal(t, true, objects["IPAddressPool/default"].Spec["autoAssign"])
+	require.Equal(t, false, objects["IPAddressPool/public"].Spec["autoAssign"])
+	require.Equal(t, []interface{}{"203.0.113.0/24"}, objects["IPAddressPool/public"].Spec["addresses"])
+
+	l2 := objects["L2Advertisement/default"].Spec
+	require.Equal(t, []interface{}{"default"}, l2["ipAddressPools"])
+	require.Equal(t, []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"zone": "a"}}}, l2["nodeSelectors"])
+
+	require.Equal(t, float64(100), objects["BGPAdvertisement/public-0"].Spec["localPref"])
+	require.Equal(t, []interface{}{"65535:65282"}, objects["BGPAdvertisement/public-1"].Spec["communities"])
+
+	peer := objects["BGPPeer/peer-0"].Spec
+	require.Equal(t, "192.168.64.1", peer["peerAddress"])
+	require.Equal(t, float64(64501), peer["peerASN"])
+	require.Equal(t, "120s", peer["holdTime"])
+	backup := objects["BGPPeer/backup"].Spec
+	require.Equal(t, "secret", backup["password"])
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"matchLabels":      map[string]interface{}{},
+		"matchExpressions": []interface{}{map[string]interface{}{"key": "zone", "operator": "Exists"}},
+	}}, backup["nodeSelectors"])
+}
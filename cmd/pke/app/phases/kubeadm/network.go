@@ -0,0 +1,79 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"net"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+// DualStackFeatureGate tells if the IPv6DualStack feature gate has to be enabled, it is enabled by default since Kubernetes 1.21.
+func DualStackFeatureGate(kubernetesVersion, cidr string) bool {
+	cidrs, err := network.ParseCIDRs(cidr)
+	if err != nil || !cidrs.DualStack() {
+		return false
+	}
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return false
+	}
+
+	return ver.LessThan(semver.MustParse("1.21.0"))
+}
+
+// NodeIPs returns the addresses of the node for each IP family of the cluster, preferring the selected addresses.
+// IPv4 only clusters keep the address selected by the kubelet unless an address is selected explicitly.
+func NodeIPs(serviceCIDR string, selected []net.IP, advertiseAddress string) (string, error) {
+	services, err := network.ParseCIDRs(serviceCIDR)
+	if err != nil {
+		return "", err
+	}
+	if services.IPv4Only() {
+		for _, ip := range selected {
+			if network.IsIPv4(ip) {
+				return ip.String(), nil
+			}
+		}
+		return "", nil
+	}
+
+	preferred := append([]net.IP(nil), selected...)
+	if advertiseAddress != "" {
+		host, _, err := SplitHostPort(advertiseAddress, "6443")
+		if err != nil {
+			return "", err
+		}
+		preferred = append(preferred, net.ParseIP(host))
+	}
+	available, err := network.Addresses()
+	if err != nil {
+		return "", err
+	}
+
+	ips, err := network.NodeIPs(services, preferred, available)
+	if err != nil {
+		return "", err
+	}
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+
+	return strings.Join(s, ","), nil
+}
@@ -0,0 +1,427 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/addons"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/controlplane"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/kubernetes"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+const (
+	use   = "migrate"
+	short = "Migrate the cluster to another network provider"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+)
+
+var _ phases.Runnable = (*Migrate)(nil)
+
+type Migrate struct {
+	to                   string
+	podNetworkCIDR       string
+	mtu                  uint
+	nodeCIDRMaskSize     uint
+	nodeCIDRMaskSizeIPv6 uint
+	imageRepository      string
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Migrate{})
+}
+
+func (*Migrate) Use() string {
+	return use
+}
+
+func (*Migrate) Short() string {
+	return short
+}
+
+func (*Migrate) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagNetworkProviderTarget, "", "Network provider to migrate to, possible values: "+strings.Join(targets, ", "))
+	flags.String(constants.FlagPodNetworkCIDR, "", "Range of IP addresses for the pods of the new network provider. Defaults to the pod network of the cluster")
+	flags.Uint(constants.FlagMTU, 0, "maximum transmission unit. 0 means default value of the Kubernetes network provider is used")
+	flags.Uint(constants.FlagNodeCIDRMaskSize, 24, "prefix length of the IPv4 pod network CIDR allocated to each node")
+	flags.Uint(constants.FlagNodeCIDRMaskSizeIPv6, 64, "prefix length of the IPv6 pod network CIDR allocated to each node")
+	flags.String(constants.FlagImageRepository, "", "Prefix for image repository")
+}
+
+func (m *Migrate) Validate(cmd *cobra.Command) error {
+	var err error
+	m.to, err = cmd.Flags().GetString(constants.FlagNetworkProviderTarget)
+	if err != nil {
+		return err
+	}
+	m.podNetworkCIDR, err = cmd.Flags().GetString(constants.FlagPodNetworkCIDR)
+	if err != nil {
+		return err
+	}
+	m.mtu, err = cmd.Flags().GetUint(constants.FlagMTU)
+	if err != nil {
+		return err
+	}
+	m.nodeCIDRMaskSize, err = cmd.Flags().GetUint(constants.FlagNodeCIDRMaskSize)
+	if err != nil {
+		return err
+	}
+	m.nodeCIDRMaskSizeIPv6, err = cmd.Flags().GetUint(constants.FlagNodeCIDRMaskSizeIPv6)
+	if err != nil {
+		return err
+	}
+	m.imageRepository, err = cmd.Flags().GetString(constants.FlagImageRepository)
+	if err != nil {
+		return err
+	}
+
+	if !isTarget(m.to) {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %q, possible values: %s", constants.FlagNetworkProviderTarget, m.to, strings.Join(targets, ", "))
+	}
+	if m.podNetworkCIDR != "" {
+		cidrs, err := network.ParseCIDRs(m.podNetworkCIDR)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagPodNetworkCIDR, err)
+		}
+		m.podNetworkCIDR = cidrs.String()
+	}
+	if _, err := os.Stat(kubeConfig); err != nil {
+		return errors.Wrapf(err, "the migration has to be run on a master, %s", kubeConfig)
+	}
+
+	return nil
+}
+
+// Run installs the target provider next to the current one, switches the nodes one by one, then removes
+// the previous provider. The progress is recorded, so an interrupted migration continues where it stopped.
+func (m *Migrate) Run(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] running\n", use)
+
+	s, err := loadState()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if s, err = m.start(out); err != nil {
+			return err
+		}
+	} else if s.To != m.to {
+		return errors.Errorf("migration from %s to %s is in progress", s.From, s.To)
+	}
+
+	return m.migration().run(out, s)
+}
+
+// migration runs the steps of a migration and records the progress after each of them.
+type migration struct {
+	steps  map[string]func(out io.Writer, s *state) error
+	save   func(s state) error
+	finish func(out io.Writer) error
+}
+
+func (m *Migrate) migration() migration {
+	return migration{
+		steps: map[string]func(out io.Writer, s *state) error{
+			stepInstall: install,
+			stepNodes:   m.migrateNodes,
+			stepActivate: func(out io.Writer, s *state) error {
+				return activate(out, *s)
+			},
+			stepCleanup: func(out io.Writer, s *state) error {
+				return m.cleanup(out, *s)
+			},
+		},
+		save:   saveState,
+		finish: deleteState,
+	}
+}
+
+// run continues the migration from its recorded step until it is done.
+func (r migration) run(out io.Writer, s *state) error {
+	for {
+		_, _ = fmt.Fprintf(out, "[%s] step: %s, %s -> %s\n", use, s.Step, s.From, s.To)
+
+		if s.Step == stepDone {
+			_, _ = fmt.Fprintf(out, "[%s] network provider is %s, migration finished\n", use, s.To)
+			return r.finish(out)
+		}
+		step, ok := r.steps[s.Step]
+		if !ok {
+			return errors.Errorf("unknown migration step %q", s.Step)
+		}
+		if err := step(out, s); err != nil {
+			return err
+		}
+
+		s.next()
+		if err := r.save(*s); err != nil {
+			return err
+		}
+	}
+}
+
+// start detects the current provider and records the beginning of the migration.
+func (m *Migrate) start(out io.Writer) (*state, error) {
+	daemonSets, err := names("daemonsets", "-n", "kube-system")
+	if err != nil {
+		return nil, err
+	}
+	from, err := detectProvider(daemonSets)
+	if err != nil {
+		return nil, err
+	}
+	if from == m.to {
+		return nil, errors.Errorf("network provider is already %s", from)
+	}
+
+	podSubnet, serviceSubnet, err := clusterNetwork()
+	if err != nil {
+		return nil, err
+	}
+	podNetworkCIDR := m.podNetworkCIDR
+	if podNetworkCIDR == "" {
+		if podSubnet == "" {
+			return nil, errors.Errorf("pod network of the cluster is unknown, use --%s", constants.FlagPodNetworkCIDR)
+		}
+		podNetworkCIDR = podSubnet
+		_, _ = fmt.Fprintf(out, "[%s] warning: %s and %s assign addresses from the same range %s, pods on migrated and not yet migrated nodes may get the same IP address until the migration finishes. use --%s to give %s a separate range\n", use, from, m.to, podSubnet, constants.FlagPodNetworkCIDR, m.to)
+	}
+
+	nodes, err := names("nodes")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &state{
+		Step: stepInstall,
+		From: from,
+		To:   m.to,
+		Network: controlplane.NetworkOptions{
+			PodNetworkCIDR:       podNetworkCIDR,
+			ServiceCIDR:          serviceSubnet,
+			ImageRepository:      m.imageRepository,
+			MTU:                  m.mtu,
+			NodeCIDRMaskSize:     m.nodeCIDRMaskSize,
+			NodeCIDRMaskSizeIPv6: m.nodeCIDRMaskSizeIPv6,
+			Single:               len(nodes) == 1,
+		},
+	}
+	_, _ = fmt.Fprintf(out, "[%s] starting migration from %s to %s\n", use, s.From, s.To)
+
+	return s, saveState(*s)
+}
+
+// install deploys the target provider without activating its CNI configuration
+// and keeps the agents of the current provider off the migrated nodes.
+func install(out io.Writer, s *state) error {
+	if s.FromNetwork == nil && s.From != constants.NetworkProviderWeave {
+		o, err := sourceNetwork(s.From)
+		if err != nil {
+			return err
+		}
+		s.FromNetwork = &o
+		if err := saveState(*s); err != nil {
+			return err
+		}
+	}
+
+	o := s.Network
+	o.PendingCNIConf = true
+	manifest, err := controlplane.NetworkProviderManifest(s.To, o)
+	if err != nil {
+		return err
+	}
+	if err := addons.ApplyManifest(out, kubeConfig, manifest); err != nil {
+		return err
+	}
+	if err := excludeMigratedNodes(out, providers[s.From].daemonSet, s.To); err != nil {
+		return err
+	}
+
+	if err := rolloutStatus(out, providers[s.To].daemonSet); err != nil {
+		return err
+	}
+
+	a, err := controlplane.Addons().Get(s.To)
+	if err != nil {
+		return err
+	}
+	config, err := json.Marshal(s.Network)
+	if err != nil {
+		return err
+	}
+	return addons.Record(kubeConfig, a.Name(), a.Version(), config)
+}
+
+// migrateNodes switches the nodes to the target provider one at a time.
+func (m *Migrate) migrateNodes(out io.Writer, s *state) error {
+	nodes, err := names("nodes")
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if s.migrated(node) {
+			continue
+		}
+		if err := m.migrateNode(out, *s, node); err != nil {
+			return errors.Wrapf(err, "failed to migrate node %q", node)
+		}
+		s.Migrated = append(s.Migrated, node)
+		if err := saveState(*s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateNode drains the node, swaps its CNI configuration, restarts the kubelet
+// and recreates the remaining pods, so each of them gets an address from the target provider.
+func (m *Migrate) migrateNode(out io.Writer, s state, node string) error {
+	_, _ = fmt.Fprintf(out, "[%s] migrating node %s\n", use, node)
+
+	if err := kubernetes.Drain(out, kubeConfig, node); err != nil {
+		return err
+	}
+	// kubectl label node <node> network.banzaicloud.io/provider=<to> --overwrite
+	if err := kubectl(out, "", "label", "node", node, labelProvider+"="+s.To, "--overwrite"); err != nil {
+		return err
+	}
+	if err := runOnNode(out, m.image(), node, "pke-network-swap-"+node, swapScript(s.From, s.To)); err != nil {
+		return err
+	}
+
+	pods, err := podNetworkPods(node)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		p := strings.SplitN(pod, "/", 2)
+		if err := kubectl(out, "", "-n", p[0], "delete", "pod", p[1], "--wait=false"); err != nil {
+			return err
+		}
+	}
+
+	return kubernetes.Uncordon(out, kubeConfig, node)
+}
+
+// activate reinstalls the target provider, so its agents manage the CNI configuration of the nodes.
+func activate(out io.Writer, s state) error {
+	manifest, err := controlplane.NetworkProviderManifest(s.To, s.Network)
+	if err != nil {
+		return err
+	}
+	if err := addons.ApplyManifest(out, kubeConfig, manifest); err != nil {
+		return err
+	}
+
+	if err := rolloutStatus(out, providers[s.To].daemonSet); err != nil {
+		return err
+	}
+
+	a, err := controlplane.Addons().Get(s.To)
+	if err != nil {
+		return err
+	}
+	config, err := json.Marshal(s.Network)
+	if err != nil {
+		return err
+	}
+	return addons.Record(kubeConfig, a.Name(), a.Version(), config)
+}
+
+// cleanup deletes the resources of the previous provider and removes its rules, interfaces and state from every node.
+func (m *Migrate) cleanup(out io.Writer, s state) error {
+	if s.From == constants.NetworkProviderWeave {
+		// the weave manifest is not embedded, every resource is labelled with name=weave-net
+		if err := kubectl(out, "", "delete", "daemonset,serviceaccount,role,rolebinding,clusterrole,clusterrolebinding", "--all-namespaces", "-l", "name=weave-net", "--ignore-not-found"); err != nil {
+			return err
+		}
+	} else {
+		o := s.FromNetwork
+		if o == nil {
+			from, err := sourceNetwork(s.From)
+			if err != nil {
+				return err
+			}
+			o = &from
+		}
+		manifest, err := controlplane.NetworkProviderManifest(s.From, *o)
+		if err != nil {
+			return err
+		}
+		if err := kubectl(out, manifest, "delete", "-f", "-", "--ignore-not-found"); err != nil {
+			return err
+		}
+	}
+
+	nodes, err := names("nodes")
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := runOnNode(out, m.image(), node, "pke-network-cleanup-"+node, cleanupScript(s.From)); err != nil {
+			return errors.Wrapf(err, "failed to clean up node %q", node)
+		}
+	}
+
+	// kubectl label nodes --all network.banzaicloud.io/provider-
+	if err := kubectl(out, "", "label", "nodes", "--all", labelProvider+"-"); err != nil {
+		return err
+	}
+
+	return addons.Forget(kubeConfig, s.From)
+}
+
+// sourceNetwork returns the options the provider was installed with. Providers installed before
+// their configuration was recorded are rendered with the network of the cluster.
+func sourceNetwork(name string) (controlplane.NetworkOptions, error) {
+	var o controlplane.NetworkOptions
+
+	installed, err := addons.LoadState(kubeConfig)
+	if err != nil {
+		return o, err
+	}
+	if config := installed[name].Config; len(config) > 0 {
+		if err := json.Unmarshal(config, &o); err != nil {
+			return o, errors.Wrapf(err, "failed to parse the configuration of %s", name)
+		}
+		return o, nil
+	}
+
+	o.PodNetworkCIDR, o.ServiceCIDR, err = clusterNetwork()
+	return o, err
+}
+
+func (m *Migrate) image() string {
+	if m.imageRepository != "" {
+		return m.imageRepository + "/" + nodePodImage
+	}
+	return nodePodImage
+}
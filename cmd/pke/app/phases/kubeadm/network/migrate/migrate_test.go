@@ -0,0 +1,100 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		step     string
+		failing  string
+		ran      []string
+		saved    []string
+		finished bool
+		err      bool
+	}{
+		{
+			name:     "start",
+			step:     stepInstall,
+			ran:      []string{stepInstall, stepNodes, stepActivate, stepCleanup},
+			saved:    []string{stepNodes, stepActivate, stepCleanup, stepDone},
+			finished: true,
+		},
+		{
+			name:     "resume",
+			step:     stepActivate,
+			ran:      []string{stepActivate, stepCleanup},
+			saved:    []string{stepCleanup, stepDone},
+			finished: true,
+		},
+		{name: "done", step: stepDone, finished: true},
+		{
+			name:    "failed step",
+			step:    stepInstall,
+			failing: stepNodes,
+			ran:     []string{stepInstall, stepNodes},
+			saved:   []string{stepNodes},
+			err:     true,
+		},
+		{name: "unknown step", step: "rollback", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ran, saved []string
+			finished := false
+
+			r := migration{
+				steps: make(map[string]func(out io.Writer, s *state) error),
+				save: func(s state) error {
+					saved = append(saved, s.Step)
+					return nil
+				},
+				finish: func(io.Writer) error {
+					finished = true
+					return nil
+				},
+			}
+			for _, step := range []string{stepInstall, stepNodes, stepActivate, stepCleanup} {
+				step := step
+				r.steps[step] = func(_ io.Writer, s *state) error {
+					ran = append(ran, step)
+					if step == tc.failing {
+						return errors.New("step failed")
+					}
+					return nil
+				}
+			}
+
+			err := r.run(ioutil.Discard, &state{Step: tc.step, From: "flannel", To: "calico"})
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.ran, ran)
+			require.Equal(t, tc.saved, saved)
+			require.Equal(t, tc.finished, finished)
+		})
+	}
+}
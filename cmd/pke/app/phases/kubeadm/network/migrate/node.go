@@ -0,0 +1,250 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ghodss/yaml"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	// labelProvider marks the nodes already switched to the target provider.
+	labelProvider = "network.banzaicloud.io/provider"
+
+	nodePodImage   = "busybox:1.35"
+	nodePodTimeout = 5 * time.Minute
+)
+
+// clusterNetwork returns the pod and service subnets of the cluster.
+func clusterNetwork() (podSubnet, serviceSubnet string, err error) {
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "-n", "kube-system", "get", "cm", "kubeadm-config", "-ojsonpath={.data.ClusterConfiguration}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to read kubeadm-config")
+	}
+
+	var c struct {
+		Networking struct {
+			PodSubnet     string `json:"podSubnet"`
+			ServiceSubnet string `json:"serviceSubnet"`
+		} `json:"networking"`
+	}
+	if err := yaml.Unmarshal(o, &c); err != nil {
+		return "", "", errors.Wrap(err, "failed to parse cluster configuration")
+	}
+
+	return c.Networking.PodSubnet, c.Networking.ServiceSubnet, nil
+}
+
+// names lists the names of the resources.
+func names(args ...string) ([]string, error) {
+	// kubectl get <args> -o jsonpath={.items[*].metadata.name}
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, append([]string{"get"}, append(args, "-o", "jsonpath={.items[*].metadata.name}")...)...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s", strings.Join(args, " "))
+	}
+
+	return strings.Fields(string(o)), nil
+}
+
+func kubectl(out io.Writer, stdin string, args ...string) error {
+	cmd := runner.Cmd(out, cmdKubectl, args...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
+
+func rolloutStatus(out io.Writer, daemonSet string) error {
+	// kubectl -n kube-system rollout status daemonset/<name> --timeout=10m
+	return kubectl(out, "", "-n", "kube-system", "rollout", "status", "daemonset/"+daemonSet, "--timeout=10m")
+}
+
+// excludeMigratedNodes keeps the agents of the previous provider off the nodes switched to the target provider.
+// Running agents are not restarted, they are removed from a node once it is labelled.
+func excludeMigratedNodes(out io.Writer, daemonSet, to string) error {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"updateStrategy": map[string]interface{}{
+				"type":          "OnDelete",
+				"rollingUpdate": nil,
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"affinity": map[string]interface{}{
+						"nodeAffinity": map[string]interface{}{
+							"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+								"nodeSelectorTerms": []interface{}{
+									map[string]interface{}{
+										"matchExpressions": []interface{}{
+											map[string]interface{}{
+												"key":      labelProvider,
+												"operator": "NotIn",
+												"values":   []string{to},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	// kubectl -n kube-system patch daemonset <name> --type merge -p <patch>
+	return kubectl(out, "", "-n", "kube-system", "patch", "daemonset", daemonSet, "--type", "merge", "-p", string(b))
+}
+
+// podNetworkPods returns the pods of the node which are not using the host network.
+func podNetworkPods(node string) ([]string, error) {
+	// kubectl get pods --all-namespaces --field-selector spec.nodeName=<node> -o json
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "pods", "--all-namespaces", "--field-selector", "spec.nodeName="+node, "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list pods of node %q", node)
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Spec struct {
+				HostNetwork bool `json:"hostNetwork"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(o, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to parse kubectl output")
+	}
+
+	var pods []string
+	for _, i := range list.Items {
+		if !i.Spec.HostNetwork {
+			pods = append(pods, i.Metadata.Namespace+"/"+i.Metadata.Name)
+		}
+	}
+	return pods, nil
+}
+
+// nodePod returns a privileged pod running the script in the namespaces of the host.
+func nodePod(name, image, node, script string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": stateNamespace,
+			"labels": map[string]string{
+				"app": "pke-network-migration",
+			},
+		},
+		"spec": map[string]interface{}{
+			"nodeName":      node,
+			"hostNetwork":   true,
+			"hostPID":       true,
+			"restartPolicy": "Never",
+			"tolerations": []interface{}{
+				map[string]string{"operator": "Exists"},
+			},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":    "migrate",
+					"image":   image,
+					"command": []string{"nsenter", "-t", "1", "-m", "-u", "-i", "-n", "-p", "--", "sh", "-c", script},
+					"securityContext": map[string]interface{}{
+						"privileged": true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// runOnNode runs the script on the node and waits for it to finish.
+func runOnNode(out io.Writer, image, node, name, script string) error {
+	b, err := json.Marshal(nodePod(name, image, node, script))
+	if err != nil {
+		return err
+	}
+
+	if err := kubectl(out, "", "-n", stateNamespace, "delete", "pod", name, "--ignore-not-found"); err != nil {
+		return err
+	}
+	if err := kubectl(out, string(b), "create", "-f", "-"); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] waiting for pod %s on node %s. this may take %s\n", use, name, node, nodePodTimeout)
+	phase, err := waitForPod(name)
+	// kubectl -n kube-system logs <name>
+	_ = kubectl(out, "", "-n", stateNamespace, "logs", name)
+	if err != nil {
+		return err
+	}
+	if phase != "Succeeded" {
+		return errors.Errorf("pod %s on node %s failed", name, node)
+	}
+
+	return kubectl(out, "", "-n", stateNamespace, "delete", "pod", name)
+}
+
+// waitForPod waits until the pod terminates and returns its phase.
+func waitForPod(name string) (string, error) {
+	tout := time.After(nodePodTimeout)
+	tick := time.NewTicker(2 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			// kubectl -n kube-system get pod <name> -o jsonpath={.status.phase}
+			cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "-n", stateNamespace, "get", "pod", name, "-o", "jsonpath={.status.phase}")
+			cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+			o, err := cmd.Output()
+			if err != nil {
+				continue
+			}
+			if phase := string(bytes.TrimSpace(o)); phase == "Succeeded" || phase == "Failed" {
+				return phase, nil
+			}
+		case <-tout:
+			return "", errors.Errorf("timeout exceeded. waiting for pod %s failed", name)
+		}
+	}
+}
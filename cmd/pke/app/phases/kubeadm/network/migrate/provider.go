@@ -0,0 +1,211 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/controlplane"
+)
+
+const (
+	cniConfDir = "/etc/cni/net.d"
+
+	ciliumCNIConf = `{
+  "cniVersion": "0.3.1",
+  "name": "cilium",
+  "type": "cilium-cni",
+  "enable-debug": false
+}`
+)
+
+// provider describes the resources a network provider leaves on the nodes.
+type provider struct {
+	// daemonSet running the agents of the provider in the kube-system namespace.
+	daemonSet string
+	// cniConfs are the CNI configuration files written by the provider, the first one is the active configuration.
+	cniConfs []string
+	// iptables match the rules and chains created by the provider.
+	iptables []string
+	// links are the network interfaces created by the provider.
+	links []string
+	// dirs contain the state of the provider on the host.
+	dirs []string
+}
+
+var providers = map[string]provider{
+	constants.NetworkProviderWeave: {
+		daemonSet: "weave-net",
+		cniConfs:  []string{"10-weave.conflist", "10-weave.conf"},
+		iptables:  []string{"WEAVE"},
+		links:     []string{"weave", "datapath", "vxlan-6784"},
+		dirs:      []string{"/var/lib/weave"},
+	},
+	constants.NetworkProviderCalico: {
+		daemonSet: "calico-node",
+		cniConfs:  []string{"10-calico.conflist", "calico-kubeconfig"},
+		iptables:  []string{"cali-"},
+		links:     []string{"vxlan.calico", "tunl0"},
+		dirs:      []string{"/var/lib/calico", "/var/run/calico"},
+	},
+	constants.NetworkProviderCilium: {
+		daemonSet: "cilium",
+		cniConfs:  []string{"05-cilium.conf", "05-cilium.conflist"},
+		iptables:  []string{"CILIUM"},
+		links:     []string{"cilium_host", "cilium_net", "cilium_vxlan"},
+		dirs:      []string{"/var/run/cilium"},
+	},
+	constants.NetworkProviderFlannel: {
+		daemonSet: "kube-flannel-ds",
+		cniConfs:  []string{"10-flannel.conflist"},
+		iptables:  []string{"FLANNEL", "flanneld"},
+		links:     []string{"flannel.1", "flannel-v6.1", "cni0"},
+		dirs:      []string{"/run/flannel"},
+	},
+	constants.NetworkProviderAntrea: {
+		daemonSet: "antrea-agent",
+		cniConfs:  []string{"10-antrea.conflist"},
+		iptables:  []string{"ANTREA"},
+		links:     []string{"antrea-gw0", "antrea-tun0"},
+		dirs:      []string{"/var/run/antrea"},
+	},
+}
+
+// targets are the providers a cluster can be migrated to.
+var targets = []string{constants.NetworkProviderCalico, constants.NetworkProviderCilium}
+
+func isTarget(name string) bool {
+	for _, t := range targets {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProvider tells if an addon is a network provider.
+func IsProvider(name string) bool {
+	_, ok := providers[name]
+	return ok
+}
+
+// Detect returns the network providers running on the cluster.
+func Detect() ([]string, error) {
+	daemonSets, err := names("daemonsets", "-n", "kube-system")
+	if err != nil {
+		return nil, err
+	}
+	return runningProviders(daemonSets), nil
+}
+
+// runningProviders returns the providers of the given agent DaemonSets.
+func runningProviders(daemonSets []string) []string {
+	var found []string
+	for _, ds := range daemonSets {
+		for name, p := range providers {
+			if p.daemonSet == ds {
+				found = append(found, name)
+			}
+		}
+	}
+	return found
+}
+
+// detectProvider returns the provider running the given agent DaemonSets.
+func detectProvider(daemonSets []string) (string, error) {
+	found := runningProviders(daemonSets)
+	switch len(found) {
+	case 0:
+		return "", errors.New("no supported network provider found")
+	case 1:
+		return found[0], nil
+	default:
+		return "", errors.Errorf("multiple network providers found: %s", strings.Join(found, ", "))
+	}
+}
+
+// swapScript switches the CNI configuration of a node from one provider to the other and restarts the kubelet.
+// The configuration of the target is either installed inactive by its agent or written by the script.
+func swapScript(from, to string) string {
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	fmt.Fprintf(&b, "cd %s\n", cniConfDir)
+
+	switch to {
+	case constants.NetworkProviderCilium:
+		b.WriteString("test -x /opt/cni/bin/cilium-cni\n")
+	default:
+		fmt.Fprintf(&b, "test -f %s\n", pendingCNIConf(to))
+	}
+
+	fmt.Fprintf(&b, "rm -f %s\n", strings.Join(providers[from].cniConfs, " "))
+
+	switch to {
+	case constants.NetworkProviderCilium:
+		fmt.Fprintf(&b, "cat > %s <<'EOF'\n%s\nEOF\n", providers[to].cniConfs[0], ciliumCNIConf)
+	default:
+		fmt.Fprintf(&b, "mv -f %s %s\n", pendingCNIConf(to), providers[to].cniConfs[0])
+	}
+
+	b.WriteString("systemctl restart kubelet\n")
+
+	return b.String()
+}
+
+// pendingCNIConf returns the name of the inactive CNI configuration the provider writes during a migration.
+func pendingCNIConf(name string) string {
+	return providers[name].cniConfs[0] + controlplane.PendingCNIConfSuffix
+}
+
+// cleanupScript removes the CNI configuration, iptables rules, network interfaces and state of a provider from a node.
+// The rules are filtered through files, so a failing iptables-save is not hidden by the pipeline,
+// and grep finding no remaining rule does not stop the script.
+func cleanupScript(name string) string {
+	p := providers[name]
+
+	var patterns []string
+	for _, pattern := range p.iptables {
+		patterns = append(patterns, "-e '"+pattern+"'")
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	fmt.Fprintf(&b, "rm -f %s/*%s", cniConfDir, controlplane.PendingCNIConfSuffix)
+	for _, c := range p.cniConfs {
+		fmt.Fprintf(&b, " %s/%s", cniConfDir, c)
+	}
+	b.WriteString("\n")
+	b.WriteString("rules=$(mktemp)\n")
+	b.WriteString("filtered=$(mktemp)\n")
+	b.WriteString("for ipt in iptables ip6tables; do\n")
+	b.WriteString("  command -v $ipt-save >/dev/null 2>&1 || continue\n")
+	b.WriteString("  $ipt-save > $rules\n")
+	fmt.Fprintf(&b, "  grep -v %s $rules > $filtered || [ $? -eq 1 ]\n", strings.Join(patterns, " "))
+	b.WriteString("  $ipt-restore < $filtered\n")
+	b.WriteString("done\n")
+	b.WriteString("rm -f $rules $filtered\n")
+	fmt.Fprintf(&b, "for link in %s; do\n", strings.Join(p.links, " "))
+	b.WriteString("  ip link delete $link 2>/dev/null || true\n")
+	b.WriteString("done\n")
+	if len(p.dirs) > 0 {
+		fmt.Fprintf(&b, "rm -rf %s\n", strings.Join(p.dirs, " "))
+	}
+
+	return b.String()
+}
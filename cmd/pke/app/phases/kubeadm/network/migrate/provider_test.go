@@ -0,0 +1,176 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+func TestDetectProvider(t *testing.T) {
+	testCases := []struct {
+		name       string
+		daemonSets []string
+		expected   string
+		err        bool
+	}{
+		{name: "weave", daemonSets: []string{"kube-proxy", "weave-net"}, expected: constants.NetworkProviderWeave},
+		{name: "calico", daemonSets: []string{"calico-node", "kube-proxy"}, expected: constants.NetworkProviderCalico},
+		{name: "flannel", daemonSets: []string{"kube-flannel-ds"}, expected: constants.NetworkProviderFlannel},
+		{name: "none", daemonSets: []string{"kube-proxy"}, err: true},
+		{name: "multiple", daemonSets: []string{"cilium", "weave-net"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := detectProvider(tc.daemonSets)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, provider)
+		})
+	}
+}
+
+func TestSwapScript(t *testing.T) {
+	// script returns the expected swap script, activating either a pending or a written CNI configuration
+	script := func(check, remove, activate string) string {
+		return "set -e\ncd /etc/cni/net.d\n" + check + "\nrm -f " + remove + "\n" + activate + "\nsystemctl restart kubelet\n"
+	}
+	pendingCalico := "mv -f 10-calico.conflist.pending 10-calico.conflist"
+	writeCilium := "cat > 05-cilium.conf <<'EOF'\n" + ciliumCNIConf + "\nEOF"
+
+	testCases := []struct {
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			from:     constants.NetworkProviderWeave,
+			to:       constants.NetworkProviderCalico,
+			expected: script("test -f 10-calico.conflist.pending", "10-weave.conflist 10-weave.conf", pendingCalico),
+		},
+		{
+			from:     constants.NetworkProviderFlannel,
+			to:       constants.NetworkProviderCalico,
+			expected: script("test -f 10-calico.conflist.pending", "10-flannel.conflist", pendingCalico),
+		},
+		{
+			from:     constants.NetworkProviderCilium,
+			to:       constants.NetworkProviderCalico,
+			expected: script("test -f 10-calico.conflist.pending", "05-cilium.conf 05-cilium.conflist", pendingCalico),
+		},
+		{
+			from:     constants.NetworkProviderAntrea,
+			to:       constants.NetworkProviderCalico,
+			expected: script("test -f 10-calico.conflist.pending", "10-antrea.conflist", pendingCalico),
+		},
+		{
+			from:     constants.NetworkProviderWeave,
+			to:       constants.NetworkProviderCilium,
+			expected: script("test -x /opt/cni/bin/cilium-cni", "10-weave.conflist 10-weave.conf", writeCilium),
+		},
+		{
+			from:     constants.NetworkProviderCalico,
+			to:       constants.NetworkProviderCilium,
+			expected: script("test -x /opt/cni/bin/cilium-cni", "10-calico.conflist calico-kubeconfig", writeCilium),
+		},
+		{
+			from:     constants.NetworkProviderFlannel,
+			to:       constants.NetworkProviderCilium,
+			expected: script("test -x /opt/cni/bin/cilium-cni", "10-flannel.conflist", writeCilium),
+		},
+		{
+			from:     constants.NetworkProviderAntrea,
+			to:       constants.NetworkProviderCilium,
+			expected: script("test -x /opt/cni/bin/cilium-cni", "10-antrea.conflist", writeCilium),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.from+" to "+tc.to, func(t *testing.T) {
+			require.Equal(t, tc.expected, swapScript(tc.from, tc.to))
+		})
+	}
+}
+
+func TestCleanupScript(t *testing.T) {
+	// script returns the expected cleanup script of a provider
+	script := func(cniConfs, patterns, links, dirs string) string {
+		return "set -e\n" +
+			"rm -f /etc/cni/net.d/*.pending " + cniConfs + "\n" +
+			"rules=$(mktemp)\n" +
+			"filtered=$(mktemp)\n" +
+			"for ipt in iptables ip6tables; do\n" +
+			"  command -v $ipt-save >/dev/null 2>&1 || continue\n" +
+			"  $ipt-save > $rules\n" +
+			"  grep -v " + patterns + " $rules > $filtered || [ $? -eq 1 ]\n" +
+			"  $ipt-restore < $filtered\n" +
+			"done\n" +
+			"rm -f $rules $filtered\n" +
+			"for link in " + links + "; do\n" +
+			"  ip link delete $link 2>/dev/null || true\n" +
+			"done\n" +
+			"rm -rf " + dirs + "\n"
+	}
+
+	testCases := []struct {
+		provider string
+		expected string
+	}{
+		{
+			provider: constants.NetworkProviderWeave,
+			expected: script("/etc/cni/net.d/10-weave.conflist /etc/cni/net.d/10-weave.conf", "-e 'WEAVE'", "weave datapath vxlan-6784", "/var/lib/weave"),
+		},
+		{
+			provider: constants.NetworkProviderCalico,
+			expected: script("/etc/cni/net.d/10-calico.conflist /etc/cni/net.d/calico-kubeconfig", "-e 'cali-'", "vxlan.calico tunl0", "/var/lib/calico /var/run/calico"),
+		},
+		{
+			provider: constants.NetworkProviderCilium,
+			expected: script("/etc/cni/net.d/05-cilium.conf /etc/cni/net.d/05-cilium.conflist", "-e 'CILIUM'", "cilium_host cilium_net cilium_vxlan", "/var/run/cilium"),
+		},
+		{
+			provider: constants.NetworkProviderFlannel,
+			expected: script("/etc/cni/net.d/10-flannel.conflist", "-e 'FLANNEL' -e 'flanneld'", "flannel.1 flannel-v6.1 cni0", "/run/flannel"),
+		},
+		{
+			provider: constants.NetworkProviderAntrea,
+			expected: script("/etc/cni/net.d/10-antrea.conflist", "-e 'ANTREA'", "antrea-gw0 antrea-tun0", "/var/run/antrea"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.provider, func(t *testing.T) {
+			require.Equal(t, tc.expected, cleanupScript(tc.provider))
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	for _, target := range targets {
+		require.Contains(t, providers, target)
+	}
+	for name, p := range providers {
+		require.NotEmpty(t, p.daemonSet, name)
+		require.NotEmpty(t, p.cniConfs, name)
+		require.NotEmpty(t, p.iptables, name)
+	}
+}
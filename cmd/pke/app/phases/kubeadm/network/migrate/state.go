@@ -0,0 +1,143 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/controlplane"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	stateNamespace = "kube-system"
+	stateConfigMap = "pke-network-migration"
+	stateKey       = "state"
+)
+
+// Migration steps.
+const (
+	// stepInstall installs the target provider with inactive CNI configuration next to the current one.
+	stepInstall = "install"
+	// stepNodes switches the CNI configuration of the nodes one by one.
+	stepNodes = "nodes"
+	// stepActivate reinstalls the target provider writing its own CNI configuration.
+	stepActivate = "activate"
+	// stepCleanup removes the resources of the previous provider.
+	stepCleanup = "cleanup"
+	stepDone    = "done"
+)
+
+// state of an ongoing migration, stored in a ConfigMap so an interrupted migration can be resumed.
+type state struct {
+	Step    string                      `json:"step"`
+	From    string                      `json:"from"`
+	To      string                      `json:"to"`
+	Network controlplane.NetworkOptions `json:"network"`
+	// FromNetwork contains the options the previous provider was installed with, recorded at the install step.
+	FromNetwork *controlplane.NetworkOptions `json:"fromNetwork,omitempty"`
+	// Nodes which have already been switched to the target provider.
+	Migrated []string `json:"migrated,omitempty"`
+}
+
+func (s state) migrated(node string) bool {
+	for _, n := range s.Migrated {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// next moves to the following step.
+func (s *state) next() {
+	switch s.Step {
+	case stepInstall:
+		s.Step = stepNodes
+	case stepNodes:
+		s.Step = stepActivate
+	case stepActivate:
+		s.Step = stepCleanup
+	default:
+		s.Step = stepDone
+	}
+}
+
+// loadState reads the state of the ongoing migration, returns nil if there is none.
+func loadState() (*state, error) {
+	// kubectl get configmap -n kube-system pke-network-migration --ignore-not-found -o jsonpath={.data.state}
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "configmap", "-n", stateNamespace, stateConfigMap, "--ignore-not-found", "-o", "jsonpath={.data."+stateKey+"}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get migration state")
+	}
+	if len(bytes.TrimSpace(o)) == 0 {
+		return nil, nil
+	}
+
+	var s state
+	if err := json.Unmarshal(o, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse migration state")
+	}
+
+	return &s, nil
+}
+
+// saveState stores the state of the migration.
+func saveState(s state) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	configMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      stateConfigMap,
+			"namespace": stateNamespace,
+		},
+		"data": map[string]string{
+			stateKey: string(b),
+		},
+	}
+	manifest, err := json.Marshal(configMap)
+	if err != nil {
+		return err
+	}
+
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "apply", "-f", "-")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = bytes.NewReader(manifest)
+	if o, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to save migration state: %s", o)
+	}
+	return nil
+}
+
+// deleteState removes the state of the finished migration.
+func deleteState(out io.Writer) error {
+	cmd := runner.Cmd(out, cmdKubectl, "delete", "configmap", "-n", stateNamespace, stateConfigMap, "--ignore-not-found")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/controlplane"
+)
+
+func TestState(t *testing.T) {
+	s := state{Step: stepInstall, From: "weave", To: "cilium"}
+
+	var steps []string
+	for s.Step != stepDone {
+		steps = append(steps, s.Step)
+		s.next()
+	}
+	require.Equal(t, []string{stepInstall, stepNodes, stepActivate, stepCleanup}, steps)
+
+	s.Migrated = []string{"node-0"}
+	require.True(t, s.migrated("node-0"))
+	require.False(t, s.migrated("node-1"))
+}
+
+func TestStateJSON(t *testing.T) {
+	s := state{
+		Step:     stepNodes,
+		From:     "weave",
+		To:       "calico",
+		Network:  controlplane.NetworkOptions{PodNetworkCIDR: "10.20.0.0/16", ServiceCIDR: "10.10.0.0/16", MTU: 1400},
+		Migrated: []string{"node-0"},
+		FromNetwork: &controlplane.NetworkOptions{
+			PodNetworkCIDR: "10.200.0.0/16",
+			ServiceCIDR:    "10.10.0.0/16",
+		},
+	}
+
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"step":"nodes","from":"weave","to":"calico","network":{"podNetworkCIDR":"10.20.0.0/16","serviceCIDR":"10.10.0.0/16","mtu":1400},"fromNetwork":{"podNetworkCIDR":"10.200.0.0/16","serviceCIDR":"10.10.0.0/16"},"migrated":["node-0"]}`, string(b))
+
+	var loaded state
+	require.NoError(t, json.Unmarshal(b, &loaded))
+	require.Equal(t, s, loaded)
+}
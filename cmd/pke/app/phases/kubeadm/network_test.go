@@ -0,0 +1,38 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeadm
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDualStackFeatureGate(t *testing.T) {
+	require.True(t, DualStackFeatureGate("1.20.15", "10.20.0.0/16,fd00:10:20::/56"))
+	require.False(t, DualStackFeatureGate("1.21.0", "10.20.0.0/16,fd00:10:20::/56"))
+	require.False(t, DualStackFeatureGate("1.20.15", "10.20.0.0/16"))
+}
+
+func TestNodeIPsIPv4Only(t *testing.T) {
+	nodeIP, err := NodeIPs("10.10.0.0/16", nil, "192.168.64.11:6443")
+	require.NoError(t, err)
+	require.Empty(t, nodeIP)
+
+	nodeIP, err = NodeIPs("10.10.0.0/16", []net.IP{net.ParseIP("fd00::11"), net.ParseIP("10.0.0.5")}, "192.168.64.11:6443")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.5", nodeIP)
+}
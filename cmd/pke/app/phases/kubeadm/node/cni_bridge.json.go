@@ -25,10 +25,11 @@ func cniBridgeTemplate() string {
 		"    \"ipMasq\": true,\n" +
 		"    \"ipam\": {\n" +
 		"        \"type\": \"host-local\",\n" +
-		"        \"ranges\": [\n" +
-		"          [{\"subnet\": \"{{ .PodNetworkCIDR }}\"}]\n" +
+		"        \"ranges\": [{{ if .IPv4PodNetworkCIDR }}\n" +
+		"          [{\"subnet\": \"{{ .IPv4PodNetworkCIDR }}\"}]{{ if .IPv6PodNetworkCIDR }},{{ end }}{{ end }}{{ if .IPv6PodNetworkCIDR }}\n" +
+		"          [{\"subnet\": \"{{ .IPv6PodNetworkCIDR }}\"}]{{ end }}\n" +
 		"        ],\n" +
-		"        \"routes\": [{\"dst\": \"0.0.0.0/0\"}]\n" +
+		"        \"routes\": [{{ if .IPv4PodNetworkCIDR }}{\"dst\": \"0.0.0.0/0\"}{{ if .IPv6PodNetworkCIDR }}, {{ end }}{{ end }}{{ if .IPv6PodNetworkCIDR }}{\"dst\": \"::/0\"}{{ end }}]\n" +
 		"    }\n" +
 		"}\n" +
 		""
@@ -34,6 +34,12 @@ import (
 //go:generate templify -t ${GOTMPL} -p node -f kubeadmConfigV1Beta3 kubeadm_v1beta3.yaml.tmpl
 
 func (n Node) writeKubeadmConfig(out io.Writer, filename string) error {
+	// Node addresses of a dual-stack or IPv6 cluster, or the explicitly selected node address
+	nodeIP, err := kubeadm.NodeIPs(n.serviceCIDR, n.nodeIPs, n.advertiseAddress)
+	if err != nil {
+		return err
+	}
+
 	// API server advertisement
 	bindPort := "6443"
 	if n.advertiseAddress != "" {
@@ -97,11 +103,14 @@ func (n Node) writeKubeadmConfig(out io.Writer, filename string) error {
 		APIServerAdvertiseAddress string
 		APIServerBindPort         string
 		CRISocket                 string
+		CgroupDriver              string
 		ControlPlaneEndpoint      string
 		Token                     string
 		CACertHash                string
 		CloudProvider             string
 		NodeLabels                string
+		NodeIP                    string
+		DualStackFeatureGate      bool
 		Taints                    []kubernetes.Taint
 		KubeReservedCPU           string
 		KubeReservedMemory        string
@@ -111,11 +120,14 @@ func (n Node) writeKubeadmConfig(out io.Writer, filename string) error {
 		APIServerAdvertiseAddress: n.advertiseAddress,
 		APIServerBindPort:         bindPort,
 		CRISocket:                 cri.GetCRISocket(n.containerRuntime),
+		CgroupDriver:              n.cgroupDriver,
 		ControlPlaneEndpoint:      n.apiServerHostPort,
 		Token:                     n.kubeadmToken,
 		CACertHash:                n.caCertHash,
 		CloudProvider:             n.cloudProvider,
 		NodeLabels:                strings.Join(nodeLabels, ","),
+		NodeIP:                    nodeIP,
+		DualStackFeatureGate:      kubeadm.DualStackFeatureGate(n.kubernetesVersion, n.serviceCIDR),
 		Taints:                    taints,
 		KubeReservedCPU:           kubeReservedCPU,
 		KubeReservedMemory:        kubeReservedMemory,
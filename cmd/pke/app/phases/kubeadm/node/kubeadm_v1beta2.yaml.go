@@ -31,6 +31,7 @@ func kubeadmConfigV1Beta2Template() string {
 		"      effect: \"{{.Effect}}\"{{end}}\n" +
 		"  kubeletExtraArgs:\n" +
 		"    {{ if .NodeLabels }}node-labels: \"{{ .NodeLabels }}\"{{end}}\n" +
+		"    {{ if .NodeIP }}node-ip: \"{{ .NodeIP }}\"{{end}}\n" +
 		"    {{ if .CloudProvider }}cloud-provider: \"{{ .CloudProvider }}\"{{end}}\n" +
 		"    {{if eq .CloudProvider \"azure\" }}cloud-config: \"/etc/kubernetes/{{ .CloudProvider }}.conf\"{{end}}\n" +
 		"    read-only-port: \"0\"\n" +
@@ -52,6 +53,9 @@ func kubeadmConfigV1Beta2Template() string {
 		"---\n" +
 		"apiVersion: kubelet.config.k8s.io/v1beta1\n" +
 		"kind: KubeletConfiguration\n" +
+		"cgroupDriver: {{ .CgroupDriver }}\n" +
+		"{{ if .DualStackFeatureGate }}featureGates:\n" +
+		"  IPv6DualStack: true{{end}}\n" +
 		"serverTLSBootstrap: true\n" +
 		"systemReserved:\n" +
 		"  cpu: 50m\n" +
@@ -17,6 +17,7 @@ package node
 import (
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,10 +29,13 @@ import (
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/flags"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
 	pipelineutil "github.com/banzaicloud/pke/cmd/pke/app/util/pipeline"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/proxy"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/validator"
 	"github.com/spf13/cobra"
@@ -60,12 +64,15 @@ type Node struct {
 
 	kubernetesVersion      string
 	containerRuntime       string
+	cgroupDriver           string
 	advertiseAddress       string
 	apiServerHostPort      string
 	kubeadmToken           string
 	caCertHash             string
 	ResetOnFailure         bool
 	podNetworkCIDR         string
+	nodeIPSelector         network.NodeIPSelector
+	nodeIPs                []net.IP
 	cloudProvider          string
 	nodepool               string
 	azureTenantID          string
@@ -78,6 +85,8 @@ type Node struct {
 	azureRouteTableName    string
 	taints                 []string
 	labels                 []string
+	serviceCIDR            string
+	proxy                  proxy.Config
 }
 
 func NewCommand(config config.Config) *cobra.Command {
@@ -97,8 +106,10 @@ func (n *Node) RegisterFlags(flags *pflag.FlagSet) {
 	flags.String(constants.FlagKubernetesVersion, n.config.Kubernetes.Version, "Kubernetes version")
 	// Kubernetes container runtime
 	flags.String(constants.FlagContainerRuntime, n.config.ContainerRuntime.Type, "Kubernetes container runtime")
+	// cgroup driver
+	flags.String(constants.FlagCgroupDriver, n.config.ContainerRuntime.CgroupDriver, "cgroup driver used by the kubelet and the container runtime, possible values: systemd, cgroupfs")
 	// Kubernetes network
-	flags.String(constants.FlagPodNetworkCIDR, "", "range of IP addresses for the pod network on the current node")
+	flags.String(constants.FlagPodNetworkCIDR, "", "range of IP addresses for the pod network on the current node, comma separated IPv4 and IPv6 ranges for dual-stack clusters")
 	// Pipeline
 	flags.StringP(constants.FlagPipelineAPIEndpoint, constants.FlagPipelineAPIEndpointShort, "", "Pipeline API server url")
 	flags.StringP(constants.FlagPipelineAPIToken, constants.FlagPipelineAPITokenShort, "", "Token for accessing Pipeline API")
@@ -110,6 +121,8 @@ func (n *Node) RegisterFlags(flags *pflag.FlagSet) {
 	// Control Plane
 	flags.String(constants.FlagAdvertiseAddress, "", "Kubernetes API Server advertise address")
 	_ = flags.MarkHidden(constants.FlagAdvertiseAddress)
+	// Node address
+	network.RegisterNodeIPFlags(flags)
 	// Kubernetes cluster join parameters
 	flags.String(constants.FlagAPIServerHostPort, "", "Kubernetes API Server host port")
 	flags.String(constants.FlagKubeadmToken, "", "PKE join token")
@@ -130,6 +143,9 @@ func (n *Node) RegisterFlags(flags *pflag.FlagSet) {
 	flags.StringSlice(constants.FlagTaints, nil, "Specifies the taints the Node should be registered with")
 	// Labels
 	flags.StringSlice(constants.FlagLabels, nil, "Specifies the labels the Node should be registered with")
+	// Proxy
+	flags.String(constants.FlagServiceCIDR, "10.10.0.0/16", "range of IP address for service VIPs, comma separated IPv4 and IPv6 ranges for dual-stack clusters")
+	proxy.RegisterFlags(flags)
 }
 
 func (n *Node) Validate(cmd *cobra.Command) error {
@@ -165,17 +181,45 @@ func (n *Node) Validate(cmd *cobra.Command) error {
 
 	switch n.containerRuntime {
 	case constants.ContainerRuntimeContainerd,
+		constants.ContainerRuntimeCRIO,
 		constants.ContainerRuntimeDocker:
 		// break
 	default:
 		return errors.Wrapf(constants.ErrUnsupportedContainerRuntime, "container runtime: %s", n.containerRuntime)
 	}
 
+	services, err := network.ParseCIDRs(n.serviceCIDR)
+	if err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagServiceCIDR, err)
+	}
+	n.serviceCIDR = services.String()
+	if n.podNetworkCIDR != "" {
+		pods, err := network.ParseCIDRs(n.podNetworkCIDR)
+		if err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagPodNetworkCIDR, err)
+		}
+		n.podNetworkCIDR = pods.String()
+	}
+
+	if n.nodeIPSelector.Explicit() {
+		if n.nodeIPs, err = n.nodeIPSelector.Select(); err != nil {
+			return err
+		}
+	}
+
 	flags.PrintFlags(cmd.OutOrStdout(), n.Use(), cmd.Flags())
 
 	return nil
 }
 
+// DefaultAdvertiseAddress sets the API server advertise address of a joining control plane node,
+// unless it is given by --kubernetes-api-server-advertise-address.
+func (n *Node) DefaultAdvertiseAddress(address string) {
+	if n.advertiseAddress == "" {
+		n.advertiseAddress = address
+	}
+}
+
 func (n *Node) Run(out io.Writer) error {
 	_, _ = fmt.Fprintf(out, "[%s] running\n", n.Use())
 
@@ -200,6 +244,13 @@ func (n *Node) workerBootstrapParameters(cmd *cobra.Command) (err error) {
 	if err != nil {
 		return
 	}
+	n.cgroupDriver, err = cmd.Flags().GetString(constants.FlagCgroupDriver)
+	if err != nil {
+		return
+	}
+	if err = cri.ValidateCgroupDriver(n.cgroupDriver); err != nil {
+		return
+	}
 	// Override values with flags
 	n.advertiseAddress, err = cmd.Flags().GetString(constants.FlagAdvertiseAddress)
 	if err != nil {
@@ -233,6 +284,10 @@ func (n *Node) workerBootstrapParameters(cmd *cobra.Command) (err error) {
 	if err != nil {
 		return
 	}
+	n.nodeIPSelector, err = network.NodeIPParameters(cmd)
+	if err != nil {
+		return
+	}
 	n.cloudProvider, err = cmd.Flags().GetString(constants.FlagCloudProvider)
 	if err != nil {
 		return
@@ -278,11 +333,28 @@ func (n *Node) workerBootstrapParameters(cmd *cobra.Command) (err error) {
 		return
 	}
 	n.labels, err = cmd.Flags().GetStringSlice(constants.FlagLabels)
+	if err != nil {
+		return
+	}
+	n.serviceCIDR, err = cmd.Flags().GetString(constants.FlagServiceCIDR)
+	if err != nil {
+		return
+	}
+	n.proxy, err = proxy.Parameters(cmd)
 
 	return
 }
 
 func (n *Node) install(out io.Writer) error {
+	// Proxy settings for kubeadm, the container runtime and the kubelet
+	n.proxy = n.proxy.WithNoProxy(n.serviceCIDR, n.podNetworkCIDR, n.nodeIPSelector.CIDR, n.apiServerHostPort, n.advertiseAddress, ".svc", ".cluster.local")
+	if err := n.proxy.Apply(); err != nil {
+		return err
+	}
+	if err := n.proxy.ConfigureNode(out, n.containerRuntime); err != nil {
+		return err
+	}
+
 	// write kubeadm config
 	if err := n.writeKubeadmConfig(out, kubeadmConfig); err != nil {
 		return err
@@ -371,12 +443,19 @@ func writeCNIBridge(out io.Writer, cloudProvider, podNetworkCIDR, filename strin
 		return err
 	}
 
+	cidrs, err := network.ParseCIDRs(podNetworkCIDR)
+	if err != nil {
+		return err
+	}
+
 	type data struct {
-		PodNetworkCIDR string
+		IPv4PodNetworkCIDR string
+		IPv6PodNetworkCIDR string
 	}
 
 	d := data{
-		PodNetworkCIDR: podNetworkCIDR,
+		IPv4PodNetworkCIDR: cidrs.IPv4(),
+		IPv6PodNetworkCIDR: cidrs.IPv6(),
 	}
 
 	return file.WriteTemplate(filename, tmpl, d)
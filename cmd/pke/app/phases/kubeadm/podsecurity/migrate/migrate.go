@@ -0,0 +1,140 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/podsecurity"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "migrate"
+	short = "Label namespaces with the Pod Security Standards matching their PodSecurityPolicies"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+)
+
+var _ phases.Runnable = (*Migrate)(nil)
+
+type Migrate struct {
+	defaultLevel string
+	dryRun       bool
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Migrate{})
+}
+
+func (*Migrate) Use() string {
+	return use
+}
+
+func (*Migrate) Short() string {
+	return short
+}
+
+func (*Migrate) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagPodSecurityStandard, constants.PodSecurityStandardRestricted, "Pod Security Standard for namespaces without any PodSecurityPolicy granted")
+	flags.Bool(constants.FlagDryRun, false, "Only print the labels without applying them")
+}
+
+func (m *Migrate) Validate(cmd *cobra.Command) error {
+	var err error
+	m.defaultLevel, err = cmd.Flags().GetString(constants.FlagPodSecurityStandard)
+	if err != nil {
+		return err
+	}
+	m.dryRun, err = cmd.Flags().GetBool(constants.FlagDryRun)
+	if err != nil {
+		return err
+	}
+
+	if err := podsecurity.ValidateLevel(m.defaultLevel); err != nil {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagPodSecurityStandard, err)
+	}
+
+	return nil
+}
+
+func (m *Migrate) Run(out io.Writer) error {
+	// kubectl get namespaces,podsecuritypolicies.policy,clusterroles,roles,clusterrolebindings,rolebindings -A -o json
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "namespaces,podsecuritypolicies.policy,clusterroles,roles,clusterrolebindings,rolebindings", "--all-namespaces", "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list PodSecurityPolicies and RBAC resources")
+	}
+
+	var list podsecurity.List
+	if err := json.Unmarshal(o, &list); err != nil {
+		return errors.Wrap(err, "failed to parse kubectl output")
+	}
+
+	labelled := make(map[string]bool)
+	for _, i := range list.Items {
+		if i.Kind == "Namespace" && i.Metadata.Labels[podsecurity.LabelEnforce] != "" {
+			labelled[i.Metadata.Name] = true
+		}
+	}
+
+	levels := podsecurity.NamespaceLevels(list.Items, m.defaultLevel)
+	namespaces := make([]string, 0, len(levels))
+	for ns := range levels {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Namespace\tPod Security Standard\tStatus\n")
+	for _, ns := range namespaces {
+		level := levels[ns]
+		status := "labelled"
+		switch {
+		case labelled[ns]:
+			status = "skipped, already labelled"
+		case m.dryRun:
+			status = "dry run"
+		default:
+			// kubectl label namespace <ns> pod-security.kubernetes.io/enforce=<level> ...
+			cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "label", "namespace", ns,
+				podsecurity.LabelEnforce+"="+level,
+				podsecurity.LabelAudit+"="+level,
+				podsecurity.LabelWarn+"="+level,
+			)
+			cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+			if o, err := cmd.CombinedOutput(); err != nil {
+				_ = tw.Flush()
+				return errors.Wrapf(err, "failed to label namespace %q: %s", ns, o)
+			}
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", ns, level, status)
+	}
+
+	return tw.Flush()
+}
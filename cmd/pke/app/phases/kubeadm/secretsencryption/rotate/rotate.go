@@ -0,0 +1,433 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rotate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/kubernetes"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "rotate"
+	short = "Rotate the key used to encrypt Kubernetes secrets"
+
+	cmdKubectl = "kubectl"
+	cmdCrictl  = "crictl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+
+	encryptionSecretLength = 32
+	// rewriteAttempts is the number of times a secret is read and replaced before the rewrite fails.
+	rewriteAttempts = 5
+)
+
+// masterNodeSelectors match the masters labelled by kubeadm before and after 1.20.
+var masterNodeSelectors = []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"}
+
+var _ phases.Runnable = (*Rotate)(nil)
+
+type Rotate struct {
+	config config.Config
+
+	provider         string
+	secret           string
+	containerRuntime string
+	nodeName         string
+}
+
+func NewCommand(c config.Config) *cobra.Command {
+	return phases.NewCommand(&Rotate{config: c})
+}
+
+func (*Rotate) Use() string {
+	return use
+}
+
+func (*Rotate) Short() string {
+	return short
+}
+
+func (r *Rotate) RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagEncryptionProvider, "", "Provider of the new key, possible values: aescbc, aesgcm, secretbox. Defaults to the provider currently in use")
+	flags.String(constants.FlagEncryptionSecret, "", "Secret of the new key (32 byte base64 encoded), generated by the master starting the rotation if not set. The other masters have to be given the same secret")
+	flags.String(constants.FlagContainerRuntime, r.config.ContainerRuntime.Type, "Kubernetes container runtime")
+	flags.String(constants.FlagNodeName, "", "Name of the node, defaults to the hostname")
+}
+
+func (r *Rotate) Validate(cmd *cobra.Command) error {
+	var err error
+	r.provider, err = cmd.Flags().GetString(constants.FlagEncryptionProvider)
+	if err != nil {
+		return err
+	}
+	r.secret, err = cmd.Flags().GetString(constants.FlagEncryptionSecret)
+	if err != nil {
+		return err
+	}
+	r.containerRuntime, err = cmd.Flags().GetString(constants.FlagContainerRuntime)
+	if err != nil {
+		return err
+	}
+	r.nodeName, err = cmd.Flags().GetString(constants.FlagNodeName)
+	if err != nil {
+		return err
+	}
+	r.nodeName, err = kubernetes.NodeName(r.nodeName)
+	if err != nil {
+		return err
+	}
+
+	if r.provider != "" {
+		if err := validateKeyProvider(r.provider); err != nil {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagEncryptionProvider, err)
+		}
+	}
+	if r.secret != "" {
+		if b, err := base64.StdEncoding.DecodeString(r.secret); err != nil || len(b) != encryptionSecretLength {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s: expected a base64 encoded %d byte key", constants.FlagEncryptionSecret, encryptionSecretLength)
+		}
+	}
+	if cri.GetCRISocket(r.containerRuntime) == "" {
+		return errors.Wrapf(constants.ErrUnsupportedContainerRuntime, "container runtime: %s", r.containerRuntime)
+	}
+
+	return nil
+}
+
+func validateKeyProvider(provider string) error {
+	if err := kubeadm.ValidateEncryptionProvider(provider); err != nil {
+		return err
+	}
+	if provider == constants.EncryptionProviderKMS {
+		return errors.New("keys of the kms provider are rotated by the KMS")
+	}
+	return nil
+}
+
+// Run applies the pending steps of the rotation on this master. Steps changing the encryption configuration
+// are applied on every master one by one, the rotation proceeds once all of them are done,
+// so the command has to be run on each master until it reports the rotation as finished.
+func (r *Rotate) Run(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] running\n", use)
+
+	masters, err := masterNodes()
+	if err != nil {
+		return err
+	}
+	if !contains(masters, r.nodeName) {
+		return errors.Errorf("node %q is not a master, masters: %s", r.nodeName, strings.Join(masters, ", "))
+	}
+
+	s, err := loadState()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if s, err = r.start(out); err != nil {
+			return err
+		}
+	}
+
+	for {
+		_, _ = fmt.Fprintf(out, "[%s] step: %s, key: %s/%s\n", use, s.Step, s.Provider, s.KeyName)
+
+		switch s.Step {
+		case stepAdd, stepPromote, stepPrune:
+			if !s.applied(r.nodeName) {
+				if err := r.apply(out, *s); err != nil {
+					return err
+				}
+				s.Applied = append(s.Applied, r.nodeName)
+				if err := saveState(out, s); err != nil {
+					return err
+				}
+			}
+			if pending := s.pending(masters); len(pending) > 0 {
+				_, _ = fmt.Fprintf(out, "[%s] step %q has to be run on master(s): %s\n", use, s.Step, strings.Join(pending, ", "))
+				return nil
+			}
+
+		case stepRewrite:
+			if err := rewriteSecrets(out); err != nil {
+				return err
+			}
+
+		case stepDone:
+			_, _ = fmt.Fprintf(out, "[%s] secrets are encrypted with %s/%s, rotation finished\n", use, s.Provider, s.KeyName)
+			return deleteState(out)
+
+		default:
+			return errors.Errorf("unknown rotation step %q", s.Step)
+		}
+
+		s.next()
+		if err := saveState(out, s); err != nil {
+			return err
+		}
+	}
+}
+
+// start generates the new key and records the beginning of the rotation. The key is added
+// to the local encryption configuration before the rotation is recorded, so it cannot get lost.
+func (r *Rotate) start(out io.Writer) (*state, error) {
+	c, err := kubeadm.ReadEncryptionConfig(kubeadm.EncryptionProviderConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read encryption configuration")
+	}
+	if len(c.Providers) == 0 {
+		return nil, errors.New("secrets are not encrypted")
+	}
+
+	provider := r.provider
+	if provider == "" {
+		provider = c.Providers[0].Type
+		if err := validateKeyProvider(provider); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.secret == "" {
+		if r.secret, err = kubeadm.GenerateEncryptionSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &state{
+		Step:      stepAdd,
+		Provider:  provider,
+		KeyName:   c.NextKeyName(),
+		KeySHA256: keySHA256(r.secret),
+	}
+	_, _ = fmt.Fprintf(out, "[%s] starting rotation to key %s/%s\n", use, s.Provider, s.KeyName)
+
+	// the key is only used for decryption until it gets promoted, the API server does not need to be restarted yet
+	c.AddKey(s.Provider, kubeadm.EncryptionKey{Name: s.KeyName, Secret: r.secret})
+	if err := kubeadm.WriteEncryptionConfig(kubeadm.EncryptionProviderConfig, c); err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] the secret of key %s/%s is kept in %q only, pass it with --%s when running the rotation on the other masters\n", use, s.Provider, s.KeyName, kubeadm.EncryptionProviderConfig, constants.FlagEncryptionSecret)
+
+	return s, saveState(out, s)
+}
+
+// key returns the new key from the local encryption configuration or --encryption-secret,
+// verified with the checksum recorded when the rotation started.
+func (r *Rotate) key(c kubeadm.EncryptionConfig, s state) (kubeadm.EncryptionKey, error) {
+	k, ok := c.Key(s.Provider, s.KeyName)
+	if !ok {
+		if r.secret == "" {
+			return k, errors.Errorf(
+				"key %s/%s is not in %q, pass its secret from a master which already applied the rotation with --%s",
+				s.Provider,
+				s.KeyName,
+				kubeadm.EncryptionProviderConfig,
+				constants.FlagEncryptionSecret,
+			)
+		}
+		k = kubeadm.EncryptionKey{Name: s.KeyName, Secret: r.secret}
+	}
+	if keySHA256(k.Secret) != s.KeySHA256 {
+		return k, errors.Errorf("secret of key %s/%s does not match the key of the rotation", s.Provider, s.KeyName)
+	}
+
+	return k, nil
+}
+
+// apply updates the local encryption configuration according to the step and restarts the API server.
+func (r *Rotate) apply(out io.Writer, s state) error {
+	c, err := kubeadm.ReadEncryptionConfig(kubeadm.EncryptionProviderConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to read encryption configuration")
+	}
+
+	key, err := r.key(c, s)
+	if err != nil {
+		return err
+	}
+
+	switch s.Step {
+	case stepAdd:
+		c.AddKey(s.Provider, key)
+	case stepPromote:
+		// the key might be missing if the master joined after the key was added
+		c.AddKey(s.Provider, key)
+		c.PromoteKey(s.Provider, key.Name)
+	case stepPrune:
+		c.AddKey(s.Provider, key)
+		c.PromoteKey(s.Provider, key.Name)
+		c.PruneKeys(s.Provider, key.Name)
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing encryption configuration: %q\n", use, kubeadm.EncryptionProviderConfig)
+	if err := kubeadm.WriteEncryptionConfig(kubeadm.EncryptionProviderConfig, c); err != nil {
+		return err
+	}
+
+	return restartAPIServer(out, cri.GetCRISocket(r.containerRuntime))
+}
+
+func masterNodes() ([]string, error) {
+	var masters []string
+	for _, selector := range masterNodeSelectors {
+		// kubectl get nodes -l node-role.kubernetes.io/control-plane -o jsonpath={.items[*].metadata.name}
+		cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "nodes", "-l", selector, "-o", "jsonpath={.items[*].metadata.name}")
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		o, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list master nodes")
+		}
+		for _, n := range strings.Fields(string(o)) {
+			if !contains(masters, n) {
+				masters = append(masters, n)
+			}
+		}
+	}
+
+	return masters, nil
+}
+
+// restartAPIServer stops the kube-apiserver container, which is then restarted by the kubelet, and waits until it is ready again.
+func restartAPIServer(out io.Writer, criSocket string) error {
+	_, _ = fmt.Fprintf(out, "[%s] restarting kube-apiserver\n", use)
+
+	ids, err := apiServerContainers(criSocket)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		// crictl stop <id>
+		_, err := runner.Cmd(out, cmdCrictl, "--runtime-endpoint", criSocket, "stop", id).CombinedOutputAsync()
+		if err != nil {
+			return errors.Wrap(err, "failed to stop kube-apiserver")
+		}
+	}
+
+	timeout := 5 * time.Minute
+	_, _ = fmt.Fprintf(out, "[%s] waiting for kube-apiserver to restart. this may take %s\n", use, timeout)
+
+	tout := time.After(timeout)
+	tick := time.NewTicker(2 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			running, err := apiServerContainers(criSocket)
+			if err != nil || len(running) == 0 || contains(ids, running[0]) {
+				continue
+			}
+			// kubectl get --raw /readyz
+			cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "--raw", "/readyz")
+			cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+			if _, err := cmd.CombinedOutput(); err == nil {
+				return nil
+			}
+		case <-tout:
+			return errors.New("timeout exceeded. waiting for kube-apiserver to restart failed")
+		}
+	}
+}
+
+func apiServerContainers(criSocket string) ([]string, error) {
+	// crictl ps --name kube-apiserver --state running -q
+	o, err := runner.Cmd(ioutil.Discard, cmdCrictl, "--runtime-endpoint", criSocket, "ps", "--name", "kube-apiserver", "--state", "running", "-q").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list kube-apiserver containers")
+	}
+
+	return strings.Fields(string(o)), nil
+}
+
+// rewriteSecrets updates every secret, so they get encrypted with the new primary key.
+// Secrets are replaced one by one, so a concurrent update only requires that secret to be retried.
+func rewriteSecrets(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] rewriting secrets\n", use)
+
+	// kubectl get secrets --all-namespaces -o jsonpath={range .items[*]}{.metadata.namespace}/{.metadata.name}{"\n"}{end}
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "secrets", "--all-namespaces", "-o", `jsonpath={range .items[*]}{.metadata.namespace}/{.metadata.name}{"\n"}{end}`)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list secrets")
+	}
+
+	secrets := strings.Fields(string(o))
+	for _, secret := range secrets {
+		p := strings.SplitN(secret, "/", 2)
+		if len(p) != 2 {
+			continue
+		}
+		if err := rewriteSecret(p[0], p[1]); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(out, "[%s] rewrote %d secrets\n", use, len(secrets))
+
+	return nil
+}
+
+// rewriteSecret replaces the secret with its current content, retrying if it was changed in the meantime.
+func rewriteSecret(namespace, name string) error {
+	var err error
+	for i := 0; i < rewriteAttempts; i++ {
+		// kubectl get secret -n <namespace> <name> --ignore-not-found -o json
+		cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "secret", "-n", namespace, name, "--ignore-not-found", "-o", "json")
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		var secret []byte
+		if secret, err = cmd.Output(); err != nil {
+			continue
+		}
+		if len(bytes.TrimSpace(secret)) == 0 {
+			// deleted since it was listed
+			return nil
+		}
+
+		// kubectl replace -f -
+		cmd = runner.Cmd(ioutil.Discard, cmdKubectl, "replace", "-f", "-")
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+		cmd.Stdin = bytes.NewReader(secret)
+		var o []byte
+		if o, err = cmd.CombinedOutput(); err == nil {
+			return nil
+		}
+		err = errors.New(string(bytes.TrimSpace(o)))
+	}
+
+	return errors.Wrapf(err, "failed to rewrite secret %s/%s", namespace, name)
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rotate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	stateNamespace = "kube-system"
+	stateSecret    = "pke-secrets-encryption-rotation"
+	stateKey       = "state"
+)
+
+// Rotation steps. Adding, promoting and pruning keys has to be applied on every master before moving on.
+const (
+	stepAdd     = "add"
+	stepPromote = "promote"
+	stepRewrite = "rewrite"
+	stepPrune   = "prune"
+	stepDone    = "done"
+)
+
+// state of an ongoing key rotation, shared between the masters through a Secret.
+// The new key itself is only kept in the encryption configuration of the masters,
+// the state identifies it by name and checksum.
+type state struct {
+	Step     string `json:"step"`
+	Provider string `json:"provider"`
+	KeyName  string `json:"keyName"`
+	// KeySHA256 is the checksum of the secret of the new key, it verifies the key given to the other masters.
+	KeySHA256 string `json:"keySHA256"`
+	// Masters which have already applied the current step.
+	Applied []string `json:"applied,omitempty"`
+
+	resourceVersion string
+}
+
+func (s state) applied(node string) bool {
+	for _, n := range s.Applied {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// pending returns the masters which have not applied the current step yet.
+func (s state) pending(masters []string) []string {
+	var p []string
+	for _, m := range masters {
+		if !s.applied(m) {
+			p = append(p, m)
+		}
+	}
+	return p
+}
+
+// next moves to the following step.
+func (s *state) next() {
+	switch s.Step {
+	case stepAdd:
+		s.Step = stepPromote
+	case stepPromote:
+		s.Step = stepRewrite
+	case stepRewrite:
+		s.Step = stepPrune
+	default:
+		s.Step = stepDone
+	}
+	s.Applied = nil
+}
+
+// keySHA256 returns the hex encoded SHA-256 checksum of the secret of a key.
+func keySHA256(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadState reads the state of the ongoing rotation, returns nil if there is none.
+func loadState() (*state, error) {
+	// kubectl get secret -n kube-system pke-secrets-encryption-rotation --ignore-not-found -o json
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "get", "secret", "-n", stateNamespace, stateSecret, "--ignore-not-found", "-o", "json")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rotation state")
+	}
+	if len(bytes.TrimSpace(o)) == 0 {
+		return nil, nil
+	}
+
+	return parseState(o)
+}
+
+// parseState parses the state stored in the Secret, keeping its resource version for the next update.
+func parseState(secret []byte) (*state, error) {
+	var obj struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Data map[string][]byte `json:"data"`
+	}
+	if err := json.Unmarshal(secret, &obj); err != nil {
+		return nil, errors.Wrap(err, "failed to decode rotation state")
+	}
+	var s state
+	if err := json.Unmarshal(obj.Data[stateKey], &s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rotation state")
+	}
+	s.resourceVersion = obj.Metadata.ResourceVersion
+
+	return &s, nil
+}
+
+// stateManifest returns the Secret holding the state. The resource version makes the API server
+// reject the update if another master changed the state since it was read.
+func stateManifest(s state) ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"name":      stateSecret,
+		"namespace": stateNamespace,
+	}
+	if s.resourceVersion != "" {
+		metadata["resourceVersion"] = s.resourceVersion
+	}
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata":   metadata,
+		"data": map[string]string{
+			stateKey: base64.StdEncoding.EncodeToString(b),
+		},
+	}
+
+	return json.Marshal(secret)
+}
+
+// saveState stores the state of the rotation. The Secret is created when the rotation starts
+// and replaced afterwards, both fail if another master got ahead in the meantime.
+func saveState(out io.Writer, s *state) error {
+	manifest, err := stateManifest(*s)
+	if err != nil {
+		return err
+	}
+
+	verb := "replace"
+	if s.resourceVersion == "" {
+		verb = "create"
+	}
+	// kubectl create|replace -f - -o jsonpath={.metadata.resourceVersion}
+	cmd := runner.Cmd(out, cmdKubectl, verb, "-f", "-", "-o", "jsonpath={.metadata.resourceVersion}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	cmd.Stdin = bytes.NewReader(manifest)
+	o, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to save rotation state, it might have been changed by another master, rerun the command")
+	}
+	s.resourceVersion = strings.TrimSpace(string(o))
+
+	return nil
+}
+
+// deleteState removes the state of the finished rotation.
+func deleteState(out io.Writer) error {
+	cmd := runner.Cmd(out, cmdKubectl, "delete", "secret", "-n", stateNamespace, stateSecret, "--ignore-not-found")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
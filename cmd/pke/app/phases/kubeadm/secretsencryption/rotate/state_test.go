@@ -0,0 +1,101 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rotate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm"
+)
+
+func TestState(t *testing.T) {
+	masters := []string{"master-0", "master-1"}
+	s := state{Step: stepAdd}
+
+	var steps []string
+	for s.Step != stepDone {
+		steps = append(steps, s.Step)
+		if s.Step != stepRewrite {
+			require.Equal(t, masters, s.pending(masters))
+			s.Applied = append(s.Applied, "master-1")
+			require.Equal(t, []string{"master-0"}, s.pending(masters))
+			s.Applied = append(s.Applied, "master-0")
+			require.Empty(t, s.pending(masters))
+		}
+		s.next()
+		require.Empty(t, s.Applied)
+	}
+
+	require.Equal(t, []string{stepAdd, stepPromote, stepRewrite, stepPrune}, steps)
+}
+
+func TestStateManifest(t *testing.T) {
+	s := state{Step: stepPromote, Provider: "aescbc", KeyName: "key2", KeySHA256: keySHA256("secret"), Applied: []string{"master-0"}, resourceVersion: "42"}
+
+	b, err := stateManifest(s)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"resourceVersion":"42"`)
+
+	got, err := parseState(b)
+	require.NoError(t, err)
+	require.Equal(t, s, *got)
+
+	s.resourceVersion = ""
+	b, err = stateManifest(s)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "resourceVersion")
+}
+
+func TestKey(t *testing.T) {
+	secret := "c2VjcmV0LXNlY3JldC1zZWNyZXQtc2VjcmV0LXNlY3I="
+	s := state{Step: stepPromote, Provider: "aescbc", KeyName: "key2", KeySHA256: keySHA256(secret)}
+	local := kubeadm.EncryptionConfig{
+		Providers: []kubeadm.EncryptionProvider{
+			{Type: "aescbc", Keys: []kubeadm.EncryptionKey{{Name: "key1", Secret: "old"}, {Name: "key2", Secret: secret}}},
+		},
+	}
+	missing := kubeadm.EncryptionConfig{
+		Providers: []kubeadm.EncryptionProvider{
+			{Type: "aescbc", Keys: []kubeadm.EncryptionKey{{Name: "key1", Secret: "old"}}},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		config kubeadm.EncryptionConfig
+		secret string
+		err    bool
+	}{
+		{name: "local", config: local},
+		{name: "given", config: missing, secret: secret},
+		{name: "not given", config: missing, err: true},
+		{name: "mismatch", config: missing, secret: "b3RoZXI=", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Rotate{secret: tc.secret}
+			k, err := r.key(tc.config, s)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, kubeadm.EncryptionKey{Name: "key2", Secret: secret}, k)
+		})
+	}
+}
@@ -15,21 +15,18 @@
 package create
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token"
-	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -39,15 +36,14 @@ const (
 	use   = "create"
 	short = "Create Kubernetes bootstrap token"
 
-	cmdKubeadm = "kubeadm"
-	kubeConfig = "/etc/kubernetes/admin.conf"
 	caCertFile = "/etc/kubernetes/pki/ca.crt"
 )
 
 var _ phases.Runnable = (*Create)(nil)
 
 type Create struct {
-	o string
+	o       string
+	options token.Options
 }
 
 func NewCommand() *cobra.Command {
@@ -64,11 +60,50 @@ func (*Create) Short() string {
 
 func (*Create) RegisterFlags(flags *pflag.FlagSet) {
 	flags.StringP(constants.FlagOutput, constants.FlagOutputShort, "", "Output format; available options are 'yaml', 'json' and 'short'")
+	RegisterOptionFlags(flags)
+}
+
+// RegisterOptionFlags registers the flags of the token options.
+func RegisterOptionFlags(flags *pflag.FlagSet) {
+	flags.Duration(constants.FlagTokenTTL, 24*time.Hour, "Duration before the token is automatically deleted, 0 means the token never expires")
+	flags.StringSlice(constants.FlagTokenUsages, []string{token.UsageAuthentication, token.UsageSigning}, "Ways in which the token can be used; available options are 'authentication' and 'signing'")
+	flags.StringSlice(constants.FlagTokenGroups, nil, "Extra groups the token authenticates as, each must start with 'system:bootstrappers:'")
+	flags.String(constants.FlagTokenDescription, "", "Human friendly description of how the token is used, prefixed with \"Created by pke\" to mark the tokens replaced on rotation")
+}
+
+// OptionParameters reads and validates the token options.
+func OptionParameters(cmd *cobra.Command) (o token.Options, err error) {
+	o.TTL, err = cmd.Flags().GetDuration(constants.FlagTokenTTL)
+	if err != nil {
+		return
+	}
+	o.Usages, err = cmd.Flags().GetStringSlice(constants.FlagTokenUsages)
+	if err != nil {
+		return
+	}
+	o.Groups, err = cmd.Flags().GetStringSlice(constants.FlagTokenGroups)
+	if err != nil {
+		return
+	}
+	o.Description, err = cmd.Flags().GetString(constants.FlagTokenDescription)
+	if err != nil {
+		return
+	}
+
+	if err = o.Validate(); err != nil {
+		err = errors.Wrap(constants.ErrInvalidInput, err.Error())
+	}
+
+	return
 }
 
 func (c *Create) Validate(cmd *cobra.Command) error {
 	var err error
 	c.o, err = cmd.Flags().GetString(constants.FlagOutput)
+	if err != nil {
+		return err
+	}
+	c.options, err = OptionParameters(cmd)
 
 	return err
 }
@@ -79,41 +114,25 @@ func (c *Create) Run(out io.Writer) error {
 		return errors.Wrap(err, "failed to generate certificate hash")
 	}
 
-	cmd := runner.Cmd(ioutil.Discard, cmdKubeadm, "token", "create")
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	o, err := cmd.Output()
+	tok, err := token.Create(ioutil.Discard, c.options)
 	if err != nil {
-		return errors.Wrap(err, "failed to create secret")
+		return err
+	}
+	id, err := token.ID(tok)
+	if err != nil {
+		return err
 	}
 
-	var t *token.Token
-
-	scn := bufio.NewScanner(bytes.NewReader(o))
-	for scn.Scan() {
-		line := scn.Text()
-		if line == "" {
-			continue
-		}
-		if err := scn.Err(); err != nil {
-			return errors.Wrapf(err, "failed to scan output: %s", o)
-		}
-
-		idx := strings.IndexRune(line, '.')
-		if idx < 0 {
-			return errors.New("creation error: invalid token format")
-		}
-
-		t, err = token.Get(ioutil.Discard, "bootstrap-token-"+line[:idx], hash)
-		if err != nil {
-			return errors.Wrapf(err, "failed to get token for %q", line)
-		}
+	t, err := token.Get(ioutil.Discard, token.SecretName(id), hash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get token for %q", tok)
 	}
 
 	switch c.o {
 	default:
 		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-		_, _ = fmt.Fprintf(tw, "Token\tTTL\tExpires\tExpired\tCert Hash\n")
-		_, _ = fmt.Fprintf(tw, "%s\t%dh\t%s\t%t\t%s\n", t.Token, t.TTL, t.Expires, t.Expired, t.CertHash)
+		_, _ = fmt.Fprintf(tw, "Token\tTTL\tExpires\tExpired\tUsages\tDescription\tCert Hash\n")
+		_, _ = fmt.Fprintf(tw, "%s\t%dh\t%s\t%t\t%s\t%s\t%s\n", t.Token, t.TTL, t.Expires, t.Expired, strings.Join(t.Usages, ","), t.Description, t.CertHash)
 		_ = tw.Flush()
 		_ = tw.Flush()
 
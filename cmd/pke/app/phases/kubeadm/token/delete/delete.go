@@ -0,0 +1,79 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delete
+
+import (
+	"fmt"
+	"io"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "delete"
+	short = "Delete Kubernetes bootstrap token(s) by token or token id"
+)
+
+var _ phases.Runnable = (*Delete)(nil)
+
+type Delete struct {
+	ids []string
+}
+
+func NewCommand() *cobra.Command {
+	cmd := phases.NewCommand(&Delete{})
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	return cmd
+}
+
+func (*Delete) Use() string {
+	return use + " <token-id>..."
+}
+
+func (*Delete) Short() string {
+	return short
+}
+
+func (*Delete) RegisterFlags(flags *pflag.FlagSet) {}
+
+func (d *Delete) Validate(cmd *cobra.Command) error {
+	d.ids = nil
+	for _, arg := range cmd.Flags().Args() {
+		id, err := token.ID(arg)
+		if err != nil {
+			return errors.Wrap(constants.ErrInvalidInput, err.Error())
+		}
+		d.ids = append(d.ids, id)
+	}
+
+	return nil
+}
+
+func (d *Delete) Run(out io.Writer) error {
+	if err := token.Delete(out, d.ids...); err != nil {
+		return err
+	}
+	for _, id := range d.ids {
+		_, _ = fmt.Fprintf(out, "[%s] deleted token %q\n", use, id)
+	}
+
+	return nil
+}
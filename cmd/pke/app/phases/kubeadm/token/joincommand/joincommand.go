@@ -0,0 +1,208 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package joincommand
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/kubeconfig"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/create"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "join-command"
+	short = "Print the command joining a new node to the cluster"
+
+	cmdKubectl = "kubectl"
+	kubeConfig = "/etc/kubernetes/admin.conf"
+	caCertFile = "/etc/kubernetes/pki/ca.crt"
+)
+
+// sharedCertificates must be copied from an existing master before joining an additional control plane node.
+var sharedCertificates = []string{
+	"/etc/kubernetes/pki/ca.crt",
+	"/etc/kubernetes/pki/ca.key",
+	"/etc/kubernetes/pki/sa.key",
+	"/etc/kubernetes/pki/sa.pub",
+	"/etc/kubernetes/pki/front-proxy-ca.crt",
+	"/etc/kubernetes/pki/front-proxy-ca.key",
+	"/etc/kubernetes/pki/etcd/ca.crt",
+	"/etc/kubernetes/pki/etcd/ca.key",
+}
+
+var _ phases.Runnable = (*JoinCommand)(nil)
+
+type JoinCommand struct {
+	token        string
+	controlPlane bool
+	options      token.Options
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&JoinCommand{})
+}
+
+func (*JoinCommand) Use() string {
+	return use
+}
+
+func (*JoinCommand) Short() string {
+	return short
+}
+
+func (*JoinCommand) RegisterFlags(flags *pflag.FlagSet) {
+	flags.Bool(constants.FlagTokenControlPlane, false, "Print the command joining an additional control plane node instead of a worker")
+	flags.String(constants.FlagKubeadmToken, "", "Existing token to use instead of creating a new one")
+	create.RegisterOptionFlags(flags)
+}
+
+func (j *JoinCommand) Validate(cmd *cobra.Command) error {
+	var err error
+	j.controlPlane, err = cmd.Flags().GetBool(constants.FlagTokenControlPlane)
+	if err != nil {
+		return err
+	}
+	j.token, err = cmd.Flags().GetString(constants.FlagKubeadmToken)
+	if err != nil {
+		return err
+	}
+	if j.token != "" && !token.Valid(j.token) {
+		return errors.Wrapf(constants.ErrInvalidInput, "--%s: invalid token, expected format is [a-z0-9]{6}.[a-z0-9]{16}", constants.FlagKubeadmToken)
+	}
+	j.options, err = create.OptionParameters(cmd)
+
+	return err
+}
+
+func (j *JoinCommand) Run(out io.Writer) error {
+	hash, err := token.CertHash(ioutil.Discard, caCertFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate certificate hash")
+	}
+
+	c, err := clusterConfig()
+	if err != nil {
+		return err
+	}
+
+	t := j.token
+	if t == "" {
+		t, err = token.Create(ioutil.Discard, j.options)
+		if err != nil {
+			return err
+		}
+	}
+
+	if j.controlPlane {
+		_, _ = fmt.Fprintf(out, "# copy the following files from an existing master node before running the command:\n")
+		for _, f := range sharedCertificates {
+			_, _ = fmt.Fprintf(out, "#   %s\n", f)
+		}
+	}
+	_, _ = fmt.Fprintln(out, Command(c, t, hash, j.controlPlane))
+
+	return nil
+}
+
+// ClusterConfig contains the cluster settings a joining node has to match.
+type ClusterConfig struct {
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint"`
+	KubernetesVersion    string `json:"kubernetesVersion"`
+	ClusterName          string `json:"clusterName"`
+	Networking           struct {
+		PodSubnet     string `json:"podSubnet"`
+		ServiceSubnet string `json:"serviceSubnet"`
+	} `json:"networking"`
+}
+
+func clusterConfig() (ClusterConfig, error) {
+	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, "-n", "kube-system", "get", "cm", "kubeadm-config", "-ojsonpath={.data.ClusterConfiguration}")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.Output()
+	if err != nil {
+		return ClusterConfig{}, errors.Wrap(err, "failed to read kubeadm-config")
+	}
+
+	var c ClusterConfig
+	if err := yaml.Unmarshal(o, &c); err != nil {
+		return ClusterConfig{}, errors.Wrap(err, "failed to parse cluster configuration")
+	}
+
+	if c.ControlPlaneEndpoint == "" {
+		admin, err := kubeconfig.AdminCluster()
+		if err != nil {
+			return ClusterConfig{}, err
+		}
+		u, err := url.Parse(admin.Server)
+		if err != nil {
+			return ClusterConfig{}, errors.Wrapf(err, "failed to parse api server address %q", admin.Server)
+		}
+		c.ControlPlaneEndpoint = u.Host
+	}
+
+	return c, nil
+}
+
+// Command returns the pke command joining a worker or an additional control plane node.
+func Command(c ClusterConfig, token, certHash string, controlPlane bool) string {
+	args := []string{"pke", "install"}
+	if controlPlane {
+		args = append(args, "master",
+			flag(constants.FlagClusterMode, "ha"),
+			"--"+constants.FlagControlPlaneJoin,
+		)
+	} else {
+		args = append(args, "worker")
+	}
+	args = append(args,
+		flag(constants.FlagAPIServerHostPort, c.ControlPlaneEndpoint),
+		flag(constants.FlagKubeadmToken, token),
+		flag(constants.FlagCACertHash, certHash),
+	)
+	if c.KubernetesVersion != "" {
+		args = append(args, flag(constants.FlagKubernetesVersion, strings.TrimPrefix(c.KubernetesVersion, "v")))
+	}
+	if c.Networking.ServiceSubnet != "" {
+		args = append(args, flag(constants.FlagServiceCIDR, c.Networking.ServiceSubnet))
+	}
+	// the pod network cidr of a worker is specific to the node
+	if controlPlane {
+		if c.ClusterName != "" {
+			args = append(args, flag(constants.FlagClusterName, c.ClusterName))
+		}
+		if c.Networking.PodSubnet != "" {
+			args = append(args, flag(constants.FlagPodNetworkCIDR, c.Networking.PodSubnet))
+		}
+	}
+
+	return strings.Join(args, " ")
+}
+
+func flag(name, value string) string {
+	return fmt.Sprintf("--%s=%s", name, value)
+}
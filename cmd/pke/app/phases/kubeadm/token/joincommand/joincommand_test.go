@@ -0,0 +1,58 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package joincommand
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	c := ClusterConfig{
+		ControlPlaneEndpoint: "192.168.64.11:6443",
+		KubernetesVersion:    "v1.23.5",
+		ClusterName:          "pke",
+	}
+	c.Networking.PodSubnet = "10.20.0.0/16"
+	c.Networking.ServiceSubnet = "10.10.0.0/16"
+
+	testCases := []struct {
+		name         string
+		controlPlane bool
+		expected     string
+	}{
+		{
+			name:     "worker",
+			expected: "pke install worker --kubernetes-api-server=192.168.64.11:6443 --kubernetes-node-token=abcdef.0123456789abcdef --kubernetes-api-server-ca-cert-hash=sha256:hash --kubernetes-version=1.23.5 --kubernetes-service-cidr=10.10.0.0/16",
+		},
+		{
+			name:         "control plane",
+			controlPlane: true,
+			expected:     "pke install master --kubernetes-master-mode=ha --kubernetes-join-control-plane --kubernetes-api-server=192.168.64.11:6443 --kubernetes-node-token=abcdef.0123456789abcdef --kubernetes-api-server-ca-cert-hash=sha256:hash --kubernetes-version=1.23.5 --kubernetes-service-cidr=10.10.0.0/16 --kubernetes-cluster-name=pke --kubernetes-pod-network-cidr=10.20.0.0/16",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Command(c, "abcdef.0123456789abcdef", "sha256:hash", tc.controlPlane))
+		})
+	}
+
+	require.Equal(t,
+		"pke install worker --kubernetes-api-server=10.0.0.1:6443 --kubernetes-node-token=t --kubernetes-api-server-ca-cert-hash=h",
+		Command(ClusterConfig{ControlPlaneEndpoint: "10.0.0.1:6443"}, "t", "h", false),
+	)
+}
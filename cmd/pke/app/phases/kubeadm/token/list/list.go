@@ -15,20 +15,17 @@
 package list
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
+	"strings"
 	"text/tabwriter"
 
 	"emperror.dev/errors"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token"
-	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,8 +35,6 @@ const (
 	use   = "list"
 	short = "List Kubernetes bootstrap token(s)"
 
-	cmdKubectl = "kubectl"
-	kubeConfig = "/etc/kubernetes/admin.conf"
 	caCertFile = "/etc/kubernetes/pki/ca.crt"
 )
 
@@ -78,41 +73,18 @@ func (l *List) Run(out io.Writer) error {
 		return errors.Wrap(err, "failed to generate certificate hash")
 	}
 
-	// kubectl get secret -n kube-system -o jsonpath='{range .items[?(.type=="bootstrap.kubernetes.io/token")]}{.metadata.name}{"\n"}{end}'
-	args := []string{"get", "secret", "-n", "kube-system", "-o", `jsonpath={range .items[?(.type=="bootstrap.kubernetes.io/token")]}{.metadata.name}{"\n"}{end}`}
-	cmd := runner.Cmd(ioutil.Discard, cmdKubectl, args...)
-	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
-	o, err := cmd.CombinedOutput()
+	tokens, err := token.List(ioutil.Discard, hash)
 	if err != nil {
-		return errors.Wrap(err, "failed to read secret")
-	}
-
-	var list = token.Output{}
-
-	scn := bufio.NewScanner(bytes.NewReader(o))
-	for scn.Scan() {
-		line := scn.Text()
-		if line == "" {
-			continue
-		}
-		if err := scn.Err(); err != nil {
-			return errors.Wrapf(err, "failed to scan output: %s", o)
-		}
-
-		t, err := token.Get(ioutil.Discard, line, hash)
-		if err != nil {
-			return errors.Wrapf(err, "failed to get token for %q", line)
-		}
-
-		list.Tokens = append(list.Tokens, t)
+		return err
 	}
+	list := token.Output{Tokens: tokens}
 
 	switch l.o {
 	default:
 		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-		_, _ = fmt.Fprintf(tw, "Token\tTTL\tExpires\tExpired\tCert Hash\n")
+		_, _ = fmt.Fprintf(tw, "Token\tTTL\tExpires\tExpired\tUsages\tDescription\tCert Hash\n")
 		for _, row := range list.Tokens {
-			_, _ = fmt.Fprintf(tw, "%s\t%dh\t%s\t%t\t%s\n", row.Token, row.TTL, row.Expires, row.Expired, row.CertHash)
+			_, _ = fmt.Fprintf(tw, "%s\t%dh\t%s\t%t\t%s\t%s\t%s\n", row.Token, row.TTL, row.Expires, row.Expired, strings.Join(row.Usages, ","), row.Description, row.CertHash)
 		}
 		_ = tw.Flush()
 
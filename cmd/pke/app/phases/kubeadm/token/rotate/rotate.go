@@ -0,0 +1,102 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rotate
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/token/create"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	use   = "rotate"
+	short = "Replace the Kubernetes bootstrap tokens created by pke for joining nodes with a new one"
+
+	caCertFile = "/etc/kubernetes/pki/ca.crt"
+)
+
+var _ phases.Runnable = (*Rotate)(nil)
+
+type Rotate struct {
+	options token.Options
+}
+
+func NewCommand() *cobra.Command {
+	return phases.NewCommand(&Rotate{})
+}
+
+func (*Rotate) Use() string {
+	return use
+}
+
+func (*Rotate) Short() string {
+	return short
+}
+
+func (*Rotate) RegisterFlags(flags *pflag.FlagSet) {
+	create.RegisterOptionFlags(flags)
+}
+
+func (r *Rotate) Validate(cmd *cobra.Command) error {
+	var err error
+	r.options, err = create.OptionParameters(cmd)
+
+	return err
+}
+
+func (r *Rotate) Run(out io.Writer) error {
+	hash, err := token.CertHash(ioutil.Discard, caCertFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate certificate hash")
+	}
+
+	tokens, err := token.List(ioutil.Discard, hash)
+	if err != nil {
+		return err
+	}
+
+	t, err := token.Create(ioutil.Discard, r.options)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "[%s] created token %q\n", use, t)
+
+	// only tokens created by pke for joining nodes are replaced, signing only and foreign tokens are kept
+	var ids []string
+	for _, old := range tokens {
+		if old.Managed() && old.HasUsage(token.UsageAuthentication) {
+			ids = append(ids, old.ID())
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := token.Delete(ioutil.Discard, ids...); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(out, "[%s] deleted token %q\n", use, id)
+	}
+
+	return nil
+}
@@ -25,6 +25,9 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"emperror.dev/errors"
@@ -32,22 +35,183 @@ import (
 )
 
 const (
+	cmdKubeadm = "kubeadm"
 	cmdKubectl = "kubectl"
 	kubeConfig = "/etc/kubernetes/admin.conf"
+
+	usagePrefix = "usage-bootstrap-"
+	groupPrefix = "system:bootstrappers:"
+
+	// UsageAuthentication allows the token to authenticate to the API server as a bootstrap token.
+	UsageAuthentication = "authentication"
+	// UsageSigning allows the token to sign the cluster-info ConfigMap.
+	UsageSigning = "signing"
+
+	// managedDescription starts the description of the tokens created by pke, only these are replaced on rotation.
+	managedDescription = "Created by pke"
+)
+
+var (
+	idRegexp    = regexp.MustCompile(`^[a-z0-9]{6}$`)
+	tokenRegexp = regexp.MustCompile(`^([a-z0-9]{6})\.([a-z0-9]{16})$`)
 )
 
 type Token struct {
-	Token    string    `json:"token"`
-	TTL      int       `json:"-"`
-	Expires  time.Time `json:"expires"`
-	Expired  bool      `json:"expired"`
-	CertHash string    `json:"hash"`
+	Token       string    `json:"token"`
+	TTL         int       `json:"-"`
+	Expires     time.Time `json:"expires"`
+	Expired     bool      `json:"expired"`
+	CertHash    string    `json:"hash"`
+	Usages      []string  `json:"usages,omitempty"`
+	Groups      []string  `json:"groups,omitempty"`
+	Description string    `json:"description,omitempty"`
 }
 
 type Output struct {
 	Tokens []*Token `json:"tokens"`
 }
 
+// Options of a new bootstrap token.
+type Options struct {
+	TTL         time.Duration
+	Usages      []string
+	Groups      []string
+	Description string
+}
+
+// Validate checks the options before they are passed to kubeadm.
+func (o Options) Validate() error {
+	if o.TTL < 0 {
+		return errors.Errorf("invalid ttl %q, must not be negative", o.TTL)
+	}
+	for _, u := range o.Usages {
+		if u != UsageAuthentication && u != UsageSigning {
+			return errors.Errorf("invalid usage %q, available usages are %q and %q", u, UsageAuthentication, UsageSigning)
+		}
+	}
+	for _, g := range o.Groups {
+		if !strings.HasPrefix(g, groupPrefix) || len(g) == len(groupPrefix) {
+			return errors.Errorf("invalid group %q, must start with %q", g, groupPrefix)
+		}
+	}
+
+	return nil
+}
+
+func (o Options) args() []string {
+	args := []string{"token", "create", "--ttl", o.TTL.String()}
+	if len(o.Usages) > 0 {
+		args = append(args, "--usages", strings.Join(o.Usages, ","))
+	}
+	if len(o.Groups) > 0 {
+		args = append(args, "--groups", strings.Join(o.Groups, ","))
+	}
+	description := managedDescription
+	if o.Description != "" {
+		description += ": " + o.Description
+	}
+	args = append(args, "--description", description)
+
+	return args
+}
+
+// Create creates a new bootstrap token and returns it.
+func Create(out io.Writer, o Options) (string, error) {
+	cmd := runner.Cmd(out, cmdKubeadm, o.args()...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	b, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create token")
+	}
+
+	t := strings.TrimSpace(string(b))
+	if !Valid(t) {
+		return "", errors.Errorf("creation error: invalid token format: %q", t)
+	}
+
+	return t, nil
+}
+
+// Delete deletes the bootstrap tokens by their ids.
+func Delete(out io.Writer, ids ...string) error {
+	cmd := runner.Cmd(out, cmdKubeadm, append([]string{"token", "delete"}, ids...)...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err := cmd.CombinedOutputAsync()
+
+	return errors.Wrapf(err, "failed to delete token(s) %s", strings.Join(ids, ", "))
+}
+
+// ID returns the id of a token or token id, or an error if it is neither.
+func ID(token string) (string, error) {
+	if idRegexp.MatchString(token) {
+		return token, nil
+	}
+	if m := tokenRegexp.FindStringSubmatch(token); m != nil {
+		return m[1], nil
+	}
+
+	return "", errors.Errorf("invalid token or token id %q", token)
+}
+
+// Valid reports whether the token has the format of a bootstrap token.
+func Valid(token string) bool {
+	return tokenRegexp.MatchString(token)
+}
+
+// SecretName returns the name of the secret storing the bootstrap token.
+func SecretName(id string) string {
+	return "bootstrap-token-" + id
+}
+
+// ID returns the public id part of the token.
+func (t *Token) ID() string {
+	return strings.SplitN(t.Token, ".", 2)[0]
+}
+
+// Managed reports whether the token was created by pke.
+func (t *Token) Managed() bool {
+	return strings.HasPrefix(t.Description, managedDescription)
+}
+
+// HasUsage reports whether the token can be used for the given purpose.
+func (t *Token) HasUsage(usage string) bool {
+	for _, u := range t.Usages {
+		if u == usage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// List returns every bootstrap token of the cluster.
+func List(out io.Writer, certHash string) ([]*Token, error) {
+	// kubectl get secret -n kube-system -o jsonpath='{range .items[?(.type=="bootstrap.kubernetes.io/token")]}{.metadata.name}{"\n"}{end}'
+	args := []string{"get", "secret", "-n", "kube-system", "-o", `jsonpath={range .items[?(.type=="bootstrap.kubernetes.io/token")]}{.metadata.name}{"\n"}{end}`}
+	cmd := runner.Cmd(out, cmdKubectl, args...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	o, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read secret")
+	}
+
+	var tokens []*Token
+	for _, line := range strings.Split(string(o), "\n") {
+		if line == "" {
+			continue
+		}
+
+		t, err := Get(out, line, certHash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get token for %q", line)
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
 func Get(out io.Writer, secret, certHash string) (*Token, error) {
 	// kubectl get -n kube-system secret -o json
 	subCmd := runner.Cmd(out, cmdKubectl, []string{"get", "secret", "-n", "kube-system", "-o", "json", secret}...)
@@ -64,40 +228,54 @@ func Get(out io.Writer, secret, certHash string) (*Token, error) {
 		return nil, err
 	}
 
-	tid, err := base64.StdEncoding.DecodeString(s.Data["token-id"])
-	if err != nil {
-		return nil, err
-	}
-	ts, err := base64.StdEncoding.DecodeString(s.Data["token-secret"])
-	if err != nil {
-		return nil, err
-	}
-	exp, err := base64.StdEncoding.DecodeString(s.Data["expiration"])
-	if err != nil {
-		return nil, err
+	return parseSecret(s.Data, certHash, time.Now())
+}
+
+// parseSecret reads the token from the data of its bootstrap token secret.
+func parseSecret(data map[string]string, certHash string, now time.Time) (*Token, error) {
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode %q", k)
+		}
+		values[k] = string(b)
 	}
 
 	var (
 		t       time.Time
 		ttl     int
 		expired bool
+		err     error
 	)
-	if exps := string(exp); exps != "" {
+	if exps := values["expiration"]; exps != "" {
 		t, err = time.Parse(time.RFC3339, exps)
 		if err != nil {
 			return nil, err
 		}
-		ttl = int(t.Sub(time.Now()).Hours())
-		expired = t.Sub(time.Now()) <= 0
+		ttl = int(t.Sub(now).Hours())
+		expired = t.Sub(now) <= 0
+	}
+
+	tok := &Token{
+		Token:       fmt.Sprintf("%s.%s", values["token-id"], values["token-secret"]),
+		TTL:         ttl,
+		Expires:     t,
+		Expired:     expired,
+		CertHash:    certHash,
+		Description: values["description"],
+	}
+	for k, v := range values {
+		if strings.HasPrefix(k, usagePrefix) && v == "true" {
+			tok.Usages = append(tok.Usages, strings.TrimPrefix(k, usagePrefix))
+		}
+	}
+	sort.Strings(tok.Usages)
+	if g := values["auth-extra-groups"]; g != "" {
+		tok.Groups = strings.Split(g, ",")
 	}
 
-	return &Token{
-		Token:    fmt.Sprintf("%s.%s", tid, ts),
-		TTL:      ttl,
-		Expires:  t,
-		Expired:  expired,
-		CertHash: certHash,
-	}, nil
+	return tok, nil
 }
 
 func CertHash(out io.Writer, certFile string) (string, error) {
@@ -0,0 +1,166 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecret(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := func(values map[string]string) map[string]string {
+		d := make(map[string]string, len(values))
+		for k, v := range values {
+			d[k] = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		return d
+	}
+
+	tok, err := parseSecret(data(map[string]string{
+		"token-id":                       "abcdef",
+		"token-secret":                   "0123456789abcdef",
+		"expiration":                     "2022-03-02T12:00:00Z",
+		"description":                    "worker join",
+		"auth-extra-groups":              "system:bootstrappers:kubeadm:default-node-token,system:bootstrappers:workers",
+		"usage-bootstrap-signing":        "true",
+		"usage-bootstrap-authentication": "true",
+	}), "sha256:hash", now)
+	require.NoError(t, err)
+	require.Equal(t, &Token{
+		Token:       "abcdef.0123456789abcdef",
+		TTL:         24,
+		Expires:     time.Date(2022, 3, 2, 12, 0, 0, 0, time.UTC),
+		CertHash:    "sha256:hash",
+		Usages:      []string{UsageAuthentication, UsageSigning},
+		Groups:      []string{"system:bootstrappers:kubeadm:default-node-token", "system:bootstrappers:workers"},
+		Description: "worker join",
+	}, tok)
+	require.Equal(t, "abcdef", tok.ID())
+	require.True(t, tok.HasUsage(UsageSigning))
+	require.False(t, tok.Managed())
+	tok.Description = "Created by pke: worker join"
+	require.True(t, tok.Managed())
+
+	tok, err = parseSecret(data(map[string]string{
+		"token-id":                       "abcdef",
+		"token-secret":                   "0123456789abcdef",
+		"expiration":                     "2022-03-01T11:00:00Z",
+		"usage-bootstrap-authentication": "false",
+		"usage-bootstrap-signing":        "true",
+	}), "", now)
+	require.NoError(t, err)
+	require.True(t, tok.Expired)
+	require.False(t, tok.HasUsage(UsageAuthentication))
+
+	tok, err = parseSecret(data(map[string]string{"token-id": "abcdef", "token-secret": "0123456789abcdef"}), "", now)
+	require.NoError(t, err)
+	require.False(t, tok.Expired)
+	require.True(t, tok.Expires.IsZero())
+
+	_, err = parseSecret(map[string]string{"token-id": "not base64!"}, "", now)
+	require.Error(t, err)
+}
+
+func TestOptions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options Options
+		args    []string
+		err     bool
+	}{
+		{
+			name:    "defaults",
+			options: Options{TTL: 24 * time.Hour},
+			args:    []string{"token", "create", "--ttl", "24h0m0s", "--description", "Created by pke"},
+		},
+		{
+			name: "all options",
+			options: Options{
+				TTL:         time.Hour,
+				Usages:      []string{UsageAuthentication, UsageSigning},
+				Groups:      []string{"system:bootstrappers:workers"},
+				Description: "worker join",
+			},
+			args: []string{"token", "create", "--ttl", "1h0m0s", "--usages", "authentication,signing", "--groups", "system:bootstrappers:workers", "--description", "Created by pke: worker join"},
+		},
+		{
+			name:    "never expires",
+			options: Options{},
+			args:    []string{"token", "create", "--ttl", "0s", "--description", "Created by pke"},
+		},
+		{
+			name:    "negative ttl",
+			options: Options{TTL: -time.Hour},
+			err:     true,
+		},
+		{
+			name:    "unknown usage",
+			options: Options{Usages: []string{"join"}},
+			err:     true,
+		},
+		{
+			name:    "group without prefix",
+			options: Options{Groups: []string{"system:masters"}},
+			err:     true,
+		},
+		{
+			name:    "group prefix only",
+			options: Options{Groups: []string{"system:bootstrappers:"}},
+			err:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.options.Validate()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.args, tc.options.args())
+		})
+	}
+}
+
+func TestID(t *testing.T) {
+	testCases := []struct {
+		token string
+		id    string
+		err   bool
+	}{
+		{token: "abcdef", id: "abcdef"},
+		{token: "abcdef.0123456789abcdef", id: "abcdef"},
+		{token: "ABCDEF", err: true},
+		{token: "abcdef.0123", err: true},
+		{token: "bootstrap-token-abcdef", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.token, func(t *testing.T) {
+			id, err := ID(tc.token)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.id, id)
+		})
+	}
+}
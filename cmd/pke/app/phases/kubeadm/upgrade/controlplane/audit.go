@@ -0,0 +1,48 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const auditPolicyFile = "/etc/kubernetes/audit-policy-file.yaml"
+
+// auditPolicyV1beta1 matches the apiVersion of audit policies written by earlier PKE releases.
+var auditPolicyV1beta1 = regexp.MustCompile(`(?m)^apiVersion:\s*["']?audit\.k8s\.io/v1beta1["']?\s*$`)
+
+// migrateAuditPolicy rewrites an audit.k8s.io/v1beta1 policy to audit.k8s.io/v1, the Policy schema is the same in both.
+func migrateAuditPolicy(out io.Writer, filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !auditPolicyV1beta1.Match(b) {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] migrating audit policy to audit.k8s.io/v1: %q\n", use, filename)
+
+	return file.Overwrite(filename, auditPolicyV1beta1.ReplaceAllString(string(b), "apiVersion: audit.k8s.io/v1"))
+}
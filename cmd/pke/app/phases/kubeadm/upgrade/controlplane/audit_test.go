@@ -0,0 +1,58 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateAuditPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-policy")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "audit-policy-file.yaml")
+
+	testCases := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{
+			name:   "v1beta1",
+			policy: "apiVersion: audit.k8s.io/v1beta1\nkind: Policy\nrules:\n  - level: Metadata\n",
+			want:   "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n  - level: Metadata\n",
+		},
+		{
+			name:   "v1",
+			policy: "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n  - level: Metadata\n",
+			want:   "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n  - level: Metadata\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		require.NoError(t, ioutil.WriteFile(filename, []byte(tc.policy), 0640), tc.name)
+		require.NoError(t, migrateAuditPolicy(ioutil.Discard, filename), tc.name)
+		b, err := ioutil.ReadFile(filename)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.want, string(b), tc.name)
+	}
+
+	require.NoError(t, migrateAuditPolicy(ioutil.Discard, filepath.Join(dir, "nonexistent.yaml")))
+}
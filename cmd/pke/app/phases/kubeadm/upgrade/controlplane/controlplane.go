@@ -25,6 +25,7 @@ import (
 	"github.com/banzaicloud/pke/cmd/pke/app/config"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/metallb"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases/kubeadm/upgrade"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/flags"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
@@ -119,6 +120,12 @@ func (c *ControlPlane) upgrade(out io.Writer, from, to *semver.Version) error {
 		return errors.Wrapf(err, "failed to upgrade kubeadm to version %s", to)
 	}
 
+	// the API server restarted by kubeadm no longer serves audit.k8s.io/v1beta1
+	err = migrateAuditPolicy(out, auditPolicyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate audit policy")
+	}
+
 	var args []string
 	if c.kubernetesAdditionalControlPlane {
 		args = []string{
@@ -176,6 +183,14 @@ func (c *ControlPlane) upgrade(out io.Writer, from, to *semver.Version) error {
 		return err
 	}
 
+	if !c.kubernetesAdditionalControlPlane {
+		// convert the ConfigMap based configuration of MetalLB installations before v0.13
+		err = metallb.Upgrade(out, kubeConfig, metallb.ConfigFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
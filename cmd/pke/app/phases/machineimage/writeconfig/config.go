@@ -43,6 +43,7 @@ type WriteConfig struct {
 
 	kubernetesVersion string
 	containerRuntime  string
+	containerdVersion string
 }
 
 func NewCommand(config config.Config) *cobra.Command {
@@ -63,6 +64,9 @@ func (w *WriteConfig) RegisterFlags(flags *pflag.FlagSet) {
 
 	// Kubernetes container runtime
 	flags.String(constants.FlagContainerRuntime, w.config.ContainerRuntime.Type, "Kubernetes container runtime")
+
+	// containerd version
+	flags.String(constants.FlagContainerdVersion, w.config.ContainerRuntime.Version, "containerd version")
 }
 
 func (w *WriteConfig) Validate(cmd *cobra.Command) (err error) {
@@ -90,7 +94,12 @@ func (w *WriteConfig) Validate(cmd *cobra.Command) (err error) {
 	}
 
 	switch w.containerRuntime {
-	case constants.ContainerRuntimeContainerd,
+	case constants.ContainerRuntimeContainerd:
+		w.containerdVersion, err = cmd.Flags().GetString(constants.FlagContainerdVersion)
+		if err != nil {
+			return
+		}
+	case constants.ContainerRuntimeCRIO,
 		constants.ContainerRuntimeDocker:
 		// break
 	default:
@@ -114,6 +123,7 @@ func (w *WriteConfig) WriteConfig(_ io.Writer, fileName string) (err error) {
 		},
 		ContainerRuntime: config.ContainerRuntimeConfig{
 			Type:      w.containerRuntime,
+			Version:   w.containerdVersion,
 			Installed: true,
 		},
 	}
@@ -195,7 +195,7 @@ func (c *Certificates) Run(out io.Writer) error {
 	}
 
 	if key, ok := secret.Values["enc"].(string); ok {
-		return kubeadm.WriteEncryptionProviderConfig(out, kubeadm.EncryptionProviderConfig, c.kubernetesVersion, key)
+		return kubeadm.WriteEncryptionProviderConfig(out, kubeadm.EncryptionProviderConfig, c.kubernetesVersion, key, kubeadm.EncryptionOptions{})
 	}
 
 	return nil
@@ -50,7 +50,7 @@ var _ phases.Runnable = (*Ready)(nil)
 
 type Ready struct {
 	role                   Role // accepted values: master, worker
-	cidr                   string
+	nodeIPSelector         network.NodeIPSelector
 	pipelineEnabled        bool
 	pipelineAPIEndpoint    string
 	pipelineAPIToken       string
@@ -79,7 +79,7 @@ func (r *Ready) RegisterFlags(flags *pflag.FlagSet) {
 	flags.Int32(constants.FlagPipelineOrganizationID, 0, "Organization ID to use with Pipeline API")
 	flags.Int32(constants.FlagPipelineClusterID, 0, "Cluster ID to use with Pipeline API")
 	flags.String(constants.FlagPipelineNodepool, "", "name of the nodepool the node belongs to")
-	flags.String(constants.FlagInfrastructureCIDR, "192.168.64.0/20", "network CIDR for the actual machine")
+	network.RegisterNodeIPFlags(flags)
 }
 
 func (r *Ready) Validate(cmd *cobra.Command) error {
@@ -108,7 +108,7 @@ func (r *Ready) Validate(cmd *cobra.Command) error {
 		return err
 	}
 
-	r.cidr, err = cmd.Flags().GetString(constants.FlagInfrastructureCIDR)
+	r.nodeIPSelector, err = network.NodeIPParameters(cmd)
 
 	return err
 }
@@ -135,14 +135,11 @@ func (r *Ready) Run(out io.Writer) error {
 	}
 
 	// ip
-	ips, err := network.IPv4Addresses()
-	if err != nil {
-		return err
-	}
-	ip, err := network.ContainsFirst(r.cidr, ips)
+	ips, err := r.nodeIPSelector.Select()
 	if err != nil {
 		return err
 	}
+	ip := ips[0]
 
 	// post node ready
 	c := pipelineutil.Client(out, r.pipelineAPIEndpoint, r.pipelineAPIToken, r.pipelineAPIInsecure)
@@ -17,11 +17,17 @@ package container
 import (
 	"fmt"
 	"io"
+	"os/exec"
+	"runtime"
 
 	"emperror.dev/errors"
 	"github.com/banzaicloud/pke/cmd/pke/app/config"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/proxy"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/validator"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -37,9 +43,17 @@ var _ phases.Runnable = (*Runtime)(nil)
 type Runtime struct {
 	config config.Config
 
+	kubernetesVersion       string
 	containerRuntime        string
+	containerdVersion       string
+	verifier                file.Verifier
+	containerdSnapshotter   string
+	sandboxRuntimes         []cri.SandboxRuntime
+	cgroupDriver            string
+	registries              map[string]config.RegistryConfig
 	imageRepository         string
 	useImageRepositoryToK8s bool
+	proxy                   proxy.Config
 }
 
 func NewCommand(config config.Config) *cobra.Command {
@@ -55,14 +69,33 @@ func (r *Runtime) Short() string {
 }
 
 func (r *Runtime) RegisterFlags(flags *pflag.FlagSet) {
+	// Kubernetes version
+	flags.String(constants.FlagKubernetesVersion, r.config.Kubernetes.Version, "Kubernetes version")
 	// Kubernetes container runtime
 	flags.String(constants.FlagContainerRuntime, r.config.ContainerRuntime.Type, "Kubernetes container runtime")
+	// containerd version
+	flags.String(constants.FlagContainerdVersion, r.config.ContainerRuntime.Version, "containerd version")
+	flags.String(constants.FlagContainerdSHA256, "", "SHA-256 checksum of the containerd release archive (required for versions without a known checksum)")
+	file.RegisterVerificationFlags(flags)
+	// containerd configuration
+	flags.String(constants.FlagContainerdSnapshotter, r.config.ContainerRuntime.Containerd.Snapshotter, "containerd snapshotter")
+	flags.StringSlice(constants.FlagSandboxRuntimes, r.config.ContainerRuntime.Containerd.SandboxRuntimes, "Sandboxed runtimes to install and register as containerd runtime handlers, possible values: gvisor (requires the checksums of the runsc binaries in a signed --checksum-manifest), kata (requires snapd)")
+	// Registry configuration
+	flags.StringSlice(constants.FlagRegistryMirrors, nil, "Registry mirror endpoints in <registry>=<endpoint> format, example: docker.io=https://mirror.gcr.io")
+	flags.StringSlice(constants.FlagRegistryAuth, nil, "Registry credentials in <registry>=<username>:<password> format")
+	flags.StringSlice(constants.FlagRegistryCAFile, nil, "Registry CA certificate files in <registry>=<path> format")
+	flags.StringSlice(constants.FlagRegistryInsecure, nil, "Registries with TLS certificate verification disabled")
+	// cgroup driver
+	flags.String(constants.FlagCgroupDriver, r.config.ContainerRuntime.CgroupDriver, "cgroup driver used by the kubelet and the container runtime, possible values: systemd, cgroupfs")
 
 	// Image repository
 	flags.String(constants.FlagImageRepository, "", "Prefix for image repository")
 
 	// Use defined image repository for K8s images as well
 	flags.Bool(constants.FlagUseImageRepositoryToK8s, false, "Use defined image repository for K8s Images as well")
+
+	// Proxy
+	proxy.RegisterFlags(flags)
 }
 
 func (r *Runtime) Validate(cmd *cobra.Command) (err error) {
@@ -85,10 +118,59 @@ func (r *Runtime) Validate(cmd *cobra.Command) (err error) {
 	}); err != nil {
 		return err
 	}
+	r.proxy, err = proxy.Parameters(cmd)
+	if err != nil {
+		return err
+	}
 
 	switch r.containerRuntime {
-	case constants.ContainerRuntimeContainerd,
-		constants.ContainerRuntimeDocker:
+	case constants.ContainerRuntimeContainerd:
+		r.containerdVersion, r.verifier, err = containerdParameters(cmd)
+		if err != nil {
+			return err
+		}
+		r.containerdSnapshotter, err = cmd.Flags().GetString(constants.FlagContainerdSnapshotter)
+		if err != nil {
+			return err
+		}
+		if err := validator.NotEmpty(map[string]interface{}{
+			constants.FlagContainerdSnapshotter: r.containerdSnapshotter,
+		}); err != nil {
+			return err
+		}
+		sandboxRuntimes, err := cmd.Flags().GetStringSlice(constants.FlagSandboxRuntimes)
+		if err != nil {
+			return err
+		}
+		r.sandboxRuntimes, err = cri.SandboxRuntimes(sandboxRuntimes)
+		if err != nil {
+			return err
+		}
+		if err := validateSandboxRuntimes(r.sandboxRuntimes, runtime.GOARCH, r.verifier, exec.LookPath); err != nil {
+			return err
+		}
+		return r.runtimeConfigParameters(cmd)
+	case constants.ContainerRuntimeCRIO:
+		r.kubernetesVersion, err = cmd.Flags().GetString(constants.FlagKubernetesVersion)
+		if err != nil {
+			return err
+		}
+		sandboxRuntimes, err := cmd.Flags().GetStringSlice(constants.FlagSandboxRuntimes)
+		if err != nil {
+			return err
+		}
+		if len(sandboxRuntimes) > 0 {
+			return errors.Wrapf(constants.ErrInvalidInput, "--%s is only supported with %s", constants.FlagSandboxRuntimes, constants.ContainerRuntimeContainerd)
+		}
+		if _, err := crioVersion(r.kubernetesVersion); err != nil {
+			return err
+		}
+		r.verifier, err = file.VerificationParameters(cmd)
+		if err != nil {
+			return err
+		}
+		return r.runtimeConfigParameters(cmd)
+	case constants.ContainerRuntimeDocker:
 		// break
 	default:
 		return errors.Wrapf(constants.ErrUnsupportedContainerRuntime, "container runtime: %s", r.containerRuntime)
@@ -97,6 +179,59 @@ func (r *Runtime) Validate(cmd *cobra.Command) (err error) {
 	return nil
 }
 
+func containerdParameters(cmd *cobra.Command) (version string, v file.Verifier, err error) {
+	version, err = cmd.Flags().GetString(constants.FlagContainerdVersion)
+	if err != nil {
+		return
+	}
+	sha256, err := cmd.Flags().GetString(constants.FlagContainerdSHA256)
+	if err != nil {
+		return
+	}
+	v, err = file.VerificationParameters(cmd)
+	if err != nil {
+		return
+	}
+	if err = validator.NotEmpty(map[string]interface{}{
+		constants.FlagContainerdVersion: version,
+	}); err != nil {
+		return
+	}
+
+	return containerdRelease(version, sha256, runtime.GOARCH, v)
+}
+
+// runtimeConfigParameters reads the settings shared by the containerd and CRI-O configurations.
+func (r *Runtime) runtimeConfigParameters(cmd *cobra.Command) (err error) {
+	r.cgroupDriver, err = cmd.Flags().GetString(constants.FlagCgroupDriver)
+	if err != nil {
+		return
+	}
+	if err = cri.ValidateCgroupDriver(r.cgroupDriver); err != nil {
+		return
+	}
+
+	mirrors, err := cmd.Flags().GetStringSlice(constants.FlagRegistryMirrors)
+	if err != nil {
+		return
+	}
+	auths, err := cmd.Flags().GetStringSlice(constants.FlagRegistryAuth)
+	if err != nil {
+		return
+	}
+	caFiles, err := cmd.Flags().GetStringSlice(constants.FlagRegistryCAFile)
+	if err != nil {
+		return
+	}
+	insecure, err := cmd.Flags().GetStringSlice(constants.FlagRegistryInsecure)
+	if err != nil {
+		return
+	}
+	r.registries, err = mergeRegistries(r.config.ContainerRuntime.Registries, mirrors, auths, caFiles, insecure)
+
+	return
+}
+
 func (r *Runtime) Run(out io.Writer) error {
 	_, _ = fmt.Fprintf(out, "[%s] running\n", r.Use())
 
@@ -104,10 +239,22 @@ func (r *Runtime) Run(out io.Writer) error {
 		_, _ = fmt.Fprintf(out, "[%s] skipping installation (already installed)\n", r.Use())
 	}
 
+	// Proxy settings for downloads and package managers
+	r.proxy = r.proxy.WithNoProxy()
+	if err := r.proxy.Apply(); err != nil {
+		return err
+	}
+	if err := linux.ConfigurePackageManagerProxy(out, r.proxy.HTTPProxy, r.proxy.HTTPSProxy); err != nil {
+		return err
+	}
+
 	switch r.containerRuntime {
 	case constants.ContainerRuntimeContainerd:
 		return r.installContainerd(out)
 
+	case constants.ContainerRuntimeCRIO:
+		return r.installCRIO(out)
+
 	case constants.ContainerRuntimeDocker:
 		return r.installDocker(out)
 
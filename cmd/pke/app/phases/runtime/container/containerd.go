@@ -0,0 +1,118 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+// containerdChecksums is the built-in checksum manifest of the known cri-containerd-cni release archives.
+var containerdChecksums = file.Checksums{
+	"cri-containerd-cni-1.6.8-linux-amd64.tar.gz": "8e227caa318faa136e4387ffd6f96baeaad5582d176202fe9da69cde87036033",
+}
+
+// containerdArchive returns the name of the cri-containerd-cni release archive of the version for the architecture.
+func containerdArchive(version, arch string) string {
+	return fmt.Sprintf("cri-containerd-cni-%s-linux-%s.tar.gz", version, arch)
+}
+
+// containerdRelease normalizes the requested containerd version and returns the verifier of its release archive.
+// An explicitly provided checksum takes precedence over the signed checksum manifests, which take precedence over the built-in ones.
+func containerdRelease(version, sha256, arch string, v file.Verifier) (string, file.Verifier, error) {
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return "", v, errors.Wrapf(constants.ErrInvalidInput, "--%s: %s", constants.FlagContainerdVersion, err)
+	}
+	version = ver.String()
+	archive := containerdArchive(version, arch)
+
+	v = file.Verifier{Keys: v.Keys}.WithChecksums(containerdChecksums).WithChecksums(v.Checksums)
+	if sha256 != "" {
+		v = v.WithChecksums(file.Checksums{archive: strings.ToLower(sha256)})
+	}
+
+	if !v.Known(archive) {
+		return "", v, errors.Wrapf(
+			constants.ErrInvalidInput,
+			"no known checksum for %s, please provide it with --%s or a signed --%s",
+			archive,
+			constants.FlagContainerdSHA256,
+			constants.FlagChecksumManifest,
+		)
+	}
+
+	return version, v, nil
+}
+
+// parseContainerdVersion extracts the version from the output of `containerd --version`.
+// example: containerd github.com/containerd/containerd v1.6.8 9cd3357b7fd7218e4aec3eae239db1f68a5a6ec6
+func parseContainerdVersion(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "containerd" {
+		return "", errors.Errorf("unexpected containerd version output: %q", output)
+	}
+
+	ver, err := semver.NewVersion(fields[2])
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse containerd version %q", fields[2])
+	}
+
+	return ver.String(), nil
+}
+
+// containerdConfig renders the containerd configuration with a runtime handler for each sandboxed runtime.
+func containerdConfig(imageRepository, cgroupDriver, snapshotter string, registries map[string]config.RegistryConfig, sandboxRuntimes []cri.SandboxRuntime) (string, error) {
+	tmpl, err := template.New("containerd-config").
+		Funcs(template.FuncMap{"quote": strconv.Quote}).
+		Parse(containerdConfigTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type data struct {
+		ImageRepository string
+		Snapshotter     string
+		SystemdCgroup   bool
+		Registries      map[string]config.RegistryConfig
+		SandboxRuntimes []cri.SandboxRuntime
+	}
+
+	d := data{
+		ImageRepository: imageRepository,
+		Snapshotter:     snapshotter,
+		SystemdCgroup:   cgroupDriver == constants.CgroupDriverSystemd,
+		Registries:      registries,
+		SandboxRuntimes: sandboxRuntimes,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
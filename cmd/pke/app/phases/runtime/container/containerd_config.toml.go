@@ -16,11 +16,70 @@ package container
 
 // containerdConfigTemplate is a generated function returning the template as a string.
 func containerdConfigTemplate() string {
-	var tmpl = "[plugins.cri]\n" +
-		"{{ if ne .ImageRepository \"\" }}\n" +
-		"sandbox_image = \"{{ .ImageRepository }}/pause:3.6\"\n" +
-		"{{ else }}\n" +
-		"sandbox_image = \"k8s.gcr.io/pause:3.6\"\n" +
-		"{{ end }}"
+	var tmpl = "version = 2\n" +
+		"\n" +
+		"[plugins]\n" +
+		"  [plugins.\"io.containerd.grpc.v1.cri\"]\n" +
+		"{{- if ne .ImageRepository \"\" }}\n" +
+		"    sandbox_image = \"{{ .ImageRepository }}/pause:3.6\"\n" +
+		"{{- else }}\n" +
+		"    sandbox_image = \"k8s.gcr.io/pause:3.6\"\n" +
+		"{{- end }}\n" +
+		"    [plugins.\"io.containerd.grpc.v1.cri\".containerd]\n" +
+		"      snapshotter = \"{{ .Snapshotter }}\"\n" +
+		"      default_runtime_name = \"runc\"\n" +
+		"      [plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes]\n" +
+		"        [plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.runc]\n" +
+		"          runtime_type = \"io.containerd.runc.v2\"\n" +
+		"          [plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.runc.options]\n" +
+		"            SystemdCgroup = {{ .SystemdCgroup }}\n" +
+		"{{- range .SandboxRuntimes }}\n" +
+		"        [plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.{{ .Handler }}]\n" +
+		"          runtime_type = \"{{ .RuntimeType }}\"\n" +
+		"{{- end }}\n" +
+		"{{- if .Registries }}\n" +
+		"    [plugins.\"io.containerd.grpc.v1.cri\".registry]\n" +
+		"      [plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors]\n" +
+		"{{- range $name, $registry := .Registries }}\n" +
+		"{{- if $registry.Mirrors }}\n" +
+		"        [plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.{{ quote $name }}]\n" +
+		"          endpoint = [{{ range $i, $mirror := $registry.Mirrors }}{{ if $i }}, {{ end }}{{ quote $mirror }}{{ end }}]\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"      [plugins.\"io.containerd.grpc.v1.cri\".registry.configs]\n" +
+		"{{- range $name, $registry := .Registries }}\n" +
+		"{{- if or $registry.Username $registry.Password $registry.Auth $registry.IdentityToken }}\n" +
+		"        [plugins.\"io.containerd.grpc.v1.cri\".registry.configs.{{ quote $name }}.auth]\n" +
+		"{{- if $registry.Username }}\n" +
+		"          username = {{ quote $registry.Username }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.Password }}\n" +
+		"          password = {{ quote $registry.Password }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.Auth }}\n" +
+		"          auth = {{ quote $registry.Auth }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.IdentityToken }}\n" +
+		"          identitytoken = {{ quote $registry.IdentityToken }}\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"{{- if or $registry.CAFile $registry.CertFile $registry.KeyFile $registry.InsecureSkipVerify }}\n" +
+		"        [plugins.\"io.containerd.grpc.v1.cri\".registry.configs.{{ quote $name }}.tls]\n" +
+		"{{- if $registry.CAFile }}\n" +
+		"          ca_file = {{ quote $registry.CAFile }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.CertFile }}\n" +
+		"          cert_file = {{ quote $registry.CertFile }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.KeyFile }}\n" +
+		"          key_file = {{ quote $registry.KeyFile }}\n" +
+		"{{- end }}\n" +
+		"{{- if $registry.InsecureSkipVerify }}\n" +
+		"          insecure_skip_verify = true\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		""
 	return tmpl
 }
@@ -20,20 +20,28 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
-	"text/template"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"emperror.dev/errors"
 
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/kubernetes"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 )
 
 const (
-	containerdVersion     = "1.6.8"
-	containerdSHA256      = "8e227caa318faa136e4387ffd6f96baeaad5582d176202fe9da69cde87036033"
-	containerdURL         = "https://github.com/containerd/containerd/releases/download/v%s/cri-containerd-cni-%s-linux-amd64.tar.gz"
+	containerdURL         = "https://github.com/containerd/containerd/releases/download/v%s/%s"
 	containerdVersionPath = "/opt/containerd/cluster/version"
+	containerdBin         = "/usr/local/bin/containerd"
 	containerdConf        = "/etc/containerd/config.toml"
+	containerdService     = "/etc/systemd/system/containerd.service"
+	kubeConfig            = "/etc/kubernetes/admin.conf"
 
 	criConfFile = "/etc/sysctl.d/99-kubernetes-cri.conf"
 	criConf     = `net.bridge.bridge-nf-call-iptables  = 1
@@ -50,6 +58,43 @@ func (r *Runtime) installContainerd(out io.Writer) error {
 		return err
 	}
 
+	if err := ensureCRIPrerequisites(out); err != nil {
+		return err
+	}
+
+	if err := pm.InstallContainerdPrerequisites(out, r.containerdVersion); err != nil {
+		return errors.Wrap(err, "unable to install containerd prerequisites")
+	}
+
+	_ = linux.SystemctlDisableAndStop(out, "containerd")
+
+	// Check containerd installed or not
+	if err := installContainerd(out, r.containerdVersion, r.verifier); err != nil {
+		return err
+	}
+
+	// The configuration is always rewritten to keep it in sync with the flags and the config file
+	if err := writeContainerdConfig(out, containerdConf, r.imageRepository, r.cgroupDriver, r.containerdSnapshotter, r.registries, r.sandboxRuntimes); err != nil {
+		return errors.Wrap(err, "unable to write containerd configuration")
+	}
+
+	if err := installSandboxRuntimes(out, r.sandboxRuntimes, r.verifier); err != nil {
+		return err
+	}
+
+	// # Start containerd.
+	if err := linux.SystemctlEnableAndStart(out, "containerd"); err != nil {
+		return err
+	}
+
+	_ = linux.SystemctlDisableAndStop(out, "kubelet")
+
+	// systemctl daemon-reload
+	return linux.SystemctlReload(out)
+}
+
+// ensureCRIPrerequisites loads the kernel modules and network settings required by CRI runtimes.
+func ensureCRIPrerequisites(out io.Writer) error {
 	// modprobe overlay
 	if err := linux.ModprobeOverlay(out); err != nil {
 		return errors.Wrap(err, "missing overlay Linux Kernel module")
@@ -74,60 +119,114 @@ func (r *Runtime) installContainerd(out io.Writer) error {
 		return errors.Wrapf(err, "unable to load all sysctl rules from files")
 	}
 
-	if err := pm.InstallContainerdPrerequisites(out, containerdVersion); err != nil {
-		return errors.Wrap(err, "unable to install containerd prerequisites")
+	return nil
+}
+
+func (u *Upgrade) upgradeContainerd(out io.Writer) error {
+	installed, err := installedContainerdVersion(out)
+	if err != nil {
+		return err
 	}
+	if installed == u.containerdVersion {
+		_, _ = fmt.Fprintf(out, "[%s] containerd %s already installed, skipping upgrade\n", use, installed)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "[%s] upgrading containerd from %s to %s\n", use, installed, u.containerdVersion)
 
-	_ = linux.SystemctlDisableAndStop(out, "containerd")
+	// Only control plane nodes have the credentials to evict pods, the other nodes have to be drained beforehand
+	_, err = os.Stat(kubeConfig)
+	drain := err == nil
+	if drain {
+		if err := kubernetes.Drain(out, kubeConfig, u.nodeName); err != nil {
+			return errors.Wrapf(err, "unable to drain node %q", u.nodeName)
+		}
+	} else if !u.drained {
+		return errors.Errorf(
+			"%s not found, drain the node from the control plane with `kubectl drain %s --ignore-daemonsets --delete-emptydir-data` and run the upgrade with --%s",
+			kubeConfig,
+			u.nodeName,
+			constants.FlagNodeDrained,
+		)
+	}
 
-	// Check containerd installed or not
-	if err := installContainerd(out, r.imageRepository); err != nil {
+	if err := linux.SystemctlStop(out, "kubelet"); err != nil {
+		return err
+	}
+	if err := linux.SystemctlStop(out, "containerd"); err != nil {
 		return err
 	}
 
-	// # Start containerd.
-	if err := linux.SystemctlEnableAndStart(out, "containerd"); err != nil {
+	err = downloadContainerd(out, u.containerdVersion, u.verifier, func(out io.Writer, r io.Reader) error {
+		return file.UntarReplace(out, r, containerdReplaceable)
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "unable to upgrade containerd to %s", u.containerdVersion)
+	}
+
+	// Bring the node back even if the upgrade failed, previous binaries are kept in that case
+	if startErr := linux.SystemctlStart(out, "containerd"); startErr != nil {
+		return errors.Combine(err, startErr)
+	}
+	if startErr := linux.SystemctlStart(out, "kubelet"); startErr != nil {
+		return errors.Combine(err, startErr)
+	}
+	if err != nil {
 		return err
 	}
 
-	_ = linux.SystemctlDisableAndStop(out, "kubelet")
+	if !drain {
+		_, _ = fmt.Fprintf(out, "[%s] containerd upgraded, uncordon the node from the control plane with `kubectl uncordon %s`\n", use, u.nodeName)
+		return nil
+	}
 
-	// systemctl daemon-reload
-	return linux.SystemctlReload(out)
+	return kubernetes.Uncordon(out, kubeConfig, u.nodeName)
 }
 
-func installContainerd(out io.Writer, imageRepository string) error {
+func installContainerd(out io.Writer, version string, v file.Verifier) error {
 	// Check containerd installed or not
 	if _, err := os.Stat(containerdVersionPath); !os.IsNotExist(err) {
-		// TODO: check containerd version
-		_, _ = fmt.Fprintln(out, "containerd already installed, skipping download")
-		return nil
+		installed, err := installedContainerdVersion(out)
+		if err != nil {
+			return err
+		}
+		if installed == version {
+			_, _ = fmt.Fprintf(out, "containerd %s already installed, skipping download\n", installed)
+			return nil
+		}
+
+		_, _ = fmt.Fprintf(out, "containerd %s already installed, replacing it with %s\n", installed, version)
+		return downloadContainerd(out, version, v, func(out io.Writer, r io.Reader) error {
+			return file.UntarReplace(out, r, containerdReplaceable)
+		})
 	}
+
+	return downloadContainerd(out, version, v, file.Untar)
+}
+
+// downloadContainerd downloads the cri-containerd-cni release archive, verifies its checksum and unpacks it with extract.
+func downloadContainerd(out io.Writer, version string, v file.Verifier, extract func(out io.Writer, r io.Reader) error) error {
 	// Download containerd tar.
 	f, err := ioutil.TempFile("", "containerd")
 	if err != nil {
 		return errors.Wrapf(err, "unable to create temporary file: %q", f.Name())
 	}
 	defer func() { _ = f.Close() }()
+	defer func() { _ = os.Remove(f.Name()) }()
 	// export CONTAINERD_VERSION="1.6.8"
 	// export CONTAINERD_SHA256="8e227caa318faa136e4387ffd6f96baeaad5582d176202fe9da69cde87036033"
 	// wget https://github.com/containerd/containerd/releases/download/v${CONTAINERD_VERSION}/cri-containerd-cni-${CONTAINERD_VERSION}-linux-amd64.tar.gz
-	dl := fmt.Sprintf(containerdURL, containerdVersion, containerdVersion)
+	archive := containerdArchive(version, runtime.GOARCH)
+	dl := fmt.Sprintf(containerdURL, version, archive)
 	u, err := url.Parse(dl)
 	if err != nil {
 		return errors.Wrapf(err, "failed to parse url: %q", dl)
 	}
 	_, _ = fmt.Fprintf(out, "wget %q -O %s\n", u.String(), f.Name())
 
-	if err = file.Download(u, f.Name()); err != nil {
-		return errors.Wrapf(err, "unable to download containerd. url: %q", u.String())
-	}
-
 	// echo "${CONTAINERD_SHA256} cri-containerd-${CONTAINERD_VERSION}.linux-amd64.tar.gz" | sha256sum --check -
-	_, _ = fmt.Fprintf(out, "echo \"%s %s\" | sha256sum --check -\n", containerdSHA256, f.Name())
-	err = file.SHA256File(f.Name(), containerdSHA256)
-	if err != nil {
-		return errors.Wrapf(err, "hash mismatch. hash: %q", containerdSHA256)
+	_, _ = fmt.Fprintf(out, "echo \"%s %s\" | sha256sum --check -\n", v.Checksums[archive], f.Name())
+	if err = v.Download(u, archive, f.Name()); err != nil {
+		return errors.Wrapf(err, "unable to download containerd. url: %q", u.String())
 	}
 
 	// Unpack.
@@ -138,29 +237,43 @@ func installContainerd(out io.Writer, imageRepository string) error {
 	}
 	defer func() { _ = fh.Close() }()
 
-	err = file.Untar(out, fh)
+	return extract(out, fh)
+}
+
+// containerdReplaceable decides which files of the release archive are replaced during an in-place upgrade.
+// Configuration files (e.g. CNI and crictl configs) are kept as they are.
+func containerdReplaceable(name string) bool {
+	for _, prefix := range []string{"/usr/local/bin/", "/usr/local/sbin/", "/opt/containerd/"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return name == containerdService
+}
+
+func installedContainerdVersion(out io.Writer) (string, error) {
+	// containerd --version
+	o, err := runner.Cmd(out, containerdBin, "--version").Output()
 	if err != nil {
-		return err
+		return "", errors.Wrap(err, "unable to get installed containerd version")
 	}
 
-	return writeContainerdConfig(out, containerdConf, imageRepository)
+	return parseContainerdVersion(string(o))
 }
 
 //go:generate templify -t ${GOTMPL} -p container -f containerdConfig containerd_config.toml.tmpl
 
-func writeContainerdConfig(out io.Writer, filename, imageRepository string) error {
-	tmpl, err := template.New("containerd-config").Parse(containerdConfigTemplate())
+func writeContainerdConfig(out io.Writer, filename, imageRepository, cgroupDriver, snapshotter string, registries map[string]config.RegistryConfig, sandboxRuntimes []cri.SandboxRuntime) error {
+	conf, err := containerdConfig(imageRepository, cgroupDriver, snapshotter, registries, sandboxRuntimes)
 	if err != nil {
 		return err
 	}
 
-	type data struct {
-		ImageRepository string
-	}
-
-	d := data{
-		ImageRepository: imageRepository,
+	_, _ = fmt.Fprintf(out, "[%s] writing containerd configuration: %s\n", use, filename)
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return err
 	}
 
-	return file.WriteTemplate(filename, tmpl, d)
+	return file.Overwrite(filename, conf)
 }
@@ -25,3 +25,7 @@ import (
 func (r *Runtime) installContainerd(w io.Writer) error {
 	return errors.Errorf("unsupported operating system")
 }
+
+func (u *Upgrade) upgradeContainerd(w io.Writer) error {
+	return errors.Errorf("unsupported operating system")
+}
@@ -0,0 +1,90 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+func TestContainerdRelease(t *testing.T) {
+	known := containerdChecksums["cri-containerd-cni-1.6.8-linux-amd64.tar.gz"]
+	signed := file.Verifier{Checksums: file.Checksums{
+		"cri-containerd-cni-1.6.0-linux-amd64.tar.gz": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		"cri-containerd-cni-1.6.8-linux-arm64.tar.gz": "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210",
+	}}
+
+	testCases := []struct {
+		name     string
+		version  string
+		sha256   string
+		arch     string
+		verifier file.Verifier
+		expVer   string
+		expHash  string
+		err      bool
+	}{
+		{"known version", "1.6.8", "", "amd64", file.Verifier{}, "1.6.8", known, false},
+		{"known version with v prefix", "v1.6.8", "", "amd64", file.Verifier{}, "1.6.8", known, false},
+		{"unknown version without checksum", "1.6.0", "", "amd64", file.Verifier{}, "", "", true},
+		{"unknown version with checksum", "1.6.0", "ABCDEF", "amd64", file.Verifier{}, "1.6.0", "abcdef", false},
+		{"unknown arch without checksum", "1.6.8", "", "arm64", file.Verifier{}, "", "", true},
+		{"version from signed manifest", "1.6.0", "", "amd64", signed, "1.6.0", signed.Checksums["cri-containerd-cni-1.6.0-linux-amd64.tar.gz"], false},
+		{"arch from signed manifest", "1.6.8", "", "arm64", signed, "1.6.8", signed.Checksums["cri-containerd-cni-1.6.8-linux-arm64.tar.gz"], false},
+		{"checksum overrides signed manifest", "1.6.0", "ABCDEF", "amd64", signed, "1.6.0", "abcdef", false},
+		{"invalid version", "latest", "", "amd64", file.Verifier{}, "", "", true},
+	}
+	for _, tc := range testCases {
+		ver, v, err := containerdRelease(tc.version, tc.sha256, tc.arch, tc.verifier)
+		if tc.err {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expVer, ver, tc.name)
+		require.Equal(t, tc.expHash, v.Checksums[containerdArchive(ver, tc.arch)], tc.name)
+	}
+}
+
+func TestParseContainerdVersion(t *testing.T) {
+	ver, err := parseContainerdVersion("containerd github.com/containerd/containerd v1.6.8 9cd3357b7fd7218e4aec3eae239db1f68a5a6ec6\n")
+	require.NoError(t, err)
+	require.Equal(t, "1.6.8", ver)
+
+	_, err = parseContainerdVersion("command not found")
+	require.Error(t, err)
+}
+
+func TestContainerdConfigSandboxRuntimes(t *testing.T) {
+	runtimes, err := cri.SandboxRuntimes([]string{constants.SandboxRuntimeGVisor, constants.SandboxRuntimeKata})
+	require.NoError(t, err)
+
+	conf, err := containerdConfig("", constants.CgroupDriverSystemd, "overlayfs", nil, runtimes)
+	require.NoError(t, err)
+	require.Contains(t, conf, `default_runtime_name = "runc"`)
+	require.Contains(t, conf, "SystemdCgroup = true")
+	require.Contains(t, conf, "[plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.runsc]\n          runtime_type = \"io.containerd.runsc.v1\"")
+	require.Contains(t, conf, "[plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.kata]\n          runtime_type = \"io.containerd.kata.v2\"")
+
+	conf, err = containerdConfig("", constants.CgroupDriverCgroupfs, "overlayfs", nil, nil)
+	require.NoError(t, err)
+	require.NotContains(t, conf, "runsc")
+	require.NotContains(t, conf, "kata")
+}
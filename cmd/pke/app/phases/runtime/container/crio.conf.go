@@ -0,0 +1,44 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+// crioConfigTemplate is a generated function returning the template as a string.
+func crioConfigTemplate() string {
+	var tmpl = "[crio]\n" +
+		"\n" +
+		"[crio.runtime]\n" +
+		"cgroup_manager = \"{{ .CgroupDriver }}\"\n" +
+		"{{- if eq .CgroupDriver \"systemd\" }}\n" +
+		"conmon_cgroup = \"system.slice\"\n" +
+		"{{- else }}\n" +
+		"conmon_cgroup = \"pod\"\n" +
+		"{{- end }}\n" +
+		"\n" +
+		"[crio.image]\n" +
+		"{{- if ne .ImageRepository \"\" }}\n" +
+		"pause_image = \"{{ .ImageRepository }}/pause:3.6\"\n" +
+		"{{- else }}\n" +
+		"pause_image = \"k8s.gcr.io/pause:3.6\"\n" +
+		"{{- end }}\n" +
+		"{{- if ne .AuthFile \"\" }}\n" +
+		"global_auth_file = \"{{ .AuthFile }}\"\n" +
+		"{{- end }}\n" +
+		"\n" +
+		"[crio.network]\n" +
+		"network_dir = \"/etc/cni/net.d/\"\n" +
+		"plugin_dirs = [\"/opt/cni/bin/\", \"/usr/libexec/cni/\"]\n" +
+		""
+	return tmpl
+}
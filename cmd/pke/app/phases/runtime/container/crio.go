@@ -0,0 +1,117 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// crioVersion returns the CRI-O release stream matching the Kubernetes version.
+// CRI-O follows the Kubernetes minor versions, e.g. CRI-O 1.22.x supports Kubernetes 1.22.x.
+func crioVersion(kubernetesVersion string) (string, error) {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(constants.ErrInvalidInput, "--%s: %s", constants.FlagKubernetesVersion, err)
+	}
+
+	return fmt.Sprintf("%d.%d", ver.Major(), ver.Minor()), nil
+}
+
+// crioRegistry is a [[registry]] entry of containers-registries.conf(5).
+type crioRegistry struct {
+	Prefix   string
+	Insecure bool
+	Mirrors  []crioMirror
+}
+
+// crioMirror is a [[registry.mirror]] entry of containers-registries.conf(5).
+type crioMirror struct {
+	Location string
+	Insecure bool
+}
+
+// crioRegistries converts the registry settings to containers-registries.conf(5) entries.
+// Mirror endpoints are given as URLs, the scheme is dropped and plain HTTP mirrors are marked insecure.
+func crioRegistries(registries map[string]config.RegistryConfig) []crioRegistry {
+	names := make([]string, 0, len(registries))
+	for name := range registries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]crioRegistry, 0, len(names))
+	for _, name := range names {
+		registry := registries[name]
+		r := crioRegistry{
+			Prefix:   name,
+			Insecure: registry.InsecureSkipVerify,
+		}
+		for _, endpoint := range registry.Mirrors {
+			m := crioMirror{Location: endpoint}
+			switch {
+			case strings.HasPrefix(endpoint, "https://"):
+				m.Location = strings.TrimPrefix(endpoint, "https://")
+			case strings.HasPrefix(endpoint, "http://"):
+				m.Location = strings.TrimPrefix(endpoint, "http://")
+				m.Insecure = true
+			}
+			m.Location = strings.TrimSuffix(m.Location, "/")
+			r.Mirrors = append(r.Mirrors, m)
+		}
+		result = append(result, r)
+	}
+
+	return result
+}
+
+// crioAuth returns the containers-auth.json(5) content for the registries with credentials.
+// Nil is returned if none of the registries has credentials.
+func crioAuth(registries map[string]config.RegistryConfig) ([]byte, error) {
+	type auth struct {
+		Auth          string `json:"auth,omitempty"`
+		IdentityToken string `json:"identitytoken,omitempty"`
+	}
+
+	auths := make(map[string]auth)
+	for name, registry := range registries {
+		a := auth{
+			Auth:          registry.Auth,
+			IdentityToken: registry.IdentityToken,
+		}
+		if a.Auth == "" && registry.Username != "" {
+			a.Auth = base64.StdEncoding.EncodeToString([]byte(registry.Username + ":" + registry.Password))
+		}
+		if a.Auth != "" || a.IdentityToken != "" {
+			auths[name] = a
+		}
+	}
+	if len(auths) == 0 {
+		return nil, nil
+	}
+
+	return json.MarshalIndent(struct {
+		Auths map[string]auth `json:"auths"`
+	}{auths}, "", "  ")
+}
@@ -0,0 +1,176 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+)
+
+const (
+	crioConf           = "/etc/crio/crio.conf"
+	crioAuthFile       = "/etc/crio/auth.json"
+	crioRegistriesConf = "/etc/containers/registries.conf.d/10-pke.conf"
+	crioCertsDir       = "/etc/containers/certs.d"
+	crioBridgeConf     = "/etc/cni/net.d/100-crio-bridge.conf"
+)
+
+func (r *Runtime) installCRIO(out io.Writer) error {
+	pm, err := linux.CRIOPackagesImpl(out)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureCRIPrerequisites(out); err != nil {
+		return err
+	}
+
+	version, err := crioVersion(r.kubernetesVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := pm.InstallCRIOPackages(out, version, r.verifier); err != nil {
+		return errors.Wrap(err, "unable to install CRI-O packages")
+	}
+
+	_ = linux.SystemctlDisableAndStop(out, "crio")
+
+	authFile, err := writeCRIORegistries(out, r.registries)
+	if err != nil {
+		return errors.Wrap(err, "unable to write CRI-O registry configuration")
+	}
+
+	if err := writeCRIOConfig(out, crioConf, r.imageRepository, r.cgroupDriver, authFile); err != nil {
+		return errors.Wrap(err, "unable to write CRI-O configuration")
+	}
+
+	// Pods must not get addresses from the bridge network shipped with CRI-O before the network provider is installed
+	if err := os.Remove(crioBridgeConf); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Start CRI-O.
+	if err := linux.SystemctlEnableAndStart(out, "crio"); err != nil {
+		return err
+	}
+
+	_ = linux.SystemctlDisableAndStop(out, "kubelet")
+
+	// systemctl daemon-reload
+	return linux.SystemctlReload(out)
+}
+
+//go:generate templify -t ${GOTMPL} -p container -f crioConfig crio.conf.tmpl
+
+func writeCRIOConfig(out io.Writer, filename, imageRepository, cgroupDriver, authFile string) error {
+	tmpl, err := template.New("crio-config").Parse(crioConfigTemplate())
+	if err != nil {
+		return err
+	}
+
+	type data struct {
+		ImageRepository string
+		CgroupDriver    string
+		AuthFile        string
+	}
+
+	d := data{
+		ImageRepository: imageRepository,
+		CgroupDriver:    cgroupDriver,
+		AuthFile:        authFile,
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing CRI-O configuration: %s\n", use, filename)
+
+	return file.WriteTemplate(filename, tmpl, d)
+}
+
+//go:generate templify -t ${GOTMPL} -p container -f crioRegistries crio_registries.conf.tmpl
+
+// writeCRIORegistries writes the mirror, credential and certificate settings of the registries
+// and returns the path of the auth file if there are any credentials.
+func writeCRIORegistries(out io.Writer, registries map[string]config.RegistryConfig) (string, error) {
+	tmpl, err := template.New("crio-registries").
+		Funcs(template.FuncMap{"quote": strconv.Quote}).
+		Parse(crioRegistriesTemplate())
+	if err != nil {
+		return "", err
+	}
+
+	type data struct {
+		Registries []crioRegistry
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing registry configuration: %s\n", use, crioRegistriesConf)
+	if err := file.WriteTemplate(crioRegistriesConf, tmpl, data{Registries: crioRegistries(registries)}); err != nil {
+		return "", err
+	}
+
+	// TLS certificates are picked up from /etc/containers/certs.d/<registry>/
+	for name, registry := range registries {
+		for src, dst := range map[string]string{
+			registry.CAFile:   "ca.crt",
+			registry.CertFile: "client.cert",
+			registry.KeyFile:  "client.key",
+		} {
+			if src == "" {
+				continue
+			}
+			if err := copyFile(src, filepath.Join(crioCertsDir, name, dst)); err != nil {
+				return "", errors.Wrapf(err, "unable to install certificate for registry %q", name)
+			}
+		}
+	}
+
+	auth, err := crioAuth(registries)
+	if err != nil {
+		return "", err
+	}
+	if auth == nil {
+		return "", nil
+	}
+
+	_, _ = fmt.Fprintf(out, "[%s] writing registry credentials: %s\n", use, crioAuthFile)
+	if err := ioutil.WriteFile(crioAuthFile, auth, 0600); err != nil {
+		return "", err
+	}
+
+	return crioAuthFile, nil
+}
+
+func copyFile(src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(dst, b, 0600)
+}
@@ -0,0 +1,37 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+// crioRegistriesTemplate is a generated function returning the template as a string.
+func crioRegistriesTemplate() string {
+	var tmpl = "{{- range $registry := .Registries }}\n" +
+		"[[registry]]\n" +
+		"prefix = {{ quote $registry.Prefix }}\n" +
+		"location = {{ quote $registry.Prefix }}\n" +
+		"{{- if $registry.Insecure }}\n" +
+		"insecure = true\n" +
+		"{{- end }}\n" +
+		"{{- range $mirror := $registry.Mirrors }}\n" +
+		"\n" +
+		"[[registry.mirror]]\n" +
+		"location = {{ quote $mirror.Location }}\n" +
+		"{{- if $mirror.Insecure }}\n" +
+		"insecure = true\n" +
+		"{{- end }}\n" +
+		"{{- end }}\n" +
+		"{{ end }}\n" +
+		""
+	return tmpl
+}
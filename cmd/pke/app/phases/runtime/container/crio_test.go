@@ -0,0 +1,76 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+)
+
+func TestCRIOVersion(t *testing.T) {
+	ver, err := crioVersion("1.22.6")
+	require.NoError(t, err)
+	require.Equal(t, "1.22", ver)
+
+	_, err = crioVersion("latest")
+	require.Error(t, err)
+}
+
+func TestCRIORegistries(t *testing.T) {
+	registries := map[string]config.RegistryConfig{
+		"quay.io": {InsecureSkipVerify: true},
+		"docker.io": {Mirrors: []string{
+			"https://mirror.gcr.io/",
+			"http://10.0.0.1:5000",
+			"registry.example.com/docker",
+		}},
+	}
+
+	require.Equal(t, []crioRegistry{
+		{
+			Prefix: "docker.io",
+			Mirrors: []crioMirror{
+				{Location: "mirror.gcr.io"},
+				{Location: "10.0.0.1:5000", Insecure: true},
+				{Location: "registry.example.com/docker"},
+			},
+		},
+		{
+			Prefix:   "quay.io",
+			Insecure: true,
+		},
+	}, crioRegistries(registries))
+}
+
+func TestCRIOAuth(t *testing.T) {
+	auth, err := crioAuth(map[string]config.RegistryConfig{
+		"docker.io": {Mirrors: []string{"https://mirror.gcr.io"}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, auth)
+
+	auth, err = crioAuth(map[string]config.RegistryConfig{
+		"registry.example.com": {Username: "user", Password: "pass"},
+		"quay.io":              {IdentityToken: "token"},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"auths": {
+		"registry.example.com": {"auth": "dXNlcjpwYXNz"},
+		"quay.io": {"identitytoken": "token"}
+	}}`, string(auth))
+}
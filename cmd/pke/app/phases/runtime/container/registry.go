@@ -0,0 +1,90 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// mergeRegistries merges the registry settings given on the command line into the ones read from the config file.
+// Command line values take precedence, mirrors are appended to the configured ones.
+func mergeRegistries(registries map[string]config.RegistryConfig, mirrors, auths, caFiles, insecure []string) (map[string]config.RegistryConfig, error) {
+	result := make(map[string]config.RegistryConfig, len(registries))
+	for name, registry := range registries {
+		registry.Mirrors = append([]string(nil), registry.Mirrors...)
+		result[name] = registry
+	}
+
+	for _, m := range mirrors {
+		name, endpoint, err := splitRegistryValue(constants.FlagRegistryMirrors, m)
+		if err != nil {
+			return nil, err
+		}
+		registry := result[name]
+		registry.Mirrors = append(registry.Mirrors, endpoint)
+		result[name] = registry
+	}
+
+	for _, a := range auths {
+		name, credentials, err := splitRegistryValue(constants.FlagRegistryAuth, a)
+		if err != nil {
+			return nil, err
+		}
+		i := strings.Index(credentials, ":")
+		if i < 1 {
+			return nil, errors.Wrapf(constants.ErrInvalidInput, "--%s: expected <registry>=<username>:<password> format for %q", constants.FlagRegistryAuth, name)
+		}
+		registry := result[name]
+		registry.Username = credentials[:i]
+		registry.Password = credentials[i+1:]
+		result[name] = registry
+	}
+
+	for _, c := range caFiles {
+		name, caFile, err := splitRegistryValue(constants.FlagRegistryCAFile, c)
+		if err != nil {
+			return nil, err
+		}
+		registry := result[name]
+		registry.CAFile = caFile
+		result[name] = registry
+	}
+
+	for _, name := range insecure {
+		if name == "" {
+			return nil, errors.Wrapf(constants.ErrInvalidInput, "--%s: empty registry name", constants.FlagRegistryInsecure)
+		}
+		registry := result[name]
+		registry.InsecureSkipVerify = true
+		result[name] = registry
+	}
+
+	return result, nil
+}
+
+// splitRegistryValue splits flag values in <registry>=<value> format.
+func splitRegistryValue(flag, value string) (string, string, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Wrapf(constants.ErrInvalidInput, "--%s: expected <registry>=<value> format, got %q", flag, value)
+	}
+
+	return parts[0], parts[1], nil
+}
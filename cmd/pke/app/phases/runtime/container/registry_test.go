@@ -0,0 +1,57 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+)
+
+func TestMergeRegistries(t *testing.T) {
+	registries := map[string]config.RegistryConfig{
+		"docker.io": {Mirrors: []string{"https://mirror.example.com"}},
+	}
+
+	result, err := mergeRegistries(
+		registries,
+		[]string{"docker.io=https://mirror.gcr.io", "quay.io=https://quay-mirror.example.com"},
+		[]string{"registry.example.com=user:pass:word"},
+		[]string{"registry.example.com=/etc/pki/registry-ca.crt"},
+		[]string{"10.0.0.1:5000"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]config.RegistryConfig{
+		"docker.io": {Mirrors: []string{"https://mirror.example.com", "https://mirror.gcr.io"}},
+		"quay.io":   {Mirrors: []string{"https://quay-mirror.example.com"}},
+		"registry.example.com": {
+			Username: "user",
+			Password: "pass:word",
+			CAFile:   "/etc/pki/registry-ca.crt",
+		},
+		"10.0.0.1:5000": {InsecureSkipVerify: true},
+	}, result)
+
+	// config file values are left untouched
+	require.Equal(t, []string{"https://mirror.example.com"}, registries["docker.io"].Mirrors)
+
+	_, err = mergeRegistries(nil, []string{"https://mirror.gcr.io"}, nil, nil, nil)
+	require.Error(t, err)
+
+	_, err = mergeRegistries(nil, nil, []string{"docker.io=token"}, nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const (
+	// gvisorVersion is the pinned gVisor release the runsc binaries are installed from.
+	gvisorVersion = "20221017.0"
+	gvisorURL     = "https://storage.googleapis.com/gvisor/releases/release/%s/%s/%s"
+	cmdSnap       = "snap"
+)
+
+// gvisorBinaries are the gVisor binaries installed for the containerd runtime handler.
+var gvisorBinaries = []string{"runsc", "containerd-shim-runsc-v1"}
+
+// gvisorChecksums is the built-in SHA-256 checksum manifest of the gVisor binaries of the pinned releases.
+// Binaries of releases without an entry have to be listed in a signed checksum manifest.
+var gvisorChecksums = file.Checksums{}
+
+// gvisorArtifact returns the name of a gVisor binary of the release for the architecture in the checksum manifests.
+func gvisorArtifact(bin, version, arch string) string {
+	return fmt.Sprintf("%s-%s-%s", bin, version, gvisorArch(arch))
+}
+
+// gvisorArch returns the architecture name used in the gVisor release urls.
+func gvisorArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		return arch
+	}
+}
+
+// gvisorVerifier returns the verifier of the gVisor binaries.
+// The signed checksum manifests take precedence over the built-in checksums.
+func gvisorVerifier(v file.Verifier) file.Verifier {
+	return file.Verifier{Keys: v.Keys}.WithChecksums(gvisorChecksums).WithChecksums(v.Checksums)
+}
+
+// validateSandboxRuntimes checks that the sandboxed runtimes can be installed on the host:
+// the gVisor binaries need trusted checksums, Kata is installed as a snap.
+func validateSandboxRuntimes(runtimes []cri.SandboxRuntime, arch string, v file.Verifier, lookPath func(string) (string, error)) error {
+	for _, r := range runtimes {
+		switch r.Name {
+		case constants.SandboxRuntimeGVisor:
+			v := gvisorVerifier(v)
+			for _, bin := range gvisorBinaries {
+				if artifact := gvisorArtifact(bin, gvisorVersion, arch); !v.Known(artifact) {
+					return errors.Wrapf(
+						constants.ErrInvalidInput,
+						"--%s: no known checksum for %s, please provide it with a signed --%s",
+						constants.FlagSandboxRuntimes,
+						artifact,
+						constants.FlagChecksumManifest,
+					)
+				}
+			}
+		case constants.SandboxRuntimeKata:
+			// CentOS and RHEL do not ship snapd
+			if _, err := lookPath(cmdSnap); err != nil {
+				return errors.Wrapf(
+					constants.ErrInvalidInput,
+					"--%s: %s is installed from the kata-containers snap, install snapd first",
+					constants.FlagSandboxRuntimes,
+					constants.SandboxRuntimeKata,
+				)
+			}
+		}
+	}
+
+	return nil
+}
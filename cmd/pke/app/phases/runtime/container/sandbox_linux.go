@@ -0,0 +1,106 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"runtime"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	gvisorBinDir = "/usr/local/bin"
+	kataSnap     = "kata-containers"
+	kataShim     = "/snap/kata-containers/current/usr/bin/containerd-shim-kata-v2"
+	kataShimLink = "/usr/local/bin/containerd-shim-kata-v2"
+)
+
+// installSandboxRuntimes installs the shims of the sandboxed runtimes registered in the containerd configuration.
+func installSandboxRuntimes(out io.Writer, runtimes []cri.SandboxRuntime, v file.Verifier) error {
+	for _, r := range runtimes {
+		var err error
+		switch r.Name {
+		case constants.SandboxRuntimeGVisor:
+			err = installGVisor(out, gvisorVerifier(v))
+		case constants.SandboxRuntimeKata:
+			err = installKata(out)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to install sandbox runtime %q", r.Name)
+		}
+	}
+
+	return nil
+}
+
+func installGVisor(out io.Writer, v file.Verifier) error {
+	for _, bin := range gvisorBinaries {
+		filename := gvisorBinDir + "/" + bin
+		if _, err := os.Stat(filename); err == nil {
+			_, _ = fmt.Fprintf(out, "%s already installed, skipping download\n", bin)
+			continue
+		}
+
+		// wget https://storage.googleapis.com/gvisor/releases/release/${GVISOR_VERSION}/x86_64/runsc
+		artifact := gvisorArtifact(bin, gvisorVersion, runtime.GOARCH)
+		dl := fmt.Sprintf(gvisorURL, gvisorVersion, gvisorArch(runtime.GOARCH), bin)
+		u, err := url.Parse(dl)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse url: %q", dl)
+		}
+		tmp := filename + ".pke-new"
+		_, _ = fmt.Fprintf(out, "wget %q -O %s\n", u.String(), tmp)
+
+		// echo "${RUNSC_SHA256} runsc" | sha256sum --check -
+		_, _ = fmt.Fprintf(out, "echo \"%s %s\" | sha256sum --check -\n", v.Checksums[artifact], tmp)
+		if err := v.Download(u, artifact, tmp); err != nil {
+			return errors.Wrapf(err, "unable to download %s. url: %q", bin, u.String())
+		}
+
+		// chmod a+rx runsc
+		if err := os.Chmod(tmp, 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filename); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func installKata(out io.Writer) error {
+	if _, err := os.Stat(kataShimLink); err == nil {
+		_, _ = fmt.Fprintln(out, "kata-containers already installed")
+		return nil
+	}
+
+	// snap install kata-containers --classic
+	if _, err := runner.Cmd(out, cmdSnap, "install", kataSnap, "--classic").CombinedOutputAsync(); err != nil {
+		return err
+	}
+
+	// ln -sf /snap/kata-containers/current/usr/bin/containerd-shim-kata-v2 /usr/local/bin/containerd-shim-kata-v2
+	return os.Symlink(kataShim, kataShimLink)
+}
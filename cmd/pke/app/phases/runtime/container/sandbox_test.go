@@ -0,0 +1,69 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/cri"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+func TestValidateSandboxRuntimes(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/snap", nil }
+	notFound := func(string) (string, error) { return "", exec.ErrNotFound }
+	signed := file.Verifier{Checksums: file.Checksums{
+		gvisorArtifact("runsc", gvisorVersion, "amd64"):                    "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		gvisorArtifact("containerd-shim-runsc-v1", gvisorVersion, "amd64"): "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210",
+	}}
+
+	testCases := []struct {
+		name     string
+		runtimes []string
+		arch     string
+		verifier file.Verifier
+		lookPath func(string) (string, error)
+		err      bool
+	}{
+		{name: "none", lookPath: notFound},
+		{name: "gvisor from signed manifest", runtimes: []string{constants.SandboxRuntimeGVisor}, arch: "amd64", verifier: signed, lookPath: notFound},
+		{name: "gvisor without checksum", runtimes: []string{constants.SandboxRuntimeGVisor}, arch: "arm64", verifier: signed, lookPath: found, err: true},
+		{name: "kata with snapd", runtimes: []string{constants.SandboxRuntimeKata}, lookPath: found},
+		{name: "kata without snapd", runtimes: []string{constants.SandboxRuntimeKata}, lookPath: notFound, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runtimes, err := cri.SandboxRuntimes(tc.runtimes)
+			require.NoError(t, err)
+
+			err = validateSandboxRuntimes(runtimes, tc.arch, tc.verifier, tc.lookPath)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGVisorArtifact(t *testing.T) {
+	require.Equal(t, "runsc-20221017.0-x86_64", gvisorArtifact("runsc", "20221017.0", "amd64"))
+	require.Equal(t, "runsc-20221017.0-aarch64", gvisorArtifact("runsc", "20221017.0", "arm64"))
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"fmt"
+	"io"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/config"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/flags"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/kubernetes"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/validator"
+)
+
+const (
+	upgradeShort = "Container runtime upgrade"
+)
+
+var _ phases.Runnable = (*Upgrade)(nil)
+
+// Upgrade replaces the container runtime of a running node in place.
+type Upgrade struct {
+	config config.Config
+
+	containerRuntime  string
+	containerdVersion string
+	verifier          file.Verifier
+	nodeName          string
+	drained           bool
+}
+
+func NewUpgradeCommand(config config.Config) *cobra.Command {
+	return phases.NewCommand(&Upgrade{config: config})
+}
+
+func (u *Upgrade) Use() string {
+	return use
+}
+
+func (u *Upgrade) Short() string {
+	return upgradeShort
+}
+
+func (u *Upgrade) RegisterFlags(flags *pflag.FlagSet) {
+	// Kubernetes container runtime
+	flags.String(constants.FlagContainerRuntime, u.config.ContainerRuntime.Type, "Kubernetes container runtime")
+	// containerd version
+	flags.String(constants.FlagContainerdVersion, u.config.ContainerRuntime.Version, "containerd version")
+	flags.String(constants.FlagContainerdSHA256, "", "SHA-256 checksum of the containerd release archive (required for versions without a known checksum)")
+	file.RegisterVerificationFlags(flags)
+	// Kubernetes node name
+	flags.String(constants.FlagNodeName, "", "Kubernetes node name to drain during the upgrade (defaults to hostname)")
+	flags.Bool(constants.FlagNodeDrained, false, "The node was cordoned and drained from the control plane, required on nodes without the admin kubeconfig if the containerd version changes")
+}
+
+func (u *Upgrade) Validate(cmd *cobra.Command) (err error) {
+	u.containerRuntime, err = cmd.Flags().GetString(constants.FlagContainerRuntime)
+	if err != nil {
+		return
+	}
+	if err = validator.NotEmpty(map[string]interface{}{
+		constants.FlagContainerRuntime: u.containerRuntime,
+	}); err != nil {
+		return
+	}
+
+	nodeName, err := cmd.Flags().GetString(constants.FlagNodeName)
+	if err != nil {
+		return
+	}
+	u.nodeName, err = kubernetes.NodeName(nodeName)
+	if err != nil {
+		return
+	}
+	u.drained, err = cmd.Flags().GetBool(constants.FlagNodeDrained)
+	if err != nil {
+		return
+	}
+
+	switch u.containerRuntime {
+	case constants.ContainerRuntimeContainerd:
+		u.containerdVersion, u.verifier, err = containerdParameters(cmd)
+		if err != nil {
+			return
+		}
+	case constants.ContainerRuntimeCRIO,
+		constants.ContainerRuntimeDocker:
+		// break
+	default:
+		return errors.Wrapf(constants.ErrUnsupportedContainerRuntime, "container runtime: %s", u.containerRuntime)
+	}
+
+	flags.PrintFlags(cmd.OutOrStdout(), u.Use(), cmd.Flags())
+
+	return nil
+}
+
+func (u *Upgrade) Run(out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "[%s] running\n", u.Use())
+
+	switch u.containerRuntime {
+	case constants.ContainerRuntimeContainerd:
+		return u.upgradeContainerd(out)
+
+	case constants.ContainerRuntimeDocker:
+		_, _ = fmt.Fprintf(out, "[%s] skipping upgrade, docker is not managed by PKE\n", u.Use())
+		return nil
+
+	case constants.ContainerRuntimeCRIO:
+		_, _ = fmt.Fprintf(out, "[%s] skipping upgrade, CRI-O is upgraded with the distribution packages\n", u.Use())
+		return nil
+
+	default:
+		return errors.Wrapf(constants.ErrUnsupportedContainerRuntime, "container runtime: %s", u.containerRuntime)
+	}
+}
@@ -22,6 +22,9 @@ import (
 	"github.com/banzaicloud/pke/cmd/pke/app/config"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/phases"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/proxy"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/validator"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,6 +41,8 @@ type Runtime struct {
 	config config.Config
 
 	kubernetesVersion string
+	proxy             proxy.Config
+	verifier          file.Verifier
 }
 
 func NewCommand(config config.Config) *cobra.Command {
@@ -55,6 +60,11 @@ func (r *Runtime) Short() string {
 func (r *Runtime) RegisterFlags(flags *pflag.FlagSet) {
 	// Kubernetes version
 	flags.String(constants.FlagKubernetesVersion, r.config.Kubernetes.Version, "Kubernetes version")
+
+	// Proxy
+	proxy.RegisterFlags(flags)
+	// Verification of the downloaded package repository keys
+	file.RegisterVerificationFlags(flags)
 }
 
 func (r *Runtime) Validate(cmd *cobra.Command) error {
@@ -69,6 +79,15 @@ func (r *Runtime) Validate(cmd *cobra.Command) error {
 	}
 	r.kubernetesVersion = ver.String()
 
+	r.proxy, err = proxy.Parameters(cmd)
+	if err != nil {
+		return err
+	}
+	r.verifier, err = file.VerificationParameters(cmd)
+	if err != nil {
+		return err
+	}
+
 	return validator.NotEmpty(map[string]interface{}{
 		constants.FlagKubernetesVersion: r.kubernetesVersion,
 	})
@@ -81,5 +100,14 @@ func (r *Runtime) Run(out io.Writer) error {
 		_, _ = fmt.Fprintf(out, "[%s] skipping installation (already installed)\n", r.Use())
 	}
 
+	// Proxy settings for downloads and package managers
+	r.proxy = r.proxy.WithNoProxy()
+	if err := r.proxy.Apply(); err != nil {
+		return err
+	}
+	if err := linux.ConfigurePackageManagerProxy(out, r.proxy.HTTPProxy, r.proxy.HTTPSProxy); err != nil {
+		return err
+	}
+
 	return r.installRuntime(out)
 }
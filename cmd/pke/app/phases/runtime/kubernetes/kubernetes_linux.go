@@ -34,16 +34,16 @@ func (r *Runtime) installRuntime(out io.Writer) error {
 	if err != nil {
 		return err
 	}
-	return install(out, r.kubernetesVersion, pm)
+	return install(out, r.kubernetesVersion, pm, r.verifier)
 }
 
-func install(out io.Writer, kubernetesVersion string, pm linux.KubernetesPackages) error {
+func install(out io.Writer, kubernetesVersion string, pm linux.KubernetesPackages, v file.Verifier) error {
 
 	if err := writeKubeletKernelParams(out, kubeletKernelparams); err != nil {
 		return errors.Wrapf(err, "unable to write kubernetes kernel params to %s", kubeletKernelparams)
 	}
 
-	if err := pm.InstallKubernetesPrerequisites(out, kubernetesVersion); err != nil {
+	if err := pm.InstallKubernetesPrerequisites(out, kubernetesVersion, v); err != nil {
 		return err
 	}
 
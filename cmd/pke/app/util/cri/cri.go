@@ -15,6 +15,8 @@
 package cri
 
 import (
+	"emperror.dev/errors"
+
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 )
 
@@ -26,7 +28,57 @@ func GetCRISocket(cri string) string {
 
 	case constants.ContainerRuntimeDocker:
 		return "/var/run/dockershim.sock"
+
+	case constants.ContainerRuntimeCRIO:
+		return "unix:///var/run/crio/crio.sock"
 	}
 
 	return ""
 }
+
+// ValidateCgroupDriver checks that the given cgroup driver is supported by both the kubelet and the container runtime.
+func ValidateCgroupDriver(cgroupDriver string) error {
+	switch cgroupDriver {
+	case constants.CgroupDriverSystemd, constants.CgroupDriverCgroupfs:
+		return nil
+	}
+
+	return errors.Wrapf(constants.ErrInvalidInput, "--%s: %q, supported values: %s, %s", constants.FlagCgroupDriver, cgroupDriver, constants.CgroupDriverSystemd, constants.CgroupDriverCgroupfs)
+}
+
+// SandboxRuntime is a sandboxed OCI runtime registered as an extra containerd runtime handler.
+type SandboxRuntime struct {
+	// Name of the RuntimeClass.
+	Name string
+	// Handler is the name of the runtime in the containerd CRI plugin configuration.
+	Handler string
+	// RuntimeType is the containerd shim of the runtime.
+	RuntimeType string
+}
+
+var sandboxRuntimes = map[string]SandboxRuntime{
+	constants.SandboxRuntimeGVisor: {
+		Name:        "gvisor",
+		Handler:     "runsc",
+		RuntimeType: "io.containerd.runsc.v1",
+	},
+	constants.SandboxRuntimeKata: {
+		Name:        "kata",
+		Handler:     "kata",
+		RuntimeType: "io.containerd.kata.v2",
+	},
+}
+
+// SandboxRuntimes returns the sandboxed runtimes with the given names.
+func SandboxRuntimes(names []string) ([]SandboxRuntime, error) {
+	var runtimes []SandboxRuntime
+	for _, name := range names {
+		r, ok := sandboxRuntimes[name]
+		if !ok {
+			return nil, errors.Wrapf(constants.ErrInvalidInput, "--%s: %q, supported values: %s, %s", constants.FlagSandboxRuntimes, name, constants.SandboxRuntimeGVisor, constants.SandboxRuntimeKata)
+		}
+		runtimes = append(runtimes, r)
+	}
+
+	return runtimes, nil
+}
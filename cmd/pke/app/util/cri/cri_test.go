@@ -0,0 +1,57 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cri
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+func TestSandboxRuntimes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		names    []string
+		handlers []string
+		err      bool
+	}{
+		{name: "none"},
+		{name: "gvisor", names: []string{constants.SandboxRuntimeGVisor}, handlers: []string{"runsc"}},
+		{name: "gvisor and kata", names: []string{constants.SandboxRuntimeGVisor, constants.SandboxRuntimeKata}, handlers: []string{"runsc", "kata"}},
+		{name: "unknown", names: []string{"firecracker"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runtimes, err := SandboxRuntimes(tc.names)
+			if tc.err {
+				require.Error(t, err)
+				require.True(t, errors.Is(err, constants.ErrInvalidInput))
+				return
+			}
+			require.NoError(t, err)
+
+			var handlers []string
+			for _, r := range runtimes {
+				handlers = append(handlers, r.Handler)
+			}
+			require.Equal(t, tc.handlers, handlers)
+		})
+	}
+}
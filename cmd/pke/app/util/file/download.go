@@ -16,6 +16,7 @@ package file
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"io"
 	"io/ioutil"
@@ -25,12 +26,30 @@ import (
 
 	"emperror.dev/errors"
 	retry "github.com/avast/retry-go"
+	"golang.org/x/net/http/httpproxy"
 )
 
+var httpClient = http.DefaultClient
+
+// SetProxy configures the proxies used by Download.
+func SetProxy(httpProxy, httpsProxy, noProxy string) {
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  httpProxy,
+		HTTPSProxy: httpsProxy,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(r *http.Request) (*url.URL, error) {
+		return proxy(r.URL)
+	}
+	httpClient = &http.Client{Transport: transport}
+}
+
 func Download(u *url.URL, f string) error {
 	err := retry.Do(
 		func() error {
-			resp, err := http.Get(u.String())
+			resp, err := httpClient.Get(u.String())
 			if err != nil {
 				return err
 			}
@@ -84,3 +103,28 @@ func SHA256File(f, hash string) error {
 
 	return nil
 }
+
+func SHA512(filepath string) (string, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return "", err
+	}
+	h := sha512.New()
+	if _, err = h.Write(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func SHA512File(f, hash string) error {
+	hs, err := SHA512(f)
+	if err != nil {
+		return err
+	}
+	if hs != hash {
+		return errors.Errorf("hash mismatch. got: %q, expected: %q", hs, hash)
+	}
+
+	return nil
+}
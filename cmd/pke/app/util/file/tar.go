@@ -26,7 +26,19 @@ import (
 	"emperror.dev/errors"
 )
 
+// Untar extracts a gzipped tarball to the root filesystem. Existing files are left untouched.
 func Untar(out io.Writer, r io.Reader) error {
+	return untar(out, r, nil)
+}
+
+// UntarReplace extracts a gzipped tarball to the root filesystem. Existing files are replaced
+// if replace returns true for their path, otherwise they are left untouched.
+// Files are replaced by renaming, so binaries in use (e.g. a running containerd shim) can be swapped.
+func UntarReplace(out io.Writer, r io.Reader, replace func(name string) bool) error {
+	return untar(out, r, replace)
+}
+
+func untar(out io.Writer, r io.Reader, replace func(name string) bool) error {
 	gz, err := gzip.NewReader(r)
 	if err != nil {
 		return errors.Wrap(err, "unable to open gzip")
@@ -53,7 +65,14 @@ func Untar(out io.Writer, r io.Reader) error {
 		case tar.TypeReg:
 			hdr.Name = Absolutise(string(os.PathSeparator), hdr.Name)
 			_, _ = fmt.Fprintf(out, "write %s ", hdr.Name)
-			f, err := os.OpenFile(hdr.Name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, hdr.FileInfo().Mode())
+			name := hdr.Name
+			if replace != nil && replace(hdr.Name) {
+				if _, err := os.Stat(hdr.Name); err == nil {
+					name = hdr.Name + ".pke-new"
+					_ = os.Remove(name)
+				}
+			}
+			f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, hdr.FileInfo().Mode())
 			if err != nil {
 				if e, ok := err.(*os.PathError); ok && e.Err == syscall.EEXIST {
 					_, _ = fmt.Fprintf(out, "exist, skipping\n")
@@ -72,6 +91,13 @@ func Untar(out io.Writer, r io.Reader) error {
 				_, _ = fmt.Fprintf(out, "err: %v\n", err)
 				return errors.Wrapf(err, "write failure. file: %s, written: %d, expected: %d", hdr.Name, hdr.Size, n)
 			}
+			if name != hdr.Name {
+				if err := os.Rename(name, hdr.Name); err != nil {
+					_, _ = fmt.Fprintf(out, "err: %v\n", err)
+					return errors.Wrapf(err, "unable to replace file: %s", hdr.Name)
+				}
+				_, _ = fmt.Fprintf(out, "replaced ")
+			}
 			_, _ = fmt.Fprintf(out, "ok\n")
 		}
 	}
@@ -0,0 +1,348 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// trustedPublicKeys contains the comma separated public keys trusted by the binary.
+// It can be set at build time with:
+// -ldflags "-X github.com/banzaicloud/pke/cmd/pke/app/util/file.trustedPublicKeys=RWQ..."
+var trustedPublicKeys string
+
+const (
+	// SignatureSuffix is appended to the name or url of an artifact to get its detached signature.
+	SignatureSuffix = ".sig"
+
+	untrustedComment = "untrusted comment:"
+	trustedComment   = "trusted comment:"
+)
+
+var (
+	algEd25519          = []byte("Ed")
+	algEd25519Prehashed = []byte("ED")
+)
+
+// Checksums is a checksum manifest mapping artifact names to their SHA-256 checksums.
+type Checksums map[string]string
+
+// ParseChecksums parses a checksum manifest in the output format of sha256sum.
+// Empty lines and lines starting with # are ignored.
+func ParseChecksums(b []byte) (Checksums, error) {
+	c := make(Checksums)
+	scn := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scn.Scan(); n++ {
+		line := strings.TrimSpace(scn.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("line %d: expected checksum and name, got: %q", n, line)
+		}
+		sum := strings.ToLower(fields[0])
+		if h, err := hex.DecodeString(sum); err != nil || len(h) != 32 {
+			return nil, errors.Errorf("line %d: invalid SHA-256 checksum: %q", n, fields[0])
+		}
+		c[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+
+	return c, scn.Err()
+}
+
+// PublicKey is an Ed25519 public key in minisign or signify format.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign or signify public key file, or the base64 encoded key itself.
+func ParsePublicKey(b []byte) (PublicKey, error) {
+	lines := signatureLines(b)
+	if len(lines) == 0 {
+		return PublicKey{}, errors.New("empty public key")
+	}
+	k, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return PublicKey{}, errors.Wrap(err, "failed to decode public key")
+	}
+	if len(k) != 2+8+ed25519.PublicKeySize || !bytes.Equal(k[:2], algEd25519) {
+		return PublicKey{}, errors.New("unsupported public key, expected an Ed25519 minisign or signify key")
+	}
+
+	var p PublicKey
+	copy(p.ID[:], k[2:10])
+	p.Key = ed25519.PublicKey(k[10:])
+
+	return p, nil
+}
+
+// VerifySignature verifies the minisign or signify signature of the message with the matching trusted key.
+// Prehashed minisign signatures are not supported, sign with `minisign -S -l` or signify instead.
+func VerifySignature(message, signature []byte, keys []PublicKey) error {
+	var lines []string
+	var comment string
+	for _, line := range strings.Split(string(signature), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, untrustedComment):
+		case strings.HasPrefix(line, trustedComment):
+			comment = strings.TrimPrefix(line, trustedComment)
+			comment = strings.TrimPrefix(comment, " ")
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return errors.New("empty signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+	if len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid signature length")
+	}
+	if bytes.Equal(sig[:2], algEd25519Prehashed) {
+		return errors.New("prehashed signatures are not supported, sign with `minisign -S -l` or signify")
+	}
+	if !bytes.Equal(sig[:2], algEd25519) {
+		return errors.Errorf("unsupported signature algorithm: %q", sig[:2])
+	}
+
+	for _, k := range keys {
+		if !bytes.Equal(k.ID[:], sig[2:10]) {
+			continue
+		}
+		if !ed25519.Verify(k.Key, message, sig[10:]) {
+			return errors.Errorf("invalid signature by key %X", k.ID)
+		}
+		// minisign signs the signature and the trusted comment as well
+		if len(lines) > 1 {
+			global, err := base64.StdEncoding.DecodeString(lines[1])
+			if err != nil {
+				return errors.Wrap(err, "failed to decode global signature")
+			}
+			if !ed25519.Verify(k.Key, append(append([]byte(nil), sig[10:]...), comment...), global) {
+				return errors.Errorf("invalid trusted comment signature by key %X", k.ID)
+			}
+		}
+		return nil
+	}
+
+	return errors.Errorf("signature by untrusted key %X", sig[2:10])
+}
+
+func signatureLines(b []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, untrustedComment) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Verifier verifies artifacts with trusted checksums and signatures of trusted keys.
+type Verifier struct {
+	Keys      []PublicKey
+	Checksums Checksums
+}
+
+// NewVerifier creates a verifier trusting the keys configured at build time and the given ones.
+func NewVerifier(keys ...PublicKey) (Verifier, error) {
+	v := Verifier{Checksums: make(Checksums)}
+	for _, k := range strings.Split(trustedPublicKeys, ",") {
+		if k == "" {
+			continue
+		}
+		p, err := ParsePublicKey([]byte(k))
+		if err != nil {
+			return Verifier{}, errors.Wrap(err, "invalid built-in trusted public key")
+		}
+		v.Keys = append(v.Keys, p)
+	}
+	v.Keys = append(v.Keys, keys...)
+
+	return v, nil
+}
+
+// WithChecksums returns a copy of the verifier trusting the given checksums as well.
+// The given checksums take precedence over the already trusted ones.
+func (v Verifier) WithChecksums(c Checksums) Verifier {
+	checksums := make(Checksums, len(v.Checksums)+len(c))
+	for name, sum := range v.Checksums {
+		checksums[name] = sum
+	}
+	for name, sum := range c {
+		checksums[name] = strings.ToLower(sum)
+	}
+	v.Checksums = checksums
+
+	return v
+}
+
+// LoadChecksums adds the checksums of a manifest signed by a trusted key with a detached signature next to it.
+func (v Verifier) LoadChecksums(filename string) (Verifier, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return v, err
+	}
+	sig, err := ioutil.ReadFile(filename + SignatureSuffix)
+	if err != nil {
+		return v, errors.Wrapf(err, "unsigned checksum manifest %q", filename)
+	}
+	if err := VerifySignature(b, sig, v.Keys); err != nil {
+		return v, errors.Wrapf(err, "failed to verify checksum manifest %q", filename)
+	}
+
+	c, err := ParseChecksums(b)
+	if err != nil {
+		return v, errors.Wrapf(err, "failed to parse checksum manifest %q", filename)
+	}
+
+	return v.WithChecksums(c), nil
+}
+
+// Known tells if there is a trusted checksum for the artifact.
+func (v Verifier) Known(name string) bool {
+	_, ok := v.Checksums[name]
+	return ok
+}
+
+// Verify verifies the file with the trusted checksum of the artifact.
+func (v Verifier) Verify(name, f string) error {
+	sum, ok := v.Checksums[name]
+	if !ok {
+		return errors.Errorf("no trusted checksum for %q", name)
+	}
+
+	return SHA256File(f, sum)
+}
+
+// Download downloads the artifact and verifies it with its trusted checksum or,
+// if there is none, with the detached signature downloaded from the url with SignatureSuffix appended.
+// The downloaded file is removed if it cannot be verified.
+func (v Verifier) Download(u *url.URL, name, f string) error {
+	if err := Download(u, f); err != nil {
+		return err
+	}
+
+	if err := v.verifyDownload(u, name, f); err != nil {
+		_ = os.Remove(f)
+		return errors.Wrapf(err, "refusing unverified artifact %q", name)
+	}
+
+	return nil
+}
+
+func (v Verifier) verifyDownload(u *url.URL, name, f string) error {
+	if v.Known(name) {
+		return v.Verify(name, f)
+	}
+	if len(v.Keys) == 0 {
+		return errors.Errorf(
+			"no trusted checksum and no trusted public key, provide a signed --%s or --%s",
+			constants.FlagChecksumManifest,
+			constants.FlagTrustedPublicKey,
+		)
+	}
+	if u.RawQuery != "" {
+		return errors.New("no trusted checksum and no signature for urls with query parameters")
+	}
+
+	sigURL := *u
+	sigURL.Path += SignatureSuffix
+	sigFile := f + SignatureSuffix
+	defer func() { _ = os.Remove(sigFile) }()
+	if err := Download(&sigURL, sigFile); err != nil {
+		return errors.Wrapf(err, "no trusted checksum and unable to download signature %q", sigURL.String())
+	}
+
+	message, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	sig, err := ioutil.ReadFile(sigFile)
+	if err != nil {
+		return err
+	}
+
+	return VerifySignature(message, sig, v.Keys)
+}
+
+// RegisterVerificationFlags registers the flags configuring the trusted keys and checksums.
+func RegisterVerificationFlags(flags *pflag.FlagSet) {
+	flags.StringSlice(constants.FlagTrustedPublicKey, nil, "Minisign or signify public key file trusted for verifying artifacts, in addition to the keys built into pke")
+	flags.StringSlice(constants.FlagChecksumManifest, nil, "SHA-256 checksum manifest of artifacts, signed by a trusted key with a detached signature in <file>"+SignatureSuffix+". Artifacts neither listed nor signed next to their url are refused, package repository keys and files are only verified if listed")
+}
+
+// VerificationParameters reads the trusted keys and checksum manifests.
+func VerificationParameters(cmd *cobra.Command) (v Verifier, err error) {
+	keyFiles, err := cmd.Flags().GetStringSlice(constants.FlagTrustedPublicKey)
+	if err != nil {
+		return
+	}
+	manifests, err := cmd.Flags().GetStringSlice(constants.FlagChecksumManifest)
+	if err != nil {
+		return
+	}
+
+	var keys []PublicKey
+	for _, f := range keyFiles {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return v, errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagTrustedPublicKey, err)
+		}
+		k, err := ParsePublicKey(b)
+		if err != nil {
+			return v, errors.Wrapf(constants.ErrInvalidInput, "--%s: %q: %v", constants.FlagTrustedPublicKey, f, err)
+		}
+		keys = append(keys, k)
+	}
+
+	v, err = NewVerifier(keys...)
+	if err != nil {
+		return
+	}
+	for _, f := range manifests {
+		v, err = v.LoadChecksums(f)
+		if err != nil {
+			return v, errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagChecksumManifest, err)
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,212 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct {
+	id      [8]byte
+	private ed25519.PrivateKey
+	public  PublicKey
+}
+
+func newTestKey(t *testing.T) testKey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	k := testKey{private: priv}
+	_, err = rand.Read(k.id[:])
+	require.NoError(t, err)
+	k.public = PublicKey{ID: k.id, Key: pub}
+
+	return k
+}
+
+// publicKeyFile returns the public key in minisign format.
+func (k testKey) publicKeyFile() []byte {
+	b := append(append([]byte("Ed"), k.id[:]...), k.public.Key...)
+	return []byte("untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(b) + "\n")
+}
+
+// sign returns a legacy minisign signature of the message, a signify one if comment is empty.
+func (k testKey) sign(message []byte, comment string) []byte {
+	sig := ed25519.Sign(k.private, message)
+	s := "untrusted comment: signature\n" + base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), k.id[:]...), sig...)) + "\n"
+	if comment != "" {
+		global := ed25519.Sign(k.private, append(sig, comment...))
+		s += "trusted comment: " + comment + "\n" + base64.StdEncoding.EncodeToString(global) + "\n"
+	}
+
+	return []byte(s)
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func TestParseChecksums(t *testing.T) {
+	sum := sha256Hex([]byte("artifact"))
+	c, err := ParseChecksums([]byte(fmt.Sprintf("# release 1.0\n\n%s  metallb.yaml\n%s *cri-containerd-cni-1.6.8-linux-arm64.tar.gz\n", sum, sum)))
+	require.NoError(t, err)
+	require.Equal(t, Checksums{
+		"metallb.yaml": sum,
+		"cri-containerd-cni-1.6.8-linux-arm64.tar.gz": sum,
+	}, c)
+
+	_, err = ParseChecksums([]byte("abcdef  metallb.yaml\n"))
+	require.Error(t, err)
+
+	_, err = ParseChecksums([]byte(sum + "\n"))
+	require.Error(t, err)
+}
+
+func TestVerifySignature(t *testing.T) {
+	trusted := newTestKey(t)
+	untrusted := newTestKey(t)
+	message := []byte("apiVersion: v1\nkind: Namespace\n")
+
+	pub, err := ParsePublicKey(trusted.publicKeyFile())
+	require.NoError(t, err)
+	require.Equal(t, trusted.public, pub)
+	keys := []PublicKey{pub}
+
+	require.NoError(t, VerifySignature(message, trusted.sign(message, ""), keys))
+	require.NoError(t, VerifySignature(message, trusted.sign(message, "timestamp:1661000000 file:metallb.yaml"), keys))
+
+	require.Error(t, VerifySignature(append(message, ' '), trusted.sign(message, ""), keys), "modified message")
+	require.Error(t, VerifySignature(message, untrusted.sign(message, ""), keys), "untrusted key")
+	require.Error(t, VerifySignature(message, trusted.sign(message, ""), nil), "no trusted keys")
+	require.Error(t, VerifySignature(message, nil, keys), "empty signature")
+
+	sig := string(trusted.sign(message, "timestamp:1661000000"))
+	tampered := strings.Replace(sig, "timestamp:1661000000", "timestamp:0", 1)
+	require.Error(t, VerifySignature(message, []byte(tampered), keys), "modified trusted comment")
+
+	prehashed := append(append([]byte("ED"), trusted.id[:]...), ed25519.Sign(trusted.private, message)...)
+	require.Error(t, VerifySignature(message, []byte(base64.StdEncoding.EncodeToString(prehashed)), keys), "prehashed signature")
+
+	_, err = ParsePublicKey([]byte("untrusted comment: key\nbm90IGEga2V5\n"))
+	require.Error(t, err)
+}
+
+func TestLoadChecksums(t *testing.T) {
+	key := newTestKey(t)
+	dir, err := ioutil.TempDir("", "verify_test")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	sum := sha256Hex([]byte("artifact"))
+	manifest := []byte(sum + "  metallb.yaml\n")
+	filename := filepath.Join(dir, "SHA256SUMS")
+	require.NoError(t, ioutil.WriteFile(filename, manifest, 0600))
+
+	v, err := NewVerifier(key.public)
+	require.NoError(t, err)
+
+	_, err = v.LoadChecksums(filename)
+	require.Error(t, err, "unsigned manifest")
+
+	require.NoError(t, ioutil.WriteFile(filename+SignatureSuffix, newTestKey(t).sign(manifest, ""), 0600))
+	_, err = v.LoadChecksums(filename)
+	require.Error(t, err, "manifest signed by untrusted key")
+
+	require.NoError(t, ioutil.WriteFile(filename+SignatureSuffix, key.sign(manifest, ""), 0600))
+	v, err = v.LoadChecksums(filename)
+	require.NoError(t, err)
+	require.True(t, v.Known("metallb.yaml"))
+	require.False(t, v.Known("weave-net.yaml"))
+}
+
+func TestVerifierDownload(t *testing.T) {
+	key := newTestKey(t)
+	signed := []byte("kind: Namespace\n")
+	files := map[string][]byte{
+		"/checksummed.yaml":         []byte("kind: ConfigMap\n"),
+		"/signed.yaml":              signed,
+		"/signed.yaml.sig":          key.sign(signed, "file:signed.yaml"),
+		"/wrong-signature.yaml":     []byte("kind: Secret\n"),
+		"/wrong-signature.yaml.sig": key.sign(signed, ""),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "verify_test")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	v, err := NewVerifier(key.public)
+	require.NoError(t, err)
+	v = v.WithChecksums(Checksums{
+		"checksummed.yaml":    sha256Hex(files["/checksummed.yaml"]),
+		"wrong-checksum.yaml": sha256Hex([]byte("something else")),
+	})
+	files["/wrong-checksum.yaml"] = []byte("kind: Pod\n")
+
+	testCases := []struct {
+		name     string
+		path     string
+		verifier Verifier
+		err      bool
+	}{
+		{name: "checksummed.yaml", path: "/checksummed.yaml", verifier: v},
+		{name: "wrong-checksum.yaml", path: "/wrong-checksum.yaml", verifier: v, err: true},
+		{name: "signed.yaml", path: "/signed.yaml", verifier: v},
+		{name: "wrong-signature.yaml", path: "/wrong-signature.yaml", verifier: v, err: true},
+		{name: "signed.yaml", path: "/signed.yaml", verifier: Verifier{}, err: true},
+		{name: "weave-net.yaml", path: "/signed.yaml?k8s-version=1.23", verifier: v, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(srv.URL + tc.path)
+			require.NoError(t, err)
+			f := filepath.Join(dir, tc.name)
+
+			err = tc.verifier.Download(u, tc.name, f)
+			if tc.err {
+				require.Error(t, err)
+				_, err = os.Stat(f)
+				require.True(t, os.IsNotExist(err), "unverified file must be removed")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
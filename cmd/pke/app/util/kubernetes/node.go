@@ -0,0 +1,59 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
+)
+
+const (
+	cmdKubectl = "kubectl"
+)
+
+// NodeName returns the given node name or the lowercase hostname of the machine if it is empty.
+func NodeName(nodeName string) (string, error) {
+	if nodeName != "" {
+		return nodeName, nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(hostname), nil
+}
+
+// Drain marks the node unschedulable and evicts all pods except the ones managed by DaemonSets.
+func Drain(out io.Writer, kubeConfig, nodeName string) error {
+	// kubectl drain <node> --ignore-daemonsets --delete-emptydir-data --timeout=10m
+	cmd := runner.Cmd(out, cmdKubectl, "drain", nodeName, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout=10m")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
+
+// Uncordon marks the node schedulable.
+func Uncordon(out io.Writer, kubeConfig, nodeName string) error {
+	// kubectl uncordon <node>
+	cmd := runner.Cmd(out, cmdKubectl, "uncordon", nodeName)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	_, err := cmd.CombinedOutputAsync()
+	return err
+}
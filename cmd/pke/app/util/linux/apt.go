@@ -15,14 +15,17 @@
 package linux
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"emperror.dev/errors"
 
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 )
@@ -34,18 +37,29 @@ const (
 	k8sDEBRepoFile     = "/etc/apt/sources.list.d/kubernetes.list"
 	k8sDEBRepo         = `deb https://apt.kubernetes.io/ kubernetes-xenial main`
 	k8sDEBRepoGPG      = "https://packages.cloud.google.com/apt/doc/apt-key.gpg"
+	// k8sDEBRepoGPGArtifact is the name of the Kubernetes repo key in checksum manifests
+	k8sDEBRepoGPGArtifact = "kubernetes-apt-key.gpg"
+
+	libcontainersDEBRepoFile = "/etc/apt/sources.list.d/devel:kubic:libcontainers:stable.list"
+	libcontainersDEBRepoURL  = "https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/%s/"
+	crioDEBRepoFile          = "/etc/apt/sources.list.d/devel:kubic:libcontainers:stable:cri-o.list"
+	crioDEBRepoURL           = "https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/%s/%s/"
 )
 
 var _ ContainerdPackages = (*AptInstaller)(nil)
+var _ CRIOPackages = (*AptInstaller)(nil)
 var _ KubernetesPackages = (*AptInstaller)(nil)
 
-type AptInstaller struct{}
+type AptInstaller struct {
+	// crioRepoOS is the name of the distribution in the CRI-O repository URLs, e.g. xUbuntu_20.04.
+	crioRepoOS string
+}
 
 func NewAptInstaller() *AptInstaller {
 	return &AptInstaller{}
 }
 
-func (a *AptInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string) error {
+func (a *AptInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string, v file.Verifier) error {
 	if err := SwapOff(out); err != nil {
 		return err
 	}
@@ -67,24 +81,11 @@ func (a *AptInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesV
 	}
 
 	// curl -s https://packages.cloud.google.com/apt/doc/apt-key.gpg | apt-key add -
-	// Download Kubernetes repo apt key.
-	f, err := ioutil.TempFile("", "kubernetes-apt-key")
-	if err != nil {
-		return errors.Wrapf(err, "unable to create temporary file: %q", f.Name())
-	}
-	defer func() { _ = f.Close() }()
-	u, err := url.Parse(k8sDEBRepoGPG)
-	if err != nil {
-		return errors.Wrapf(err, "unable to parse Kubernetes repo apt key. url: %q", k8sDEBRepoGPG)
-	}
-	if err = file.Download(u, f.Name()); err != nil {
-		return errors.Wrapf(err, "unable to download Kubernetes repo apt key. url: %q", u.String())
-	}
-	if _, err := runner.Cmd(out, cmdAptKey, "add", f.Name()).CombinedOutputAsync(); err != nil {
+	if err := aptKeyAdd(out, v, k8sDEBRepoGPG, k8sDEBRepoGPGArtifact); err != nil {
 		return errors.Wrap(err, "unable to add Kubernetes repo apt key")
 	}
 
-	_, err = runner.Cmd(out, cmdApt, "update").CombinedOutputAsync()
+	_, err := runner.Cmd(out, cmdApt, "update").CombinedOutputAsync()
 	return err
 }
 
@@ -118,6 +119,53 @@ func (a *AptInstaller) InstallContainerdPrerequisites(out io.Writer, containerdV
 	return nil
 }
 
+func (a *AptInstaller) InstallCRIOPackages(out io.Writer, crioVersion string, v file.Verifier) error {
+	if a.crioRepoOS == "" {
+		return errors.Wrap(constants.ErrUnsupportedOS, "CRI-O repository of the distribution is unknown")
+	}
+	repos := map[string]string{
+		libcontainersDEBRepoFile: fmt.Sprintf(libcontainersDEBRepoURL, a.crioRepoOS),
+		crioDEBRepoFile:          fmt.Sprintf(crioDEBRepoURL, crioVersion, a.crioRepoOS),
+	}
+	for repoFile, repoURL := range repos {
+		// echo "deb $REPO_URL /" > $REPO_FILE
+		if err := file.Overwrite(repoFile, "deb "+repoURL+" /"); err != nil {
+			return err
+		}
+		// curl -L $REPO_URL/Release.key | apt-key add -
+		if err := aptKeyAdd(out, v, repoURL+"Release.key", strings.TrimSuffix(filepath.Base(repoFile), ".list")+".key"); err != nil {
+			return errors.Wrapf(err, "unable to add apt key of repo %q", repoURL)
+		}
+	}
+
+	if _, err := runner.Cmd(out, cmdApt, "update").CombinedOutputAsync(); err != nil {
+		return err
+	}
+
+	// apt-get install -y cri-o cri-o-runc
+	return AptInstall(out, []string{"cri-o", "cri-o-runc"})
+}
+
+// aptKeyAdd downloads the repo key from the given url and adds it to the trusted keys,
+// it is verified as the named artifact if a checksum manifest lists it.
+func aptKeyAdd(out io.Writer, v file.Verifier, keyURL, name string) error {
+	f, err := ioutil.TempFile("", "apt-key")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file")
+	}
+	defer func() { _ = f.Close() }()
+	defer func() { _ = os.Remove(f.Name()) }()
+	u, err := url.Parse(keyURL)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse apt key url: %q", keyURL)
+	}
+	if err = downloadRepoArtifact(out, v, u, name, f.Name()); err != nil {
+		return errors.Wrapf(err, "unable to download apt key. url: %q", u.String())
+	}
+	_, err = runner.Cmd(out, cmdAptKey, "add", f.Name()).CombinedOutputAsync()
+	return err
+}
+
 func aptErrorMatcher(text string) bool {
 	return strings.HasPrefix(text, "E:")
 }
@@ -16,7 +16,9 @@ package linux
 
 import (
 	"io"
+	"strconv"
 
+	"emperror.dev/errors"
 	"github.com/Masterminds/semver"
 	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 )
@@ -76,3 +78,48 @@ func ContainerdPackagesImpl(out io.Writer) (ContainerdPackages, error) {
 
 	return nil, constants.ErrUnsupportedOS
 }
+
+func CRIOPackagesImpl(out io.Writer) (CRIOPackages, error) {
+	ver, err := CentOSVersion(out)
+	if err != nil {
+		ver, err = RedHatVersion(out)
+	}
+	if err == nil {
+		v, err := semver.NewVersion(ver)
+		if err != nil {
+			return nil, errors.Wrapf(constants.ErrUnsupportedOS, "version: %q", ver)
+		}
+		repoOS, err := CRIORepoOS("CentOS", strconv.FormatInt(v.Major(), 10))
+		if err != nil {
+			return nil, err
+		}
+		return &DnfInstaller{crioRepoOS: repoOS}, nil
+	}
+
+	distro, err := LSBReleaseDistributorID(out)
+	if err != nil {
+		return nil, constants.ErrUnsupportedOS
+	}
+	relNum, err := LSBReleaseReleaseNumber(out)
+	if err != nil {
+		return nil, constants.ErrUnsupportedOS
+	}
+	repoOS, err := CRIORepoOS(distro, relNum)
+	if err != nil {
+		return nil, err
+	}
+	return &AptInstaller{crioRepoOS: repoOS}, nil
+}
+
+// CRIORepoOS returns the name of the distribution in the URLs of the CRI-O package repositories.
+// RHEL uses the CentOS repositories of the same major version.
+func CRIORepoOS(distro, release string) (string, error) {
+	switch {
+	case distro == "CentOS" && release == "8":
+		return "CentOS_8", nil
+	case distro == "Ubuntu" && (release == "20.04" || release == "22.04"):
+		return "xUbuntu_" + release, nil
+	default:
+		return "", errors.Wrapf(constants.ErrUnsupportedOS, "CRI-O packages are not available for %s %s", distro, release)
+	}
+}
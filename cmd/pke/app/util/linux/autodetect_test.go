@@ -0,0 +1,49 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRIORepoOS(t *testing.T) {
+	testCases := []struct {
+		distro  string
+		release string
+		repoOS  string
+		err     bool
+	}{
+		{distro: "Ubuntu", release: "20.04", repoOS: "xUbuntu_20.04"},
+		{distro: "Ubuntu", release: "22.04", repoOS: "xUbuntu_22.04"},
+		{distro: "CentOS", release: "8", repoOS: "CentOS_8"},
+		{distro: "Ubuntu", release: "16.04", err: true},
+		{distro: "CentOS", release: "7", err: true},
+		{distro: "Debian", release: "11", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.distro+" "+tc.release, func(t *testing.T) {
+			repoOS, err := CRIORepoOS(tc.distro, tc.release)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.repoOS, repoOS)
+		})
+	}
+}
@@ -15,10 +15,14 @@
 package linux
 
 import (
+	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 
 	"emperror.dev/errors"
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 	"github.com/banzaicloud/pke/cmd/pke/app/util/runner"
 )
@@ -31,8 +35,13 @@ baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-x86_64
 enabled=1
 gpgcheck=1
 repo_gpgcheck=1
-gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
+gpgkey=file://` + k8sRPMRepoGPGDir + `/kubernetes-yum-key.gpg file://` + k8sRPMRepoGPGDir + `/kubernetes-rpm-package-key.gpg
 excludepkgs=[kube*]`
+
+	libcontainersRPMRepoFile = "/etc/yum.repos.d/devel:kubic:libcontainers:stable.repo"
+	libcontainersRPMRepoURL  = "https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/%s/devel:kubic:libcontainers:stable.repo"
+	crioRPMRepoFile          = "/etc/yum.repos.d/devel:kubic:libcontainers:stable:cri-o.repo"
+	crioRPMRepoURL           = "https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/%[1]s/%[2]s/devel:kubic:libcontainers:stable:cri-o:%[1]s.repo"
 )
 
 func DnfInstall(out io.Writer, packages packages) error {
@@ -45,15 +54,19 @@ func DnfInstall(out io.Writer, packages packages) error {
 }
 
 var _ ContainerdPackages = (*DnfInstaller)(nil)
+var _ CRIOPackages = (*DnfInstaller)(nil)
 var _ KubernetesPackages = (*DnfInstaller)(nil)
 
-type DnfInstaller struct{}
+type DnfInstaller struct {
+	// crioRepoOS is the name of the distribution in the CRI-O repository URLs, e.g. CentOS_8.
+	crioRepoOS string
+}
 
 func NewDnfInstaller() *DnfInstaller {
 	return &DnfInstaller{}
 }
 
-func (y *DnfInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string) error {
+func (y *DnfInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string, v file.Verifier) error {
 	if err := SwapOff(out); err != nil {
 		return err
 	}
@@ -67,6 +80,9 @@ func (y *DnfInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesV
 	}
 
 	if _, err := os.Stat(banzaiCloudRPMRepo); err != nil {
+		if err := downloadRPMRepoKeys(out, v); err != nil {
+			return err
+		}
 		err = file.Overwrite(k8sRPMRepoFile, k8sRPMRepoDnf)
 		if err != nil {
 			return err
@@ -105,3 +121,26 @@ func (y *DnfInstaller) InstallContainerdPrerequisites(out io.Writer, containerdV
 
 	return nil
 }
+
+func (y *DnfInstaller) InstallCRIOPackages(out io.Writer, crioVersion string, v file.Verifier) error {
+	if y.crioRepoOS == "" {
+		return errors.Wrap(constants.ErrUnsupportedOS, "CRI-O repository of the distribution is unknown")
+	}
+	repos := map[string]string{
+		libcontainersRPMRepoFile: fmt.Sprintf(libcontainersRPMRepoURL, y.crioRepoOS),
+		crioRPMRepoFile:          fmt.Sprintf(crioRPMRepoURL, crioVersion, y.crioRepoOS),
+	}
+	for repoFile, repoURL := range repos {
+		// curl -L -o $REPO_FILE $REPO_URL
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse repo url: %q", repoURL)
+		}
+		if err := downloadRepoArtifact(out, v, u, filepath.Base(repoFile), repoFile); err != nil {
+			return errors.Wrapf(err, "unable to download repo file. url: %q", repoURL)
+		}
+	}
+
+	// dnf install -y cri-o
+	return DnfInstall(out, packages{{"cri-o", ""}})
+}
@@ -15,11 +15,15 @@
 package linux
 
 import (
+	"fmt"
 	"io"
+	"net/url"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
 )
 
 type KubernetesPackages interface {
-	InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string) error
+	InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string, v file.Verifier) error
 	InstallKubernetesPackages(out io.Writer, kubernetesVersion string) error
 	InstallKubeadmPackage(out io.Writer, kubernetesVersion string) error
 }
@@ -27,3 +31,18 @@ type KubernetesPackages interface {
 type ContainerdPackages interface {
 	InstallContainerdPrerequisites(out io.Writer, containerdVersion string) error
 }
+
+type CRIOPackages interface {
+	InstallCRIOPackages(out io.Writer, crioVersion string, v file.Verifier) error
+}
+
+// downloadRepoArtifact downloads a package repository key or file. It is verified if a checksum manifest lists it,
+// otherwise it is trusted as before, the packages are still verified by the package manager with the repository keys.
+func downloadRepoArtifact(out io.Writer, v file.Verifier, u *url.URL, name, f string) error {
+	if v.Known(name) {
+		return v.Download(u, name, f)
+	}
+
+	_, _ = fmt.Fprintf(out, "no trusted checksum for %q, downloading %s without verification\n", name, u)
+	return file.Download(u, f)
+}
@@ -0,0 +1,114 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+)
+
+const (
+	aptConfDir   = "/etc/apt/apt.conf.d"
+	aptProxyConf = "/etc/apt/apt.conf.d/90pke-proxy"
+	dnfConf      = "/etc/dnf/dnf.conf"
+	yumConf      = "/etc/yum.conf"
+)
+
+// ConfigurePackageManagerProxy configures the proxy of the package managers available on the machine.
+func ConfigurePackageManagerProxy(out io.Writer, httpProxy, httpsProxy string) error {
+	if httpProxy == "" && httpsProxy == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(aptConfDir); err == nil {
+		_, _ = fmt.Fprintf(out, "writing apt proxy configuration: %s\n", aptProxyConf)
+		var b strings.Builder
+		if httpProxy != "" {
+			b.WriteString(fmt.Sprintf("Acquire::http::Proxy %q;\n", httpProxy))
+		}
+		if httpsProxy != "" {
+			b.WriteString(fmt.Sprintf("Acquire::https::Proxy %q;\n", httpsProxy))
+		}
+		if err := file.Overwrite(aptProxyConf, b.String()); err != nil {
+			return err
+		}
+	}
+
+	// dnf and yum use a single proxy for every repository
+	proxy := httpsProxy
+	if proxy == "" {
+		proxy = httpProxy
+	}
+	for _, conf := range []string{dnfConf, yumConf} {
+		if _, err := os.Stat(conf); err != nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "writing proxy configuration: %s\n", conf)
+		b, err := ioutil.ReadFile(conf)
+		if err != nil {
+			return err
+		}
+		if err := file.Overwrite(conf, setMainOption(string(b), "proxy", proxy)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setMainOption sets the option in the [main] section of a dnf/yum configuration file.
+func setMainOption(conf, key, value string) string {
+	var (
+		lines  []string
+		inMain bool
+		set    bool
+	)
+	conf = strings.TrimSuffix(conf, "\n")
+	for _, line := range strings.Split(conf, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inMain && !set {
+				lines = append(lines, key+"="+value)
+				set = true
+			}
+			inMain = trimmed == "[main]"
+		}
+		if inMain && strings.HasPrefix(strings.ReplaceAll(trimmed, " ", ""), key+"=") {
+			if !set {
+				lines = append(lines, key+"="+value)
+				set = true
+			}
+			continue
+		}
+		if conf == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if !set {
+		if !inMain {
+			lines = append(lines, "[main]")
+		}
+		lines = append(lines, key+"="+value)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
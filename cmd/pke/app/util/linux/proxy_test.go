@@ -0,0 +1,54 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMainOption(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     string
+		expected string
+	}{
+		{
+			name:     "append to main",
+			conf:     "[main]\ngpgcheck=1\n",
+			expected: "[main]\ngpgcheck=1\nproxy=http://proxy:3128\n",
+		},
+		{
+			name:     "replace existing",
+			conf:     "[main]\nproxy = http://old:3128\ngpgcheck=1\n",
+			expected: "[main]\nproxy=http://proxy:3128\ngpgcheck=1\n",
+		},
+		{
+			name:     "main followed by other section",
+			conf:     "[main]\ngpgcheck=1\n[repo]\nproxy=_none_\n",
+			expected: "[main]\ngpgcheck=1\nproxy=http://proxy:3128\n[repo]\nproxy=_none_\n",
+		},
+		{
+			name:     "missing main",
+			conf:     "",
+			expected: "[main]\nproxy=http://proxy:3128\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, setMainOption(tc.conf, "proxy", "http://proxy:3128"), tc.name)
+	}
+}
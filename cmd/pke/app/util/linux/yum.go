@@ -16,7 +16,9 @@ package linux
 
 import (
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"emperror.dev/errors"
@@ -34,10 +36,19 @@ baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-x86_64
 enabled=1
 gpgcheck=1
 repo_gpgcheck=1
-gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
+gpgkey=file://` + k8sRPMRepoGPGDir + `/kubernetes-yum-key.gpg file://` + k8sRPMRepoGPGDir + `/kubernetes-rpm-package-key.gpg
 exclude=kube*`
+
+	// k8sRPMRepoGPGDir contains the keys of the Kubernetes repo, named as the artifacts in checksum manifests
+	k8sRPMRepoGPGDir = "/etc/pki/rpm-gpg"
 )
 
+// k8sRPMRepoGPGKeys are the urls of the Kubernetes repo keys by their artifact name.
+var k8sRPMRepoGPGKeys = map[string]string{
+	"kubernetes-yum-key.gpg":         "https://packages.cloud.google.com/yum/doc/yum-key.gpg",
+	"kubernetes-rpm-package-key.gpg": "https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg",
+}
+
 func yumErrorMatcher(text string) bool {
 	return strings.Contains(strings.ToLower(text), "error") || strings.Contains(text, "No package ")
 }
@@ -59,7 +70,7 @@ var _ KubernetesPackages = (*YumInstaller)(nil)
 
 type YumInstaller struct{}
 
-func (y *YumInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string) error {
+func (y *YumInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesVersion string, v file.Verifier) error {
 	// Set SELinux in permissive mode (effectively disabling it)
 	// setenforce 0
 	err := runner.Cmd(out, "setenforce", "0").Run()
@@ -93,9 +104,12 @@ func (y *YumInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesV
 		// enabled=1
 		// gpgcheck=1
 		// repo_gpgcheck=1
-		// gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
+		// gpgkey=file:///etc/pki/rpm-gpg/kubernetes-yum-key.gpg file:///etc/pki/rpm-gpg/kubernetes-rpm-package-key.gpg
 		// exclude=kube*
 		// EOF
+		if err := downloadRPMRepoKeys(out, v); err != nil {
+			return err
+		}
 		err = file.Overwrite(k8sRPMRepoFile, k8sRPMRepo)
 		if err != nil {
 			return err
@@ -104,6 +118,23 @@ func (y *YumInstaller) InstallKubernetesPrerequisites(out io.Writer, kubernetesV
 	return nil
 }
 
+// downloadRPMRepoKeys downloads the keys of the Kubernetes repo, so they can be verified like the apt key.
+func downloadRPMRepoKeys(out io.Writer, v file.Verifier) error {
+	if err := os.MkdirAll(k8sRPMRepoGPGDir, 0755); err != nil {
+		return err
+	}
+	for name, keyURL := range k8sRPMRepoGPGKeys {
+		u, err := url.Parse(keyURL)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse repo key url: %q", keyURL)
+		}
+		if err := downloadRepoArtifact(out, v, u, name, filepath.Join(k8sRPMRepoGPGDir, name)); err != nil {
+			return errors.Wrapf(err, "unable to download repo key. url: %q", keyURL)
+		}
+	}
+	return nil
+}
+
 func NewYumInstaller() *YumInstaller {
 	return &YumInstaller{}
 }
@@ -15,7 +15,9 @@
 package network
 
 import (
+	"bytes"
 	"net"
+	"strings"
 
 	"emperror.dev/errors"
 )
@@ -38,3 +40,198 @@ func ContainsFirst(cidr string, ips []net.IP) (net.IP, error) {
 
 	return nil, errors.Errorf("cidr %q does not contain ip %q", cidr, ips)
 }
+
+// CIDRs contains the CIDRs of a single-stack or dual-stack network, the first one belongs to the primary IP family.
+type CIDRs []*net.IPNet
+
+// ParseCIDRs parses a comma separated list of CIDRs.
+// Dual-stack networks have exactly one IPv4 and one IPv6 CIDR.
+func ParseCIDRs(s string) (CIDRs, error) {
+	var cidrs CIDRs
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		ip, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		if !ip.Equal(n.IP) {
+			return nil, errors.Errorf("%q is not a network address, did you mean %q?", cidr, n.String())
+		}
+		cidrs = append(cidrs, n)
+	}
+
+	switch len(cidrs) {
+	case 0:
+		return nil, errors.New("no CIDR given")
+	case 1:
+	case 2:
+		if IsIPv6(cidrs[0].IP) == IsIPv6(cidrs[1].IP) {
+			return nil, errors.Errorf("dual-stack CIDRs must contain one IPv4 and one IPv6 CIDR, got: %q", s)
+		}
+	default:
+		return nil, errors.Errorf("at most one IPv4 and one IPv6 CIDR can be given, got: %q", s)
+	}
+
+	return cidrs, nil
+}
+
+// IPv4 returns the IPv4 CIDR or an empty string if there is none.
+func (c CIDRs) IPv4() string {
+	for _, n := range c {
+		if IsIPv4(n.IP) {
+			return n.String()
+		}
+	}
+
+	return ""
+}
+
+// IPv6 returns the IPv6 CIDR or an empty string if there is none.
+func (c CIDRs) IPv6() string {
+	for _, n := range c {
+		if IsIPv6(n.IP) {
+			return n.String()
+		}
+	}
+
+	return ""
+}
+
+// DualStack tells if the network has both IPv4 and IPv6 CIDRs.
+func (c CIDRs) DualStack() bool {
+	return c.IPv4() != "" && c.IPv6() != ""
+}
+
+// IPv4Only tells if the network is a single-stack IPv4 network.
+func (c CIDRs) IPv4Only() bool {
+	return c.IPv4() != "" && c.IPv6() == ""
+}
+
+// SameFamilies tells if both networks have the same IP families in the same order.
+func (c CIDRs) SameFamilies(o CIDRs) bool {
+	if len(c) != len(o) {
+		return false
+	}
+	for i := range c {
+		if IsIPv6(c[i].IP) != IsIPv6(o[i].IP) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c CIDRs) String() string {
+	s := make([]string, 0, len(c))
+	for _, n := range c {
+		s = append(s, n.String())
+	}
+
+	return strings.Join(s, ",")
+}
+
+// NodeIPs selects an address for each IP family of the network from the available ones, preferring the given addresses.
+func NodeIPs(c CIDRs, preferred, available []net.IP) ([]net.IP, error) {
+	var ips []net.IP
+	for _, n := range c {
+		family := IsIPv4
+		if IsIPv6(n.IP) {
+			family = IsIPv6
+		}
+
+		var ip net.IP
+		for _, candidate := range append(append([]net.IP(nil), preferred...), available...) {
+			if candidate != nil && family(candidate) {
+				ip = candidate
+				break
+			}
+		}
+		if ip == nil {
+			return nil, errors.Errorf("no node address found for the IP family of %q", n.String())
+		}
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// Overlaps tells if the two networks have common addresses.
+func Overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// Subnets returns the number of subnets with the given prefix length the network can be divided into.
+// The result is capped at the maximum value of an int.
+func Subnets(n *net.IPNet, prefixLength int) (int, error) {
+	ones, bits := n.Mask.Size()
+	if prefixLength < ones || prefixLength > bits {
+		return 0, errors.Errorf("prefix length /%d is out of range for %q", prefixLength, n.String())
+	}
+	if prefixLength-ones >= 31 {
+		return int(^uint(0) >> 1), nil
+	}
+
+	return 1 << uint(prefixLength-ones), nil
+}
+
+// IPRange is an inclusive range of IP addresses.
+type IPRange struct {
+	First net.IP
+	Last  net.IP
+}
+
+// ParseIPRange parses an IP range given either as a CIDR or as a first and last address separated by a dash.
+func ParseIPRange(s string) (IPRange, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return IPRange{}, err
+		}
+		last := make(net.IP, len(n.IP))
+		for i := range n.IP {
+			last[i] = n.IP[i] | ^n.Mask[i]
+		}
+		return IPRange{First: n.IP, Last: last}, nil
+	}
+
+	p := strings.SplitN(s, "-", 2)
+	if len(p) != 2 {
+		return IPRange{}, errors.Errorf("invalid IP range %q, expected a CIDR or a range like 192.168.0.100-192.168.0.110", s)
+	}
+	first, last := net.ParseIP(strings.TrimSpace(p[0])), net.ParseIP(strings.TrimSpace(p[1]))
+	if first == nil || last == nil {
+		return IPRange{}, errors.Errorf("invalid IP range %q", s)
+	}
+	if IsIPv4(first) != IsIPv4(last) {
+		return IPRange{}, errors.Errorf("IP range %q mixes IP families", s)
+	}
+	if bytes.Compare(first.To16(), last.To16()) > 0 {
+		return IPRange{}, errors.Errorf("first address of IP range %q is greater than the last one", s)
+	}
+
+	return IPRange{First: first, Last: last}, nil
+}
+
+// Overlaps tells if the range has common addresses with the network.
+func (r IPRange) Overlaps(n *net.IPNet) bool {
+	return n.Contains(r.First) || n.Contains(r.Last) || r.Contains(n.IP)
+}
+
+// OverlapsRange tells if the ranges have common addresses.
+func (r IPRange) OverlapsRange(o IPRange) bool {
+	return bytes.Compare(r.First.To16(), o.Last.To16()) <= 0 && bytes.Compare(o.First.To16(), r.Last.To16()) <= 0
+}
+
+// Contains tells if the address is in the range.
+func (r IPRange) Contains(ip net.IP) bool {
+	ip = ip.To16()
+	return bytes.Compare(r.First.To16(), ip) <= 0 && bytes.Compare(ip, r.Last.To16()) <= 0
+}
+
+func (r IPRange) String() string {
+	return r.First.String() + "-" + r.Last.String()
+}
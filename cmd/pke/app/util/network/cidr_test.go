@@ -57,6 +57,7 @@ func TestContainsFirst(t *testing.T) {
 		{"IP not in CIDR", "10.240.0.0/24", []net.IP{net.ParseIP("1.1.1.1")}, net.ParseIP("1.1.1.1"), true},
 		{"invalid CIDR", "10.240.0.0/100", []net.IP{net.ParseIP("10.240.0.1")}, nil, true},
 		{"multiple IPs in CIDR, first match", "10.240.0.0/24", []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("10.240.10.1"), net.ParseIP("10.240.0.1")}, net.ParseIP("10.240.0.1"), false},
+		{"IPv6 IP in CIDR", "2001:db8::/64", []net.IP{net.ParseIP("10.240.0.1"), net.ParseIP("2001:db8::1")}, net.ParseIP("2001:db8::1"), false},
 	}
 	for _, tc := range testCases {
 		ip, err := ContainsFirst(tc.cidr, tc.ips)
@@ -69,3 +70,175 @@ func TestContainsFirst(t *testing.T) {
 		require.Equal(t, tc.expected, ip)
 	}
 }
+
+func TestParseCIDRs(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cidrs     string
+		ipv4      string
+		ipv6      string
+		dualStack bool
+		err       bool
+	}{
+		{name: "IPv4", cidrs: "10.20.0.0/16", ipv4: "10.20.0.0/16"},
+		{name: "IPv6", cidrs: "fd00:10:20::/56", ipv6: "fd00:10:20::/56"},
+		{name: "dual-stack", cidrs: "10.20.0.0/16,fd00:10:20::/56", ipv4: "10.20.0.0/16", ipv6: "fd00:10:20::/56", dualStack: true},
+		{name: "dual-stack IPv6 primary", cidrs: "fd00:10:20::/56, 10.20.0.0/16", ipv4: "10.20.0.0/16", ipv6: "fd00:10:20::/56", dualStack: true},
+		{name: "two IPv4", cidrs: "10.20.0.0/16,10.30.0.0/16", err: true},
+		{name: "three CIDRs", cidrs: "10.20.0.0/16,fd00:10:20::/56,10.30.0.0/16", err: true},
+		{name: "host address", cidrs: "10.20.0.1/16", err: true},
+		{name: "invalid", cidrs: "10.20.0.0", err: true},
+		{name: "empty", cidrs: "", err: true},
+	}
+	for _, tc := range testCases {
+		cidrs, err := ParseCIDRs(tc.cidrs)
+		if tc.err {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.ipv4, cidrs.IPv4(), tc.name)
+		require.Equal(t, tc.ipv6, cidrs.IPv6(), tc.name)
+		require.Equal(t, tc.dualStack, cidrs.DualStack(), tc.name)
+		require.Equal(t, tc.ipv4 != "" && tc.ipv6 == "", cidrs.IPv4Only(), tc.name)
+	}
+}
+
+func TestSameFamilies(t *testing.T) {
+	parse := func(s string) CIDRs {
+		cidrs, err := ParseCIDRs(s)
+		require.NoError(t, err)
+		return cidrs
+	}
+
+	require.True(t, parse("10.10.0.0/16,fd00:10:10::/112").SameFamilies(parse("10.20.0.0/16,fd00:10:20::/56")))
+	require.False(t, parse("10.10.0.0/16,fd00:10:10::/112").SameFamilies(parse("fd00:10:20::/56,10.20.0.0/16")))
+	require.False(t, parse("10.10.0.0/16").SameFamilies(parse("10.20.0.0/16,fd00:10:20::/56")))
+	require.False(t, parse("10.10.0.0/16").SameFamilies(parse("fd00:10:20::/56")))
+}
+
+func TestNodeIPs(t *testing.T) {
+	dualStack, err := ParseCIDRs("10.10.0.0/16,fd00:10:10::/112")
+	require.NoError(t, err)
+	ipv6, err := ParseCIDRs("fd00:10:10::/112")
+	require.NoError(t, err)
+
+	available := []net.IP{net.ParseIP("192.168.64.11"), net.ParseIP("2001:db8::11"), net.ParseIP("2001:db8::12")}
+
+	ips, err := NodeIPs(dualStack, nil, available)
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("192.168.64.11"), net.ParseIP("2001:db8::11")}, ips)
+
+	ips, err = NodeIPs(dualStack, []net.IP{net.ParseIP("2001:db8::12")}, available)
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("192.168.64.11"), net.ParseIP("2001:db8::12")}, ips)
+
+	ips, err = NodeIPs(ipv6, nil, available)
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("2001:db8::11")}, ips)
+
+	_, err = NodeIPs(ipv6, nil, available[:1])
+	require.Error(t, err)
+}
+
+func TestOverlaps(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		overlaps bool
+	}{
+		{"10.10.0.0/16", "10.20.0.0/16", false},
+		{"10.0.0.0/8", "10.20.0.0/16", true},
+		{"10.20.0.0/16", "10.0.0.0/8", true},
+		{"10.20.0.0/16", "10.20.0.0/16", true},
+		{"10.0.0.0/8", "fd00::/8", false},
+	}
+	for _, tc := range testCases {
+		_, a, err := net.ParseCIDR(tc.a)
+		require.NoError(t, err)
+		_, b, err := net.ParseCIDR(tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.overlaps, Overlaps(a, b), "%s %s", tc.a, tc.b)
+	}
+}
+
+func TestSubnets(t *testing.T) {
+	testCases := []struct {
+		cidr         string
+		prefixLength int
+		expected     int
+		err          bool
+	}{
+		{"10.20.0.0/16", 24, 256, false},
+		{"10.20.0.0/24", 24, 1, false},
+		{"10.20.0.0/25", 24, 0, true},
+		{"10.20.0.0/16", 33, 0, true},
+		{"fd00::/56", 64, 256, false},
+		{"fd00::/8", 64, int(^uint(0) >> 1), false},
+	}
+	for _, tc := range testCases {
+		_, n, err := net.ParseCIDR(tc.cidr)
+		require.NoError(t, err)
+		subnets, err := Subnets(n, tc.prefixLength)
+		if tc.err {
+			require.Error(t, err, tc.cidr)
+			continue
+		}
+		require.NoError(t, err, tc.cidr)
+		require.Equal(t, tc.expected, subnets, tc.cidr)
+	}
+}
+
+func TestParseIPRange(t *testing.T) {
+	testCases := []struct {
+		name     string
+		s        string
+		expected string
+		overlaps string
+		err      bool
+	}{
+		{"range", "192.168.0.100-192.168.0.110", "192.168.0.100-192.168.0.110", "192.168.0.96/28", false},
+		{"range covering network", "10.0.0.0-10.255.255.255", "10.0.0.0-10.255.255.255", "10.20.0.0/16", false},
+		{"CIDR", "192.168.0.128/25", "192.168.0.128-192.168.0.255", "192.168.0.0/24", false},
+		{"IPv6 range", "fd00::10-fd00::20", "fd00::10-fd00::20", "fd00::/64", false},
+		{"reversed", "192.168.0.110-192.168.0.100", "", "", true},
+		{"mixed families", "192.168.0.100-fd00::1", "", "", true},
+		{"single address", "192.168.0.100", "", "", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseIPRange(tc.s)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, r.String())
+			_, n, err := net.ParseCIDR(tc.overlaps)
+			require.NoError(t, err)
+			require.True(t, r.Overlaps(n))
+			_, other, err := net.ParseCIDR("172.16.0.0/12")
+			require.NoError(t, err)
+			require.False(t, r.Overlaps(other))
+		})
+	}
+}
+
+func TestIPRangeOverlapsRange(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"192.168.0.100-192.168.0.110", "192.168.0.110-192.168.0.120", true},
+		{"192.168.0.100-192.168.0.110", "192.168.0.0/24", true},
+		{"192.168.0.100-192.168.0.110", "192.168.0.111-192.168.0.120", false},
+		{"192.168.0.0/24", "fd00::/64", false},
+	}
+	for _, tc := range testCases {
+		a, err := ParseIPRange(tc.a)
+		require.NoError(t, err)
+		b, err := ParseIPRange(tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, a.OverlapsRange(b), "%s %s", tc.a, tc.b)
+		require.Equal(t, tc.expected, b.OverlapsRange(a), "%s %s", tc.b, tc.a)
+	}
+}
@@ -20,7 +20,57 @@ import (
 	"emperror.dev/errors"
 )
 
+// Addresses returns the IPv4 and IPv6 addresses of the network interfaces.
+// Loopback and IPv6 link-local addresses are skipped.
+func Addresses() ([]net.IP, error) {
+	ips, err := addresses(func(net.IP) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IP address found")
+	}
+
+	return ips, nil
+}
+
 func IPv4Addresses() ([]net.IP, error) {
+	ips, err := addresses(IsIPv4)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IPv4 address found")
+	}
+
+	return ips, nil
+}
+
+// IPv6Addresses returns the IPv6 addresses of the network interfaces.
+func IPv6Addresses() ([]net.IP, error) {
+	ips, err := addresses(IsIPv6)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IPv6 address found")
+	}
+
+	return ips, nil
+}
+
+// InterfaceAddresses returns the IPv4 and IPv6 addresses of the named network interface.
+// IPv6 link-local addresses are skipped.
+func InterfaceAddresses(name string) ([]net.IP, error) {
+	i, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return interfaceAddresses(*i, func(net.IP) bool { return true })
+}
+
+func addresses(filter func(net.IP) bool) ([]net.IP, error) {
 	ips := make([]net.IP, 0)
 
 	interfaces, err := net.Interfaces()
@@ -28,32 +78,49 @@ func IPv4Addresses() ([]net.IP, error) {
 		return nil, err
 	}
 	for _, i := range interfaces {
-		addresses, err := i.Addrs()
+		addresses, err := interfaceAddresses(i, filter)
 		if err != nil {
 			return nil, err
 		}
-		for _, addr := range addresses {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
-			}
-			if ip == nil || ip.IsLoopback() {
-				continue
-			}
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
-			}
-			ips = append(ips, ip)
-		}
+		ips = append(ips, addresses...)
 	}
 
-	if len(ips) == 0 {
-		return nil, errors.New("no IPv4 address found")
+	return ips, nil
+}
+
+func interfaceAddresses(i net.Interface, filter func(net.IP) bool) ([]net.IP, error) {
+	ips := make([]net.IP, 0)
+
+	addresses, err := i.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addresses {
+		var ip net.IP
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip = v.IP
+		}
+		if ip == nil || ip.IsLoopback() || (IsIPv6(ip) && ip.IsLinkLocalUnicast()) || !filter(ip) {
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		ips = append(ips, ip)
 	}
 
 	return ips, nil
 }
+
+// IsIPv4 tells if the address is an IPv4 address.
+func IsIPv4(ip net.IP) bool {
+	return ip.To4() != nil
+}
+
+// IsIPv6 tells if the address is an IPv6 address.
+func IsIPv6(ip net.IP) bool {
+	return ip.To4() == nil && ip.To16() != nil
+}
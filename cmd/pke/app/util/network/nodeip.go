@@ -0,0 +1,176 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"net"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// NodeIPSelector selects the address of the node by explicit address, network interface or infrastructure CIDR,
+// in this order of precedence.
+type NodeIPSelector struct {
+	NodeIP    string
+	Interface string
+	CIDR      string
+}
+
+// RegisterNodeIPFlags registers the node address selection flags.
+func RegisterNodeIPFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagNodeIP, "", "Address of the node, comma separated IPv4 and IPv6 addresses for dual-stack clusters")
+	flags.String(constants.FlagInfrastructureInterface, "", "Network interface of the node address, or default-route to use the interface of the default route")
+	flags.String(constants.FlagInfrastructureCIDR, "192.168.64.0/20", "network CIDR for the actual machine")
+}
+
+// NodeIPParameters reads and validates the node address selection flags.
+func NodeIPParameters(cmd *cobra.Command) (s NodeIPSelector, err error) {
+	s.NodeIP, err = cmd.Flags().GetString(constants.FlagNodeIP)
+	if err != nil {
+		return
+	}
+	s.Interface, err = cmd.Flags().GetString(constants.FlagInfrastructureInterface)
+	if err != nil {
+		return
+	}
+	s.CIDR, err = cmd.Flags().GetString(constants.FlagInfrastructureCIDR)
+	if err != nil {
+		return
+	}
+
+	if s.NodeIP != "" {
+		if _, err := parseIPs(s.NodeIP); err != nil {
+			return s, errors.Wrapf(constants.ErrInvalidInput, "--%s: %v", constants.FlagNodeIP, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Explicit tells if the node address is selected by explicit address or network interface.
+// Otherwise the infrastructure CIDR is used, which is only required by Pipeline.
+func (s NodeIPSelector) Explicit() bool {
+	return s.NodeIP != "" || s.Interface != ""
+}
+
+// Select returns the addresses of the node, the first one is the primary address.
+func (s NodeIPSelector) Select() ([]net.IP, error) {
+	return s.selectFrom(hostAddresses{})
+}
+
+// addressSource provides the addresses of the host.
+type addressSource interface {
+	Addresses() ([]net.IP, error)
+	InterfaceAddresses(name string) ([]net.IP, error)
+	DefaultRouteInterface() (string, error)
+}
+
+func (s NodeIPSelector) selectFrom(host addressSource) ([]net.IP, error) {
+	switch {
+	case s.NodeIP != "":
+		ips, err := parseIPs(s.NodeIP)
+		if err != nil {
+			return nil, err
+		}
+		available, err := host.Addresses()
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !containsIP(available, ip) {
+				return nil, errors.Errorf("--%s: %s is not an address of this host", constants.FlagNodeIP, ip)
+			}
+		}
+		return ips, nil
+
+	case s.Interface != "":
+		name := s.Interface
+		if name == constants.InfrastructureInterfaceDefaultRoute {
+			var err error
+			if name, err = host.DefaultRouteInterface(); err != nil {
+				return nil, err
+			}
+		}
+		ips, err := host.InterfaceAddresses(name)
+		if err != nil {
+			return nil, errors.WrapIff(err, "--%s: failed to get addresses of %q", constants.FlagInfrastructureInterface, name)
+		}
+		if len(ips) == 0 {
+			return nil, errors.Errorf("--%s: no address found on %q", constants.FlagInfrastructureInterface, name)
+		}
+		return ips, nil
+
+	default:
+		available, err := host.Addresses()
+		if err != nil {
+			return nil, err
+		}
+		ip, err := ContainsFirst(s.CIDR, available)
+		if err != nil {
+			return nil, err
+		}
+		return []net.IP{ip}, nil
+	}
+}
+
+// parseIPs parses a comma separated list of at most one IPv4 and one IPv6 address.
+func parseIPs(s string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, v := range strings.Split(s, ",") {
+		ip := net.ParseIP(strings.TrimSpace(v))
+		if ip == nil {
+			return nil, errors.Errorf("invalid IP address %q", v)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) > 2 || (len(ips) == 2 && IsIPv4(ips[0]) == IsIPv4(ips[1])) {
+		return nil, errors.Errorf("at most one IPv4 and one IPv6 address can be given, got: %q", s)
+	}
+
+	return ips, nil
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hostAddresses provides the addresses of the network interfaces of the host.
+type hostAddresses struct{}
+
+func (hostAddresses) Addresses() ([]net.IP, error) {
+	return Addresses()
+}
+
+func (hostAddresses) InterfaceAddresses(name string) ([]net.IP, error) {
+	return InterfaceAddresses(name)
+}
+
+func (hostAddresses) DefaultRouteInterface() (string, error) {
+	return DefaultRouteInterface()
+}
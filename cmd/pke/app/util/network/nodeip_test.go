@@ -0,0 +1,94 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHost struct {
+	interfaces   map[string][]net.IP
+	defaultRoute string
+}
+
+func (h fakeHost) Addresses() ([]net.IP, error) {
+	var ips []net.IP
+	for _, name := range []string{"eth0", "eth1"} {
+		ips = append(ips, h.interfaces[name]...)
+	}
+	return ips, nil
+}
+
+func (h fakeHost) InterfaceAddresses(name string) ([]net.IP, error) {
+	ips, ok := h.interfaces[name]
+	if !ok {
+		return nil, errors.New("no such network interface")
+	}
+	return ips, nil
+}
+
+func (h fakeHost) DefaultRouteInterface() (string, error) {
+	return h.defaultRoute, nil
+}
+
+func TestNodeIPSelector(t *testing.T) {
+	host := fakeHost{
+		interfaces: map[string][]net.IP{
+			// storage network
+			"eth0": {net.ParseIP("10.0.0.5").To4()},
+			// management network
+			"eth1": {net.ParseIP("192.168.64.11").To4(), net.ParseIP("fd00::11")},
+		},
+		defaultRoute: "eth1",
+	}
+
+	testCases := []struct {
+		name     string
+		selector NodeIPSelector
+		expected []string
+		err      bool
+	}{
+		{name: "CIDR", selector: NodeIPSelector{CIDR: "192.168.64.0/20"}, expected: []string{"192.168.64.11"}},
+		{name: "no address in CIDR", selector: NodeIPSelector{CIDR: "172.16.0.0/12"}, err: true},
+		{name: "interface", selector: NodeIPSelector{Interface: "eth0", CIDR: "192.168.64.0/20"}, expected: []string{"10.0.0.5"}},
+		{name: "default route", selector: NodeIPSelector{Interface: "default-route"}, expected: []string{"192.168.64.11", "fd00::11"}},
+		{name: "missing interface", selector: NodeIPSelector{Interface: "eth2"}, err: true},
+		{name: "node IP", selector: NodeIPSelector{NodeIP: "10.0.0.5", Interface: "eth1"}, expected: []string{"10.0.0.5"}},
+		{name: "dual-stack node IP", selector: NodeIPSelector{NodeIP: "192.168.64.11,fd00::11"}, expected: []string{"192.168.64.11", "fd00::11"}},
+		{name: "foreign node IP", selector: NodeIPSelector{NodeIP: "10.0.0.6"}, err: true},
+		{name: "invalid node IP", selector: NodeIPSelector{NodeIP: "10.0.0"}, err: true},
+		{name: "same family node IPs", selector: NodeIPSelector{NodeIP: "10.0.0.5,192.168.64.11"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ips, err := tc.selector.selectFrom(host)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var s []string
+			for _, ip := range ips {
+				s = append(s, ip.String())
+			}
+			require.Equal(t, tc.expected, s)
+		})
+	}
+}
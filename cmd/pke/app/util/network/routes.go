@@ -0,0 +1,190 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+const (
+	procIPv4Routes = "/proc/net/route"
+	procIPv6Routes = "/proc/net/ipv6_route"
+)
+
+// Route is an entry of the kernel routing table.
+type Route struct {
+	Interface   string
+	Destination *net.IPNet
+	Metric      uint32
+}
+
+func (r Route) String() string {
+	return r.Destination.String() + " dev " + r.Interface
+}
+
+// Routes returns the IPv4 and IPv6 routes of the host except for the default and loopback routes.
+func Routes() ([]Route, error) {
+	routes, err := hostRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	return specificRoutes(routes), nil
+}
+
+// specificRoutes filters out the default and loopback routes.
+func specificRoutes(routes []Route) []Route {
+	var specific []Route
+	for _, r := range routes {
+		if ones, _ := r.Destination.Mask.Size(); r.Interface != "lo" && ones > 0 {
+			specific = append(specific, r)
+		}
+	}
+
+	return specific
+}
+
+// DefaultRouteInterface returns the interface of the default route with the lowest metric, IPv4 routes are preferred.
+func DefaultRouteInterface() (string, error) {
+	routes, err := hostRoutes()
+	if err != nil {
+		return "", err
+	}
+
+	return defaultRouteInterface(routes)
+}
+
+func defaultRouteInterface(routes []Route) (string, error) {
+	var def *Route
+	for i, r := range routes {
+		if ones, _ := r.Destination.Mask.Size(); ones != 0 || r.Interface == "lo" {
+			continue
+		}
+		if def == nil ||
+			(IsIPv4(r.Destination.IP) && !IsIPv4(def.Destination.IP)) ||
+			(IsIPv4(r.Destination.IP) == IsIPv4(def.Destination.IP) && r.Metric < def.Metric) {
+			def = &routes[i]
+		}
+	}
+	if def == nil {
+		return "", errors.New("no default route found")
+	}
+
+	return def.Interface, nil
+}
+
+func hostRoutes() ([]Route, error) {
+	var routes []Route
+	for _, p := range []struct {
+		name  string
+		parse func(io.Reader) ([]Route, error)
+	}{
+		{name: procIPv4Routes, parse: parseIPv4Routes},
+		{name: procIPv6Routes, parse: parseIPv6Routes},
+	} {
+		f, err := os.Open(p.name)
+		if os.IsNotExist(err) {
+			// IPv6 may be disabled
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r, err := p.parse(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.WrapIff(err, "failed to parse %s", p.name)
+		}
+		routes = append(routes, r...)
+	}
+
+	return routes, nil
+}
+
+// parseIPv4Routes parses the /proc/net/route format.
+func parseIPv4Routes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	s := bufio.NewScanner(r)
+	for first := true; s.Scan(); first = false {
+		fields := strings.Fields(s.Text())
+		if first || len(fields) < 8 {
+			// header
+			continue
+		}
+		dst, err := parseHexIPv4(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseHexIPv4(fields[7])
+		if err != nil {
+			return nil, err
+		}
+		metric, err := strconv.ParseUint(fields[6], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, Route{Interface: fields[0], Destination: &net.IPNet{IP: dst, Mask: net.IPMask(mask)}, Metric: uint32(metric)})
+	}
+
+	return routes, s.Err()
+}
+
+// parseHexIPv4 parses an IPv4 address in host byte order (little-endian) hex format.
+func parseHexIPv4(s string) (net.IP, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.LittleEndian.PutUint32(ip, uint32(v))
+
+	return ip, nil
+}
+
+// parseIPv6Routes parses the /proc/net/ipv6_route format.
+func parseIPv6Routes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		dst, err := hex.DecodeString(fields[0])
+		if err != nil || len(dst) != net.IPv6len {
+			return nil, errors.Errorf("invalid destination %q", fields[0])
+		}
+		prefixLength, err := strconv.ParseUint(fields[1], 16, 8)
+		if err != nil || prefixLength > 128 {
+			return nil, errors.Errorf("invalid prefix length %q", fields[1])
+		}
+		metric, err := strconv.ParseUint(fields[5], 16, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid metric %q", fields[5])
+		}
+		routes = append(routes, Route{Interface: fields[9], Destination: &net.IPNet{IP: net.IP(dst), Mask: net.CIDRMask(int(prefixLength), 128)}, Metric: uint32(metric)})
+	}
+
+	return routes, s.Err()
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPv4Routes(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0140A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0040A8C0	00000000	0001	0	0	100	00F0FFFF	0	0	0
+tunl0	0000140A	00000000	0001	0	0	0	0000FFFF	0	0	0
+lo	0000007F	00000000	0001	0	0	0	000000FF	0	0	0
+`
+	routes, err := parseIPv4Routes(strings.NewReader(table))
+	require.NoError(t, err)
+	require.Len(t, routes, 4)
+	iface, err := defaultRouteInterface(routes)
+	require.NoError(t, err)
+	require.Equal(t, "eth0", iface)
+
+	routes = specificRoutes(routes)
+	require.Len(t, routes, 2)
+	require.Equal(t, "192.168.64.0/20 dev eth0", routes[0].String())
+	require.Equal(t, "10.20.0.0/16 dev tunl0", routes[1].String())
+}
+
+func TestParseIPv6Routes(t *testing.T) {
+	table := `fd000010002000000000000000000000 38 00000000000000000000000000000000 00 00000000000000000000000000000000 00000400 00000001 00000000 00000001     eth0
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003     eth0
+00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001       lo
+`
+	routes, err := parseIPv6Routes(strings.NewReader(table))
+	require.NoError(t, err)
+	require.Len(t, routes, 4)
+	iface, err := defaultRouteInterface(routes)
+	require.NoError(t, err)
+	require.Equal(t, "eth0", iface)
+
+	routes = specificRoutes(routes)
+	require.Len(t, routes, 2)
+	require.Equal(t, "fd00:10:20::/56 dev eth0", routes[0].String())
+	require.Equal(t, "fe80::/64 dev eth0", routes[1].String())
+}
+
+func TestDefaultRouteInterface(t *testing.T) {
+	route := func(cidr, iface string, metric uint32) Route {
+		_, n, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		return Route{Interface: iface, Destination: n, Metric: metric}
+	}
+
+	iface, err := defaultRouteInterface([]Route{
+		route("::/0", "eth2", 0),
+		route("0.0.0.0/0", "eth1", 200),
+		route("192.168.64.0/20", "eth0", 0),
+		route("0.0.0.0/0", "eth0", 100),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "eth0", iface)
+
+	iface, err = defaultRouteInterface([]Route{route("::/0", "eth2", 0), route("fd00::/64", "eth0", 0)})
+	require.NoError(t, err)
+	require.Equal(t, "eth2", iface)
+
+	_, err = defaultRouteInterface([]Route{route("192.168.64.0/20", "eth0", 0)})
+	require.Error(t, err)
+}
@@ -0,0 +1,293 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podsecurity
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+// Object is the subset of the PodSecurityPolicy, RBAC and Namespace resources used during the migration.
+type Object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec     PodSecurityPolicySpec `json:"spec"`
+	Rules    []PolicyRule          `json:"rules"`
+	RoleRef  RoleRef               `json:"roleRef"`
+	Subjects []Subject             `json:"subjects"`
+}
+
+// List is a kubectl list of objects.
+type List struct {
+	Items []Object `json:"items"`
+}
+
+// PodSecurityPolicySpec is the subset of policy/v1beta1 PodSecurityPolicySpec deciding its Pod Security Standard.
+type PodSecurityPolicySpec struct {
+	Privileged               bool              `json:"privileged"`
+	HostNetwork              bool              `json:"hostNetwork"`
+	HostPID                  bool              `json:"hostPID"`
+	HostIPC                  bool              `json:"hostIPC"`
+	HostPorts                []json.RawMessage `json:"hostPorts"`
+	AllowedCapabilities      []string          `json:"allowedCapabilities"`
+	RequiredDropCapabilities []string          `json:"requiredDropCapabilities"`
+	Volumes                  []string          `json:"volumes"`
+	AllowPrivilegeEscalation *bool             `json:"allowPrivilegeEscalation"`
+	RunAsUser                struct {
+		Rule string `json:"rule"`
+	} `json:"runAsUser"`
+}
+
+// PolicyRule is an RBAC policy rule.
+type PolicyRule struct {
+	APIGroups     []string `json:"apiGroups"`
+	Resources     []string `json:"resources"`
+	Verbs         []string `json:"verbs"`
+	ResourceNames []string `json:"resourceNames"`
+}
+
+// RoleRef references the role of an RBAC binding.
+type RoleRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Subject is a subject of an RBAC binding.
+type Subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+const (
+	seccompAllowedProfileNames = "seccomp.security.alpha.kubernetes.io/allowedProfileNames"
+	serviceAccountsGroup       = "system:serviceaccounts"
+	serviceAccountsGroupPrefix = "system:serviceaccounts:"
+	authenticatedGroup         = "system:authenticated"
+)
+
+// capabilities which may be added under the baseline Pod Security Standard.
+var baselineCapabilities = map[string]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// volume types allowed under the restricted Pod Security Standard.
+var restrictedVolumes = map[string]bool{
+	"configMap":             true,
+	"csi":                   true,
+	"downwardAPI":           true,
+	"emptyDir":              true,
+	"ephemeral":             true,
+	"persistentVolumeClaim": true,
+	"projected":             true,
+	"secret":                true,
+}
+
+// PolicyLevel returns the least restrictive Pod Security Standard which admits every pod the PodSecurityPolicy admits.
+func PolicyLevel(psp Object) string {
+	spec := psp.Spec
+	if spec.Privileged || spec.HostNetwork || spec.HostPID || spec.HostIPC || len(spec.HostPorts) > 0 {
+		return constants.PodSecurityStandardPrivileged
+	}
+	for _, c := range spec.AllowedCapabilities {
+		if !baselineCapabilities[strings.TrimPrefix(strings.ToUpper(c), "CAP_")] {
+			return constants.PodSecurityStandardPrivileged
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v == "*" || v == "hostPath" {
+			return constants.PodSecurityStandardPrivileged
+		}
+	}
+
+	if spec.AllowPrivilegeEscalation == nil || *spec.AllowPrivilegeEscalation {
+		return constants.PodSecurityStandardBaseline
+	}
+	if spec.RunAsUser.Rule != "MustRunAsNonRoot" {
+		return constants.PodSecurityStandardBaseline
+	}
+	if !containsAny(spec.RequiredDropCapabilities, "ALL") {
+		return constants.PodSecurityStandardBaseline
+	}
+	for _, c := range spec.AllowedCapabilities {
+		if strings.TrimPrefix(strings.ToUpper(c), "CAP_") != "NET_BIND_SERVICE" {
+			return constants.PodSecurityStandardBaseline
+		}
+	}
+	for _, v := range spec.Volumes {
+		if !restrictedVolumes[v] {
+			return constants.PodSecurityStandardBaseline
+		}
+	}
+	profiles := psp.Metadata.Annotations[seccompAllowedProfileNames]
+	if profiles == "" {
+		return constants.PodSecurityStandardBaseline
+	}
+	for _, p := range strings.Split(profiles, ",") {
+		p = strings.TrimSpace(p)
+		if p != "runtime/default" && p != "docker/default" && !strings.HasPrefix(p, "localhost/") {
+			return constants.PodSecurityStandardBaseline
+		}
+	}
+
+	return constants.PodSecurityStandardRestricted
+}
+
+// NamespaceLevels maps every namespace to the Pod Security Standard matching the PodSecurityPolicies
+// its workloads are allowed to use. PodSecurityPolicies granted by a RoleBinding apply to the namespace of the binding,
+// ClusterRoleBindings apply to the namespaces of their service account subjects, or to every namespace
+// if granted to all service accounts or authenticated users. Namespaces without any grant get defaultLevel.
+func NamespaceLevels(items []Object, defaultLevel string) map[string]string {
+	var (
+		psps       = make(map[string]string)
+		roles      = make(map[string]string)
+		namespaces []string
+	)
+	for _, o := range items {
+		switch o.Kind {
+		case "PodSecurityPolicy":
+			psps[o.Metadata.Name] = PolicyLevel(o)
+		case "Namespace":
+			namespaces = append(namespaces, o.Metadata.Name)
+		}
+	}
+	for _, o := range items {
+		switch o.Kind {
+		case "ClusterRole", "Role":
+			if level := roleLevel(o.Rules, psps); level != "" {
+				roles[roleKey(o.Kind, o.Metadata.Namespace, o.Metadata.Name)] = level
+			}
+		}
+	}
+
+	var (
+		clusterLevel string
+		granted      = make(map[string]string)
+	)
+	grant := func(namespace, level string) {
+		if l, ok := granted[namespace]; ok {
+			level = LeastRestrictive(l, level)
+		}
+		granted[namespace] = level
+	}
+	for _, o := range items {
+		switch o.Kind {
+		case "RoleBinding":
+			ns := ""
+			if o.RoleRef.Kind == "Role" {
+				ns = o.Metadata.Namespace
+			}
+			if level, ok := roles[roleKey(o.RoleRef.Kind, ns, o.RoleRef.Name)]; ok && len(o.Subjects) > 0 {
+				grant(o.Metadata.Namespace, level)
+			}
+		case "ClusterRoleBinding":
+			level, ok := roles[roleKey(o.RoleRef.Kind, "", o.RoleRef.Name)]
+			if !ok {
+				continue
+			}
+			for _, s := range o.Subjects {
+				switch {
+				case s.Kind == "ServiceAccount":
+					grant(s.Namespace, level)
+				case s.Kind == "Group" && strings.HasPrefix(s.Name, serviceAccountsGroupPrefix):
+					grant(strings.TrimPrefix(s.Name, serviceAccountsGroupPrefix), level)
+				case s.Kind == "Group" && (s.Name == serviceAccountsGroup || s.Name == authenticatedGroup):
+					if clusterLevel == "" {
+						clusterLevel = level
+					}
+					clusterLevel = LeastRestrictive(clusterLevel, level)
+				}
+			}
+		}
+	}
+
+	result := make(map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		level, ok := granted[ns]
+		switch {
+		case ok && clusterLevel != "":
+			level = LeastRestrictive(level, clusterLevel)
+		case clusterLevel != "":
+			level = clusterLevel
+		case !ok:
+			level = defaultLevel
+		}
+		result[ns] = level
+	}
+
+	return result
+}
+
+// roleLevel returns the least restrictive Pod Security Standard among the PodSecurityPolicies the rules allow to use.
+func roleLevel(rules []PolicyRule, psps map[string]string) string {
+	var level string
+	for _, r := range rules {
+		if !(containsAny(r.APIGroups, "policy", "extensions", "*") && containsAny(r.Resources, "podsecuritypolicies", "*") && containsAny(r.Verbs, "use", "*")) {
+			continue
+		}
+		names := r.ResourceNames
+		if len(names) == 0 {
+			for name := range psps {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			l, ok := psps[name]
+			if !ok {
+				continue
+			}
+			if level == "" {
+				level = l
+			}
+			level = LeastRestrictive(level, l)
+		}
+	}
+
+	return level
+}
+
+func roleKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func containsAny(s []string, values ...string) bool {
+	for _, e := range s {
+		for _, v := range values {
+			if e == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podsecurity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testObjects = `{
+  "items": [
+    {"kind": "Namespace", "metadata": {"name": "kube-system"}},
+    {"kind": "Namespace", "metadata": {"name": "default"}},
+    {"kind": "Namespace", "metadata": {"name": "app"}},
+    {"kind": "Namespace", "metadata": {"name": "monitoring"}},
+    {"kind": "PodSecurityPolicy", "metadata": {"name": "pke.privileged"}, "spec": {"privileged": true, "volumes": ["*"]}},
+    {"kind": "PodSecurityPolicy", "metadata": {"name": "pke.unprivileged-addon"}, "spec": {"volumes": ["configMap", "secret"], "runAsUser": {"rule": "RunAsAny"}}},
+    {
+      "kind": "PodSecurityPolicy",
+      "metadata": {"name": "restricted", "annotations": {"seccomp.security.alpha.kubernetes.io/allowedProfileNames": "runtime/default"}},
+      "spec": {"allowPrivilegeEscalation": false, "requiredDropCapabilities": ["ALL"], "volumes": ["configMap", "secret", "emptyDir"], "runAsUser": {"rule": "MustRunAsNonRoot"}}
+    },
+    {
+      "kind": "ClusterRole",
+      "metadata": {"name": "pke:podsecuritypolicy:privileged"},
+      "rules": [{"apiGroups": ["policy"], "resources": ["podsecuritypolicies"], "verbs": ["use"], "resourceNames": ["pke.privileged"]}]
+    },
+    {
+      "kind": "Role",
+      "metadata": {"name": "pke:podsecuritypolicy:unprivileged-addon", "namespace": "kube-system"},
+      "rules": [{"apiGroups": ["policy"], "resources": ["podsecuritypolicies"], "verbs": ["use"], "resourceNames": ["pke.unprivileged-addon"]}]
+    },
+    {
+      "kind": "ClusterRole",
+      "metadata": {"name": "restricted"},
+      "rules": [{"apiGroups": ["policy"], "resources": ["podsecuritypolicies"], "verbs": ["use"], "resourceNames": ["restricted"]}]
+    },
+    {
+      "kind": "RoleBinding",
+      "metadata": {"name": "pke:podsecuritypolicy:unprivileged-addon", "namespace": "kube-system"},
+      "roleRef": {"kind": "Role", "name": "pke:podsecuritypolicy:unprivileged-addon"},
+      "subjects": [{"kind": "Group", "name": "system:serviceaccounts:kube-system"}]
+    },
+    {
+      "kind": "ClusterRoleBinding",
+      "metadata": {"name": "monitoring"},
+      "roleRef": {"kind": "ClusterRole", "name": "pke:podsecuritypolicy:privileged"},
+      "subjects": [{"kind": "ServiceAccount", "name": "node-exporter", "namespace": "monitoring"}]
+    },
+    {
+      "kind": "RoleBinding",
+      "metadata": {"name": "restricted", "namespace": "app"},
+      "roleRef": {"kind": "ClusterRole", "name": "restricted"},
+      "subjects": [{"kind": "Group", "name": "system:serviceaccounts:app"}]
+    }
+  ]
+}`
+
+func TestNamespaceLevels(t *testing.T) {
+	var list List
+	require.NoError(t, json.Unmarshal([]byte(testObjects), &list))
+
+	require.Equal(t, map[string]string{
+		"kube-system": "baseline",
+		"default":     "restricted",
+		"app":         "restricted",
+		"monitoring":  "privileged",
+	}, NamespaceLevels(list.Items, "restricted"))
+
+	list.Items = append(list.Items, Object{
+		Kind:     "ClusterRoleBinding",
+		RoleRef:  RoleRef{Kind: "ClusterRole", Name: "pke:podsecuritypolicy:privileged"},
+		Subjects: []Subject{{Kind: "Group", Name: "system:authenticated"}},
+	})
+
+	for ns, level := range NamespaceLevels(list.Items, "restricted") {
+		require.Equal(t, "privileged", level, ns)
+	}
+}
+
+func TestPolicyLevel(t *testing.T) {
+	var list List
+	require.NoError(t, json.Unmarshal([]byte(testObjects), &list))
+
+	levels := make(map[string]string)
+	for _, o := range list.Items {
+		if o.Kind == "PodSecurityPolicy" {
+			levels[o.Metadata.Name] = PolicyLevel(o)
+		}
+	}
+
+	require.Equal(t, map[string]string{
+		"pke.privileged":         "privileged",
+		"pke.unprivileged-addon": "baseline",
+		"restricted":             "restricted",
+	}, levels)
+}
+
+func TestConfigAPIVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		expected string
+		err      bool
+	}{
+		{version: "1.21.9", err: true},
+		{version: "1.22.6", expected: "pod-security.admission.config.k8s.io/v1alpha1"},
+		{version: "1.23.3", expected: "pod-security.admission.config.k8s.io/v1beta1"},
+		{version: "1.25.0", expected: "pod-security.admission.config.k8s.io/v1"},
+	}
+
+	for _, tc := range testCases {
+		v, err := ConfigAPIVersion(tc.version)
+		if tc.err {
+			require.Error(t, err, tc.version)
+			continue
+		}
+		require.NoError(t, err, tc.version)
+		require.Equal(t, tc.expected, v, tc.version)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podsecurity
+
+import (
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+)
+
+const (
+	// LabelEnforce namespace label selecting the enforced Pod Security Standard.
+	LabelEnforce = "pod-security.kubernetes.io/enforce"
+	// LabelAudit namespace label selecting the audited Pod Security Standard.
+	LabelAudit = "pod-security.kubernetes.io/audit"
+	// LabelWarn namespace label selecting the Pod Security Standard that generates warnings.
+	LabelWarn = "pod-security.kubernetes.io/warn"
+)
+
+// levels in increasing order of restrictiveness.
+var levels = []string{
+	constants.PodSecurityStandardPrivileged,
+	constants.PodSecurityStandardBaseline,
+	constants.PodSecurityStandardRestricted,
+}
+
+// ValidateLevel checks whether level is a known Pod Security Standard.
+func ValidateLevel(level string) error {
+	if rank(level) < 0 {
+		return errors.Errorf("unknown Pod Security Standard %q, possible values: privileged, baseline, restricted", level)
+	}
+	return nil
+}
+
+// LeastRestrictive returns the most permissive of the given Pod Security Standards.
+func LeastRestrictive(a, b string) string {
+	if rank(a) < rank(b) {
+		return a
+	}
+	return b
+}
+
+func rank(level string) int {
+	for i, l := range levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ConfigAPIVersion returns the PodSecurityConfiguration API version served by the given Kubernetes version.
+func ConfigAPIVersion(kubernetesVersion string) (string, error) {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse Kubernetes version %q", kubernetesVersion)
+	}
+
+	switch {
+	case ver.Minor() < 22:
+		return "", errors.Errorf("Pod Security Admission is not available in Kubernetes %s, version 1.22 or newer is required", kubernetesVersion)
+	case ver.Minor() == 22:
+		return "pod-security.admission.config.k8s.io/v1alpha1", nil
+	case ver.Minor() < 25:
+		return "pod-security.admission.config.k8s.io/v1beta1", nil
+	default:
+		return "pod-security.admission.config.k8s.io/v1", nil
+	}
+}
+
+// FeatureGateRequired tells whether the PodSecurity feature gate has to be enabled explicitly (alpha in Kubernetes 1.22).
+func FeatureGateRequired(kubernetesVersion string) bool {
+	ver, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return false
+	}
+	return ver.Major() == 1 && ver.Minor() == 22
+}
@@ -0,0 +1,218 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/pke/cmd/pke/app/constants"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/file"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/linux"
+	"github.com/banzaicloud/pke/cmd/pke/app/util/network"
+)
+
+const (
+	dropInDir  = "/etc/systemd/system/%s.service.d"
+	dropInFile = "http-proxy.conf"
+)
+
+// Config contains the proxy settings used by PKE and the components it installs.
+type Config struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    []string
+}
+
+// RegisterFlags registers the proxy flags.
+func RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(constants.FlagHTTPProxy, "", "Proxy for HTTP requests, example: http://proxy.example.com:3128")
+	flags.String(constants.FlagHTTPSProxy, "", "Proxy for HTTPS requests, example: http://proxy.example.com:3128")
+	flags.StringSlice(constants.FlagNoProxy, nil, "Hosts, domains and CIDRs that should not be proxied. Service, pod and infrastructure CIDRs, the API server endpoint and node IPs are added automatically")
+}
+
+// Parameters reads and validates the proxy flags.
+func Parameters(cmd *cobra.Command) (c Config, err error) {
+	c.HTTPProxy, err = cmd.Flags().GetString(constants.FlagHTTPProxy)
+	if err != nil {
+		return
+	}
+	c.HTTPSProxy, err = cmd.Flags().GetString(constants.FlagHTTPSProxy)
+	if err != nil {
+		return
+	}
+	c.NoProxy, err = cmd.Flags().GetStringSlice(constants.FlagNoProxy)
+	if err != nil {
+		return
+	}
+
+	for _, p := range []struct{ flag, proxy string }{
+		{constants.FlagHTTPProxy, c.HTTPProxy},
+		{constants.FlagHTTPSProxy, c.HTTPSProxy},
+	} {
+		flag, proxy := p.flag, p.proxy
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return c, errors.Wrapf(constants.ErrInvalidInput, "--%s: %q is not a valid proxy url", flag, proxy)
+		}
+	}
+
+	return c, nil
+}
+
+// Enabled tells if any proxy is configured.
+func (c Config) Enabled() bool {
+	return c.HTTPProxy != "" || c.HTTPSProxy != ""
+}
+
+// WithNoProxy returns a copy of the config with the given entries and the local addresses of the node added to NO_PROXY.
+// Host port pairs, CIDRs and comma separated lists of them are accepted, empty entries and duplicates are dropped.
+func (c Config) WithNoProxy(entries ...string) Config {
+	local := []string{"localhost", "127.0.0.1", "::1"}
+	if ips, err := network.Addresses(); err == nil {
+		for _, ip := range ips {
+			local = append(local, ip.String())
+		}
+	}
+
+	seen := make(map[string]bool)
+	var noProxy []string
+	var all []string
+	for _, entry := range append(append(append([]string(nil), c.NoProxy...), local...), entries...) {
+		all = append(all, strings.Split(entry, ",")...)
+	}
+	for _, entry := range all {
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+		entry = strings.TrimSpace(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		noProxy = append(noProxy, entry)
+	}
+	c.NoProxy = noProxy
+
+	return c
+}
+
+// Environment returns the proxy environment variables in both upper and lower case.
+// The order is fixed, so the rendered systemd drop-ins only change when the settings do.
+func (c Config) Environment() []string {
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", c.HTTPProxy},
+		{"HTTPS_PROXY", c.HTTPSProxy},
+		{"NO_PROXY", strings.Join(c.NoProxy, ",")},
+	} {
+		if v.value == "" {
+			continue
+		}
+		env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+	}
+
+	return env
+}
+
+// Apply makes the proxy settings effective for the downloads of PKE and for the commands it runs (e.g. kubeadm, kubectl, package managers).
+func (c Config) Apply() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	for _, env := range c.Environment() {
+		kv := strings.SplitN(env, "=", 2)
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	file.SetProxy(c.HTTPProxy, c.HTTPSProxy, strings.Join(c.NoProxy, ","))
+
+	return nil
+}
+
+// WriteSystemdDropIn writes the proxy environment of the service into a systemd drop-in.
+// An existing drop-in is removed if no proxy is configured.
+func (c Config) WriteSystemdDropIn(out io.Writer, service string) error {
+	dir := fmt.Sprintf(dropInDir, service)
+	filename := filepath.Join(dir, dropInFile)
+
+	if !c.Enabled() {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "writing proxy settings of %s to %s\n", service, filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("[Service]\n")
+	for _, env := range c.Environment() {
+		b.WriteString(fmt.Sprintf("Environment=%q\n", env))
+	}
+
+	return file.Overwrite(filename, b.String())
+}
+
+// ConfigureNode writes the proxy drop-ins of the container runtime and the kubelet and restarts the container runtime to pick up the changes.
+// The kubelet is started later by kubeadm.
+func (c Config) ConfigureNode(out io.Writer, containerRuntime string) error {
+	service := runtimeService(containerRuntime)
+	for _, s := range []string{service, "kubelet"} {
+		if err := c.WriteSystemdDropIn(out, s); err != nil {
+			return errors.Wrapf(err, "unable to write proxy settings of %s", s)
+		}
+	}
+
+	if err := linux.SystemctlReload(out); err != nil {
+		return err
+	}
+
+	if !c.Enabled() {
+		return nil
+	}
+
+	if err := linux.Systemctl(out, "restart", service); err != nil {
+		return errors.Wrapf(err, "unable to restart %s", service)
+	}
+
+	return nil
+}
+
+func runtimeService(containerRuntime string) string {
+	switch containerRuntime {
+	case constants.ContainerRuntimeCRIO:
+		return "crio"
+	default:
+		return containerRuntime
+	}
+}
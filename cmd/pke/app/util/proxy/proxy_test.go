@@ -0,0 +1,61 @@
+// Copyright © 2022 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithNoProxy(t *testing.T) {
+	c := Config{
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    []string{"example.com", "10.10.0.0/16"},
+	}
+
+	c = c.WithNoProxy("10.10.0.0/16,fd00:10:10::/112", "10.20.0.0/16", "192.168.64.0/20", "api.example.com:6443", "[2001:db8::1]:6443", "", ".svc")
+
+	require.Equal(t, []string{"example.com", "10.10.0.0/16", "localhost", "127.0.0.1", "::1"}, c.NoProxy[:5])
+	require.Subset(t, c.NoProxy, []string{"fd00:10:10::/112", "10.20.0.0/16", "192.168.64.0/20", "api.example.com", "2001:db8::1", ".svc"})
+	require.NotContains(t, c.NoProxy, "")
+	require.NotContains(t, c.NoProxy, "api.example.com:6443")
+}
+
+func TestEnvironment(t *testing.T) {
+	c := Config{
+		HTTPProxy:  "http://proxy.example.com:3128",
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    []string{"localhost", "10.10.0.0/16"},
+	}
+
+	// the order is stable, so the systemd drop-ins are not rewritten on every run
+	for i := 0; i < 10; i++ {
+		require.Equal(t, []string{
+			"HTTP_PROXY=http://proxy.example.com:3128",
+			"http_proxy=http://proxy.example.com:3128",
+			"HTTPS_PROXY=http://proxy.example.com:3128",
+			"https_proxy=http://proxy.example.com:3128",
+			"NO_PROXY=localhost,10.10.0.0/16",
+			"no_proxy=localhost,10.10.0.0/16",
+		}, c.Environment())
+	}
+	require.Equal(t, []string{
+		"HTTPS_PROXY=http://proxy.example.com:3128",
+		"https_proxy=http://proxy.example.com:3128",
+	}, Config{HTTPSProxy: "http://proxy.example.com:3128"}.Environment())
+	require.True(t, c.Enabled())
+	require.False(t, Config{NoProxy: []string{"localhost"}}.Enabled())
+}